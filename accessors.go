@@ -0,0 +1,48 @@
+package ecs
+
+// Get returns the object's component of type T, and whether it has one. T
+// may also be an interface, in which case the first component implementing
+// it is returned:
+//
+//     if pos, ok := ecs.Get[Position](ob); ok {
+//         ...
+//     }
+func Get[T any](ob *Object) (T, bool) {
+	ob.mu.RLock()
+	dense := ob.dense
+	for _, c := range ob.components {
+		if v, ok := c.(T); ok {
+			ob.mu.RUnlock()
+			return v, true
+		}
+	}
+	ob.mu.RUnlock()
+
+	var zero T
+	if len(dense) == 0 {
+		return zero, false
+	}
+	if col, ok := DenseColumn[T](ob.world); ok && hasType(dense, col.columnType()) {
+		// read straight from the column, without boxing
+		return col.Get(ob.entity)
+	}
+	for _, c := range ob.denseComponents() {
+		if v, ok := c.(T); ok {
+			return v, true
+		}
+	}
+	return zero, false
+}
+
+// Has reports whether the object has a component of type T.
+func Has[T any](ob *Object) bool {
+	_, ok := Get[T](ob)
+	return ok
+}
+
+// Set replaces the object's component of the same type as component, or adds
+// it if the object doesn't have one. It is the same as SetComponent, but
+// checks component's type at compile time.
+func Set[T any](ob *Object, component T) {
+	ob.SetComponent(component)
+}
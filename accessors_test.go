@@ -0,0 +1,58 @@
+package ecs_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestAccessors(t *testing.T) {
+	ob := ecs.NewObject(Position(1))
+
+	if pos, ok := ecs.Get[Position](ob); !ok || pos != 1 {
+		t.Errorf("bad position: got %v, %v", pos, ok)
+	}
+	if v, ok := ecs.Get[Velocity](ob); ok || v != 0 {
+		t.Errorf("expected no velocity, got %v", v)
+	}
+	if !ecs.Has[Position](ob) || ecs.Has[Velocity](ob) {
+		t.Error("Has reported the wrong components")
+	}
+
+	ecs.Set(ob, Position(5))
+	ecs.Set(ob, Velocity(2))
+	if got := len(ob.Components()); got != 2 {
+		t.Errorf("expected 2 components, got %d", got)
+	}
+	if pos, _ := ecs.Get[Position](ob); pos != 5 {
+		t.Errorf("position not replaced: got %v", pos)
+	}
+	if v, _ := ecs.Get[Velocity](ob); v != 2 {
+		t.Errorf("velocity not added: got %v", v)
+	}
+}
+
+func TestGetInterface(t *testing.T) {
+	ob := ecs.NewObject(Velocity(3), Score{Points: 7})
+	if s, ok := ecs.Get[fmt.Stringer](ob); ok {
+		t.Errorf("no component implements Stringer, got %v", s)
+	}
+	if _, ok := ecs.Get[interface{}](ob); !ok {
+		t.Error("every component implements the empty interface")
+	}
+}
+
+func TestSetWatched(t *testing.T) {
+	world := ecs.NewWorld()
+	e := world.AddObject(ecs.NewObject(Position(1)))
+	var kinds []ecs.ChangeKind
+	world.Watch(e, func(c ecs.Change) { kinds = append(kinds, c.Kind) })
+
+	ob := world.GetObject(e)
+	ecs.Set(ob, Position(2))
+	ecs.Set(ob, Velocity(1))
+	if len(kinds) != 2 || kinds[0] != ecs.ComponentChanged || kinds[1] != ecs.ComponentAdded {
+		t.Errorf("bad changes: %v", kinds)
+	}
+}
@@ -0,0 +1,235 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotArchived is returned when an entity isn't in a world's archive.
+var ErrNotArchived = errors.New("entity not archived")
+
+// An Archive is cold storage for objects that have been evicted from a world,
+// such as the characters of players who haven't logged in for a while. The
+// world encodes each object itself, so an archive only needs to store opaque
+// data by entity. Archives must be safe for concurrent use.
+type Archive interface {
+	// Put stores data for an entity, replacing anything already stored.
+	Put(entity Entity, data []byte) error
+
+	// Get returns the data stored for an entity, or an error wrapping
+	// ErrNotArchived if there is none.
+	Get(entity Entity) ([]byte, error)
+
+	// Delete removes an entity's data. Deleting an entity that isn't stored
+	// is not an error.
+	Delete(entity Entity) error
+
+	// Scan calls fn for every stored entity, in no particular order, until
+	// fn returns an error, which Scan returns.
+	Scan(fn func(entity Entity, data []byte) error) error
+}
+
+// DirArchive is an Archive that stores each object in its own file within a
+// directory, which must already exist.
+type DirArchive string
+
+const archiveExt = ".obj"
+
+func (d DirArchive) path(entity Entity) string {
+	return filepath.Join(string(d), strconv.FormatUint(uint64(entity), 10)+archiveExt)
+}
+
+func (d DirArchive) Put(entity Entity, data []byte) error {
+	// write to a temporary file first, so a crash can't leave a partial object
+	f, err := os.CreateTemp(string(d), "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), d.path(entity))
+}
+
+func (d DirArchive) Get(entity Entity) ([]byte, error) {
+	data, err := os.ReadFile(d.path(entity))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %d", ErrNotArchived, entity)
+	}
+	return data, err
+}
+
+func (d DirArchive) Delete(entity Entity) error {
+	if err := os.Remove(d.path(entity)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (d DirArchive) Scan(fn func(entity Entity, data []byte) error) error {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, archiveExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, archiveExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(string(d), name))
+		if os.IsNotExist(err) {
+			// rehydrated since the directory was read
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := fn(Entity(id), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *World) archive() (Archive, error) {
+	if w.Archive == nil {
+		return nil, errors.New("world has no archive")
+	}
+	return w.Archive, nil
+}
+
+// Evict moves objects out of the world and into its Archive, freeing the
+// memory they use while keeping them available to Rehydrate and to archival
+// queries. Unlike RemoveObject, eviction doesn't run finalizers, since the
+// objects still exist, but it does run OnRemove hooks, just as Rehydrate runs
+// OnAdd hooks. Every component type must have been registered, as with Save.
+//
+// Each object is stored before it's removed, so if an error is returned,
+// objects before the failing one have been evicted and the rest are still in
+// the world.
+func (w *World) Evict(entities ...Entity) error {
+	archive, err := w.archive()
+	if err != nil {
+		return err
+	}
+	for _, entity := range entities {
+		ob := w.GetObject(entity)
+		if ob == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := encodeObject(gob.NewEncoder(&buf), ob); err != nil {
+			return fmt.Errorf("evicting entity %d: %w", entity, err)
+		}
+		if err := archive.Put(entity, buf.Bytes()); err != nil {
+			return fmt.Errorf("evicting entity %d: %w", entity, err)
+		}
+		if w.detachObject(entity) != nil {
+			w.metrics().Counter("ecs_objects_evicted", 1)
+			w.hookObject(ob, ComponentRemoved)
+		}
+	}
+	return nil
+}
+
+// Rehydrate moves an object out of the world's Archive and back into the
+// world, with the same entity it had before it was evicted.
+func (w *World) Rehydrate(entity Entity) (*Object, error) {
+	archive, err := w.archive()
+	if err != nil {
+		return nil, err
+	}
+	data, err := archive.Get(entity)
+	if err != nil {
+		return nil, err
+	}
+	ob, err := decodeArchived(entity, data)
+	if err != nil {
+		return nil, err
+	}
+	return ob, w.restore(archive, ob)
+}
+
+// restore adds an archived object to the world and removes it from the
+// archive.
+func (w *World) restore(archive Archive, ob *Object) error {
+	reserveEntity(ob.entity)
+	w.AddObject(ob)
+	w.metrics().Counter("ecs_objects_rehydrated", 1)
+	return archive.Delete(ob.entity)
+}
+
+func decodeArchived(entity Entity, data []byte) (*Object, error) {
+	ob, err := decodeObject(gob.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decoding archived entity %d: %w", entity, err)
+	}
+	return ob, nil
+}
+
+// Archived returns every object in the world's Archive that matches the
+// query, in ascending order of entity. They are copies, detached from the
+// world, so they can be inspected but changes to them aren't stored; use
+// Rehydrate to bring them back into the world. This reads every archived
+// object, so it is intended for administrative queries rather than for use
+// in systems.
+func (q *Query) Archived() ([]*Object, error) {
+	archive, err := q.w.archive()
+	if err != nil {
+		return nil, err
+	}
+	var objects []*Object
+	err = archive.Scan(func(entity Entity, data []byte) error {
+		ob, err := decodeArchived(entity, data)
+		if err != nil {
+			return err
+		}
+		if q.matches(ob) {
+			objects = append(objects, ob)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].entity < objects[j].entity
+	})
+	return objects, nil
+}
+
+// Rehydrate moves every archived object that matches the query back into the
+// world, then returns every object in the world that matches, whether it was
+// archived or not.
+func (q *Query) Rehydrate() ([]*Object, error) {
+	archive, err := q.w.archive()
+	if err != nil {
+		return nil, err
+	}
+	archived, err := q.Archived()
+	if err != nil {
+		return nil, err
+	}
+	for _, ob := range archived {
+		if err := q.w.restore(archive, ob); err != nil {
+			return nil, err
+		}
+	}
+	return q.Objects(), nil
+}
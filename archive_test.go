@@ -0,0 +1,107 @@
+package ecs_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Owner struct{ Player string }
+
+func init() {
+	ecs.Register(Owner{})
+}
+
+func TestEvict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	world := ecs.NewWorld()
+	if err := world.Evict(1); err == nil {
+		t.Error("expected an error evicting without an archive")
+	}
+	world.Archive = ecs.DirArchive(dir)
+
+	var finalized int
+	world.AddFinalizer(Position(0), func(ecs.Entity, interface{}) { finalized++ })
+
+	e := world.AddObject(ecs.NewObject(Position(1), Owner{Player: "mallory"}))
+	if err := world.Evict(e); err != nil {
+		t.Fatalf("failed to evict: %s", err)
+	}
+	if world.GetObject(e) != nil {
+		t.Error("evicted object is still in the world")
+	}
+	if finalized != 0 {
+		t.Error("eviction should not run finalizers")
+	}
+
+	ob, err := world.Rehydrate(e)
+	if err != nil {
+		t.Fatalf("failed to rehydrate: %s", err)
+	}
+	if ob.Entity() != e || world.GetObject(e) != ob {
+		t.Errorf("rehydrated object not restored with its entity")
+	}
+	if got := ob.Component(Owner{}); got != (Owner{Player: "mallory"}) {
+		t.Errorf("bad owner after rehydrating: %v", got)
+	}
+	if _, err := world.Rehydrate(e); !errors.Is(err, ecs.ErrNotArchived) {
+		t.Errorf("expected ErrNotArchived, got %v", err)
+	}
+}
+
+func TestArchivedQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	world := ecs.NewWorld()
+	world.Archive = ecs.DirArchive(dir)
+
+	resident := world.AddObject(ecs.NewObject(Owner{Player: "mallory"}))
+	archived := world.AddObject(ecs.NewObject(Owner{Player: "mallory"}, Position(2)))
+	other := world.AddObject(ecs.NewObject(Position(3)))
+	if err := world.Evict(archived, other); err != nil {
+		t.Fatalf("failed to evict: %s", err)
+	}
+
+	q := world.Query().With(Owner{})
+	if n := q.Count(); n != 1 {
+		t.Errorf("resident count: got %d, want 1", n)
+	}
+	stubs, err := q.Archived()
+	if err != nil {
+		t.Fatalf("failed to query archive: %s", err)
+	}
+	if len(stubs) != 1 || stubs[0].Entity() != archived || stubs[0].Component(Position(0)) != Position(2) {
+		t.Errorf("bad archived matches: %v", stubs)
+	}
+	if world.GetObject(archived) != nil {
+		t.Error("querying the archive should not rehydrate")
+	}
+
+	objects, err := q.Rehydrate()
+	if err != nil {
+		t.Fatalf("failed to rehydrate: %s", err)
+	}
+	if len(objects) != 2 {
+		t.Errorf("expected both owned objects, got %d", len(objects))
+	}
+	for _, e := range []ecs.Entity{resident, archived} {
+		if world.GetObject(e) == nil {
+			t.Errorf("entity %d should be in the world", e)
+		}
+	}
+	if world.GetObject(other) != nil {
+		t.Error("non-matching object should stay archived")
+	}
+}
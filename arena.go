@@ -0,0 +1,138 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// An arena holds the reflection values a system needs on every tick, so that
+// they are built once and recycled rather than allocated for every object.
+// Each scheduled system has its own arena.
+type arena struct {
+	f        reflect.Value
+	name     string
+	argTypes []reflect.Type
+
+	// params classifies each of the function's parameters, so that ticks
+	// don't have to rediscover how to fill them in.
+	params []param
+
+	// returnsError is whether the function's last result is an error.
+	returnsError bool
+
+	// invoker calls the function without reflection, if it is an Invoker.
+	invoker Invoker
+
+	// args recycles the argument slices passed to the system function.
+	args sync.Pool
+}
+
+// paramKind identifies where a system parameter's value comes from.
+type paramKind int
+
+const (
+	paramWorld paramKind = iota
+	paramContext
+	paramCommands
+	paramTime
+	paramShard
+	paramElapsed
+	paramRes
+	paramSingle
+	paramColumn
+	paramIter
+
+	// paramDynamic parameters are resources, entities, or components, which
+	// can only be told apart at tick time, since resources may be added to
+	// the world at any time.
+	paramDynamic
+)
+
+type param struct {
+	kind paramKind
+	t    reflect.Type
+
+	// res is the zero value of a Res or ResMut parameter, and column of a
+	// Column parameter.
+	res    resParam
+	column columnParam
+
+	// iter is the iterator passed to an iterator parameter, or err is why it
+	// couldn't be made. Iterators hold no state of their own between calls,
+	// so one is made per parameter and reused on every tick.
+	iter reflect.Value
+	err  error
+}
+
+func newArena(w *World, s System) *arena {
+	f := reflect.ValueOf(s.Func)
+	ft := f.Type()
+	a := &arena{
+		f:        f,
+		name:     s.name(),
+		argTypes: make([]reflect.Type, ft.NumIn()),
+		params:   make([]param, ft.NumIn()),
+	}
+	for i := range a.argTypes {
+		t := ft.In(i)
+		a.argTypes[i] = t
+		a.params[i] = classifyParam(w, t)
+	}
+	if n := ft.NumOut(); n > 0 && ft.Out(n-1) == errorType {
+		a.returnsError = true
+	}
+	results := 0
+	if inv, ok := s.Func.(Invoker); ok && directlyCallable(ft) {
+		a.invoker = inv
+		results = ft.NumOut()
+	}
+	a.args.New = func() interface{} {
+		return make([]reflect.Value, len(a.argTypes), len(a.argTypes)+results)
+	}
+	return a
+}
+
+func classifyParam(w *World, t reflect.Type) param {
+	p := param{t: t}
+	switch {
+	case t == worldType:
+		p.kind = paramWorld
+	case t == contextType:
+		p.kind = paramContext
+	case t == commandsType:
+		p.kind = paramCommands
+	case t == timeType:
+		p.kind = paramTime
+	case t == shardType:
+		p.kind = paramShard
+	case t == elapsedType:
+		p.kind = paramElapsed
+	case t.Implements(resParamType):
+		p.kind = paramRes
+		p.res = reflect.Zero(t).Interface().(resParam)
+	case t.Implements(singleParamType):
+		p.kind = paramSingle
+	case t.Implements(columnParamType):
+		p.kind = paramColumn
+		p.column = reflect.Zero(t).Interface().(columnParam)
+	case t.Kind() == reflect.Func:
+		p.kind = paramIter
+		p.iter, p.err = w.makeObjectIter(t)
+	default:
+		p.kind = paramDynamic
+	}
+	return p
+}
+
+// getArgs returns scratch space for the system's arguments, with capacity
+// after them for the results of an Invoker. It should be returned with
+// putArgs once the caller is done with it.
+func (a *arena) getArgs() []reflect.Value {
+	return a.args.Get().([]reflect.Value)
+}
+
+func (a *arena) putArgs(args []reflect.Value) {
+	// drop references, so that recycled slices don't keep components alive
+	clear(args[:cap(args)])
+	a.args.Put(args[:len(a.argTypes)])
+}
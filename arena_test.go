@@ -0,0 +1,53 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestIteratorAllocations(t *testing.T) {
+	const objects = 1000
+
+	allocs := func(f interface{}) float64 {
+		world := ecs.NewWorld()
+		for i := 0; i < objects; i++ {
+			world.AddObject(ecs.NewObject(Position(i)))
+		}
+		world.AddSystem(ecs.System{Func: f})
+		world.Run()
+		return testing.AllocsPerRun(10, world.Run)
+	}
+
+	plain := allocs(func(p Position) {})
+	withIter := allocs(func(p Position, targets func() (Target, bool)) {})
+
+	// building an iterator for every object would cost at least one
+	// allocation each
+	if extra := withIter - plain; extra >= objects/2 {
+		t.Errorf("iterator parameters cost %.0f allocations per run over %d objects", extra, objects)
+	}
+}
+
+func TestLateResource(t *testing.T) {
+	type Gravity int
+
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Velocity(0)))
+	world.AddSystem(ecs.System{Func: func(v Velocity, g *Gravity) Velocity {
+		return v + Velocity(*g)
+	}})
+
+	// parameters are classified when the system is added, but resources
+	// added afterwards must still be found
+	world.Run()
+	g := Gravity(3)
+	world.AddResource(&g)
+	world.Run()
+
+	for _, v := range ecs.Each[Velocity](world) {
+		if v != 3 {
+			t.Errorf("bad velocity: got %v, want 3", v)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// AssetState is how far along an asset is in loading.
+type AssetState int
+
+const (
+	// AssetUnloaded assets haven't been requested yet.
+	AssetUnloaded AssetState = iota
+
+	// AssetLoading assets are waiting for, or being loaded by, a worker.
+	AssetLoading
+
+	// AssetLoaded assets can be retrieved with Handle.Get.
+	AssetLoaded
+
+	// AssetFailed assets couldn't be loaded; Handle.Err says why.
+	AssetFailed
+)
+
+func (s AssetState) String() string {
+	switch s {
+	case AssetLoading:
+		return "loading"
+	case AssetLoaded:
+		return "loaded"
+	case AssetFailed:
+		return "failed"
+	}
+	return "unloaded"
+}
+
+// AssetFinished is emitted once an asset has finished loading, successfully
+// or not.
+type AssetFinished struct {
+	Path string
+	Err  error
+}
+
+// A Handle is a component referring to an asset of type T, such as a texture,
+// sound, or configuration file, by its path. Handles are cheap to copy and
+// compare, and the asset itself is loaded in the background by the world's
+// Assets:
+//
+//     ecs.AddAssetLoader(assets, loadTexture)
+//     world.AddObject(ecs.NewObject(ecs.LoadAsset[*Texture](assets, "hero.png"), Position{}))
+//
+//     func draw(assets *ecs.Assets, h ecs.Handle[*Texture], p Position) {
+//         if tex, ok := h.Get(assets); ok {
+//             ...
+//         }
+//     }
+type Handle[T any] struct {
+	Path string
+}
+
+// State returns how far along the handle's asset is in loading.
+func (h Handle[T]) State(a *Assets) AssetState {
+	state, _, _ := a.lookup(h.assetKey())
+	return state
+}
+
+// Get returns the handle's asset, and whether it has been loaded.
+func (h Handle[T]) Get(a *Assets) (T, bool) {
+	state, value, _ := a.lookup(h.assetKey())
+	if state != AssetLoaded {
+		var zero T
+		return zero, false
+	}
+	return value.(T), true
+}
+
+// Err returns the error that loading the handle's asset failed with, if any.
+func (h Handle[T]) Err(a *Assets) error {
+	_, _, err := a.lookup(h.assetKey())
+	return err
+}
+
+func (h Handle[T]) assetKey() assetKey {
+	return assetKey{reflect.TypeOf((*T)(nil)).Elem(), h.Path}
+}
+
+// assetHandle is implemented by every Handle, so that handles of any type
+// can be found among an object's components.
+type assetHandle interface {
+	assetKey() assetKey
+}
+
+type assetKey struct {
+	t    reflect.Type
+	path string
+}
+
+type asset struct {
+	state AssetState
+	value interface{}
+	err   error
+}
+
+// Assets is a resource that loads the assets handles refer to on a pool of
+// background workers, using the loader added for each asset type with
+// AddAssetLoader. Each asset is loaded once, however many handles refer to
+// it.
+type Assets struct {
+	w       *World
+	workers chan struct{}
+
+	mu      sync.Mutex
+	loaders map[reflect.Type]func(path string) (interface{}, error)
+	assets  map[assetKey]*asset
+}
+
+// NewAssets creates an asset loader for the world, which loads at most
+// workers assets at once, and adds it to the world as a resource.
+func NewAssets(w *World, workers int) *Assets {
+	if workers < 1 {
+		workers = 1
+	}
+	a := &Assets{
+		w:       w,
+		workers: make(chan struct{}, workers),
+		loaders: make(map[reflect.Type]func(string) (interface{}, error)),
+		assets:  make(map[assetKey]*asset),
+	}
+	w.AddResource(a)
+	return a
+}
+
+// AddAssetLoader sets the function that loads assets of type T.
+func AddAssetLoader[T any](a *Assets, load func(path string) (T, error)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.loaders[reflect.TypeOf((*T)(nil)).Elem()] = func(path string) (interface{}, error) {
+		return load(path)
+	}
+}
+
+// LoadAsset starts loading an asset of type T in the background, unless it
+// has already been requested, and returns a handle to it.
+func LoadAsset[T any](a *Assets, path string) Handle[T] {
+	h := Handle[T]{Path: path}
+	a.request(h.assetKey())
+	return h
+}
+
+// Ready reports whether every asset that the object has a handle to has been
+// loaded, starting to load any that haven't been requested yet. Systems can
+// use it to hold off activating an object until its assets are available.
+func (a *Assets) Ready(ob *Object) bool {
+	ready := true
+	for _, c := range ob.Components() {
+		h, ok := c.(assetHandle)
+		if !ok {
+			continue
+		}
+		state, _, _ := a.request(h.assetKey())
+		if state != AssetLoaded {
+			ready = false
+		}
+	}
+	return ready
+}
+
+// Pending returns the number of assets that are still loading.
+func (a *Assets) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for _, as := range a.assets {
+		if as.state == AssetLoading {
+			n++
+		}
+	}
+	return n
+}
+
+// System returns a global system that starts loading the assets referred to
+// by every handle in the world, such as those of objects loaded from a scene
+// or snapshot, which were never requested with LoadAsset.
+func (a *Assets) System(ticker <-chan time.Time) System {
+	return System{
+		Func:   a.requestAll,
+		Name:   "Assets",
+		Ticker: ticker,
+		Global: true,
+	}
+}
+
+func (a *Assets) requestAll(w *World) {
+	for _, ob := range w.Objects() {
+		for _, c := range ob.Components() {
+			if h, ok := c.(assetHandle); ok {
+				a.request(h.assetKey())
+			}
+		}
+	}
+}
+
+func (a *Assets) lookup(key assetKey) (AssetState, interface{}, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	as, ok := a.assets[key]
+	if !ok {
+		return AssetUnloaded, nil, nil
+	}
+	return as.state, as.value, as.err
+}
+
+// request starts loading an asset if it hasn't been requested yet, and
+// returns its state.
+func (a *Assets) request(key assetKey) (AssetState, interface{}, error) {
+	a.mu.Lock()
+	if as, ok := a.assets[key]; ok {
+		state, value, err := as.state, as.value, as.err
+		a.mu.Unlock()
+		return state, value, err
+	}
+	load, ok := a.loaders[key.t]
+	if !ok {
+		err := fmt.Errorf("no loader for assets of type %s", key.t)
+		a.assets[key] = &asset{state: AssetFailed, err: err}
+		a.mu.Unlock()
+		a.w.Emit(AssetFinished{Path: key.path, Err: err})
+		return AssetFailed, nil, err
+	}
+	as := &asset{state: AssetLoading}
+	a.assets[key] = as
+	a.mu.Unlock()
+
+	go func() {
+		a.workers <- struct{}{}
+		value, err := load(key.path)
+		<-a.workers
+
+		a.mu.Lock()
+		if err != nil {
+			as.state, as.err = AssetFailed, err
+		} else {
+			as.state, as.value = AssetLoaded, value
+		}
+		a.mu.Unlock()
+		a.w.Emit(AssetFinished{Path: key.path, Err: err})
+	}()
+	return AssetLoading, nil, nil
+}
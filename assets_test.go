@@ -0,0 +1,99 @@
+package ecs_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Texture struct{ Name string }
+
+type Sound struct{}
+
+// waitFor steps the world until cond holds, failing the test if it doesn't
+// within a second.
+func waitFor(t *testing.T, w *ecs.World, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition never held")
+		}
+		time.Sleep(time.Millisecond)
+		w.Step()
+	}
+}
+
+func TestAssets(t *testing.T) {
+	world := ecs.NewWorld()
+	assets := ecs.NewAssets(world, 2)
+	release := make(chan struct{})
+	loads := 0
+	ecs.AddAssetLoader(assets, func(path string) (*Texture, error) {
+		<-release
+		loads++
+		if path == "missing.png" {
+			return nil, errors.New("not found")
+		}
+		return &Texture{Name: path}, nil
+	})
+	var finished []ecs.AssetFinished
+	ecs.OnMessage(world, func(ev ecs.AssetFinished) { finished = append(finished, ev) })
+
+	hero := ecs.LoadAsset[*Texture](assets, "hero.png")
+	e := world.AddObject(ecs.NewObject(hero, Position(0)))
+	if got := hero.State(assets); got != ecs.AssetLoading {
+		t.Errorf("state = %s, want loading", got)
+	}
+	if assets.Ready(world.GetObject(e)) {
+		t.Error("object ready before its texture loaded")
+	}
+	if _, ok := hero.Get(assets); ok {
+		t.Error("got the texture before it loaded")
+	}
+
+	close(release)
+	waitFor(t, world, func() bool { return assets.Ready(world.GetObject(e)) })
+	if tex, ok := hero.Get(assets); !ok || tex.Name != "hero.png" {
+		t.Errorf("texture = %v, %t", tex, ok)
+	}
+	ecs.LoadAsset[*Texture](assets, "hero.png")
+	if loads != 1 {
+		t.Errorf("texture loaded %d times, want 1", loads)
+	}
+	if len(finished) != 1 || finished[0].Path != "hero.png" || finished[0].Err != nil {
+		t.Errorf("finished = %v", finished)
+	}
+
+	missing := ecs.LoadAsset[*Texture](assets, "missing.png")
+	waitFor(t, world, func() bool { return missing.State(assets) == ecs.AssetFailed })
+	if missing.Err(assets) == nil {
+		t.Error("expected an error for a failed asset")
+	}
+	if noLoader := ecs.LoadAsset[Sound](assets, "boom.wav"); noLoader.State(assets) != ecs.AssetFailed {
+		t.Error("expected an asset without a loader to fail")
+	}
+}
+
+func TestAssetsSystem(t *testing.T) {
+	world := ecs.NewWorld()
+	assets := ecs.NewAssets(world, 1)
+	ecs.AddAssetLoader(assets, func(path string) (*Texture, error) {
+		return &Texture{Name: path}, nil
+	})
+	world.AddSystem(assets.System(nil))
+
+	// a handle that was never requested, as if loaded from a scene
+	h := ecs.Handle[*Texture]{Path: "tree.png"}
+	world.AddObject(ecs.NewObject(h))
+	if h.State(assets) != ecs.AssetUnloaded {
+		t.Error("asset requested before the system ran")
+	}
+	world.Step()
+	waitFor(t, world, func() bool { return h.State(assets) == ecs.AssetLoaded })
+	if assets.Pending() != 0 {
+		t.Errorf("%d assets pending, want 0", assets.Pending())
+	}
+}
@@ -0,0 +1,207 @@
+package ecs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"io"
+	"reflect"
+	"sync/atomic"
+)
+
+// Save writes every object in the world, along with the next entity ID to be
+// allocated, in a compact binary format that is considerably faster to encode
+// and decode than JSON. Every component type must have been registered with
+// Register or RegisterName; components are encoded using their registered
+// Codec if they have one, and gob otherwise.
+func (w *World) Save(out io.Writer) error {
+	bw := bufio.NewWriter(out)
+	if err := w.encodeObjects(gob.NewEncoder(bw)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Load reads objects written by Save, replacing every object currently in the
+// world. Entity IDs are preserved, and entities allocated afterwards will not
+// collide with them.
+func (w *World) Load(in io.Reader) error {
+	return w.decodeObjects(gob.NewDecoder(bufio.NewReader(in)))
+}
+
+func (w *World) encodeObjects(enc *gob.Encoder) error {
+	w.objectsMu.RLock()
+	defer w.objectsMu.RUnlock()
+
+	if err := enc.Encode(Entity(atomic.LoadUint64(&gid) + 1)); err != nil {
+		return err
+	}
+	if err := enc.Encode(len(w.objects)); err != nil {
+		return err
+	}
+	for _, ob := range w.objects {
+		if err := encodeObject(enc, ob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *World) decodeObjects(dec *gob.Decoder) error {
+	var next Entity
+	if err := dec.Decode(&next); err != nil {
+		return err
+	}
+	var n int
+	if err := dec.Decode(&n); err != nil {
+		return err
+	}
+
+	objects := make([]*Object, n)
+	for i := range objects {
+		ob, err := decodeObject(dec)
+		if err != nil {
+			return err
+		}
+		objects[i] = ob
+	}
+
+	w.replaceObjects(objects, next)
+	return nil
+}
+
+func encodeObject(enc *gob.Encoder, ob *Object) error {
+	if err := enc.Encode(ob.entity); err != nil {
+		return err
+	}
+	components := ob.Components()
+	if err := enc.Encode(len(components)); err != nil {
+		return err
+	}
+	for _, c := range components {
+		if err := encodeComponent(enc, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeObject(dec *gob.Decoder) (*Object, error) {
+	ob := new(Object)
+	if err := dec.Decode(&ob.entity); err != nil {
+		return nil, err
+	}
+	var n int
+	if err := dec.Decode(&n); err != nil {
+		return nil, err
+	}
+	ob.components = make([]interface{}, n)
+	for i := range ob.components {
+		c, err := decodeComponent(dec)
+		if err != nil {
+			return nil, err
+		}
+		ob.components[i] = c
+	}
+	return ob, nil
+}
+
+func encodeComponent(enc *gob.Encoder, c interface{}) error {
+	t := reflect.TypeOf(c)
+	name, err := registeredName(t)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(name); err != nil {
+		return err
+	}
+
+	if codec := registeredCodec(t); codec != nil {
+		data, err := codec.Encode(c)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(data)
+	}
+
+	// gob refuses to encode types without any data, such as marker
+	// components, but there's nothing to encode anyway
+	if t.Size() == 0 {
+		return nil
+	}
+	return enc.Encode(c)
+}
+
+func decodeComponent(dec *gob.Decoder) (interface{}, error) {
+	var name string
+	if err := dec.Decode(&name); err != nil {
+		return nil, err
+	}
+	t, err := registeredType(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if codec := registeredCodec(t); codec != nil {
+		var data []byte
+		if err := dec.Decode(&data); err != nil {
+			return nil, err
+		}
+		return codec.Decode(data)
+	}
+
+	v := reflect.New(t)
+	if t.Size() != 0 {
+		if err := dec.DecodeValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return v.Elem().Interface(), nil
+}
+
+// ComponentName returns the name that the component's type was registered
+// under.
+func ComponentName(component interface{}) (string, error) {
+	return registeredName(reflect.TypeOf(component))
+}
+
+// MarshalComponent encodes a single component as Save would, returning the
+// name its type was registered under along with the encoded bytes. It lets
+// other packages, such as ecsnet, send components individually.
+func MarshalComponent(component interface{}) (string, []byte, error) {
+	t := reflect.TypeOf(component)
+	name, err := registeredName(t)
+	if err != nil {
+		return "", nil, err
+	}
+	if codec := registeredCodec(t); codec != nil {
+		data, err := codec.Encode(component)
+		return name, data, err
+	}
+	if t.Size() == 0 {
+		return name, nil, nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(component); err != nil {
+		return "", nil, err
+	}
+	return name, buf.Bytes(), nil
+}
+
+// UnmarshalComponent decodes a component encoded by MarshalComponent.
+func UnmarshalComponent(name string, data []byte) (interface{}, error) {
+	t, err := registeredType(name)
+	if err != nil {
+		return nil, err
+	}
+	if codec := registeredCodec(t); codec != nil {
+		return codec.Decode(data)
+	}
+	v := reflect.New(t)
+	if t.Size() != 0 {
+		if err := gob.NewDecoder(bytes.NewReader(data)).DecodeValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,89 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBridgeFull is returned by Bridge.TrySend when the destination world has
+// not yet received enough of the bridge's pending events to make room.
+var ErrBridgeFull = errors.New("bridge is full")
+
+// Bridge carries events of type T from other worlds, or any other goroutine,
+// into a destination world. Events sent over a bridge are picked up at the
+// destination's next tick boundary, after one of its systems finishes a tick,
+// and are then delivered to its OnEvent handlers like any other event:
+//
+//     toMatch := ecs.NewBridge[MatchFound](match, 16)
+//     toMatch.TrySend(MatchFound{ID: id})
+//
+// A bridge for several event types can be made with an interface type, such
+// as NewBridge[any], at the cost of checking types at run time.
+//
+// A bridge holds a bounded number of pending events, so a destination world
+// that falls behind applies backpressure to its senders.
+type Bridge[T any] struct {
+	ch chan T
+}
+
+// bridge is implemented by every Bridge, whatever its event type, so that a
+// world can receive from all of them.
+type bridge interface {
+	receive(events []queuedEvent) []queuedEvent
+}
+
+// NewBridge creates a bridge into the world that holds at most capacity
+// pending events.
+func NewBridge[T any](w *World, capacity int) *Bridge[T] {
+	b := &Bridge[T]{ch: make(chan T, capacity)}
+	w.eventsMu.Lock()
+	w.bridges = append(w.bridges, b)
+	w.eventsMu.Unlock()
+	return b
+}
+
+// Send queues an event for delivery, waiting for room if the bridge is full
+// until the context is done.
+func (b *Bridge[T]) Send(ctx context.Context, ev T) error {
+	select {
+	case b.ch <- ev:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySend queues an event for delivery, or returns ErrBridgeFull without
+// waiting if there is no room.
+func (b *Bridge[T]) TrySend(ev T) error {
+	select {
+	case b.ch <- ev:
+		return nil
+	default:
+		return ErrBridgeFull
+	}
+}
+
+// Pending returns the number of events waiting to be received.
+func (b *Bridge[T]) Pending() int {
+	return len(b.ch)
+}
+
+// receive appends the events pending when it is called to events and returns
+// the result. Events sent meanwhile are left for the next call, so a busy
+// sender can't keep it receiving indefinitely.
+func (b *Bridge[T]) receive(events []queuedEvent) []queuedEvent {
+	for n := len(b.ch); n > 0; n-- {
+		select {
+		case ev := <-b.ch:
+			priority := 0
+			if p, ok := interface{}(ev).(Prioritized); ok {
+				priority = p.Priority()
+			}
+			events = append(events, queuedEvent{Event: ev, Priority: priority})
+		default:
+			return events
+		}
+	}
+	return events
+}
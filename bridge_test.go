@@ -0,0 +1,106 @@
+package ecs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestBridge(t *testing.T) {
+	type MatchFound struct{ ID int }
+
+	lobby, match := ecs.NewWorld(), ecs.NewWorld()
+	toMatch := ecs.NewBridge[MatchFound](match, 1)
+
+	lobby.AddObject(ecs.NewObject(Player{}))
+	lobby.AddSystem(ecs.System{Func: func(Player) {
+		if err := toMatch.TrySend(MatchFound{ID: 1}); err != nil {
+			t.Errorf("failed to send first event: %s", err)
+		}
+		if err := toMatch.TrySend(MatchFound{ID: 2}); err != ecs.ErrBridgeFull {
+			t.Errorf("expected bridge to be full, got %v", err)
+		}
+	}})
+	lobby.Run()
+
+	var received []MatchFound
+	match.OnEvent(MatchFound{}, func(ev interface{}) {
+		received = append(received, ev.(MatchFound))
+	})
+	if len(received) != 0 {
+		t.Fatal("events should not be delivered before the match ticks")
+	}
+
+	match.AddObject(ecs.NewObject())
+	match.AddSystem(ecs.System{Func: func() {}})
+	match.Run()
+
+	if got, want := len(received), 1; got != want {
+		t.Fatalf("wrong number of events received: got %d, want %d", got, want)
+	}
+	if got, want := received[0].ID, 1; got != want {
+		t.Errorf("bad event: got %d, want %d", got, want)
+	}
+
+	// now that the match has caught up, there should be room again
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := toMatch.Send(ctx, MatchFound{ID: 3}); err != nil {
+		t.Errorf("failed to send: %s", err)
+	}
+}
+
+// echo sends another echo over echoes whenever its priority is checked, as a
+// sender that keeps a bridge busy would.
+type echo struct{}
+
+var echoes *ecs.Bridge[echo]
+
+func (echo) Priority() int {
+	echoes.TrySend(echo{})
+	return 0
+}
+
+func TestBridgeBusySender(t *testing.T) {
+	world := ecs.NewWorld()
+	echoes = ecs.NewBridge[echo](world, 4)
+	received := 0
+	world.OnEvent(echo{}, func(interface{}) { received++ })
+	echoes.TrySend(echo{})
+
+	world.Step()
+	if received != 1 {
+		t.Errorf("received %d events, want only the one pending", received)
+	}
+	if got := echoes.Pending(); got != 1 {
+		t.Errorf("pending = %d, want 1", got)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	type PlayerJoined struct{ Name string }
+
+	shard := ecs.NewWorld()
+	toShard := ecs.NewChannel[PlayerJoined](shard, 1)
+	var joined []string
+	ecs.OnMessage(shard, func(msg PlayerJoined) {
+		joined = append(joined, msg.Name)
+	})
+
+	if err := toShard.TrySend(PlayerJoined{Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := toShard.TrySend(PlayerJoined{Name: "bob"}); err != ecs.ErrBridgeFull {
+		t.Errorf("expected channel to be full, got %v", err)
+	}
+	if got := toShard.Pending(); got != 1 {
+		t.Errorf("pending = %d, want 1", got)
+	}
+
+	shard.Step()
+	if len(joined) != 1 || joined[0] != "alice" {
+		t.Errorf("received %v, want [alice]", joined)
+	}
+}
@@ -0,0 +1,51 @@
+package ecs
+
+import "context"
+
+// A Channel is a Bridge for sending messages between worlds, such as one per
+// map instance or shard, whose messages are received with OnMessage handlers
+// that take the message type itself:
+//
+//     toShard := ecs.NewChannel[PlayerJoined](shard, 64)
+//     ecs.OnMessage(shard, func(msg PlayerJoined) { ... })
+//
+//     toShard.TrySend(PlayerJoined{Name: "alice"})
+//
+// Like any bridge, a channel's messages are delivered at the destination's
+// next tick boundary, alongside its other events.
+type Channel[T any] struct {
+	b *Bridge[T]
+}
+
+// NewChannel creates a channel into the world that holds at most capacity
+// pending messages.
+func NewChannel[T any](w *World, capacity int) *Channel[T] {
+	return &Channel[T]{b: NewBridge[T](w, capacity)}
+}
+
+// Send queues a message for delivery, waiting for room if the channel is full
+// until the context is done.
+func (c *Channel[T]) Send(ctx context.Context, msg T) error {
+	return c.b.Send(ctx, msg)
+}
+
+// TrySend queues a message for delivery, or returns ErrBridgeFull without
+// waiting if there is no room.
+func (c *Channel[T]) TrySend(msg T) error {
+	return c.b.TrySend(msg)
+}
+
+// Pending returns the number of messages waiting to be received.
+func (c *Channel[T]) Pending() int {
+	return c.b.Pending()
+}
+
+// OnMessage registers a handler that will be invoked with each event of type
+// T delivered to the world, whether emitted by the world itself or received
+// over a channel or bridge. T must not be an interface type.
+func OnMessage[T any](w *World, handler func(T)) {
+	var zero T
+	w.OnEvent(zero, func(ev interface{}) {
+		handler(ev.(T))
+	})
+}
@@ -0,0 +1,145 @@
+package ecs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tick returns the number of the world's current tick, which is advanced by
+// each tick of the world's clock and each call to Step. It starts at zero,
+// so the first tick is tick 1.
+func (w *World) Tick() uint64 {
+	return atomic.LoadUint64(&w.ticks)
+}
+
+// clocked reports whether a system ticks on the world's clock.
+func (w *World) clocked(s *scheduledSystem) bool {
+	return w.TickRate > 0 && !s.periodic() && s.EveryNTicks > 0
+}
+
+// dueOn reports whether the system should run on tick n.
+func (s *scheduledSystem) dueOn(n uint64) bool {
+	return s.EveryNTicks <= 1 || n%uint64(s.EveryNTicks) == 0
+}
+
+// worldClock ticks the systems subscribed to a world's clock. It runs while
+// any system is subscribed.
+type worldClock struct {
+	mu   sync.Mutex
+	subs map[*scheduledSystem]*clockSub
+
+	// quit is closed to stop the running clock, or nil if there isn't one.
+	quit chan struct{}
+}
+
+// clockSub is a system's subscription to the world's clock.
+type clockSub struct {
+	ticks chan clockTick
+
+	// gone is closed when the system unsubscribes, so that the clock never
+	// waits on a system that has stopped.
+	gone chan struct{}
+}
+
+type clockTick struct {
+	n    uint64
+	now  time.Time
+	done *sync.WaitGroup
+}
+
+// subscribe subscribes a system to the clock, starting the clock if it isn't
+// running.
+func (c *worldClock) subscribe(w *World, s *scheduledSystem) *clockSub {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sub := &clockSub{ticks: make(chan clockTick), gone: make(chan struct{})}
+	if c.subs == nil {
+		c.subs = make(map[*scheduledSystem]*clockSub)
+	}
+	c.subs[s] = sub
+	if c.quit == nil {
+		c.quit = make(chan struct{})
+		go c.run(w, w.TickRate, c.quit)
+	}
+	return sub
+}
+
+// unsubscribe unsubscribes a system from the clock, stopping the clock along
+// with the last one.
+func (c *worldClock) unsubscribe(s *scheduledSystem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	close(c.subs[s].gone)
+	delete(c.subs, s)
+	if len(c.subs) == 0 {
+		close(c.quit)
+		c.quit = nil
+	}
+}
+
+func (c *worldClock) run(w *World, rate time.Duration, quit chan struct{}) {
+	ticker := time.NewTicker(rate)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			c.tick(w, now)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// tick advances the world's tick, sends it to every system due on it, and
+// waits for them to finish.
+func (c *worldClock) tick(w *World, now time.Time) {
+	c.mu.Lock()
+	subs := make(map[*scheduledSystem]*clockSub, len(c.subs))
+	for s, sub := range c.subs {
+		subs[s] = sub
+	}
+	c.mu.Unlock()
+
+	n := atomic.AddUint64(&w.ticks, 1)
+	var done sync.WaitGroup
+	for s, sub := range subs {
+		if !s.dueOn(n) {
+			// not due, but not stalled either
+			atomic.StoreInt64(&s.lastSeen, time.Now().UnixNano())
+			continue
+		}
+		done.Add(1)
+		select {
+		case sub.ticks <- clockTick{n: n, now: now, done: &done}:
+		case <-sub.gone:
+			done.Done()
+		}
+	}
+	done.Wait()
+}
+
+// runClocked runs a system on the world's clock until it fails, is removed,
+// or the context is cancelled.
+func (s *scheduledSystem) runClocked(ctx context.Context, w *World) error {
+	sub := w.clock.subscribe(w, s)
+	defer w.clock.unsubscribe(s)
+	for {
+		select {
+		case t := <-sub.ticks:
+			s.tickOnThread(w, t.now, t.n)
+			w.tickBoundary()
+			t.done.Done()
+			if err := s.failure.get(); err != nil {
+				return err
+			}
+
+		case <-s.stop:
+			return nil
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ecs_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestTickRate(t *testing.T) {
+	world := ecs.NewWorld()
+	world.TickRate = time.Millisecond
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string][]uint64)
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	record := func(name string) func(*ecs.World, Position) {
+		return func(w *ecs.World, _ Position) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[name] = append(seen[name], w.Tick())
+			if w.Tick() >= 20 {
+				cancel()
+			}
+		}
+	}
+	world.AddSystem(ecs.System{Name: "every", Func: record("every"), EveryNTicks: 1})
+	world.AddSystem(ecs.System{Name: "second", Func: record("second"), EveryNTicks: 2})
+	world.AddSystem(ecs.System{Name: "tenth", Func: record("tenth"), EveryNTicks: 10})
+	world.RunContext(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for name, divisor := range map[string]uint64{"every": 1, "second": 2, "tenth": 10} {
+		ticks := seen[name]
+		if len(ticks) == 0 {
+			t.Errorf("%s: never ticked", name)
+			continue
+		}
+		for i, n := range ticks {
+			if n%divisor != 0 || (i > 0 && n != ticks[i-1]+divisor) {
+				t.Errorf("%s: out of phase: %v", name, ticks)
+				break
+			}
+		}
+	}
+}
+
+func TestStepTick(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+	var ticks []uint64
+	world.AddSystem(ecs.System{
+		Func:        func(w *ecs.World, _ Position) { ticks = append(ticks, w.Tick()) },
+		EveryNTicks: 3,
+	})
+	for i := 0; i < 7; i++ {
+		world.Step()
+	}
+	if world.Tick() != 7 {
+		t.Errorf("bad tick after 7 steps: %d", world.Tick())
+	}
+	if len(ticks) != 2 || ticks[0] != 3 || ticks[1] != 6 {
+		t.Errorf("bad ticks: got %v, want [3 6]", ticks)
+	}
+}
@@ -0,0 +1,114 @@
+package ecs
+
+import (
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+)
+
+// Clone returns a deep, independent copy of the world, for speculative
+// simulation: an AI can step a clone a few ticks ahead to see what would
+// happen if it acted, without touching the real world.
+//
+//     future := world.Clone()
+//     future.GetObject(unit).SetComponent(Order{Target: target})
+//     for i := 0; i < 10; i++ {
+//         future.Step()
+//     }
+//
+// The clone has copies of the world's objects, with the same entities, and
+// of its resources, each deep copied using its Clone method if it is a
+// Cloner. It also has the world's settings, systems, dense storage, hooks,
+// finalizers, event handlers, and current tick. Pending events and commands,
+// bridges, and the Archive aren't copied.
+//
+// Systems and handlers are shared as they are, so any that capture the
+// original world or its objects in a closure still refer to them; systems
+// should take a *World parameter instead. Systems also share their tickers,
+// so a clone is best driven with Step, which ignores them. Since the clone's
+// entities are the same as the world's, objects must not be transferred
+// between them. Clone should be called between ticks.
+func (w *World) Clone() *World {
+	c := NewWorld()
+	c.OnError = w.OnError
+	c.OnPanic = w.OnPanic
+	c.StrictTypes = w.StrictTypes
+	c.UniqueComponents = w.UniqueComponents
+	c.OnDryRun = w.OnDryRun
+	c.Logger = w.Logger
+	c.OnSystemTick = w.OnSystemTick
+	c.OnSlowSystem = w.OnSlowSystem
+	c.Metrics = w.Metrics
+	c.RecycleEntities = w.RecycleEntities
+	c.TickRate = w.TickRate
+	c.HealthTimeout = w.HealthTimeout
+	c.QueryCache = w.QueryCache
+	if w.slots != nil {
+		c.slots = make(chan struct{}, cap(w.slots))
+	}
+	c.deterministic, c.seed = w.deterministic, w.seed
+	atomic.StoreUint64(&c.ticks, w.Tick())
+
+	w.resourcesMu.RLock()
+	resources := make([]interface{}, 0, len(w.resources))
+	for _, r := range w.resources {
+		resources = append(resources, r.Interface())
+	}
+	w.resourcesMu.RUnlock()
+	for _, r := range resources {
+		c.AddResource(deepCopy(r))
+	}
+	if w.deterministic {
+		// the random number generator's state is unexported, so it is
+		// recreated from the seed and the number of values drawn
+		if r, ok := w.Resource((*rand.Rand)(nil)).(*rand.Rand); ok && r == w.rng {
+			c.source = w.source.clone()
+			c.rng = rand.New(c.source)
+			c.AddResource(c.rng)
+		}
+	}
+
+	w.finalizersMu.RLock()
+	for t, fns := range w.finalizers {
+		c.finalizers[t] = append([]func(Entity, interface{}){}, fns...)
+	}
+	w.finalizersMu.RUnlock()
+	w.hooksMu.RLock()
+	for key, fns := range w.hooks {
+		c.hooks[key] = append([]func(Entity, interface{}){}, fns...)
+	}
+	w.hooksMu.RUnlock()
+	w.eventsMu.Lock()
+	for t, fns := range w.handlers {
+		c.handlers[t] = append([]func(interface{}){}, fns...)
+	}
+	w.eventsMu.Unlock()
+
+	columns := make(map[reflect.Type]denseColumn)
+	for t, col := range w.columnMap() {
+		columns[t] = col.empty()
+	}
+	c.columns.Store(&columns)
+
+	objects := w.Objects()
+	clones := make([]*Object, len(objects))
+	for i, ob := range objects {
+		components := ob.Components()
+		for j, comp := range components {
+			components[j] = deepCopy(comp)
+		}
+		clones[i] = &Object{entity: ob.entity, components: components}
+	}
+	c.replaceObjects(clones, 0)
+
+	for _, s := range w.systemList() {
+		if s.removed() {
+			continue
+		}
+		ss := c.schedule(s.System)
+		ss.disabled = atomic.LoadInt32(&s.disabled)
+		ss.ran = atomic.LoadInt32(&s.ran)
+		c.systems = append(c.systems, ss)
+	}
+	return c
+}
@@ -0,0 +1,89 @@
+package ecs_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// Handle is a component whose Clone method allocates a new ID rather than
+// copying it.
+type Handle struct {
+	ID     int
+	cloned bool
+}
+
+func (h Handle) Clone() interface{} {
+	return Handle{ID: h.ID + 100, cloned: true}
+}
+
+func TestClone(t *testing.T) {
+	world := ecs.NewWorld()
+	ecs.StoreDense[Velocity](world)
+	e := world.AddObject(ecs.NewObject(Position(0), Velocity(2), Backpack{Items: []string{"map"}}, Handle{ID: 1}))
+	world.AddSystem(ecs.System{
+		Func: func(p Position, v Velocity) Position { return p + Position(v) },
+	})
+	world.Step()
+
+	future := world.Clone()
+	if future.Tick() != world.Tick() {
+		t.Errorf("clone's tick = %d, want %d", future.Tick(), world.Tick())
+	}
+	for i := 0; i < 3; i++ {
+		future.Step()
+	}
+	ob := future.GetObject(e)
+	if ob == nil {
+		t.Fatal("object missing from clone")
+	}
+	if got := ob.Component(Position(0)); got != Position(8) {
+		t.Errorf("clone's position = %v, want 8", got)
+	}
+	if got := world.GetObject(e).Component(Position(0)); got != Position(2) {
+		t.Errorf("original's position = %v, want 2", got)
+	}
+
+	ob.Component(Backpack{}).(Backpack).Items[0] = "compass"
+	if got := world.GetObject(e).Component(Backpack{}).(Backpack).Items; !reflect.DeepEqual(got, []string{"map"}) {
+		t.Errorf("original's backpack = %v, want [map]", got)
+	}
+	if got := ob.Component(Handle{}).(Handle).ID; got != 101 {
+		t.Errorf("clone's handle = %d, want 101 from Clone", got)
+	}
+	if _, ok := ecs.DenseColumn[Velocity](future); !ok {
+		t.Error("clone lacks dense storage for Velocity")
+	}
+}
+
+func TestCloneDeterministic(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Deterministic(5)
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddSystem(ecs.System{
+		Func: func(r *rand.Rand, p Position) Position { return p + Position(r.Intn(100)) },
+	})
+	world.Step()
+	world.Step()
+
+	future := world.Clone()
+	var a, b []interface{}
+	for i := 0; i < 5; i++ {
+		world.Step()
+		future.Step()
+	}
+	for _, ob := range world.Objects() {
+		a = append(a, ob.Components()...)
+	}
+	for _, ob := range future.Objects() {
+		b = append(b, ob.Components()...)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("clone diverged: %v != %v", b, a)
+	}
+	if world.Resource((*rand.Rand)(nil)) == future.Resource((*rand.Rand)(nil)) {
+		t.Error("clone shares the original's random number generator")
+	}
+}
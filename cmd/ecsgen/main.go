@@ -0,0 +1,295 @@
+// Command ecsgen generates code that lets a world call systems without
+// reflection, while they are still written as plain functions. Mark each
+// system function with an ecs:system directive, and run ecsgen from
+// go:generate in the same package:
+//
+//     //go:generate go run github.com/dradtke/ecs-go/cmd/ecsgen
+//
+//     //ecs:system
+//     func Movement(p Position, v Velocity) Position {
+//         ...
+//     }
+//
+// For each marked function, ecsgen writes an ecs.Invoker type that calls it
+// directly, and a constructor named after the function that returns it as a
+// system, which is used in place of the function:
+//
+//     world.AddSystem(MovementSystem())
+//
+// The constructor is exported only if the function is. Generated code is
+// written to systems_gen.go, or the file given by -output.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	directive = "//ecs:system"
+	ecsPath   = "github.com/dradtke/ecs-go"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("ecsgen: ")
+	output := flag.String("output", "systems_gen.go", "name of the generated file")
+	flag.Parse()
+
+	src, err := generate(".", *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// system is a function marked with the directive.
+type system struct {
+	name    string
+	params  []string
+	results []string
+	isError []bool
+}
+
+// generate returns the generated code for the package in dir, ignoring the
+// previously generated output file.
+func generate(dir, output string) ([]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var (
+		pkg     string
+		systems []system
+		imports = make(map[string]string) // name -> path
+	)
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == filepath.Base(output) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg = f.Name.Name
+
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !marked(fn) {
+				continue
+			}
+			s, used, err := parseSystem(fset, f, fn)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", fset.Position(fn.Pos()), fn.Name.Name, err)
+			}
+			systems = append(systems, s)
+			for name, path := range used {
+				if other, ok := imports[name]; ok && other != path {
+					return nil, fmt.Errorf("%s: %s: package name %s refers to both %q and %q", fset.Position(fn.Pos()), fn.Name.Name, name, other, path)
+				}
+				imports[name] = path
+			}
+		}
+	}
+	if pkg == "" {
+		return nil, errors.New("no Go files found")
+	}
+	if len(systems) == 0 {
+		return nil, fmt.Errorf("no functions marked with %s", directive)
+	}
+	imports["reflect"] = "reflect"
+	imports["ecs"] = ecsPath
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by ecsgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	// standard library packages first, as goimports would have it
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if si, sj := isStd(imports[names[i]]), isStd(imports[names[j]]); si != sj {
+			return si
+		}
+		return imports[names[i]] < imports[names[j]]
+	})
+	for i, name := range names {
+		path := imports[name]
+		if i > 0 && isStd(path) != isStd(imports[names[i-1]]) {
+			buf.WriteString("\n")
+		}
+		if name == defaultName(path) {
+			fmt.Fprintf(&buf, "\t%s\n", strconv.Quote(path))
+		} else {
+			fmt.Fprintf(&buf, "\t%s %s\n", name, strconv.Quote(path))
+		}
+	}
+	buf.WriteString(")\n")
+	for _, s := range systems {
+		s.write(&buf)
+	}
+	return format.Source(buf.Bytes())
+}
+
+func marked(fn *ast.FuncDecl) bool {
+	if fn.Doc == nil {
+		return false
+	}
+	for _, c := range fn.Doc.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSystem describes a marked function, and returns the imports its
+// signature uses.
+func parseSystem(fset *token.FileSet, f *ast.File, fn *ast.FuncDecl) (system, map[string]string, error) {
+	s := system{name: fn.Name.Name}
+	if fn.Recv != nil {
+		return s, nil, errors.New("methods cannot be marked as systems")
+	}
+	if fn.Type.TypeParams != nil {
+		return s, nil, errors.New("generic functions cannot be marked as systems")
+	}
+
+	used := make(map[string]string)
+	expr := func(e ast.Expr) (string, error) {
+		var err error
+		ast.Inspect(e, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if id, ok := sel.X.(*ast.Ident); ok {
+				path, found := importPath(f, id.Name)
+				if !found {
+					err = fmt.Errorf("no import for package %s", id.Name)
+				}
+				used[id.Name] = path
+			}
+			return false
+		})
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, e)
+		return buf.String(), err
+	}
+
+	for _, field := range fn.Type.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return s, nil, errors.New("systems cannot be variadic")
+		}
+		t, err := expr(field.Type)
+		if err != nil {
+			return s, nil, err
+		}
+		for i := 0; i < max(len(field.Names), 1); i++ {
+			s.params = append(s.params, t)
+		}
+	}
+	if fn.Type.Results != nil {
+		for _, field := range fn.Type.Results.List {
+			t, err := expr(field.Type)
+			if err != nil {
+				return s, nil, err
+			}
+			for i := 0; i < max(len(field.Names), 1); i++ {
+				s.results = append(s.results, t)
+				s.isError = append(s.isError, t == "error")
+			}
+		}
+	}
+	return s, used, nil
+}
+
+// importPath returns the path of the package imported by f under name.
+func importPath(f *ast.File, name string) (string, bool) {
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil && spec.Name.Name == name || spec.Name == nil && defaultName(path) == name {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func isStd(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// defaultName guesses the name of the package at path, which for this
+// repository's own path isn't its last element.
+func defaultName(path string) string {
+	if path == ecsPath {
+		return "ecs"
+	}
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func (s system) invoker() string {
+	r, n := utf8.DecodeRuneInString(s.name)
+	return string(unicode.ToLower(r)) + s.name[n:] + "Invoker"
+}
+
+func (s system) write(buf *bytes.Buffer) {
+	invoker := s.invoker()
+	fmt.Fprintf(buf, "\n// %s calls %s without reflection.\n", invoker, s.name)
+	fmt.Fprintf(buf, "type %s func(%s)", invoker, strings.Join(s.params, ", "))
+	switch len(s.results) {
+	case 0:
+	case 1:
+		fmt.Fprintf(buf, " %s", s.results[0])
+	default:
+		fmt.Fprintf(buf, " (%s)", strings.Join(s.results, ", "))
+	}
+	buf.WriteString("\n\n")
+
+	fmt.Fprintf(buf, "func (fn %s) Invoke(args, results []reflect.Value) {\n\t", invoker)
+	if len(s.results) > 0 {
+		rs := make([]string, len(s.results))
+		for i := range rs {
+			rs[i] = fmt.Sprintf("r%d", i)
+		}
+		fmt.Fprintf(buf, "%s := ", strings.Join(rs, ", "))
+	}
+	args := make([]string, len(s.params))
+	for i, p := range s.params {
+		args[i] = fmt.Sprintf("args[%d].Interface().(%s)", i, p)
+	}
+	fmt.Fprintf(buf, "fn(%s)\n", strings.Join(args, ", "))
+	for i := range s.results {
+		if s.isError[i] && i == len(s.results)-1 {
+			fmt.Fprintf(buf, "\tresults[%d] = ecs.ErrorResult(r%d)\n", i, i)
+		} else {
+			fmt.Fprintf(buf, "\tresults[%d] = reflect.ValueOf(r%d)\n", i, i)
+		}
+	}
+	buf.WriteString("}\n")
+
+	constructor := s.name + "System"
+	fmt.Fprintf(buf, "\n// %s returns %s as a system that the world calls without reflection.\n", constructor, s.name)
+	fmt.Fprintf(buf, "func %s() ecs.System {\n\treturn ecs.System{Name: %q, Func: %s(%s)}\n}\n", constructor, s.name, invoker, s.name)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSource(t *testing.T, src string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "systems.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGenerate(t *testing.T) {
+	dir := writeSource(t, `package game
+
+import (
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Position int
+type Velocity int
+
+//ecs:system
+func Movement(p Position, v Velocity) Position {
+	return p + Position(v)
+}
+
+//ecs:system
+func expire(cmds *ecs.Commands, e ecs.Entity, d time.Duration) error {
+	return nil
+}
+
+func notASystem(p Position) Position { return p }
+`)
+	src, err := generate(dir, "systems_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "systems_gen.go", src, 0); err != nil {
+		t.Fatalf("generated code doesn't parse: %v\n%s", err, src)
+	}
+
+	out := string(src)
+	for _, want := range []string{
+		"package game",
+		`"reflect"`,
+		`"time"`,
+		`"github.com/dradtke/ecs-go"`,
+		"type movementInvoker func(Position, Velocity) Position",
+		"results[0] = reflect.ValueOf(r0)",
+		"func MovementSystem() ecs.System",
+		"type expireInvoker func(*ecs.Commands, ecs.Entity, time.Duration) error",
+		"results[0] = ecs.ErrorResult(r0)",
+		"func expireSystem() ecs.System",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated code is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "notASystem") {
+		t.Errorf("generated code for an unmarked function:\n%s", out)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	for name, src := range map[string]string{
+		"unmarked": `package game
+
+func movement() {}
+`,
+		"method": `package game
+
+type T struct{}
+
+//ecs:system
+func (T) movement() {}
+`,
+		"variadic": `package game
+
+//ecs:system
+func movement(xs ...int) {}
+`,
+		"generic": `package game
+
+//ecs:system
+func movement[T any](x T) {}
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := generate(writeSource(t, src), "systems_gen.go"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
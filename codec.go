@@ -0,0 +1,86 @@
+package ecs
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+var (
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// BinaryCodec returns a codec for components of the same type as component,
+// which encode themselves with encoding.BinaryMarshaler and decode with
+// encoding.BinaryUnmarshaler on their pointer type. It panics if the type
+// doesn't implement both. Many types implement them for other reasons,
+// including any struct that embeds a time.Time, so a type's own encoding is
+// only used once it is registered:
+//
+//     ecs.RegisterCodec(Health{}, ecs.BinaryCodec(Health{}))
+func BinaryCodec(component interface{}) Codec {
+	t := reflect.TypeOf(component)
+	if !declaresCodec(t) {
+		panic(fmt.Sprintf("ecs: %s doesn't implement encoding.BinaryMarshaler and encoding.BinaryUnmarshaler", t))
+	}
+	return marshalerCodec{t}
+}
+
+// declaresCodec reports whether components of type t encode themselves with
+// encoding.BinaryMarshaler and decode with encoding.BinaryUnmarshaler.
+func declaresCodec(t reflect.Type) bool {
+	return t != nil && t.Kind() != reflect.Ptr &&
+		t.Implements(binaryMarshalerType) &&
+		reflect.PointerTo(t).Implements(binaryUnmarshalerType)
+}
+
+// marshalerCodec is the codec returned by BinaryCodec.
+type marshalerCodec struct {
+	t reflect.Type
+}
+
+func (c marshalerCodec) Encode(component interface{}) ([]byte, error) {
+	return component.(encoding.BinaryMarshaler).MarshalBinary()
+}
+
+func (c marshalerCodec) Decode(data []byte) (interface{}, error) {
+	v := reflect.New(c.t)
+	if err := v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// NewCodec returns a codec for components of type T built from a pair of
+// functions, which is usually the simplest way to adapt a serialization
+// library:
+//
+//     ecs.RegisterCodec(Transform{}, ecs.NewCodec(
+//         func(t Transform) ([]byte, error) { return proto.Marshal(t.toProto()) },
+//         decodeTransform,
+//     ))
+func NewCodec[T any](encode func(T) ([]byte, error), decode func([]byte) (T, error)) Codec {
+	return funcCodec[T]{encode, decode}
+}
+
+type funcCodec[T any] struct {
+	encode func(T) ([]byte, error)
+	decode func([]byte) (T, error)
+}
+
+func (c funcCodec[T]) Encode(component interface{}) ([]byte, error) {
+	t, ok := component.(T)
+	if !ok {
+		return nil, fmt.Errorf("codec for %T cannot encode %T", t, component)
+	}
+	return c.encode(t)
+}
+
+func (c funcCodec[T]) Decode(data []byte) (interface{}, error) {
+	t, err := c.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,152 @@
+package ecs_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// Health encodes itself as a fixed-width big-endian integer.
+type Health struct {
+	current int32
+}
+
+func (h Health) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint32(nil, uint32(h.current)), nil
+}
+
+func (h *Health) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return errors.New("bad health")
+	}
+	h.current = int32(binary.BigEndian.Uint32(data))
+	return nil
+}
+
+type Label string
+
+func init() {
+	ecs.Register(Health{})
+	ecs.Register(Label(""))
+}
+
+func TestBinaryCodec(t *testing.T) {
+	ecs.RegisterCodec(Health{}, ecs.BinaryCodec(Health{}))
+	defer ecs.RegisterCodec(Health{}, nil)
+
+	// gob and JSON can't see Health's unexported field, so it only survives
+	// a round trip through its own codec.
+	for _, format := range []string{"binary", "json"} {
+		world := ecs.NewWorld()
+		e := world.AddObject(ecs.NewObject(Health{current: 42}))
+
+		var buf bytes.Buffer
+		loaded := ecs.NewWorld()
+		var err error
+		if format == "binary" {
+			if err = world.Save(&buf); err == nil {
+				err = loaded.Load(&buf)
+			}
+		} else {
+			if err = world.SaveJSON(&buf); err == nil {
+				err = loaded.LoadJSON(&buf)
+			}
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		if got := loaded.GetObject(e).Component(Health{}); got != (Health{current: 42}) {
+			t.Errorf("%s: loaded %v, want {42}", format, got)
+		}
+	}
+}
+
+func TestNewCodec(t *testing.T) {
+	ecs.RegisterCodec(Label(""), ecs.NewCodec(
+		func(l Label) ([]byte, error) { return []byte(strings.ToUpper(string(l))), nil },
+		func(data []byte) (Label, error) { return Label(strings.ToLower(string(data))), nil },
+	))
+	defer ecs.RegisterCodec(Label(""), nil)
+
+	name, data, err := ecs.MarshalComponent(Label("hero"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "HERO" {
+		t.Errorf("encoded %q, want HERO", data)
+	}
+	c, err := ecs.UnmarshalComponent(name, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != Label("hero") {
+		t.Errorf("decoded %v, want hero", c)
+	}
+
+	world := ecs.NewWorld()
+	e := world.AddObject(ecs.NewObject(Label("hero")))
+	var buf bytes.Buffer
+	if err := world.SaveJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"data":"SEVSTw=="`) {
+		t.Errorf("snapshot doesn't hold the codec's encoding: %s", buf.String())
+	}
+	loaded := ecs.NewWorld()
+	if err := loaded.LoadJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.GetObject(e).Component(Label("")); got != Label("hero") {
+		t.Errorf("loaded %v, want hero", got)
+	}
+}
+
+// Version implements encoding.BinaryMarshaler, but hasn't registered it as
+// its codec.
+type Version struct {
+	Major, Minor uint8
+}
+
+func (v Version) MarshalBinary() ([]byte, error) {
+	return []byte{v.Major, v.Minor}, nil
+}
+
+func (v *Version) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return errors.New("bad version")
+	}
+	v.Major, v.Minor = data[0], data[1]
+	return nil
+}
+
+func init() {
+	ecs.Register(Version{})
+}
+
+func TestBinaryCodecOptIn(t *testing.T) {
+	world := ecs.NewWorld()
+	e := world.AddObject(ecs.NewObject(Version{1, 2}))
+	var buf bytes.Buffer
+	if err := world.SaveJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.String()
+	if !strings.Contains(saved, `"value":{"Major":1,"Minor":2}`) {
+		t.Errorf("snapshot doesn't hold the JSON encoding: %s", saved)
+	}
+
+	// Snapshots saved before the codec was registered still load.
+	ecs.RegisterCodec(Version{}, ecs.BinaryCodec(Version{}))
+	defer ecs.RegisterCodec(Version{}, nil)
+	loaded := ecs.NewWorld()
+	if err := loaded.LoadJSON(strings.NewReader(saved)); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.GetObject(e).Component(Version{}); got != (Version{1, 2}) {
+		t.Errorf("bad version: got %v, want {1 2}", got)
+	}
+}
@@ -0,0 +1,226 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// OverflowPolicy determines what a command buffer does when a command is
+// queued while it is full.
+type OverflowPolicy int
+
+const (
+	// FlushEarly applies every queued command immediately, on the goroutine
+	// of whichever system is queueing the new command, rather than waiting
+	// for the end of the tick.
+	FlushEarly OverflowPolicy = iota
+
+	// Block makes the system queueing the command wait until the buffer is
+	// applied at a tick boundary. Since the producing system can't finish its
+	// own tick while it is waiting, this relies on other systems to reach
+	// their tick boundaries, and can deadlock if none of them can.
+	Block
+
+	// Drop discards the command. The number of discarded commands is
+	// reported with a CommandsDropped event when the buffer is next applied.
+	Drop
+)
+
+// CommandsDropped is emitted when a command buffer using the Drop policy
+// discarded commands because it was full.
+type CommandsDropped struct {
+	Count int
+}
+
+var commandsType = reflect.TypeOf(&Commands{})
+
+// Commands buffers changes to the structure of the world, such as adding or
+// removing objects and components, until the end of the current tick. Systems
+// receive the world's command buffer by accepting a *Commands parameter, and
+// can use it to make changes without disturbing other systems that are in the
+// middle of iterating over objects.
+type Commands struct {
+	w        *World
+	mu       sync.Mutex
+	flushed  *sync.Cond
+	queue    []func(*World)
+	capacity int
+	policy   OverflowPolicy
+	dropped  int
+
+	// dry is set for the buffers passed to dry-run systems, which record
+	// commands there instead of queueing them.
+	dry *dryRun
+}
+
+func newCommands(w *World) *Commands {
+	c := &Commands{w: w}
+	c.flushed = sync.NewCond(&c.mu)
+	return c
+}
+
+// WithCommandCapacity limits the number of commands that may be queued in the
+// world's command buffer at once, and sets what happens when a command is
+// queued while it is full. By default there is no limit.
+func WithCommandCapacity(capacity int, policy OverflowPolicy) Option {
+	return func(w *World) {
+		w.commands.capacity = capacity
+		w.commands.policy = policy
+	}
+}
+
+// Commands returns the world's command buffer.
+func (w *World) Commands() *Commands {
+	return w.commands
+}
+
+// Spawn queues an object with the given components to be added to the world.
+// The object's entity is allocated immediately, so it can be referred to by
+// other commands before the object is added.
+func (c *Commands) Spawn(cs ...interface{}) Entity {
+	entity := newEntity()
+	c.push(DryRunCommand{Op: "Spawn", Entity: entity, Args: cs}, func(w *World) {
+		w.AddObject(w.newObject(entity, cs))
+	})
+	return entity
+}
+
+// SpawnTemporary is like Spawn, but the object only lasts for a single tick:
+// it is added at the next tick boundary, as usual, and despawned at the
+// boundary after that, once another system has had a chance to see it. As
+// with World.Despawn, it isn't removed while a system is still iterating over
+// it. This suits short-lived values modeled as objects, such as collision
+// contacts or render commands, which would otherwise need a system to clean
+// them up.
+func (c *Commands) SpawnTemporary(cs ...interface{}) Entity {
+	entity := newEntity()
+	c.push(DryRunCommand{Op: "SpawnTemporary", Entity: entity, Args: cs}, func(w *World) {
+		w.AddObject(w.newObject(entity, cs))
+		w.temporaryMu.Lock()
+		w.temporary = append(w.temporary, entity)
+		w.temporaryMu.Unlock()
+	})
+	return entity
+}
+
+// Despawn queues an object to be removed from the world. When the command is
+// applied, the object is despawned as with World.Despawn, so it isn't removed
+// while another system's tick might still be iterating over it.
+func (c *Commands) Despawn(entity Entity) {
+	c.push(DryRunCommand{Op: "Despawn", Entity: entity}, func(w *World) {
+		w.queueDespawn(entity, false)
+	})
+}
+
+// AddComponent queues a component to be added to an object.
+func (c *Commands) AddComponent(entity Entity, component interface{}) {
+	c.push(DryRunCommand{Op: "AddComponent", Entity: entity, Args: []interface{}{component}}, func(w *World) {
+		if ob := w.GetObject(entity); ob != nil {
+			ob.AddComponent(component)
+		}
+	})
+}
+
+// SetComponent queues a component to replace an object's component of the
+// same type, or to be added if it has none.
+func (c *Commands) SetComponent(entity Entity, component interface{}) {
+	c.push(DryRunCommand{Op: "SetComponent", Entity: entity, Args: []interface{}{component}}, func(w *World) {
+		if ob := w.GetObject(entity); ob != nil {
+			ob.SetComponent(component)
+		}
+	})
+}
+
+// Merge queues the object with entity b to be merged into the one with entity
+// a, as with World.Merge. If the merge fails, the error is logged.
+func (c *Commands) Merge(a, b Entity, policy MergePolicy) {
+	c.push(DryRunCommand{Op: "Merge", Entity: a, Args: []interface{}{b, policy}}, func(w *World) {
+		if err := w.Merge(a, b, policy); err != nil {
+			w.logger().Error("merge failed", "entity", a, "merged", b, "error", err)
+		}
+	})
+}
+
+// Transfer queues the object with the given entity to be transferred to
+// another world, as with World.Transfer. If the transfer fails, the error is
+// logged.
+func (c *Commands) Transfer(entity Entity, dst *World) {
+	c.push(DryRunCommand{Op: "Transfer", Entity: entity, Args: []interface{}{dst}}, func(w *World) {
+		if err := w.Transfer(entity, dst); err != nil {
+			w.logger().Error("transfer failed", "entity", entity, "error", err)
+		}
+	})
+}
+
+// RemoveComponent queues the removal of an object's component of the same
+// type as component.
+func (c *Commands) RemoveComponent(entity Entity, component interface{}) {
+	c.push(DryRunCommand{Op: "RemoveComponent", Entity: entity, Args: []interface{}{component}}, func(w *World) {
+		if ob := w.GetObject(entity); ob != nil {
+			ob.RemoveComponent(component)
+		}
+	})
+}
+
+// Emit queues an event to be emitted as with World.Emit when the buffer is
+// applied, which is still in time for it to be delivered at the same tick
+// boundary. Events emitted by a dry-run system are reported to
+// World.OnDryRun and then discarded.
+func (c *Commands) Emit(ev interface{}) {
+	c.push(DryRunEvent{Event: ev}, func(w *World) {
+		w.Emit(ev)
+	})
+}
+
+// Len returns the number of queued commands.
+func (c *Commands) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// push queues a command, or for a dry-run system's buffer, records its
+// description instead.
+func (c *Commands) push(desc interface{}, cmd func(*World)) {
+	if c.dry != nil {
+		c.dry.record(desc)
+		return
+	}
+	c.mu.Lock()
+	for c.capacity > 0 && len(c.queue) >= c.capacity {
+		switch c.policy {
+		case FlushEarly:
+			c.mu.Unlock()
+			c.apply()
+			c.mu.Lock()
+		case Block:
+			c.flushed.Wait()
+		case Drop:
+			c.dropped++
+			c.mu.Unlock()
+			return
+		}
+	}
+	c.queue = append(c.queue, cmd)
+	c.mu.Unlock()
+}
+
+// apply runs every queued command.
+func (c *Commands) apply() {
+	c.mu.Lock()
+	queue, dropped := c.queue, c.dropped
+	c.queue, c.dropped = nil, 0
+	c.flushed.Broadcast()
+	c.mu.Unlock()
+
+	for _, cmd := range queue {
+		cmd(c.w)
+	}
+	if len(queue) > 0 {
+		c.w.metrics().Counter("ecs_commands_applied", float64(len(queue)))
+	}
+	if dropped > 0 {
+		c.w.metrics().Counter("ecs_commands_dropped", float64(dropped))
+		c.w.Emit(CommandsDropped{Count: dropped})
+	}
+}
@@ -0,0 +1,166 @@
+package ecs_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestCommands(t *testing.T) {
+	type Bullet struct{}
+
+	world := ecs.NewWorld()
+	gun := world.AddObject(ecs.NewObject(Player{}))
+	world.AddSystem(ecs.System{Func: func(cmds *ecs.Commands, entity ecs.Entity, _ Player) {
+		cmds.Spawn(Bullet{})
+		cmds.AddComponent(entity, Target{})
+		cmds.Emit(bossDefeated{})
+	}})
+	delivered := 0
+	world.OnEvent(bossDefeated{}, func(interface{}) { delivered++ })
+
+	world.Run()
+
+	if delivered != 1 {
+		t.Errorf("expected one event, got %d", delivered)
+	}
+
+	if got := world.Explain(Bullet{}).Matched; got != 1 {
+		t.Errorf("expected one bullet, got %d", got)
+	}
+	if world.GetObject(gun).Component(Target{}) == nil {
+		t.Error("expected component to be added")
+	}
+}
+
+func TestCommandOverflow(t *testing.T) {
+	type Particle struct{}
+
+	spawn := func(cmds *ecs.Commands, _ Player) {
+		for i := 0; i < 10; i++ {
+			cmds.Spawn(Particle{})
+		}
+	}
+
+	t.Run("flush early", func(t *testing.T) {
+		world := ecs.NewWorld(ecs.WithCommandCapacity(3, ecs.FlushEarly))
+		world.AddObject(ecs.NewObject(Player{}))
+		world.AddSystem(ecs.System{Func: spawn})
+		world.Run()
+
+		if got, want := world.Explain(Particle{}).Matched, 10; got != want {
+			t.Errorf("wrong number of particles: got %d, want %d", got, want)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		world := ecs.NewWorld(ecs.WithCommandCapacity(3, ecs.Drop))
+		world.AddObject(ecs.NewObject(Player{}))
+		world.AddSystem(ecs.System{Func: spawn})
+
+		var dropped int
+		world.OnEvent(ecs.CommandsDropped{}, func(ev interface{}) {
+			dropped += ev.(ecs.CommandsDropped).Count
+		})
+		world.Run()
+
+		if got, want := world.Explain(Particle{}).Matched, 3; got != want {
+			t.Errorf("wrong number of particles: got %d, want %d", got, want)
+		}
+		if got, want := dropped, 7; got != want {
+			t.Errorf("wrong number of dropped commands: got %d, want %d", got, want)
+		}
+	})
+}
+
+func TestSpawnTemporary(t *testing.T) {
+	type Contact struct{}
+
+	world := ecs.NewWorld()
+	var seen int
+	world.AddSystem(ecs.System{Func: func(Contact) { seen++ }})
+
+	world.Commands().SpawnTemporary(Contact{})
+	world.Run()
+
+	if seen != 1 {
+		t.Errorf("temporary object should be seen by one tick, seen by %d", seen)
+	}
+	if got := world.Explain(Contact{}).Matched; got != 0 {
+		t.Errorf("temporary object should have been removed, found %d", got)
+	}
+}
+
+type expiredEvent struct{}
+
+func TestSpawnTemporaryWaitsForRunningTicks(t *testing.T) {
+	world := ecs.NewWorld()
+	world.RecycleEntities = true
+	world.AddObject(ecs.NewObject(Velocity(0)))
+	temp := world.Commands().SpawnTemporary(Position(0))
+
+	started := make(chan struct{})
+	spawned := make(chan struct{})
+	var reused ecs.Entity
+	world.OnEvent(expiredEvent{}, func(interface{}) {
+		reused = world.Commands().Spawn(Position(100))
+		close(spawned)
+	})
+
+	attached := false
+	world.AddSystem(ecs.System{Func: func(Position) Position {
+		close(started)
+		<-spawned
+		attached = world.GetObject(temp) != nil
+		return Position(1)
+	}})
+	world.AddSystem(ecs.System{Func: func(Velocity) {
+		<-started
+		world.Emit(expiredEvent{})
+	}})
+	world.Run()
+
+	if !attached {
+		t.Error("temporary object was removed while another system was iterating over it")
+	}
+	if world.GetObject(temp) != nil {
+		t.Error("expected temporary object to be removed once every system finished")
+	}
+	if got := world.GetObject(reused).Component(Position(0)); got != Position(100) {
+		t.Errorf("write-back landed on a recycled object: got %v, want 100", got)
+	}
+}
+
+func TestSend(t *testing.T) {
+	world := ecs.NewWorld()
+	player := world.AddObject(ecs.NewObject(Position(0)))
+	var moves int32
+	world.OnEvent(Velocity(0), func(interface{}) { atomic.AddInt32(&moves, 1) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			world.Send(func(w *ecs.World) {
+				ob := w.GetObject(player)
+				ob.SetComponent(ob.Component(Position(0)).(Position) + 1)
+				w.Emit(Velocity(1))
+			})
+		}()
+	}
+	wg.Wait()
+
+	if got := world.GetObject(player).Component(Position(0)); got != Position(0) {
+		t.Errorf("commands applied before the next tick: position %v", got)
+	}
+	world.Step()
+	if got := world.GetObject(player).Component(Position(0)); got != Position(10) {
+		t.Errorf("position = %v, want 10", got)
+	}
+	if got := atomic.LoadInt32(&moves); got != 10 {
+		t.Errorf("%d events delivered, want 10", got)
+	}
+}
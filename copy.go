@@ -0,0 +1,96 @@
+package ecs
+
+import "reflect"
+
+// A Cloner is a component or resource that copies itself, for types that
+// copying through reflection gets wrong, such as those holding references in
+// unexported fields, or handles to things that shouldn't be shared. Clone
+// must return a value of the same type that is safe to modify independently
+// of the original.
+//
+// Prefabs, checkpoints, and World.Clone all use a component's Clone method if
+// it has one.
+type Cloner interface {
+	Clone() interface{}
+}
+
+// deepCopy returns a copy of a component that shares no pointers, slices, or
+// maps with the original, using its Clone method if it is a Cloner.
+// Otherwise, unexported struct fields can't be set through reflection, so
+// they are copied shallowly.
+func deepCopy(c interface{}) interface{} {
+	if c == nil {
+		return nil
+	}
+	if cl, ok := c.(Cloner); ok {
+		return cl.Clone()
+	}
+	return copyValue(reflect.ValueOf(c), make(map[uintptr]reflect.Value)).Interface()
+}
+
+// copyValue recursively copies v. seen maps already-copied pointers to their
+// copies, so that shared and cyclic references are preserved.
+func copyValue(v reflect.Value, seen map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if cp, ok := seen[v.Pointer()]; ok {
+			return cp
+		}
+		cp := reflect.New(v.Type().Elem())
+		seen[v.Pointer()] = cp
+		cp.Elem().Set(copyValue(v.Elem(), seen))
+		return cp
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(copyValue(v.Index(i), seen))
+		}
+		return cp
+
+	case reflect.Array:
+		cp := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(copyValue(v.Index(i), seen))
+		}
+		return cp
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(copyValue(iter.Key(), seen), copyValue(iter.Value(), seen))
+		}
+		return cp
+
+	case reflect.Struct:
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if f := cp.Field(i); f.CanSet() {
+				f.Set(copyValue(v.Field(i), seen))
+			}
+		}
+		return cp
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(copyValue(v.Elem(), seen))
+		return cp
+
+	default:
+		return v
+	}
+}
@@ -0,0 +1,415 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// StoreDense makes the world store components of type T in a single
+// contiguous slice, rather than boxed alongside each object's other
+// components. Systems that accept a Column[T] can then iterate every such
+// component in memory order, which is much friendlier to the CPU cache for
+// small, hot components such as positions and velocities:
+//
+//     ecs.StoreDense[Position](world)
+//     ecs.StoreDense[Velocity](world)
+//
+//     world.AddSystem(ecs.System{
+//         Global: true,
+//         Func: func(pos ecs.Column[Position], vel ecs.Column[Velocity]) {
+//             pos.Each(func(e ecs.Entity, p *Position) {
+//                 if v, ok := vel.Get(e); ok {
+//                     p.X += v.X
+//                     p.Y += v.Y
+//                 }
+//             })
+//         },
+//     })
+//
+// Dense storage is otherwise transparent: objects' methods, queries, and
+// ordinary systems see dense components just as they see any other, although
+// Components lists them after the boxed ones. An object can have at most one
+// component of a dense type, so adding a second one replaces the first,
+// unless the world has UniqueComponents set. Components are moved into dense
+// storage when an object is added to the world, or when StoreDense is called,
+// and back out when it is removed.
+func StoreDense[T any](w *World) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Interface {
+		panic(fmt.Sprintf("ecs: cannot store interface type %s densely", t))
+	}
+
+	w.columnsMu.Lock()
+	old := w.columnMap()
+	if _, ok := old[t]; ok {
+		w.columnsMu.Unlock()
+		return
+	}
+	columns := make(map[reflect.Type]denseColumn, len(old)+1)
+	for ct, c := range old {
+		columns[ct] = c
+	}
+	columns[t] = &column[T]{index: make(map[Entity]int)}
+	w.columns.Store(&columns)
+	w.columnsMu.Unlock()
+
+	for _, ob := range w.Objects() {
+		w.moveToColumns(ob)
+	}
+}
+
+// denseColumn is the untyped view of a column, used by the world to store and
+// retrieve components without knowing their type.
+type denseColumn interface {
+	get(entity Entity) (interface{}, bool)
+
+	// put stores a component, and reports whether it replaced one.
+	put(entity Entity, c interface{}) bool
+
+	// putIfAbsent stores a component unless the entity already has one.
+	putIfAbsent(entity Entity, c interface{})
+
+	remove(entity Entity) (interface{}, bool)
+	clear()
+
+	// empty returns a new, empty column of the same type.
+	empty() denseColumn
+}
+
+// column is the dense storage for components of type T: a sparse set, with
+// the components packed into values, the entity each belongs to alongside it
+// in entities, and index mapping entities back to positions.
+type column[T any] struct {
+	mu       sync.RWMutex
+	entities []Entity
+	values   []T
+	index    map[Entity]int
+}
+
+func (c *column[T]) getTyped(entity Entity) (T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if i, ok := c.index[entity]; ok {
+		return c.values[i], true
+	}
+	var zero T
+	return zero, false
+}
+
+func (c *column[T]) get(entity Entity) (interface{}, bool) {
+	v, ok := c.getTyped(entity)
+	if !ok {
+		return nil, false
+	}
+	return v, true
+}
+
+func (c *column[T]) put(entity Entity, v interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i, ok := c.index[entity]; ok {
+		c.values[i] = v.(T)
+		return true
+	}
+	c.index[entity] = len(c.values)
+	c.entities = append(c.entities, entity)
+	c.values = append(c.values, v.(T))
+	return false
+}
+
+func (c *column[T]) putIfAbsent(entity Entity, v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.index[entity]; ok {
+		return
+	}
+	c.index[entity] = len(c.values)
+	c.entities = append(c.entities, entity)
+	c.values = append(c.values, v.(T))
+}
+
+func (c *column[T]) remove(entity Entity) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, ok := c.index[entity]
+	if !ok {
+		return nil, false
+	}
+	v := c.values[i]
+
+	// swap the last component into the gap, to keep the slice packed
+	last := len(c.values) - 1
+	c.values[i], c.entities[i] = c.values[last], c.entities[last]
+	c.index[c.entities[i]] = i
+	var zero T
+	c.values[last] = zero
+	c.values, c.entities = c.values[:last], c.entities[:last]
+	delete(c.index, entity)
+	return v, true
+}
+
+func (c *column[T]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entities, c.values = nil, nil
+	c.index = make(map[Entity]int)
+}
+
+func (c *column[T]) empty() denseColumn {
+	return &column[T]{index: make(map[Entity]int)}
+}
+
+func (w *World) columnMap() map[reflect.Type]denseColumn {
+	if m := w.columns.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// column returns the dense storage for components of type t, or nil if they
+// are boxed.
+func (w *World) column(t reflect.Type) denseColumn {
+	return w.columnMap()[t]
+}
+
+// moveToColumns moves the object's components of dense types out of its
+// component list and into the world's columns.
+func (w *World) moveToColumns(ob *Object) {
+	columns := w.columnMap()
+	if len(columns) == 0 {
+		return
+	}
+
+	type move struct {
+		col denseColumn
+		c   interface{}
+	}
+	var moves []move
+	ob.mu.Lock()
+	kept := ob.components[:0]
+	dense := append([]reflect.Type(nil), ob.dense...)
+	for _, c := range ob.components {
+		t := reflect.TypeOf(c)
+		col, ok := columns[t]
+		if !ok {
+			kept = append(kept, c)
+			continue
+		}
+		moves = append(moves, move{col, c})
+		if !hasType(dense, t) {
+			dense = append(dense, t)
+		}
+	}
+	ob.components = kept
+	ob.dense = dense
+	ob.mask = nil
+	ob.mu.Unlock()
+
+	for _, m := range moves {
+		m.col.putIfAbsent(ob.entity, m.c)
+	}
+	if len(moves) > 0 {
+		w.cache.update(w, ob)
+	}
+}
+
+// moveFromColumns moves the object's dense components back into its own
+// component list, such as when it is removed from the world.
+func (w *World) moveFromColumns(ob *Object) {
+	ob.mu.RLock()
+	dense := ob.dense
+	ob.mu.RUnlock()
+	if len(dense) == 0 {
+		return
+	}
+
+	var moved []interface{}
+	for _, t := range dense {
+		if c, ok := w.column(t).remove(ob.entity); ok {
+			moved = append(moved, c)
+		}
+	}
+	ob.mu.Lock()
+	for _, c := range moved {
+		if !hasType(typesOf(ob.components), reflect.TypeOf(c)) {
+			ob.components = append(ob.components, c)
+		}
+	}
+	ob.dense = nil
+	ob.mask = nil
+	ob.mu.Unlock()
+}
+
+func hasType(types []reflect.Type, t reflect.Type) bool {
+	for _, other := range types {
+		if other == t {
+			return true
+		}
+	}
+	return false
+}
+
+func typesOf(components []interface{}) []reflect.Type {
+	types := make([]reflect.Type, len(components))
+	for i, c := range components {
+		types[i] = reflect.TypeOf(c)
+	}
+	return types
+}
+
+// denseColumn returns the column holding the object's component of type t,
+// or nil if it doesn't have one in dense storage.
+func (ob *Object) denseColumn(t reflect.Type) denseColumn {
+	ob.mu.RLock()
+	dense := ob.dense
+	ob.mu.RUnlock()
+	if !hasType(dense, t) {
+		return nil
+	}
+	return ob.world.column(t)
+}
+
+// storageColumn returns the column that a new component of type t should be
+// stored in, or nil if it should be boxed.
+func (ob *Object) storageColumn(t reflect.Type) denseColumn {
+	if ob.world == nil || atomic.LoadUint64(&ob.seq) == 0 {
+		return nil
+	}
+	return ob.world.column(t)
+}
+
+// putDense stores a component of type t in its column, and reports whether
+// it replaced one.
+func (ob *Object) putDense(col denseColumn, t reflect.Type, c interface{}) (replaced bool) {
+	if col.put(ob.entity, c) {
+		return true
+	}
+	ob.mu.Lock()
+	if !hasType(ob.dense, t) {
+		// copy, since readers use the slice without holding the lock
+		ob.dense = append(ob.dense[:len(ob.dense):len(ob.dense)], t)
+		ob.mask = nil
+	}
+	ob.mu.Unlock()
+	ob.world.cache.update(ob.world, ob)
+	return false
+}
+
+// removeDense removes the object's component of type t from its column.
+func (ob *Object) removeDense(col denseColumn, t reflect.Type) (interface{}, bool) {
+	c, ok := col.remove(ob.entity)
+	ob.mu.Lock()
+	dense := make([]reflect.Type, 0, len(ob.dense))
+	for _, other := range ob.dense {
+		if other != t {
+			dense = append(dense, other)
+		}
+	}
+	ob.dense = dense
+	ob.mask = nil
+	ob.mu.Unlock()
+	ob.world.cache.update(ob.world, ob)
+	return c, ok
+}
+
+// denseComponents returns the object's components in dense storage.
+func (ob *Object) denseComponents() []interface{} {
+	ob.mu.RLock()
+	dense := ob.dense
+	ob.mu.RUnlock()
+	var components []interface{}
+	for _, t := range dense {
+		if c, ok := ob.world.column(t).get(ob.entity); ok {
+			components = append(components, c)
+		}
+	}
+	return components
+}
+
+// acceptedDense returns the first of the object's components in dense
+// storage that a system parameter of type t can receive.
+func (w *World) acceptedDense(ob *Object, t reflect.Type) (reflect.Value, bool) {
+	ob.mu.RLock()
+	dense := ob.dense
+	ob.mu.RUnlock()
+	for _, dt := range dense {
+		if !w.accepts(t, dt) {
+			continue
+		}
+		if c, ok := w.column(dt).get(ob.entity); ok {
+			return reflect.ValueOf(c), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// A Column is a system parameter giving direct access to the dense storage of
+// components of type T; see StoreDense. Systems that accept one hold
+// exclusive access to components of type T while they tick. If T isn't
+// stored densely, the system is skipped.
+//
+// Changes made through a Column are not reported to watchers, and take
+// effect immediately, so DryRun systems cannot accept one.
+type Column[T any] struct {
+	c *column[T]
+}
+
+type columnParam interface {
+	columnType() reflect.Type
+	bindColumn(w *World) (reflect.Value, bool)
+}
+
+var columnParamType = reflect.TypeOf((*columnParam)(nil)).Elem()
+
+func (Column[T]) columnType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (Column[T]) bindColumn(w *World) (reflect.Value, bool) {
+	c, ok := DenseColumn[T](w)
+	return reflect.ValueOf(c), ok
+}
+
+// DenseColumn returns the dense storage of components of type T, and whether
+// they are stored densely.
+func DenseColumn[T any](w *World) (Column[T], bool) {
+	c, ok := w.column(reflect.TypeOf((*T)(nil)).Elem()).(*column[T])
+	return Column[T]{c: c}, ok
+}
+
+// Len returns the number of components in the column.
+func (c Column[T]) Len() int {
+	c.c.mu.RLock()
+	defer c.c.mu.RUnlock()
+	return len(c.c.values)
+}
+
+// Get returns the component belonging to an entity, and whether it has one.
+func (c Column[T]) Get(entity Entity) (T, bool) {
+	return c.c.getTyped(entity)
+}
+
+// Set replaces the component belonging to an entity, and reports whether it
+// had one to replace. It doesn't add components to entities without one.
+func (c Column[T]) Set(entity Entity, v T) bool {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+	i, ok := c.c.index[entity]
+	if ok {
+		c.c.values[i] = v
+	}
+	return ok
+}
+
+// Each calls fn for every component in the column, in storage order, with a
+// pointer through which fn may change it. fn must not add or remove
+// components of type T, or use any other method of the column.
+func (c Column[T]) Each(fn func(entity Entity, v *T)) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+	for i := range c.c.values {
+		fn(c.c.entities[i], &c.c.values[i])
+	}
+}
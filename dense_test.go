@@ -0,0 +1,165 @@
+package ecs_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestStoreDense(t *testing.T) {
+	world := ecs.NewWorld()
+	early := ecs.NewObject(Position(0), Velocity(1), Player{})
+	world.AddObject(early)
+
+	// components already in the world move into dense storage
+	ecs.StoreDense[Position](world)
+	ecs.StoreDense[Velocity](world)
+	late := ecs.NewObject(Position(10), Velocity(2))
+	world.AddObject(late)
+
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}})
+	world.Step()
+
+	if got, ok := ecs.Get[Position](early); !ok || got != 1 {
+		t.Errorf("bad early position: got %v, %t, want 1", got, ok)
+	}
+	if got := late.Component(Position(0)); got != Position(12) {
+		t.Errorf("bad late position: got %v, want 12", got)
+	}
+	if got := len(early.Components()); got != 3 {
+		t.Errorf("bad number of components: got %d, want 3", got)
+	}
+	if got := world.Query().With(Position(0), Player{}).Count(); got != 1 {
+		t.Errorf("bad query count: got %d, want 1", got)
+	}
+
+	// columns can be used directly
+	pos, ok := ecs.DenseColumn[Position](world)
+	if !ok {
+		t.Fatal("positions aren't stored densely")
+	}
+	if got := pos.Len(); got != 2 {
+		t.Errorf("bad column length: got %d, want 2", got)
+	}
+	world.AddSystem(ecs.System{Global: true, Func: func(pos ecs.Column[Position], vel ecs.Column[Velocity]) {
+		pos.Each(func(e ecs.Entity, p *Position) {
+			if v, ok := vel.Get(e); ok {
+				*p *= Position(v)
+			}
+		})
+	}})
+	world.Step()
+	if got := late.Component(Position(0)); got != Position(28) {
+		t.Errorf("bad late position after column system: got %v, want 28", got)
+	}
+
+	late.RemoveComponent(Velocity(0))
+	if ecs.Has[Velocity](late) {
+		t.Error("velocity wasn't removed")
+	}
+	if got := world.Query().With(Velocity(0)).Count(); got != 1 {
+		t.Errorf("bad velocity count: got %d, want 1", got)
+	}
+
+	// removed objects take their components with them
+	world.RemoveObject(early.Entity())
+	if got := pos.Len(); got != 1 {
+		t.Errorf("bad column length after removal: got %d, want 1", got)
+	}
+	if got := early.Component(Velocity(0)); got != Velocity(1) {
+		t.Errorf("bad velocity after removal: got %v, want 1", got)
+	}
+}
+
+func TestStoreDenseSnapshot(t *testing.T) {
+	ecs.Register(Position(0))
+
+	world := ecs.NewWorld()
+	ecs.StoreDense[Position](world)
+	ob := ecs.NewObject(Position(7))
+	world.AddObject(ob)
+
+	var buf bytes.Buffer
+	if err := world.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	ob.SetComponent(Position(8))
+	if err := world.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	pos, _ := ecs.DenseColumn[Position](world)
+	if got, ok := pos.Get(ob.Entity()); !ok || got != 7 {
+		t.Errorf("bad position after load: got %v, %t, want 7", got, ok)
+	}
+	if got := pos.Len(); got != 1 {
+		t.Errorf("bad column length after load: got %d, want 1", got)
+	}
+}
+
+const benchmarkObjects = 10000
+
+func movementWorld(dense bool) *ecs.World {
+	world := ecs.NewWorld()
+	if dense {
+		ecs.StoreDense[Position](world)
+		ecs.StoreDense[Velocity](world)
+	}
+	for i := 0; i < benchmarkObjects; i++ {
+		world.AddObject(ecs.NewObject(Position(i), Velocity(1)))
+	}
+	return world
+}
+
+func TestColumnDryRun(t *testing.T) {
+	world := ecs.NewWorld()
+	ecs.StoreDense[Position](world)
+	err := world.AddSystem(ecs.System{
+		Global: true,
+		Func:   func(pos ecs.Column[Position]) {},
+		DryRun: true,
+	})
+	if err == nil {
+		t.Error("a dry-run system should not be able to accept a Column")
+	}
+}
+
+func BenchmarkMovementBoxed(b *testing.B) {
+	world := movementWorld(false)
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		world.Step()
+	}
+}
+
+func BenchmarkMovementDense(b *testing.B) {
+	world := movementWorld(true)
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		world.Step()
+	}
+}
+
+func BenchmarkMovementColumn(b *testing.B) {
+	world := movementWorld(true)
+	world.AddSystem(ecs.System{Global: true, Func: func(pos ecs.Column[Position], vel ecs.Column[Velocity]) {
+		pos.Each(func(e ecs.Entity, p *Position) {
+			if v, ok := vel.Get(e); ok {
+				*p += Position(v)
+			}
+		})
+	}})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		world.Step()
+	}
+}
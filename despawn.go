@@ -0,0 +1,165 @@
+package ecs
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// pendingDespawn is an entity waiting to be removed at a tick boundary. epoch
+// is the most recent system tick to have started when it was queued.
+type pendingDespawn struct {
+	entity    Entity
+	recursive bool
+	epoch     uint64
+}
+
+// iterations numbers system ticks as they start, and tracks which are still
+// running. Under RunContext, one system's tick boundary can come while
+// others are still iterating over the objects they matched, so a despawn is
+// only applied once every tick that was running when it was queued has
+// finished. Ticks that start later skip the object, since it is already
+// marked as despawning.
+type iterations struct {
+	mu      sync.Mutex
+	started uint64
+	running map[uint64]bool
+}
+
+// begin records the start of a tick, returning its number.
+func (it *iterations) begin() uint64 {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.running == nil {
+		it.running = make(map[uint64]bool)
+	}
+	it.started++
+	it.running[it.started] = true
+	return it.started
+}
+
+// end records the end of a tick.
+func (it *iterations) end(n uint64) {
+	it.mu.Lock()
+	delete(it.running, n)
+	it.mu.Unlock()
+}
+
+// latest returns the number of the most recent tick to have started.
+func (it *iterations) latest() uint64 {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.started
+}
+
+// oldest returns the number of the earliest tick still running, or one past
+// the latest if none are.
+func (it *iterations) oldest() uint64 {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	oldest := it.started + 1
+	for n := range it.running {
+		if n < oldest {
+			oldest = n
+		}
+	}
+	return oldest
+}
+
+// Despawn removes an object from the world at a tick boundary, once no system
+// can still be iterating over it, and is the safe way for a system to remove
+// objects, including the one it was passed. Under Step, that is the next tick
+// boundary; under RunContext, it is the first boundary after every system
+// tick that was running when Despawn was called has finished. Until then, the
+// object stays in the world, but isn't passed to any more systems. Unlike
+// Commands.Despawn, the removal is never dropped or delayed by the command
+// buffer's capacity.
+func (w *World) Despawn(entity Entity) {
+	w.queueDespawn(entity, false)
+}
+
+// DespawnRecursive is like Despawn, but also removes every object beneath the
+// entity in its hierarchy, as found through Parent components at the tick
+// boundary. Children are removed before their parents.
+func (w *World) DespawnRecursive(entity Entity) {
+	w.queueDespawn(entity, true)
+}
+
+func (w *World) queueDespawn(entity Entity, recursive bool) {
+	if ob := w.GetObject(entity); ob != nil {
+		atomic.StoreInt32(&ob.despawning, 1)
+	}
+	// the flag must be set before the epoch is read; see iterations
+	epoch := w.iterations.latest()
+	w.despawnMu.Lock()
+	w.despawns = append(w.despawns, pendingDespawn{entity, recursive, epoch})
+	w.despawnMu.Unlock()
+}
+
+// applyDespawns removes the objects queued by Despawn and DespawnRecursive
+// that no running system tick can still be iterating over.
+func (w *World) applyDespawns() {
+	w.despawnMu.Lock()
+	if len(w.despawns) == 0 {
+		w.despawnMu.Unlock()
+		return
+	}
+	oldest := w.iterations.oldest()
+	var despawns, waiting []pendingDespawn
+	for _, d := range w.despawns {
+		if d.epoch < oldest {
+			despawns = append(despawns, d)
+		} else {
+			waiting = append(waiting, d)
+		}
+	}
+	w.despawns = waiting
+	w.despawnMu.Unlock()
+	if len(despawns) == 0 {
+		return
+	}
+
+	var children map[Entity][]Entity
+	for _, d := range despawns {
+		if !d.recursive {
+			w.despawn(d.entity)
+			continue
+		}
+		if children == nil {
+			children = w.childrenByParent()
+		}
+		w.despawnRecursive(d.entity, children, make(map[Entity]bool))
+	}
+}
+
+func (w *World) despawn(entity Entity) {
+	// The object stays marked as despawning until it is added to a world
+	// again, so that ticks still holding it skip it.
+	if removed := w.detachObject(entity); removed != nil {
+		w.removed(removed)
+	}
+}
+
+// despawnRecursive removes an entity and its descendants, using seen to
+// guard against cycles in the hierarchy.
+func (w *World) despawnRecursive(entity Entity, children map[Entity][]Entity, seen map[Entity]bool) {
+	if seen[entity] {
+		return
+	}
+	seen[entity] = true
+	for _, child := range children[entity] {
+		w.despawnRecursive(child, children, seen)
+	}
+	w.despawn(entity)
+}
+
+// childrenByParent maps each entity to the entities whose Parent component
+// refers to it, as Children would return.
+func (w *World) childrenByParent() map[Entity][]Entity {
+	children := make(map[Entity][]Entity)
+	for _, ob := range w.Objects() {
+		if p, ok := ob.Component(Parent{}).(Parent); ok {
+			children[p.Entity] = append(children[p.Entity], ob.entity)
+		}
+	}
+	return children
+}
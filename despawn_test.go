@@ -0,0 +1,100 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestDespawn(t *testing.T) {
+	world := ecs.NewWorld()
+	a := world.AddObject(ecs.NewObject(Position(0)))
+	b := world.AddObject(ecs.NewObject(Position(0)))
+
+	var seen []ecs.Entity
+	world.AddSystem(ecs.System{Func: func(world *ecs.World, e ecs.Entity, _ Position) {
+		world.Despawn(e)
+		if world.GetObject(e) == nil {
+			t.Error("object was removed mid-iteration")
+		}
+	}})
+	world.AddSystem(ecs.System{Func: func(e ecs.Entity, _ Position) {
+		seen = append(seen, e)
+	}})
+	world.Step()
+
+	if len(seen) != 0 {
+		t.Errorf("despawned objects were passed to a later system: %v", seen)
+	}
+	if world.GetObject(a) != nil || world.GetObject(b) != nil {
+		t.Error("expected objects to be removed at the tick boundary")
+	}
+}
+
+func TestDespawnRecursive(t *testing.T) {
+	world := ecs.NewWorld()
+	var finalized []ecs.Entity
+	world.AddFinalizer(Position(0), func(e ecs.Entity, _ interface{}) {
+		finalized = append(finalized, e)
+	})
+
+	tank := world.AddObject(ecs.NewObject(Position(0)))
+	turret := world.AddObject(ecs.NewObject(Position(1), ecs.Parent{Entity: tank}))
+	barrel := world.AddObject(ecs.NewObject(Position(2), ecs.Parent{Entity: turret}))
+	other := world.AddObject(ecs.NewObject(Position(3)))
+
+	world.DespawnRecursive(tank)
+	if world.GetObject(tank) == nil {
+		t.Fatal("object was removed before the tick boundary")
+	}
+	world.Step()
+
+	for _, e := range []ecs.Entity{tank, turret, barrel} {
+		if world.GetObject(e) != nil {
+			t.Errorf("entity %d wasn't removed", e)
+		}
+	}
+	if world.GetObject(other) == nil {
+		t.Error("unrelated object was removed")
+	}
+	want := []ecs.Entity{barrel, turret, tank}
+	if len(finalized) != len(want) {
+		t.Fatalf("bad finalization order: got %v, want %v", finalized, want)
+	}
+	for i := range want {
+		if finalized[i] != want[i] {
+			t.Fatalf("bad finalization order: got %v, want %v", finalized, want)
+		}
+	}
+}
+
+type despawnedEvent struct{}
+
+func TestDespawnWaitsForRunningTicks(t *testing.T) {
+	world := ecs.NewWorld()
+	e := world.AddObject(ecs.NewObject(Position(0), Velocity(0)))
+
+	started := make(chan struct{})
+	boundary := make(chan struct{})
+	world.OnEvent(despawnedEvent{}, func(interface{}) { close(boundary) })
+
+	attached := false
+	world.AddSystem(ecs.System{Func: func(world *ecs.World, e ecs.Entity, _ Position) {
+		close(started)
+		<-boundary
+		attached = world.GetObject(e) != nil
+	}})
+	world.AddSystem(ecs.System{Func: func(world *ecs.World, e ecs.Entity, _ Velocity) {
+		<-started
+		world.Despawn(e)
+		world.Emit(despawnedEvent{})
+	}})
+	world.Run()
+
+	if !attached {
+		t.Error("object was removed while another system was iterating over it")
+	}
+	if world.GetObject(e) != nil {
+		t.Error("expected object to be removed once every system finished")
+	}
+}
@@ -0,0 +1,174 @@
+package ecs
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// DeterministicEpoch is the simulated time of tick zero in a deterministic
+// world.
+var DeterministicEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// defaultDeterministicStep is the simulated time between the ticks of a
+// deterministic world without a TickRate.
+const defaultDeterministicStep = time.Second / 60
+
+// Deterministic puts the world in deterministic mode, so that the same inputs
+// always produce the same world state, as lockstep networking and replay
+// debugging require. It should be called before the world first runs. In
+// deterministic mode:
+//
+//   - RunContext ticks every system on the calling goroutine, one at a
+//     time, in the order they were added, once per TickRate, or as fast as
+//     possible if TickRate is zero. Tickers and intervals are ignored,
+//     although EveryNTicks still applies, and systems without either run
+//     only on the first tick after they are added.
+//   - Parallel systems visit objects one at a time, in the order they were
+//     added to the world, and sharded systems visit one shard at a time, in
+//     order.
+//   - The time passed to systems is simulated: tick n happens at
+//     DeterministicEpoch plus n times TickRate, or n sixtieths of a second
+//     if TickRate is zero. This applies to Step as well.
+//   - A *rand.Rand seeded with seed is added as a resource, which systems
+//     should draw random numbers from rather than the math/rand functions.
+//
+// Entities are allocated from a counter shared by every world in the
+// process, so two runs only allocate the same entities if they start from
+// the same point, such as in fresh processes. Timeouts, and anything else
+// based on the wall clock, remain nondeterministic, but retry backoff is
+// counted in ticks; see RetryPolicy.
+func (w *World) Deterministic(seed int64) {
+	w.deterministic, w.seed = true, seed
+	w.source = newCountingSource(seed)
+	w.rng = rand.New(w.source)
+	w.AddResource(w.rng)
+}
+
+// countingSource counts the values drawn from a seeded source, so that a
+// clone of the world can have its own source in the same state.
+type countingSource struct {
+	src   rand.Source64
+	seed  int64
+	drawn uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{src: rand.NewSource(seed).(rand.Source64), seed: seed}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.drawn++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.drawn++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed, s.drawn = seed, 0
+}
+
+// clone returns a source in the same state, by drawing as many values from a
+// newly seeded one.
+func (s *countingSource) clone() *countingSource {
+	c := newCountingSource(s.seed)
+	for c.drawn < s.drawn {
+		c.Uint64()
+	}
+	return c
+}
+
+// restore puts the source in the same state as another, which is left as it
+// is.
+func (s *countingSource) restore(o *countingSource) {
+	c := o.clone()
+	s.src, s.seed, s.drawn = c.src, c.seed, c.drawn
+}
+
+// tickTime returns the time of tick n.
+func (w *World) tickTime(n uint64) time.Time {
+	if !w.deterministic {
+		return time.Now()
+	}
+	return w.simulatedTime(n)
+}
+
+// simulatedTime returns the time of tick n in a deterministic world.
+func (w *World) simulatedTime(n uint64) time.Time {
+	step := w.TickRate
+	if step <= 0 {
+		step = defaultDeterministicStep
+	}
+	return DeterministicEpoch.Add(time.Duration(n) * step)
+}
+
+// runDeterministic runs every system on the calling goroutine until the
+// context is cancelled, or there are no systems left to run.
+func (w *World) runDeterministic(ctx context.Context, failed func()) []error {
+	w.tickBoundary()
+	w.lifecycle.begin(w)
+	defer w.lifecycle.end()
+
+	var pace <-chan time.Time
+	if w.TickRate > 0 {
+		ticker := time.NewTicker(w.TickRate)
+		defer ticker.Stop()
+		pace = ticker.C
+	}
+
+	var (
+		errs    []error
+		started = make(map[*scheduledSystem]bool)
+		stopped = make(map[*scheduledSystem]bool)
+	)
+	for {
+		systems := w.systemList()
+		n := atomic.AddUint64(&w.ticks, 1)
+		now := w.tickTime(n)
+		running := 0
+		for _, s := range systems {
+			if stopped[s] {
+				continue
+			}
+			if !s.periodic() && s.EveryNTicks == 0 {
+				if started[s] {
+					continue
+				}
+				started[s] = true
+			}
+			running++
+			s.failure.set(nil)
+			s.tick(w, now, n)
+			w.tickBoundary()
+			if err := s.failure.get(); err != nil {
+				stopped[s] = true
+				errs = append(errs, &SystemError{System: s.name(), Err: err})
+				if failed != nil {
+					failed()
+				}
+			}
+		}
+		w.lifecycle.markReady()
+		if running == 0 {
+			return errs
+		}
+
+		select {
+		case <-ctx.Done():
+			return errs
+		default:
+		}
+		if pace != nil {
+			select {
+			case <-pace:
+			case <-ctx.Done():
+				return errs
+			}
+		}
+	}
+}
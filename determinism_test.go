@@ -0,0 +1,105 @@
+package ecs_test
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func runDeterministic(seed int64) ([]interface{}, time.Time) {
+	world := ecs.NewWorld()
+	world.Deterministic(seed)
+	for i := 0; i < 50; i++ {
+		world.AddObject(ecs.NewObject(Position(i), Velocity(0)))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var last time.Time
+	world.AddSystem(ecs.System{
+		Func:     func(r *rand.Rand, v Velocity) Velocity { return v + Velocity(r.Intn(10)) },
+		Parallel: true,
+	})
+	world.AddSystem(ecs.System{
+		Func: func(p Position, v Velocity) Position { return p + Position(v) },
+	})
+	world.AddSystem(ecs.System{
+		Global: true,
+		Func: func(w *ecs.World, now time.Time) {
+			last = now
+			if w.Tick() == 20 {
+				cancel()
+			}
+		},
+		EveryNTicks: 1,
+	})
+	world.RunContext(ctx)
+
+	var state []interface{}
+	for _, ob := range world.Objects() {
+		state = append(state, ob.Components()...)
+	}
+	return state, last
+}
+
+func TestDeterministic(t *testing.T) {
+	a, lastA := runDeterministic(42)
+	b, lastB := runDeterministic(42)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("runs with the same seed produced different states")
+	}
+	if want := ecs.DeterministicEpoch.Add(20 * (time.Second / 60)); !lastA.Equal(want) || !lastB.Equal(want) {
+		t.Errorf("bad simulated time: got %s and %s, want %s", lastA, lastB, want)
+	}
+	if c, _ := runDeterministic(7); reflect.DeepEqual(a, c) {
+		t.Error("runs with different seeds produced the same state")
+	}
+}
+
+func TestDeterministicStartup(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Deterministic(1)
+	world.AddObject(ecs.NewObject(Position(0)))
+	startups := 0
+	world.AddSystem(ecs.System{Func: func(Position) { startups++ }})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	world.AddSystem(ecs.System{Global: true, EveryNTicks: 1, Func: func(w *ecs.World) {
+		if w.Tick() == 10 {
+			cancel()
+		}
+	}})
+	if err := world.RunContext(ctx); err == nil || ctx.Err() != context.Canceled {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+	if startups != 1 {
+		t.Errorf("startup system ran %d times, want 1", startups)
+	}
+}
+
+func TestDeterministicOneShot(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Deterministic(1)
+	world.AddObject(ecs.NewObject(Position(0)))
+	ran := 0
+	world.AddSystem(ecs.System{Func: func(Position) { ran++ }})
+	world.AddSystem(ecs.System{Global: true, Func: func() { ran++ }})
+
+	done := make(chan struct{})
+	go func() {
+		world.Run()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return once every one-shot system had run")
+	}
+	if ran != 2 {
+		t.Errorf("one-shot systems ran %d times, want 2", ran)
+	}
+}
@@ -0,0 +1,78 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// DryRunCommand is reported to World.OnDryRun in place of a command that a
+// dry-run system queued with its *Commands parameter.
+type DryRunCommand struct {
+	// Op is the name of the Commands method, such as "Spawn" or "Despawn".
+	Op string
+
+	// Entity is the object the command applies to, or for Spawn and
+	// SpawnTemporary, the entity the new object would have had.
+	Entity Entity
+
+	// Args holds the command's other arguments, such as the components
+	// passed to Spawn or AddComponent.
+	Args []interface{}
+}
+
+// DryRunEvent is reported to World.OnDryRun in place of an event that a
+// dry-run system emitted with Commands.Emit.
+type DryRunEvent struct {
+	Event interface{}
+}
+
+// DryRunResource is reported to World.OnDryRun in place of a resource that a
+// dry-run system replaced with ResMut.Set.
+type DryRunResource struct {
+	Value interface{}
+}
+
+// dryRun collects what one call of a dry-run system would have changed.
+type dryRun struct {
+	mu        sync.Mutex
+	writes    []interface{}
+	changes   []interface{}
+	resources map[reflect.Type]reflect.Value
+}
+
+// record adds a DryRunCommand or DryRunEvent to the changes.
+func (d *dryRun) record(change interface{}) {
+	d.mu.Lock()
+	d.changes = append(d.changes, change)
+	d.mu.Unlock()
+}
+
+// setResource records a resource replaced with ResMut.Set, which the call
+// sees in place of the world's.
+func (d *dryRun) setResource(t reflect.Type, v reflect.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resources == nil {
+		d.resources = make(map[reflect.Type]reflect.Value)
+	}
+	d.resources[t] = v
+	d.changes = append(d.changes, DryRunResource{Value: v.Interface()})
+}
+
+func (d *dryRun) resource(t reflect.Type) (reflect.Value, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	v, ok := d.resources[t]
+	return v, ok
+}
+
+// report passes everything collected to the world's OnDryRun, if anything
+// was.
+func (d *dryRun) report(w *World, name string, entity Entity) {
+	d.mu.Lock()
+	writes := append(d.writes, d.changes...)
+	d.mu.Unlock()
+	if len(writes) > 0 {
+		w.handleDryRun(name, entity, writes)
+	}
+}
@@ -26,10 +26,12 @@ package ecs
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
+	"math/rand"
 	"reflect"
 	"runtime"
-	"strings"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -44,30 +46,328 @@ var (
 	worldType         = reflect.TypeOf(&World{})
 )
 
+var (
+	// ErrSkipEntity may be returned by a system to ignore the current entity.
+	// Any other values the system returned are discarded rather than written
+	// back, the error isn't reported to OnError, and the system continues on
+	// to the next entity.
+	ErrSkipEntity = errors.New("skip entity")
+
+	// ErrStopSystem may be returned by a system to stop ticking it entirely.
+	// Values returned alongside it are discarded, no further entities are
+	// visited this tick, and the system is disabled as if by
+	// World.DisableSystem. It isn't reported to OnError.
+	ErrStopSystem = errors.New("stop system")
+
+	// ErrFatal may be wrapped by an error a system returns to mark it as
+	// fatal, such as fmt.Errorf("%w: database unavailable", ErrFatal). The
+	// system is stopped as with ErrStopSystem, but it has failed, and
+	// RunContext returns the error.
+	ErrFatal = errors.New("fatal system error")
+
+	// ErrDuplicateComponent is reported when a world with UniqueComponents
+	// set rejects a component of a type the object already has.
+	ErrDuplicateComponent = errors.New("duplicate component")
+)
+
 type World struct {
 	// OnError is a callback that will be invoked when a system returns an error as its final argument.
 	OnError func(name string, args []interface{}, err error)
 
+	// OnPanic is a callback that will be invoked when a system panics. entity
+	// is the object the system was invoked on, or zero for global systems.
+	// The panic is recovered, and the system moves on to the next object.
+	OnPanic func(name string, entity Entity, value interface{}, stack []byte)
+
+	// StrictTypes makes systems match components by exact type, rather than
+	// by assignability, although parameters of interface type still match
+	// any component that implements them. Each value a system returns must
+	// then match exactly one of the object's components, or else the value
+	// is discarded and the mismatch is reported to OnError.
+	StrictTypes bool
+
+	// UniqueComponents makes the world reject components of a type an object
+	// already has. Duplicates passed to AddObject or AddComponent are
+	// discarded, keeping the existing component, and reported to OnError as
+	// ErrDuplicateComponent with an empty system name. Otherwise duplicates
+	// are allowed, but systems only read and write the first component of
+	// each type.
+	UniqueComponents bool
+
+	// OnDryRun is a callback that will be invoked with what a dry-run system
+	// would have changed on each call: the component values it would have
+	// written to the object, followed by a DryRunCommand, DryRunEvent, or
+	// DryRunResource for each command, event, and resource replacement, in
+	// the order it made them. entity is zero for global systems.
+	OnDryRun func(name string, entity Entity, writes []interface{})
+
+	// Logger receives structured records for errors without a callback,
+	// invalid system parameters, and scheduler events. If nil, slog's
+	// default logger is used.
+	Logger *slog.Logger
+
+	// OnSystemTick, if set, is invoked after every tick of every system with
+	// statistics about the tick. A Profiler can be used to aggregate them.
+	// It may be called concurrently for systems on different tickers.
+	OnSystemTick func(name string, stats TickStats)
+
+	// OnSlowSystem, if set, is invoked when a tick of a system with a
+	// Timeout is still running once the timeout has passed, with how long
+	// it has been running. It is called from its own goroutine, so that a
+	// hung system is reported even if its tick never finishes. If nil, slow
+	// systems are logged.
+	OnSlowSystem func(name string, elapsed time.Duration)
+
+	// Metrics, if set, receives measurements from the world's scheduler,
+	// storage, and events. See MetricsSink for what is reported.
+	Metrics MetricsSink
+
+	// Archive, if set, is the cold storage that Evict moves objects into.
+	Archive Archive
+
+	// RecycleEntities, if set, makes the world reuse the entities and
+	// objects it removes, so that a long-running world that spawns and
+	// despawns objects constantly doesn't keep allocating new ones. The
+	// index of a removed object's entity is reused with its generation
+	// bumped, so that stale entities can't refer to new objects; see
+	// Entity. Removed objects are reused by SpawnBatch and by the world's
+	// Commands, so an *Object must not be used once it has been removed.
+	RecycleEntities bool
+
+	// TickRate, if set, is the period of the world's clock, which ticks the
+	// systems that subscribe to it by setting EveryNTicks. Unlike systems
+	// with tickers of their own, they stay in phase: a system ticking every
+	// 2nd tick and one ticking every 10th tick both tick on every 10th, and
+	// every system ticking on the same tick sees the same World.Tick. The
+	// clock doesn't start its next tick until every system due on the last
+	// one has finished.
+	TickRate time.Duration
+
+	// HealthTimeout is how long a system with a Ticker may go without
+	// ticking while the world is running before Healthy reports a problem.
+	// If zero, 10 seconds is used.
+	HealthTimeout time.Duration
+
+	// QueryCache controls the match sets the world builds for the queries
+	// and systems it runs often.
+	QueryCache QueryCacheConfig
+
 	objects   []*Object
 	objectsMu sync.RWMutex
 
-	systems []System
+	// nextSeq numbers objects in the order they are added, so that match
+	// sets can keep the same order as objects.
+	nextSeq uint64
+	cache   matchCache
+
+	// columns holds the dense storage for types registered with
+	// StoreDense. The map is replaced rather than modified, so it can be
+	// read without locking; columnsMu serializes replacements.
+	columns   atomic.Pointer[map[reflect.Type]denseColumn]
+	columnsMu sync.Mutex
+
+	lifecycle lifecycle
+
+	// ticks is the number of the world's current tick; see Tick.
+	ticks uint64
+
+	// deterministic and seed are set by Deterministic, along with the
+	// random number generator it adds and its source.
+	deterministic bool
+	seed          int64
+	rng           *rand.Rand
+	source        *countingSource
+
+	// stepping counts the calls to Step in progress.
+	stepping int32
+
+	// boundaries counts tick boundaries, which recordings are stamped
+	// with, and replayRecorder and replay are set while the world is being
+	// recorded or replayed.
+	boundaries     uint64
+	replayRecorder atomic.Pointer[ReplayRecorder]
+	replay         atomic.Pointer[replayFeed]
+
+	clock worldClock
+
+	// systems is replaced rather than modified, so that a run can range
+	// over it while systems are removed or replaced; systemsMu serializes
+	// replacements.
+	systems   []*scheduledSystem
+	systemsMu sync.RWMutex
+
+	// resumed holds scheduler state restored by Resume, which is applied to
+	// systems by name as they are added.
+	resumed map[string]systemState
+
+	finalizers   map[reflect.Type][]func(Entity, interface{})
+	finalizersMu sync.RWMutex
+
+	hooks   map[hookKey][]func(Entity, interface{})
+	hooksMu sync.RWMutex
+
+	locks   map[reflect.Type]*componentLock
+	locksMu sync.Mutex
+
+	eventsMu sync.Mutex
+	events   []queuedEvent
+	handlers map[reflect.Type][]func(interface{})
+	bridges  []bridge
+
+	commands *Commands
+
+	// inbox holds the commands queued by Send since the last tick boundary.
+	inboxMu sync.Mutex
+	inbox   []func(*World)
+
+	// shedBelow is the priority below which systems are skipped while
+	// shedding is non-zero.
+	shedBelow int64
+	shedding  int32
+
+	repairs repairs
+
+	// temporary holds the entities spawned with Commands.SpawnTemporary
+	// since the last tick boundary.
+	temporaryMu sync.Mutex
+	temporary   []Entity
+
+	// despawns holds the entities queued by Despawn and DespawnRecursive
+	// that haven't been removed yet, and iterations tracks the system ticks
+	// that might still be iterating over them.
+	despawnMu  sync.Mutex
+	despawns   []pendingDespawn
+	iterations iterations
+
+	// once holds the systems queued by RunSystemOnce since the last tick
+	// boundary.
+	onceMu sync.Mutex
+	once   []System
+
+	objectPool objectPool
+
+	names     nameIndex
+	tags      tagIndex
+	relations relationIndex
+
+	// slots limits how many goroutines may be ticking systems at once, or is
+	// nil if there's no limit.
+	slots chan struct{}
+
+	resources   map[reflect.Type]reflect.Value
+	resourcesMu sync.RWMutex
+	transitions []transitioner
+
+	watchers   map[Entity][]*watcher
+	watchAll   []*watcher
+	watchersMu sync.RWMutex
+	watching   int32
 }
 
-func NewWorld() *World {
-	return &World{
-		objects: make([]*Object, 0),
-		systems: make([]System, 0),
+func NewWorld(opts ...Option) *World {
+	w := &World{
+		objects:    make([]*Object, 0),
+		systems:    make([]*scheduledSystem, 0),
+		finalizers: make(map[reflect.Type][]func(Entity, interface{})),
+		hooks:      make(map[hookKey][]func(Entity, interface{})),
+		locks:      make(map[reflect.Type]*componentLock),
+		handlers:   make(map[reflect.Type][]func(interface{})),
+		resources:  make(map[reflect.Type]reflect.Value),
+		watchers:   make(map[Entity][]*watcher),
+	}
+	w.commands = newCommands(w)
+	for _, opt := range opts {
+		opt(w)
 	}
+	return w
 }
 
 func (w *World) AddObject(ob *Object) Entity {
+	if w.UniqueComponents {
+		ob.mu.Lock()
+		ob.components = w.dedupe(ob)
+		ob.mu.Unlock()
+	}
 	w.objectsMu.Lock()
-	defer w.objectsMu.Unlock()
+	ob.world = w
+	atomic.StoreInt32(&ob.despawning, 0)
+	w.nextSeq++
+	atomic.StoreUint64(&ob.seq, w.nextSeq)
 	w.objects = append(w.objects, ob)
+	w.objectsMu.Unlock()
+	w.moveToColumns(ob)
+	w.cache.update(w, ob)
+	w.index(ob)
+	w.metrics().Counter("ecs_objects_added", 1)
+	w.hookObject(ob, ComponentAdded)
 	return ob.entity
 }
 
+// SpawnBatch adds n new objects to the world, with the components build
+// returns for each index, and returns their entities in order. It is much
+// cheaper than calling AddObject n times: the entities and objects are
+// allocated together, and the world is locked once for the whole batch
+// rather than once per object.
+func (w *World) SpawnBatch(n int, build func(i int) []interface{}) []Entity {
+	if n <= 0 {
+		return nil
+	}
+	entities := newEntities(n)
+	obs := make([]*Object, n)
+	var storage []Object
+	for i, entity := range entities {
+		var ob *Object
+		if w.RecycleEntities {
+			ob = w.objectPool.get(w.iterations.oldest())
+		}
+		if ob == nil {
+			if storage == nil {
+				storage = make([]Object, n-i)
+			}
+			ob, storage = &storage[0], storage[1:]
+		}
+		ob.entity = entity
+		ob.components = build(i)
+		if w.UniqueComponents {
+			ob.components = w.dedupe(ob)
+		}
+		ob.world = w
+		obs[i] = ob
+	}
+
+	w.objectsMu.Lock()
+	w.objects = slices.Grow(w.objects, n)
+	for _, ob := range obs {
+		w.nextSeq++
+		atomic.StoreUint64(&ob.seq, w.nextSeq)
+		w.objects = append(w.objects, ob)
+	}
+	w.objectsMu.Unlock()
+
+	if len(w.columnMap()) > 0 {
+		for _, ob := range obs {
+			w.moveToColumns(ob)
+		}
+	}
+	w.cache.update(w, obs...)
+	w.metrics().Counter("ecs_objects_added", float64(n))
+	for _, ob := range obs {
+		w.index(ob)
+		w.hookObject(ob, ComponentAdded)
+	}
+	return entities
+}
+
+// Objects returns every object in the world. The returned slice is a copy, so
+// it is safe to use even if objects are later added or removed.
+func (w *World) Objects() []*Object {
+	w.objectsMu.RLock()
+	defer w.objectsMu.RUnlock()
+	objects := make([]*Object, len(w.objects))
+	copy(objects, w.objects)
+	return objects
+}
+
 func (w *World) GetObject(entity Entity) *Object {
 	w.objectsMu.RLock()
 	defer w.objectsMu.RUnlock()
@@ -79,58 +379,272 @@ func (w *World) GetObject(entity Entity) *Object {
 	return nil
 }
 
+// RemoveObject removes an object from the world, then runs any OnRemove hooks
+// and finalizers registered for its components.
 func (w *World) RemoveObject(entity Entity) {
+	if removed := w.detachObject(entity); removed != nil {
+		w.removed(removed)
+	}
+}
+
+// removed records the removal of an object from the world, and runs its
+// hooks and finalizers.
+func (w *World) removed(ob *Object) {
+	w.metrics().Counter("ecs_objects_removed", 1)
+	w.hookObject(ob, ComponentRemoved)
+	w.finalize(ob)
+	w.unrelateTarget(ob.entity)
+	w.recycle(ob)
+}
+
+// detachObject removes an object from the world's list of objects, returning
+// it, or nil if there was none.
+func (w *World) detachObject(entity Entity) *Object {
+	var removed *Object
 	w.objectsMu.Lock()
-	defer w.objectsMu.Unlock()
 	for i, ob := range w.objects {
 		if ob.entity == entity {
 			w.objects = append(w.objects[:i], w.objects[i+1:]...)
-			return
+			atomic.StoreUint64(&ob.seq, 0)
+			removed = ob
+			break
 		}
 	}
+	w.objectsMu.Unlock()
+	if removed != nil {
+		w.moveFromColumns(removed)
+		w.cache.update(w, removed)
+		w.index(removed)
+	}
+	return removed
 }
 
-func (w *World) AddSystem(s System) {
-	w.systems = append(w.systems, s)
+// AddSystem adds a system to the world, after checking that its function has
+// a signature the world knows how to call. If it doesn't, the system is not
+// added and an error describing the problem is returned.
+//
+// Systems may be added while the world is running, such as when content is
+// loaded mid-game. A system added to a running world starts right away,
+// waiting for its Ticker like any other, or ticking once if it has none, but
+// isn't waited on by Ready.
+func (w *World) AddSystem(s System) error {
+	if err := s.validate(w); err != nil {
+		return err
+	}
+	ss := w.schedule(s)
+	if state, ok := w.resumed[ss.name()]; ok {
+		ss.restore(state)
+	}
+	w.systemsMu.Lock()
+	w.systems = append(w.systems[:len(w.systems):len(w.systems)], ss)
+	w.lifecycle.launch(ss)
+	w.systemsMu.Unlock()
+	w.logger().Debug("system added", "system", ss.name())
+	return nil
+}
+
+// MustAddSystem is like AddSystem, but panics if the system is invalid.
+func (w *World) MustAddSystem(s System) {
+	if err := w.AddSystem(s); err != nil {
+		panic(err)
+	}
 }
 
+// Run runs the world until every system has finished. Use RunContext to find
+// out why the world stopped.
 func (w *World) Run() {
 	w.RunContext(context.Background())
 }
 
-func (w *World) RunContext(ctx context.Context) {
-	var wg sync.WaitGroup
-	wg.Add(len(w.systems))
+// Step ticks every system once, ignoring their tickers. Unlike Run, systems
+// tick one at a time on the calling goroutine, in the order they were added,
+// each followed by its tick boundary. This makes Step suited to driving a
+// world from a game loop or a test, where every frame should play out the
+// same way.
+func (w *World) Step() {
+	atomic.AddInt32(&w.stepping, 1)
+	defer atomic.AddInt32(&w.stepping, -1)
+	w.tickBoundary()
+	n := atomic.AddUint64(&w.ticks, 1)
+	for _, s := range w.systemList() {
+		s.tick(w, w.tickTime(n), n)
+		w.tickBoundary()
+	}
+}
+
+// RunContext runs every system in its own goroutine until they have all
+// finished or the context is cancelled. Systems whose parameters and return
+// values share a component type never tick at the same time, but systems that
+// touch disjoint sets of components may tick concurrently.
+//
+// RunContext returns nil if every system finished on its own. Otherwise, the
+// returned error joins the context's cancellation cause, if any, with a
+// *SystemError for every system that failed. A system fails if it returns an
+// error wrapping ErrFatal, or panics with DisableOnPanic set, and a
+// failed system stops running immediately rather than waiting for its ticker
+// or the context. Errors that systems report to OnError are not terminal, and
+// aren't included.
+func (w *World) RunContext(ctx context.Context) error {
+	errs := w.run(ctx, nil)
+	if ctx.Err() != nil {
+		errs = append([]error{context.Cause(ctx)}, errs...)
+	}
+	return errors.Join(errs...)
+}
+
+// run runs every system until they have all finished or the context is
+// cancelled, and returns a *SystemError for every system that failed. If
+// failed is set, it is called as soon as a system fails.
+func (w *World) run(ctx context.Context, failed func()) []error {
+	if w.deterministic {
+		return w.runDeterministic(ctx, failed)
+	}
+
+	// apply anything that was deferred before the world started
+	w.tickBoundary()
+	w.lifecycle.begin(w)
+	defer w.lifecycle.end()
+
+	var (
+		mu      sync.Mutex
+		errs    []error
+		startup sync.WaitGroup
+
+		// startupFailed is set if a system without a Ticker fails, in which
+		// case the world never becomes ready
+		startupFailed int32
+	)
+	runSystem := func(s *scheduledSystem, atStartup bool) {
+		err := s.run(ctx, w)
+		failure := err != nil && err != ctx.Err()
+		if atStartup {
+			if failure {
+				atomic.StoreInt32(&startupFailed, 1)
+			}
+			startup.Done()
+		}
+		if !failure {
+			// cancellation is reported once by RunContext, not per system
+			return
+		}
+		mu.Lock()
+		errs = append(errs, &SystemError{System: s.name(), Err: err})
+		mu.Unlock()
+		if failed != nil {
+			failed()
+		}
+	}
 
+	// systems added while the world runs don't hold up readiness
+	g := newRunGroup(func(s *scheduledSystem) { runSystem(s, false) })
+	w.systemsMu.RLock()
+	w.lifecycle.setRun(ctx, g)
 	for _, s := range w.systems {
-		go func(s System) {
-			s.run(ctx, w)
-			wg.Done()
-		}(s)
+		once := !s.periodic() && !w.clocked(s)
+		if once {
+			startup.Add(1)
+		}
+		g.goFunc(func() { runSystem(s, once) })
 	}
+	w.systemsMu.RUnlock()
+	go func() {
+		startup.Wait()
+		if atomic.LoadInt32(&startupFailed) == 0 {
+			w.lifecycle.markReady()
+		}
+	}()
 
-	wg.Wait()
+	g.serve()
+	w.lifecycle.setRun(nil, nil)
+	return errs
+}
+
+func (w *World) logger() *slog.Logger {
+	if w.Logger != nil {
+		return w.Logger
+	}
+	return slog.Default()
 }
 
 func (w *World) handleSystemError(name string, args []interface{}, err error) {
+	w.metrics().Counter("ecs_system_errors", 1, "system", name)
 	if w.OnError != nil {
 		(w.OnError)(name, args, err)
 		return
 	}
 
-	log.Printf(`system "%s" returned error: %s`, name, err)
+	w.logger().Error("system returned error", "system", name, "error", err)
 }
 
-func (w *World) makeObjectIter(t reflect.Type) (reflect.Value, error) {
+// dedupe returns the object's components without any duplicates, reporting
+// each one that was dropped.
+func (w *World) dedupe(ob *Object) []interface{} {
+	seen := make(map[reflect.Type]bool, len(ob.components))
+	components := ob.components[:0:0]
+	for _, c := range ob.components {
+		t := reflect.TypeOf(c)
+		if seen[t] {
+			w.reportDuplicate(ob, c)
+			continue
+		}
+		seen[t] = true
+		components = append(components, c)
+	}
+	return components
+}
+
+func (w *World) reportDuplicate(ob *Object, component interface{}) {
+	err := fmt.Errorf("%w: entity %d already has a %T", ErrDuplicateComponent, ob.entity, component)
+	if w.OnError != nil {
+		(w.OnError)("", []interface{}{component}, err)
+		return
+	}
+	w.logger().Warn("duplicate component discarded", "entity", ob.entity, "error", err)
+}
+
+func (w *World) handleDryRun(name string, entity Entity, writes []interface{}) {
+	if w.OnDryRun != nil {
+		(w.OnDryRun)(name, entity, writes)
+		return
+	}
+
+	w.logger().Info("dry-run system would have written components", "system", name, "entity", entity, "writes", writes)
+}
+
+// validateObjectIter checks that t is a valid iterator signature.
+func validateObjectIter(t reflect.Type) error {
+	if _, ok := seqComponent(t); ok {
+		return nil
+	}
 	if t.NumIn() > 1 {
-		return reflect.Value{}, errors.New("invalid signature: at most one argument expected")
+		return errors.New("invalid signature: at most one argument expected")
+	}
+	if t.NumIn() == 1 && t.In(0) != intType {
+		return errors.New("invalid signature: argument must be an int")
 	}
 
 	if t.NumOut() < 2 {
-		return reflect.Value{}, errors.New("invalid signature: at least two return values expected")
+		return errors.New("invalid signature: at least two return values expected")
 	}
 	if t.Out(t.NumOut()-1).Kind() != reflect.Bool {
-		return reflect.Value{}, errors.New("invalid signature: last return value must be a boolean")
+		return errors.New("invalid signature: last return value must be a boolean")
+	}
+	return nil
+}
+
+func (w *World) makeObjectIter(t reflect.Type) (reflect.Value, error) {
+	if err := validateObjectIter(t); err != nil {
+		return reflect.Value{}, err
+	}
+	if ct, ok := seqComponent(t); ok {
+		return w.makeSeq(t, ct), nil
+	}
+
+	var required componentMask
+	for out := 0; out < t.NumOut()-1; out++ {
+		if ot := t.Out(out); ot != intType && ot != entityType && ot.Kind() != reflect.Interface {
+			required.set(componentID(ot))
+		}
 	}
 
 	return reflect.MakeFunc(t, func(args []reflect.Value) (results []reflect.Value) {
@@ -146,6 +660,9 @@ func (w *World) makeObjectIter(t reflect.Type) (reflect.Value, error) {
 
 	ol:
 		for i, ob := range w.objects[start:] {
+			if !ob.hasTypes(required) {
+				continue
+			}
 			for out := 0; out < t.NumOut()-1; out++ {
 				ot := t.Out(out)
 				if ot == intType {
@@ -178,16 +695,82 @@ func (w *World) makeObjectIter(t reflect.Type) (reflect.Value, error) {
 	}), nil
 }
 
-type Entity uint64
+// accepts reports whether a system parameter of type param can receive a
+// component of type component.
+func (w *World) accepts(param, component reflect.Type) bool {
+	if w.StrictTypes && param.Kind() != reflect.Interface {
+		return param == component
+	}
+	return component.AssignableTo(param)
+}
+
+// writeIndex returns the index of the component that a system's return value
+// of type t should overwrite, or -1 if there isn't one. The caller must hold
+// the object's lock.
+func (w *World) writeIndex(ob *Object, t reflect.Type) (int, error) {
+	if !w.StrictTypes {
+		for i, c := range ob.components {
+			if t.AssignableTo(reflect.TypeOf(c)) {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}
+
+	index := -1
+	for i, c := range ob.components {
+		if reflect.TypeOf(c) == t {
+			if index >= 0 {
+				return -1, fmt.Errorf("strict types: return value of type %s matches multiple components", t)
+			}
+			index = i
+		}
+	}
+	if index < 0 {
+		return -1, fmt.Errorf("strict types: return value of type %s matches no components", t)
+	}
+	return index, nil
+}
+
+func valueInterfaces(values []reflect.Value) []interface{} {
+	is := make([]interface{}, len(values))
+	for i, v := range values {
+		is[i] = v.Interface()
+	}
+	return is
+}
 
+// An Object is a collection of components. Its components may be read and
+// changed from any goroutine, including while systems are running.
 type Object struct {
-	entity     Entity
+	entity Entity
+	world  *World
+
+	mu         sync.RWMutex
 	components []interface{}
+
+	// mask is the set of the components' types, or nil if it needs to be
+	// recomputed because they have changed.
+	mask componentMask
+
+	// seq orders the object within its world, or is zero if it isn't in
+	// one. It is set while holding the world's objects lock, and read
+	// atomically.
+	seq uint64
+
+	// dense are the types of the object's components that are held in its
+	// world's columns rather than in components. The slice is replaced
+	// rather than modified, so it can be used after releasing the lock.
+	dense []reflect.Type
+
+	// despawning is set once the object has been queued for removal by
+	// Despawn, so that systems skip it until it is removed.
+	despawning int32
 }
 
 func NewObject(cs ...interface{}) *Object {
 	return &Object{
-		entity:     Entity(atomic.AddUint64(&gid, 1)),
+		entity:     newEntity(),
 		components: cs,
 	}
 }
@@ -196,12 +779,22 @@ func (ob *Object) Entity() Entity {
 	return ob.entity
 }
 
+// Components returns a copy of the object's components.
 func (ob *Object) Components() []interface{} {
-	return ob.components
+	ob.mu.RLock()
+	components := append([]interface{}(nil), ob.components...)
+	ob.mu.RUnlock()
+	return append(components, ob.denseComponents()...)
 }
 
 func (ob *Object) Component(component interface{}) interface{} {
 	t := reflect.TypeOf(component)
+	if col := ob.denseColumn(t); col != nil {
+		c, _ := col.get(ob.entity)
+		return c
+	}
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
 	for _, c := range ob.components {
 		if reflect.TypeOf(c) == t {
 			return c
@@ -210,20 +803,128 @@ func (ob *Object) Component(component interface{}) interface{} {
 	return nil
 }
 
+// AddComponent adds a component to the object. If the object already has a
+// component of the same type, the new one is added alongside it, unless the
+// object is in a world with UniqueComponents set; use SetComponent to replace
+// it instead.
 func (ob *Object) AddComponent(component interface{}) {
+	t := reflect.TypeOf(component)
+	if indexedType(t) {
+		defer ob.reindex()
+	}
+	if col := ob.storageColumn(t); col != nil {
+		if _, ok := col.get(ob.entity); ok && ob.world.UniqueComponents {
+			ob.world.reportDuplicate(ob, component)
+			return
+		}
+		kind := ComponentAdded
+		if ob.putDense(col, t, component) {
+			kind = ComponentChanged
+		}
+		ob.world.notifyWatchers(Change{Entity: ob.entity, Kind: kind, Component: component})
+		return
+	}
+	ob.mu.Lock()
+	if ob.world != nil && ob.world.UniqueComponents {
+		for _, c := range ob.components {
+			if reflect.TypeOf(c) == t {
+				ob.mu.Unlock()
+				ob.world.reportDuplicate(ob, component)
+				return
+			}
+		}
+	}
 	ob.components = append(ob.components, component)
+	ob.mask = nil
+	ob.mu.Unlock()
+	if ob.world != nil {
+		ob.world.cache.update(ob.world, ob)
+		ob.world.notifyWatchers(Change{Entity: ob.entity, Kind: ComponentAdded, Component: component})
+	}
 }
 
-func (ob *Object) RemoveComponent(component interface{}) {
+// SetComponent replaces the object's component of the same type as
+// component, or adds it if the object doesn't have one.
+func (ob *Object) SetComponent(component interface{}) {
 	t := reflect.TypeOf(component)
+	if indexedType(t) {
+		defer ob.reindex()
+	}
+	kind := ComponentAdded
+	if col := ob.storageColumn(t); col != nil {
+		if ob.putDense(col, t, component) {
+			kind = ComponentChanged
+		}
+		ob.world.notifyWatchers(Change{Entity: ob.entity, Kind: kind, Component: component})
+		return
+	}
+	ob.mu.Lock()
 	for i, c := range ob.components {
 		if reflect.TypeOf(c) == t {
-			ob.components = append(ob.components[:i], ob.components[i+1:]...)
+			ob.components[i] = component
+			kind = ComponentChanged
+			break
+		}
+	}
+	if kind == ComponentAdded {
+		ob.components = append(ob.components, component)
+		ob.mask = nil
+	}
+	ob.mu.Unlock()
+	if ob.world != nil {
+		if kind == ComponentAdded {
+			ob.world.cache.update(ob.world, ob)
+		}
+		ob.world.notifyWatchers(Change{Entity: ob.entity, Kind: kind, Component: component})
+	}
+}
+
+func (ob *Object) RemoveComponent(component interface{}) {
+	t := reflect.TypeOf(component)
+	if indexedType(t) {
+		defer ob.reindex()
+	}
+	if col := ob.denseColumn(t); col != nil {
+		if c, ok := ob.removeDense(col, t); ok {
+			ob.world.notifyWatchers(Change{Entity: ob.entity, Kind: ComponentRemoved, Component: c})
+		}
+		return
+	}
+	var removed []interface{}
+	ob.mu.Lock()
+	kept := ob.components[:0]
+	for _, c := range ob.components {
+		if reflect.TypeOf(c) == t {
+			removed = append(removed, c)
+		} else {
+			kept = append(kept, c)
+		}
+	}
+	ob.components = kept
+	if len(removed) > 0 {
+		ob.mask = nil
+	}
+	ob.mu.Unlock()
+	if ob.world != nil && len(removed) > 0 {
+		ob.world.cache.update(ob.world, ob)
+		for _, c := range removed {
+			ob.world.notifyWatchers(Change{Entity: ob.entity, Kind: ComponentRemoved, Component: c})
 		}
 	}
 }
 
 func (ob *Object) getComponentValue(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Interface {
+		return ob.implementer(t)
+	}
+	if col := ob.denseColumn(t); col != nil {
+		if c, ok := col.get(ob.entity); ok {
+			return reflect.ValueOf(c)
+		}
+		return reflect.Value{}
+	}
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
 	for _, c := range ob.components {
 		v := reflect.ValueOf(c)
 		if v.Type() == t {
@@ -233,26 +934,269 @@ func (ob *Object) getComponentValue(t reflect.Type) reflect.Value {
 	return reflect.Value{}
 }
 
+// implementer returns the object's first component that implements the
+// interface t.
+func (ob *Object) implementer(t reflect.Type) reflect.Value {
+	ob.mu.RLock()
+	dense := ob.dense
+	for _, c := range ob.components {
+		if v := reflect.ValueOf(c); v.Type().Implements(t) {
+			ob.mu.RUnlock()
+			return v
+		}
+	}
+	ob.mu.RUnlock()
+	for _, dt := range dense {
+		if !dt.Implements(t) {
+			continue
+		}
+		if c, ok := ob.world.column(dt).get(ob.entity); ok {
+			return reflect.ValueOf(c)
+		}
+	}
+	return reflect.Value{}
+}
+
+// implements reports whether any of the object's components implements the
+// interface t.
+func (ob *Object) implements(t reflect.Type) bool {
+	return ob.implementer(t).IsValid()
+}
+
+// componentAt returns the component at index i, provided it is still of type
+// t. Since an object has at most one component of a dense type, i is ignored
+// for those.
+func (ob *Object) componentAt(i int, t reflect.Type) (interface{}, bool) {
+	if col := ob.denseColumn(t); col != nil {
+		return col.get(ob.entity)
+	}
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+	if i < len(ob.components) && reflect.TypeOf(ob.components[i]) == t {
+		return ob.components[i], true
+	}
+	return nil, false
+}
+
+// replaceAt overwrites the component at index i, provided it is still of type
+// t.
+func (ob *Object) replaceAt(i int, t reflect.Type, c interface{}) {
+	if col := ob.denseColumn(t); col != nil {
+		col.put(ob.entity, c)
+		return
+	}
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if i < len(ob.components) && reflect.TypeOf(ob.components[i]) == t {
+		ob.components[i] = c
+	}
+}
+
+// acceptedComponent returns the first of the object's components that a
+// system parameter of type t can receive.
+func (w *World) acceptedComponent(ob *Object, t reflect.Type) (reflect.Value, bool) {
+	ob.mu.RLock()
+	for _, c := range ob.components {
+		if cv := reflect.ValueOf(c); w.accepts(t, cv.Type()) {
+			ob.mu.RUnlock()
+			return cv, true
+		}
+	}
+	ob.mu.RUnlock()
+	return w.acceptedDense(ob, t)
+}
+
+// writeBack overwrites the component that a system's return value should
+// replace, unless dryRun is set, and returns its index, or -1 if there isn't
+// one.
+func (w *World) writeBack(ob *Object, result reflect.Value, dryRun bool) (int, error) {
+	if indexedType(result.Type()) && !dryRun {
+		defer ob.reindex()
+	}
+	if col := ob.denseColumn(result.Type()); col != nil {
+		if !dryRun {
+			col.put(ob.entity, result.Interface())
+		}
+		return 0, nil
+	}
+	retyped := false
+	ob.mu.Lock()
+	i, err := w.writeIndex(ob, result.Type())
+	if err == nil && i >= 0 && !dryRun {
+		if reflect.TypeOf(ob.components[i]) != result.Type() {
+			ob.mask = nil
+			retyped = true
+		}
+		ob.components[i] = result.Interface()
+	}
+	ob.mu.Unlock()
+	if retyped {
+		w.cache.update(w, ob)
+	}
+	return i, err
+}
+
 type System struct {
-	Func   interface{}
-	Name   string
+	Func interface{}
+
+	// Name identifies the system in logs and errors, and to methods such as
+	// DisableSystem and RemoveSystem. It defaults to the name of Func.
+	// Names aren't keys: several systems may share one, in which case the
+	// methods that look systems up by name act on the first one added.
+	Name string
+
+	// Label, if set, groups the system with others for tooling, such as
+	// "physics" or "ai". SystemsLabeled returns every system with a label.
+	Label string
+
+	// Stage, if set, names the part of the frame the system belongs to, such
+	// as "input", "update", or "render". It is informational, for tooling
+	// that lays out the schedule: systems tick in the same order whatever
+	// their stage.
+	Stage string
+
 	Ticker <-chan time.Time
+
+	// Every, if set, makes the system tick at the given interval, as if it
+	// had a Ticker that the world creates when it starts running and stops
+	// when it stops. It cannot be combined with Ticker.
+	Every time.Duration
+
+	// EveryNTicks, if greater than one, makes the system run on only every
+	// Nth tick, such as every 4th frame of a world driven by Step, or every
+	// 4th tick of its Ticker. The ticks in between are still due, so they
+	// count towards Healthy, but aren't reported to OnSystemTick.
+	//
+	// If the world has a TickRate, a system with EveryNTicks set and no
+	// Ticker or interval of its own ticks on the world's clock instead, on
+	// every tick whose number is a multiple of EveryNTicks. Set it to 1 to
+	// tick on every tick of the clock.
+	EveryNTicks int
+
+	// RunIf, if set, is checked before every tick, and the tick is skipped
+	// if it returns false.
+	RunIf func() bool
+
+	// Global systems are invoked once per tick rather than once per object,
+	// and so may only accept parameters that don't depend on an object, such
+	// as *World, time.Time, resources, and iterators.
+	Global bool
+
+	// Reads and Writes declare the components that the system accesses
+	// other than through its parameters and results, such as through a
+	// *World parameter, each as a value of the component's type or a nil
+	// pointer to an interface. The system holds their locks while it ticks,
+	// as it does for the components it accepts and returns, so it never
+	// ticks alongside a system that writes what it reads.
+	Reads  []interface{}
+	Writes []interface{}
+
+	// DryRun systems run as normal, but instead of overwriting components
+	// with their return values, queueing commands, emitting events with
+	// Commands.Emit, or replacing resources with ResMut.Set, they report
+	// what they would have done to World.OnDryRun. This makes it possible to
+	// check a new version of a system against real data before enabling it.
+	// Events emitted with Commands.Emit are buffered and discarded after
+	// being reported, never delivered. Dry-run systems cannot accept a *World
+	// or a Column, which would let them change the world directly.
+	DryRun bool
+
+	// Parallel splits the objects matched on each tick across a pool of
+	// goroutines, one per available CPU, subject to the world's parallelism
+	// limit. It should only be set for systems that read and write nothing
+	// but the components of the object they are invoked on; OnError may be
+	// called concurrently for parallel systems.
+	Parallel bool
+
+	// DisableOnPanic disables the system, as with World.DisableSystem, if it
+	// panics.
+	DisableOnPanic bool
+
+	// Timeout, if set, is how long a tick of the system may take. Once it
+	// has passed, the tick's context.Context, which the system can accept
+	// as a parameter, is cancelled, no further objects are visited on that
+	// tick, and the slow tick is reported to World.OnSlowSystem. A system
+	// that ignores its context can't be interrupted, but is still reported.
+	Timeout time.Duration
+
+	// Retry determines how the system recovers from the errors it returns.
+	// See RetryPolicy.
+	Retry RetryPolicy
+
+	// Once removes the system from the world, as with World.RemoveSystem,
+	// after the first tick it actually runs, so it runs exactly once. Ticks
+	// that are skipped, such as while it is disabled, or because RunIf
+	// returned false, don't count. To run a system once at the next tick
+	// boundary instead, use World.RunSystemOnce.
+	Once bool
+
+	// MainThread makes RunContext tick the system on the goroutine that
+	// called it, rather than on a goroutine of its own, for systems that
+	// must run on the OS main thread, such as those that draw with GLFW or
+	// SDL. Other systems keep running concurrently. To run on the main
+	// thread, call runtime.LockOSThread from an init function and RunContext
+	// from main. A main thread system can't be Parallel or sharded.
+	MainThread bool
+
+	// Shards, if greater than zero, splits objects across goroutines like
+	// Parallel, but assigns each object to one of a fixed number of shards
+	// based on its entity. Every object in a shard is processed by the same
+	// goroutine on each tick, and an object stays in the same shard for its
+	// whole life, so systems can keep per-shard caches by accepting a Shard
+	// parameter.
+	Shards int
+
+	// Priority determines which systems are skipped first when the world is
+	// shedding load. See World.ShedLoad.
+	Priority int
+
+	// DependsOn names the systems that produce the data this system
+	// consumes. If one of them is skipped to shed load, this system is
+	// skipped too.
+	DependsOn []string
+
+	// arena, counters, clock, ctx, failure, retries, and scheduled are set
+	// when the system is scheduled.
+	arena     *arena
+	counters  *tickCounters
+	clock     *systemClock
+	ctx       *tickContext
+	failure   *failure
+	retries   *retryState
+	scheduled *scheduledSystem
 }
 
-func (s System) run(ctx context.Context, w *World) error {
-	if s.Ticker == nil {
-		s.tick(w, time.Now())
-		return nil
+func (s *scheduledSystem) run(ctx context.Context, w *World) error {
+	s.failure.set(nil)
+	if w.clocked(s) {
+		return s.runClocked(ctx, w)
+	}
+	ticker := s.Ticker
+	if s.Every > 0 {
+		t := time.NewTicker(s.Every)
+		defer t.Stop()
+		ticker = t.C
+	}
+	if ticker == nil {
+		s.tickOnThread(w, time.Now(), 0)
+		w.tickBoundary()
+		return s.failure.get()
 	}
 
-	// TODO: add a "pause" capability
 	for {
 		select {
-		case now, ok := <-s.Ticker:
+		case now, ok := <-ticker:
 			if !ok {
 				return nil
 			}
-			s.tick(w, now)
+			s.tickOnThread(w, now, atomic.AddUint64(&s.due, 1))
+			w.tickBoundary()
+			if err := s.failure.get(); err != nil {
+				return err
+			}
+
+		case <-s.stop:
+			return nil
 
 		case <-ctx.Done():
 			return ctx.Err()
@@ -260,91 +1204,313 @@ func (s System) run(ctx context.Context, w *World) error {
 	}
 }
 
+// tickOnThread ticks the system, on the goroutine running the world if it is a
+// MainThread system.
+func (s *scheduledSystem) tickOnThread(w *World, now time.Time, n uint64) {
+	if s.MainThread {
+		if main := w.lifecycle.mainThread(); main != nil {
+			done := make(chan struct{})
+			main <- func() {
+				defer close(done)
+				s.tick(w, now, n)
+			}
+			<-done
+			return
+		}
+	}
+	s.tick(w, now, n)
+}
+
+// tick runs the system once, unless it has been disabled or n isn't a multiple
+// of its EveryNTicks, and records how long it took.
+func (s *scheduledSystem) tick(w *World, now time.Time, n uint64) {
+	atomic.StoreInt64(&s.lastSeen, time.Now().UnixNano())
+	if !s.dueOn(n) {
+		return
+	}
+	if !s.enabled() || s.removed() || (s.RunIf != nil && !s.RunIf()) {
+		return
+	}
+	if producer, skip := w.shed(s); skip {
+		atomic.StoreInt32(&s.shed, 1)
+		w.logger().Debug("system skipped", "system", s.name(), "producer", producer)
+		w.Emit(SystemSkipped{System: s.name(), Producer: producer})
+		return
+	}
+	atomic.StoreInt32(&s.shed, 0)
+	clock, simulated := w.retryClock(now, n)
+	if s.backingOff(clock) {
+		w.logger().Debug("system skipped while backing off", "system", s.name())
+		return
+	}
+	if s.Once {
+		if !atomic.CompareAndSwapInt32(&s.ran, 0, 1) {
+			return
+		}
+		defer w.unschedule(s)
+	}
+	w.acquireSlot()
+	defer w.releaseSlots(1)
+	defer w.iterations.end(w.iterations.begin())
+	lockAll(s.locks)
+
+	s.counters.reset()
+	start := time.Now()
+	stopTimeout := s.startTimeout(w, start)
+	s.tickWithRetries(w, now, clock, simulated)
+	stopTimeout()
+	elapsed := time.Since(start)
+	atomic.StoreInt64(&s.lastTick, int64(elapsed))
+	atomic.AddUint64(&s.ticks, 1)
+	unlockAll(s.locks)
+
+	w.metrics().Histogram("ecs_system_tick_seconds", elapsed.Seconds(), "system", s.name())
+	if w.OnSystemTick != nil {
+		w.OnSystemTick(s.name(), s.counters.stats(elapsed))
+	}
+}
+
 func (s System) tick(w *World, now time.Time) {
-	f := reflect.ValueOf(s.Func)
+	s.clock.advance(w, now)
 
-	argTypes := make([]reflect.Type, f.Type().NumIn())
-	argValues := make([]reflect.Value, f.Type().NumIn())
-	for i := 0; i < f.Type().NumIn(); i++ {
-		argTypes[i] = f.Type().In(i)
+	if s.Global {
+		argValues := s.arena.getArgs()
+		defer s.arena.putArgs(argValues)
+		s.tickObject(w, argValues, nil, nil, now, 0)
+		return
 	}
 
-ol:
-	for _, ob := range w.objects {
-	tl:
-		for i, t := range argTypes {
-			if t == worldType {
-				argValues[i] = reflect.ValueOf(w)
-				continue tl
+	required := s.arena.requiredMask(w)
+	objects := w.systemMatching(required)
+	if s.Shards > 0 {
+		s.tickSharded(w, objects, required, now)
+		return
+	}
+
+	if !s.Parallel || w.deterministic {
+		argValues := s.arena.getArgs()
+		defer s.arena.putArgs(argValues)
+		for _, ob := range objects {
+			if s.tickObject(w, argValues, ob, required, now, 0) {
+				return
 			}
+		}
+		return
+	}
 
-			if t == entityType {
-				argValues[i] = reflect.ValueOf(ob.entity)
-				continue tl
+	if len(objects) == 0 {
+		return
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(objects) {
+		workers = len(objects)
+	}
+	// this tick already holds one slot, so only extra workers need more
+	extra := w.tryAcquireSlots(workers - 1)
+	defer w.releaseSlots(extra)
+	workers = extra + 1
+	chunk := (len(objects) + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	var stopped int32
+	for start := 0; start < len(objects); start += chunk {
+		end := start + chunk
+		if end > len(objects) {
+			end = len(objects)
+		}
+		wg.Add(1)
+		go func(obs []*Object) {
+			defer wg.Done()
+			argValues := s.arena.getArgs()
+			defer s.arena.putArgs(argValues)
+			for _, ob := range obs {
+				if atomic.LoadInt32(&stopped) != 0 {
+					return
+				}
+				if s.tickObject(w, argValues, ob, required, now, 0) {
+					atomic.StoreInt32(&stopped, 1)
+				}
+			}
+		}(objects[start:end])
+	}
+	wg.Wait()
+}
+
+// tickObject invokes the system function on a single object, skipping it if
+// it doesn't have the required components, as given by the arena's
+// requiredMask. argValues is scratch space that is overwritten on each call.
+// ob is nil for global systems. It reports whether the system asked to stop
+// with ErrStopSystem.
+func (s System) tickObject(w *World, argValues []reflect.Value, ob *Object, required componentMask, now time.Time, shard Shard) (stop bool) {
+	return s.tickAttempt(w, argValues, ob, required, now, shard, 0)
+}
+
+// tickAttempt is tickObject, starting from the given attempt of the system's
+// RetryPolicy.
+func (s System) tickAttempt(w *World, argValues []reflect.Value, ob *Object, required componentMask, now time.Time, shard Shard, attempt int) (stop bool) {
+	if ob != nil && (atomic.LoadInt32(&ob.despawning) != 0 || !w.couldMatch(ob, required)) {
+		return false
+	}
+	if s.Timeout > 0 && s.ctx.expired() {
+		return true
+	}
+	commands := w.commands
+	var dry *dryRun
+	if s.DryRun {
+		dry = new(dryRun)
+		commands = &Commands{w: w, dry: dry}
+		defer func() { dry.report(w, s.name(), entityOf(ob)) }()
+	}
+	for i, p := range s.arena.params {
+		switch p.kind {
+		case paramWorld:
+			argValues[i] = reflect.ValueOf(w)
+
+		case paramContext:
+			argValues[i] = reflect.ValueOf(s.ctx.get())
+
+		case paramCommands:
+			argValues[i] = reflect.ValueOf(commands)
+
+		case paramTime:
+			argValues[i] = reflect.ValueOf(now)
+
+		case paramShard:
+			argValues[i] = reflect.ValueOf(shard)
+
+		case paramElapsed:
+			argValues[i] = reflect.ValueOf(s.clock.current())
+
+		case paramRes:
+			r, ok := w.resource(p.res.resourceType())
+			if !ok {
+				return false
 			}
+			argValues[i] = p.res.bind(w, r, dry)
 
-			if t == timeType {
-				argValues[i] = reflect.ValueOf(now)
-				continue tl
+		case paramSingle:
+			v, err := w.single(p.t)
+			if err != nil {
+				atomic.AddInt64(&s.counters.errors, 1)
+				w.handleSystemError(s.name(), nil, err)
+				return false
 			}
+			argValues[i] = v
 
-			if t.Kind() == reflect.Func {
-				// anything to do if the func takes arguments?
-				var err error
-				if argValues[i], err = w.makeObjectIter(t); err != nil {
-					log.Printf("failed to make object iter: %s", err)
-					continue ol
-				} else {
-					continue tl
-				}
+		case paramColumn:
+			c, ok := p.column.bindColumn(w)
+			if !ok {
+				return false
 			}
+			argValues[i] = c
 
-			for _, c := range ob.components {
-				cv := reflect.ValueOf(c)
-				if cv.Type().AssignableTo(t) {
-					argValues[i] = cv // need to convert?
-					continue tl
-				}
+		case paramIter:
+			if p.err != nil {
+				w.logger().Error("invalid iterator parameter", "system", s.name(), "type", p.t, "error", p.err)
+				return false
+			}
+			argValues[i] = p.iter
+
+		default:
+			if r, ok := w.resource(p.t); ok {
+				argValues[i] = r
+				continue
+			}
+
+			if ob == nil {
+				w.logger().Error("global system cannot accept parameter", "system", s.name(), "type", p.t)
+				return false
+			}
+
+			if p.t == entityType {
+				argValues[i] = reflect.ValueOf(ob.entity)
+				continue
 			}
 
-			// skipping this object because it doesn't have the required components
-			continue ol
+			cv, ok := w.acceptedComponent(ob, p.t)
+			if !ok {
+				// skipping this object because it doesn't have the required components
+				return false
+			}
+			argValues[i] = cv
 		}
+	}
 
-		results := f.Call(argValues)
-		if len(results) == 0 {
-			continue ol
+	if ob != nil {
+		atomic.AddInt64(&s.counters.matched, 1)
+		if w.watched() {
+			w.notifyWatchers(Change{Entity: ob.entity, Kind: QueryMatched, System: s.name()})
 		}
+	}
 
-		if v := results[len(results)-1]; v.Type() == errorType {
-			results = results[:len(results)-1]
-			if !v.IsNil() {
-				name := s.Name
-				if name == "" {
-					name = runtime.FuncForPC(f.Pointer()).Name()
-					if dot := strings.LastIndex(name, "."); dot > -1 {
-						name = name[dot+1:]
-					}
-				}
-				args := make([]interface{}, len(argValues))
-				for i, v := range argValues {
-					args[i] = v.Interface()
-				}
-				err := v.Interface().(error)
-				w.handleSystemError(name, args, err)
-			}
+	var results []reflect.Value
+	for ; ; attempt++ {
+		var ok bool
+		results, ok = s.call(w, s.arena.f, argValues, ob)
+		if !ok || len(results) == 0 {
+			return false
+		}
+		if !s.arena.returnsError || attempt >= s.Retry.EntityRetries || !retryable(results[len(results)-1]) {
+			break
+		}
+		if s.Retry.delay(attempt) > 0 {
+			// retried once the tick has released its locks
+			s.retries.postpone(entityRetry{
+				ob:      ob,
+				shard:   shard,
+				attempt: attempt + 1,
+				args:    valueInterfaces(argValues),
+				err:     results[len(results)-1].Interface().(error),
+			})
+			return false
 		}
+	}
 
-	rl:
-		for _, result := range results {
-			for i, c := range ob.components {
-				cv := reflect.ValueOf(c)
-				if result.Type().AssignableTo(cv.Type()) {
-					// TODO: finish
-					reflect.ValueOf(ob.components).Index(i).Set(result)
-					continue rl
-				}
+	if s.arena.returnsError {
+		v := results[len(results)-1]
+		results = results[:len(results)-1]
+		if !v.IsNil() {
+			err := v.Interface().(error)
+			switch {
+			case errors.Is(err, ErrSkipEntity):
+				return false
+			case errors.Is(err, ErrStopSystem):
+				w.logger().Info("system stopped", "system", s.name(), "entity", entityOf(ob))
+				s.scheduled.setEnabled(w, false)
+				return true
+			case errors.Is(err, ErrFatal):
+				w.logger().Error("system failed", "system", s.name(), "entity", entityOf(ob), "error", err)
+				s.failure.set(err)
+				s.scheduled.setEnabled(w, false)
+				return true
 			}
+			atomic.AddInt64(&s.counters.errors, 1)
+			atomic.StoreInt32(&s.retries.failed, 1)
+			w.handleSystemError(s.name(), valueInterfaces(argValues), err)
+		}
+	}
+
+	if ob == nil {
+		return false
+	}
+
+	for _, result := range results {
+		i, err := w.writeBack(ob, result, s.DryRun)
+		if err != nil {
+			atomic.AddInt64(&s.counters.errors, 1)
+			w.handleSystemError(s.name(), valueInterfaces(argValues), err)
+			continue
+		}
+		if i < 0 {
+			continue
+		}
+		if dry != nil {
+			dry.writes = append(dry.writes, result.Interface())
+			continue
+		}
+		if w.watched() {
+			w.notifyWatchers(Change{Entity: ob.entity, Kind: ComponentChanged, Component: result.Interface(), System: s.name()})
 		}
 	}
+	return false
 }
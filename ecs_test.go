@@ -1,9 +1,18 @@
 package ecs_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -130,6 +139,134 @@ func TestError(t *testing.T) {
 	}
 }
 
+func TestStep(t *testing.T) {
+	world := ecs.NewWorld()
+	ob := ecs.NewObject(Position(1))
+	world.AddObject(ob)
+
+	var order []string
+	world.AddSystem(ecs.System{Name: "double", Func: func(p Position) Position {
+		order = append(order, "double")
+		return p * 2
+	}})
+	world.AddSystem(ecs.System{Name: "increment", Func: func(p Position) Position {
+		order = append(order, "increment")
+		return p + 1
+	}})
+
+	world.Step()
+	world.Step()
+
+	if got, want := ob.Component(Position(0)), Position(7); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []string{"double", "increment", "double", "increment"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("systems should tick in order: got %v, want %v", order, want)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	world := ecs.NewWorld()
+	world.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	world.AddObject(ecs.NewObject(Position(1)))
+	world.AddSystem(ecs.System{
+		Name: "failing",
+		Func: func(p Position) error { return errors.New("oops") },
+	})
+	world.DisableSystem("failing")
+	world.EnableSystem("failing")
+	world.Run()
+
+	var msgs []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record struct {
+			Msg    string
+			System string
+			Error  string
+		}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		if record.System != "failing" {
+			t.Errorf("record %q missing system name", record.Msg)
+		}
+		msgs = append(msgs, record.Msg)
+	}
+
+	want := []string{"system added", "system disabled", "system enabled", "system returned error"}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("got records %q, want %q", msgs, want)
+	}
+}
+
+func TestErrorSentinels(t *testing.T) {
+	world := ecs.NewWorld()
+	world.OnError = func(name string, args []interface{}, err error) {
+		t.Errorf("sentinel errors should not be reported: %s", err)
+	}
+
+	objects := []*ecs.Object{
+		ecs.NewObject(Position(1)),
+		ecs.NewObject(Position(-1)),
+		ecs.NewObject(Position(2)),
+		ecs.NewObject(Position(0)),
+		ecs.NewObject(Position(3)),
+	}
+	for _, ob := range objects {
+		world.AddObject(ob)
+	}
+
+	world.AddSystem(ecs.System{
+		Name: "step",
+		Func: func(p Position) (Position, error) {
+			switch {
+			case p < 0:
+				return 100, ecs.ErrSkipEntity
+			case p == 0:
+				return 100, fmt.Errorf("out of range: %w", ecs.ErrStopSystem)
+			}
+			return p + 1, nil
+		},
+	})
+
+	world.Run()
+
+	var got []Position
+	for _, ob := range objects {
+		got = append(got, ob.Component(Position(0)).(Position))
+	}
+	if want := []Position{2, -1, 3, 0, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if info, _ := world.LookupSystem("step"); info.Enabled {
+		t.Error("system should be disabled after returning ErrStopSystem")
+	}
+}
+
+func TestStopSystemSharingName(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	var healthy, stopping int
+	world.AddSystem(ecs.System{Name: "step", Func: func(Position) { healthy++ }})
+	world.AddSystem(ecs.System{Name: "step", Func: func(Position) error {
+		stopping++
+		return ecs.ErrStopSystem
+	}})
+	world.Step()
+	world.Step()
+
+	if healthy != 2 {
+		t.Errorf("system sharing a stopped system's name ticked %d times, want 2", healthy)
+	}
+	if stopping != 1 {
+		t.Errorf("stopped system ticked %d times, want 1", stopping)
+	}
+}
+
 func TestMoveTowardsTarget(t *testing.T) {
 	world := ecs.NewWorld()
 	player := ecs.NewObject(Player{}, Position(1))
@@ -214,6 +351,91 @@ func TestAddAndRemoveComponents(t *testing.T) {
 	}
 }
 
+func TestSetComponent(t *testing.T) {
+	player := ecs.NewObject(Position(1))
+	player.AddComponent(Position(2))
+	if got := len(player.Components()); got != 2 {
+		t.Fatalf("AddComponent should allow duplicates by default, got %d components", got)
+	}
+
+	player.SetComponent(Position(3))
+	player.SetComponent(Velocity(4))
+	if got, want := player.Components(), []interface{}{Position(3), Position(2), Velocity(4)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad components: got %v, want %v", got, want)
+	}
+}
+
+func TestUniqueComponents(t *testing.T) {
+	world := ecs.NewWorld()
+	world.UniqueComponents = true
+	var errs []error
+	world.OnError = func(name string, args []interface{}, err error) {
+		errs = append(errs, err)
+	}
+
+	player := ecs.NewObject(Position(1), Position(2), Velocity(3))
+	world.AddObject(player)
+	player.AddComponent(Velocity(4))
+	player.SetComponent(Velocity(5))
+
+	if got, want := player.Components(), []interface{}{Position(1), Velocity(5)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad components: got %v, want %v", got, want)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ecs.ErrDuplicateComponent) {
+			t.Errorf("expected ErrDuplicateComponent, got %v", err)
+		}
+	}
+}
+
+func TestConcurrentComponentMutation(t *testing.T) {
+	type Tag struct{}
+
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Position(0), Velocity(1))
+	world.AddObject(player)
+
+	movement, steering := make(chan time.Time), make(chan time.Time)
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}, Ticker: movement})
+	world.AddSystem(ecs.System{Func: func(v Velocity) Velocity {
+		return v
+	}, Ticker: steering})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			player.AddComponent(Tag{})
+			player.SetComponent(Velocity(1))
+			_ = player.Components()
+			player.RemoveComponent(Tag{})
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for i := 0; i < 100; i++ {
+			movement <- time.Now()
+			steering <- time.Now()
+		}
+		<-done
+		cancel()
+	}()
+	world.RunContext(ctx)
+
+	if player.Component(Tag{}) != nil {
+		t.Error("tag should have been removed")
+	}
+	if got := player.Component(Position(0)).(Position); got <= 0 {
+		t.Errorf("position should have advanced, got %v", got)
+	}
+}
+
 func TestAddAndRemoveObjects(t *testing.T) {
 	world := ecs.NewWorld()
 
@@ -233,8 +455,10 @@ func TestSelfDestruct(t *testing.T) {
 
 	// Systems can take a few special types, including *ecs.World and ecs.Entity,
 	// in order to modify the world.
+	// Despawn waits for the system to finish iterating before removing the
+	// object.
 	selfDestruct := func(world *ecs.World, entity ecs.Entity, _ Marked) {
-		world.RemoveObject(entity)
+		world.Despawn(entity)
 	}
 
 	world := ecs.NewWorld()
@@ -251,3 +475,796 @@ func TestSelfDestruct(t *testing.T) {
 		t.Error("expected object not to self-destruct")
 	}
 }
+
+func TestParallel(t *testing.T) {
+	movement := func(p Position, v Velocity) Position {
+		return Position(int(p) + int(v))
+	}
+
+	world := ecs.NewWorld()
+	world.AddSystem(ecs.System{Func: movement, Parallel: true})
+
+	objects := make([]*ecs.Object, 100)
+	for i := range objects {
+		objects[i] = ecs.NewObject(Position(i), Velocity(2))
+		world.AddObject(objects[i])
+	}
+
+	world.Run()
+
+	for i, ob := range objects {
+		if got, want := ob.Component(Position(0)).(Position), Position(i+2); got != want {
+			t.Errorf("bad position for object %d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSystems(t *testing.T) {
+	movement := func(p Position, v Velocity) Position {
+		return Position(int(p) + int(v))
+	}
+
+	world := ecs.NewWorld()
+	world.AddSystem(ecs.System{Func: movement, Name: "movement", Label: "physics", Stage: "update"})
+	player := ecs.NewObject(Position(1), Velocity(2))
+	world.AddObject(player)
+
+	info, ok := world.LookupSystem("movement")
+	if !ok {
+		t.Fatal("system not found")
+	}
+	if info.Label != "physics" || info.Stage != "update" {
+		t.Errorf("bad label or stage: %q, %q", info.Label, info.Stage)
+	}
+	if info.Interval != 0 || info.EveryNTicks != 0 {
+		t.Errorf("bad interval: %v, every %d ticks", info.Interval, info.EveryNTicks)
+	}
+	if got, want := info.Reads, []reflect.Type{reflect.TypeOf(Position(0)), reflect.TypeOf(Velocity(0))}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad reads: got %v, want %v", got, want)
+	}
+	if got, want := info.Writes, []reflect.Type{reflect.TypeOf(Position(0))}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad writes: got %v, want %v", got, want)
+	}
+	if !info.Enabled {
+		t.Error("system should be enabled")
+	}
+
+	world.DisableSystem("movement")
+	world.Run()
+	if p := player.Component(Position(0)).(Position); p != Position(1) {
+		t.Errorf("disabled system should not have run, position is %v", p)
+	}
+
+	world.EnableSystem("movement")
+	world.Run()
+	if p := player.Component(Position(0)).(Position); p != Position(3) {
+		t.Errorf("enabled system should have run, position is %v", p)
+	}
+
+	if systems := world.Systems(); len(systems) != 1 || systems[0].Name != "movement" {
+		t.Errorf("bad systems: %v", systems)
+	}
+
+	world.AddSystem(ecs.System{Func: func(Velocity) {}, Name: "drag", Label: "physics", Every: time.Second})
+	world.AddSystem(ecs.System{Func: func(Position) {}, Name: "draw", Label: "render", EveryNTicks: 2})
+	physics := world.SystemsLabeled("physics")
+	if len(physics) != 2 || physics[0].Name != "movement" || physics[1].Name != "drag" {
+		t.Fatalf("bad physics systems: %v", physics)
+	}
+	if physics[1].Interval != time.Second {
+		t.Errorf("bad interval: got %v, want %v", physics[1].Interval, time.Second)
+	}
+	if render := world.SystemsLabeled("render"); len(render) != 1 || render[0].EveryNTicks != 2 {
+		t.Errorf("bad render systems: %v", render)
+	}
+}
+
+func TestConflictingSystems(t *testing.T) {
+	var (
+		inUse      int32
+		overlapped int32
+	)
+	movement := func(p Position) Position {
+		if !atomic.CompareAndSwapInt32(&inUse, 0, 1) {
+			atomic.StoreInt32(&overlapped, 1)
+			return p
+		}
+		time.Sleep(time.Millisecond)
+		atomic.StoreInt32(&inUse, 0)
+		return p + 1
+	}
+
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddSystem(ecs.System{Func: movement, Name: "a", Ticker: MaxTicker(time.Millisecond, 20)})
+	world.AddSystem(ecs.System{Func: movement, Name: "b", Ticker: MaxTicker(time.Millisecond, 20)})
+
+	world.Run()
+
+	if atomic.LoadInt32(&overlapped) != 0 {
+		t.Error("systems writing the same component ticked concurrently")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	movement := func(p Position, v Velocity) Position {
+		return Position(int(p) + int(v))
+	}
+
+	world := ecs.NewWorld()
+	world.AddSystem(ecs.System{Func: movement, Name: "movement", DryRun: true})
+	player := ecs.NewObject(Position(1), Velocity(2))
+	world.AddObject(player)
+
+	var reported []interface{}
+	world.OnDryRun = func(name string, entity ecs.Entity, writes []interface{}) {
+		if got, want := name, "movement"; got != want {
+			t.Errorf("received bad name: got %s, want %s", got, want)
+		}
+		if got, want := entity, player.Entity(); got != want {
+			t.Errorf("received bad entity: got %v, want %v", got, want)
+		}
+		reported = writes
+	}
+
+	world.Run()
+
+	if got, want := reported, []interface{}{Position(3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad reported writes: got %v, want %v", got, want)
+	}
+	if p := player.Component(Position(0)).(Position); p != Position(1) {
+		t.Errorf("dry run should not change position, got %v", p)
+	}
+}
+
+func TestDryRunCommands(t *testing.T) {
+	world := ecs.NewWorld()
+	target := world.AddObject(ecs.NewObject(Position(1)))
+	var spawned ecs.Entity
+	world.AddSystem(ecs.System{
+		Name:   "cleanup",
+		Global: true,
+		DryRun: true,
+		Func: func(cmds *ecs.Commands) {
+			spawned = cmds.Spawn(Velocity(1))
+			cmds.Despawn(target)
+		},
+	})
+
+	var reported []interface{}
+	world.OnDryRun = func(_ string, entity ecs.Entity, writes []interface{}) {
+		if entity != 0 {
+			t.Errorf("global system reported entity %v", entity)
+		}
+		reported = writes
+	}
+	world.Run()
+
+	want := []interface{}{
+		ecs.DryRunCommand{Op: "Spawn", Entity: spawned, Args: []interface{}{Velocity(1)}},
+		ecs.DryRunCommand{Op: "Despawn", Entity: target},
+	}
+	if !reflect.DeepEqual(reported, want) {
+		t.Errorf("bad reported commands: got %v, want %v", reported, want)
+	}
+	if len(world.Objects()) != 1 || world.GetObject(target) == nil {
+		t.Errorf("dry run should not apply commands, got objects %v", world.Objects())
+	}
+}
+
+func TestDryRunEvents(t *testing.T) {
+	world := ecs.NewWorld()
+	player := world.AddObject(ecs.NewObject(Position(0)))
+	world.AddSystem(ecs.System{
+		Name:   "defeat",
+		DryRun: true,
+		Func: func(cmds *ecs.Commands, p Position) Position {
+			cmds.Emit(bossDefeated{})
+			return p + 1
+		},
+	})
+	delivered := 0
+	world.OnEvent(bossDefeated{}, func(interface{}) { delivered++ })
+
+	var reported []interface{}
+	world.OnDryRun = func(_ string, entity ecs.Entity, writes []interface{}) {
+		if entity != player {
+			t.Errorf("bad reported entity: got %v, want %v", entity, player)
+		}
+		reported = writes
+	}
+	world.Run()
+
+	if want := []interface{}{Position(1), ecs.DryRunEvent{Event: bossDefeated{}}}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("bad reported writes: got %v, want %v", reported, want)
+	}
+	if delivered != 0 {
+		t.Errorf("dry run should not emit events, delivered %d", delivered)
+	}
+}
+
+func TestDryRunRejectsWorld(t *testing.T) {
+	world := ecs.NewWorld()
+	err := world.AddSystem(ecs.System{
+		DryRun: true,
+		Func: func(w *ecs.World, p Position) Position {
+			w.Emit(bossDefeated{})
+			return p
+		},
+	})
+	if err == nil {
+		t.Error("a dry-run system should not be able to accept a *World")
+	}
+}
+
+func TestDryRunResources(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddResource(Total(1))
+	var seen Total
+	world.AddSystem(ecs.System{
+		Name:   "count",
+		Global: true,
+		DryRun: true,
+		Func: func(total ecs.ResMut[Total]) {
+			total.Set(total.Get() + 1)
+			seen = total.Get()
+		},
+	})
+
+	var reported []interface{}
+	world.OnDryRun = func(_ string, _ ecs.Entity, writes []interface{}) {
+		reported = writes
+	}
+	world.Run()
+
+	if want := []interface{}{ecs.DryRunResource{Value: Total(2)}}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("bad reported writes: got %v, want %v", reported, want)
+	}
+	if seen != 2 {
+		t.Errorf("dry-run system should see its own change, got %v", seen)
+	}
+	if got := world.Resource(Total(0)); got != Total(1) {
+		t.Errorf("dry run should not replace resources, got %v", got)
+	}
+}
+
+func TestStrictTypes(t *testing.T) {
+	type Health int
+
+	heal := func(h Health) int {
+		return int(h) + 1
+	}
+
+	newWorld := func(strict bool) (*ecs.World, *ecs.Object, *[]error) {
+		world := ecs.NewWorld()
+		world.StrictTypes = strict
+		var errs []error
+		world.OnError = func(name string, args []interface{}, err error) {
+			errs = append(errs, err)
+		}
+		world.AddSystem(ecs.System{Func: heal})
+		ob := ecs.NewObject(Health(1), 5)
+		world.AddObject(ob)
+		return world, ob, &errs
+	}
+
+	// the unnamed int return value is assignable to the unrelated int component
+	world, ob, errs := newWorld(false)
+	world.Run()
+	if got, want := ob.Component(0), 2; got != want {
+		t.Errorf("permissive: bad int component: got %v, want %v", got, want)
+	}
+	if len(*errs) != 0 {
+		t.Errorf("permissive: unexpected errors: %v", *errs)
+	}
+
+	world, ob, errs = newWorld(true)
+	ob.AddComponent(7)
+	world.Run()
+	if got, want := ob.Component(0), 5; got != want {
+		t.Errorf("strict: int component should not change: got %v, want %v", got, want)
+	}
+	if len(*errs) != 1 {
+		t.Errorf("strict: expected an error for the ambiguous return value, got %v", *errs)
+	}
+}
+
+func TestRunContextError(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddSystem(ecs.System{Func: func(Position) {}})
+	if err := world.RunContext(context.Background()); err != nil {
+		t.Errorf("expected clean exit, got %s", err)
+	}
+
+	shutdown := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	world.AddSystem(ecs.System{
+		Func:   func(Position) { cancel(shutdown) },
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+	if err := world.RunContext(ctx); !errors.Is(err, shutdown) {
+		t.Errorf("expected cancellation cause, got %v", err)
+	}
+}
+
+func TestRunContextFailure(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	unavailable := fmt.Errorf("%w: database unavailable", ecs.ErrFatal)
+	world.AddSystem(ecs.System{
+		Name:   "save",
+		Func:   func(Position) error { return unavailable },
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+	world.AddSystem(ecs.System{
+		Name:           "crash",
+		Func:           func(Position) { panic("boom") },
+		Ticker:         time.NewTicker(time.Millisecond).C,
+		DisableOnPanic: true,
+	})
+	world.AddSystem(ecs.System{
+		Name: "done",
+		Func: func(Position) error { return ecs.ErrStopSystem },
+	})
+
+	// failed systems stop the world on their own, without a cancellation
+	err := world.RunContext(context.Background())
+	if !errors.Is(err, ecs.ErrFatal) || !errors.Is(err, unavailable) {
+		t.Errorf("expected the fatal error, got %v", err)
+	}
+
+	failed := make(map[string]error)
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var se *ecs.SystemError
+		if errors.As(e, &se) {
+			failed[se.System] = se.Err
+		}
+	}
+	if len(failed) != 2 {
+		t.Errorf("expected two failed systems, got %v", failed)
+	}
+	var pe *ecs.PanicError
+	if !errors.As(failed["crash"], &pe) || pe.Value != "boom" {
+		t.Errorf("expected a panic error, got %v", failed["crash"])
+	}
+}
+
+func TestFatalSystemSharingName(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	var healthy, failing int
+	world.AddSystem(ecs.System{Name: "save", Func: func(Position) { healthy++ }})
+	world.AddSystem(ecs.System{Name: "save", Func: func(Position) error {
+		failing++
+		return ecs.ErrFatal
+	}})
+	world.Step()
+	world.Step()
+
+	if healthy != 2 {
+		t.Errorf("system sharing a failed system's name ticked %d times, want 2", healthy)
+	}
+	if failing != 1 {
+		t.Errorf("failed system ticked %d times, want 1", failing)
+	}
+}
+
+func TestShards(t *testing.T) {
+	const shards = 4
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[ecs.Entity]ecs.Shard)
+	)
+	record := func(shard ecs.Shard, entity ecs.Entity, p Position) Position {
+		mu.Lock()
+		defer mu.Unlock()
+		if shard < 0 || shard >= shards {
+			t.Errorf("shard %d out of range", shard)
+		}
+		if prev, ok := seen[entity]; ok && prev != shard {
+			t.Errorf("entity %v moved from shard %d to %d", entity, prev, shard)
+		}
+		seen[entity] = shard
+		return p + 1
+	}
+
+	world := ecs.NewWorld()
+	world.AddSystem(ecs.System{Func: record, Shards: shards, Ticker: MaxTicker(time.Millisecond, 3)})
+	objects := make([]*ecs.Object, 50)
+	for i := range objects {
+		objects[i] = ecs.NewObject(Position(0))
+		world.AddObject(objects[i])
+	}
+
+	world.Run()
+
+	for _, ob := range objects {
+		if got, want := ob.Component(Position(0)), Position(3); got != want {
+			t.Errorf("bad position: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestAddSystemValidation(t *testing.T) {
+	tests := map[string]ecs.System{
+		"nil func":             {},
+		"not a func":           {Func: 5},
+		"bad iterator":         {Func: func(iter func() Position) {}},
+		"misplaced error":      {Func: func(p Position) (error, Position) { return nil, p }},
+		"global with entity":   {Func: func(ecs.Entity) {}, Global: true},
+		"global with resource": {Func: func(*Score) {}, Global: true},
+		"global with result":   {Func: func() Position { return 0 }, Global: true},
+		"negative interval":    {Func: func(Position) {}, Every: -time.Second},
+		"ticker and interval":  {Func: func(Position) {}, Every: time.Second, Ticker: make(chan time.Time)},
+		"negative divisor":     {Func: func(Position) {}, EveryNTicks: -1},
+		"negative timeout":     {Func: func(Position) {}, Timeout: -time.Second},
+		"negative retries":     {Func: func(Position) {}, Retry: ecs.RetryPolicy{TickRetries: -1}},
+		"parallel main thread": {Func: func(Position) {}, MainThread: true, Parallel: true},
+	}
+	for name, s := range tests {
+		if err := ecs.NewWorld().AddSystem(s); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	world := ecs.NewWorld()
+	world.AddResource(&Score{})
+	if err := world.AddSystem(ecs.System{Func: func(*Score, *ecs.World) {}, Global: true}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if got := len(world.Systems()); got != 1 {
+		t.Errorf("expected only the valid system to be added, got %d", got)
+	}
+}
+
+func TestSpawnBatch(t *testing.T) {
+	world := ecs.NewWorld()
+	ecs.StoreDense[Velocity](world)
+	first := world.AddObject(ecs.NewObject(Position(-1)))
+
+	entities := world.SpawnBatch(100, func(i int) []interface{} {
+		return []interface{}{Position(i), Velocity(1)}
+	})
+	if len(entities) != 100 {
+		t.Fatalf("bad number of entities: got %d, want 100", len(entities))
+	}
+	for i := 1; i < len(entities); i++ {
+		if entities[i] <= entities[i-1] || entities[i] <= first {
+			t.Fatalf("entities aren't increasing: %v", entities[:i+1])
+		}
+	}
+	ob := world.GetObject(entities[10])
+	if got := ob.Component(Position(0)); got != Position(10) {
+		t.Errorf("bad position: got %v, want 10", got)
+	}
+
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}})
+	world.Step()
+	if got := ob.Component(Position(0)); got != Position(11) {
+		t.Errorf("bad position after step: got %v, want 11", got)
+	}
+	if got := world.Query().With(Velocity(0)).Count(); got != 100 {
+		t.Errorf("bad velocity count: got %d, want 100", got)
+	}
+	if got := world.SpawnBatch(0, nil); got != nil {
+		t.Errorf("expected no entities, got %v", got)
+	}
+}
+
+func BenchmarkAddObject(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		world := ecs.NewWorld()
+		for j := 0; j < 10000; j++ {
+			world.AddObject(ecs.NewObject(Position(j), Velocity(1)))
+		}
+	}
+}
+
+func BenchmarkSpawnBatch(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		world := ecs.NewWorld()
+		world.SpawnBatch(10000, func(j int) []interface{} {
+			return []interface{}{Position(j), Velocity(1)}
+		})
+	}
+}
+
+func TestRemoveSystem(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	player := ecs.NewObject(Position(0))
+	world.AddObject(player)
+	world.AddSystem(ecs.System{Name: "move", Func: func(p Position) Position { return p + 1 }})
+
+	if world.RemoveSystem("missing") {
+		t.Error("removed a system that doesn't exist")
+	}
+	if !world.RemoveSystem("move") {
+		t.Fatal("system not found")
+	}
+	if _, ok := world.LookupSystem("move"); ok {
+		t.Error("removed system is still listed")
+	}
+	world.Step()
+	if p := player.Component(Position(0)).(Position); p != 0 {
+		t.Errorf("removed system ran, position is %v", p)
+	}
+
+	// removing a running system stops its goroutine, so the world finishes
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	world.AddSystem(ecs.System{Name: "tick", Func: func(p Position) Position { return p + 1 }, Ticker: ticker.C})
+	done := make(chan error)
+	go func() { done <- world.RunContext(context.Background()) }()
+	for player.Component(Position(0)).(Position) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	world.RemoveSystem("tick")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("world didn't stop after its only system was removed")
+	}
+}
+
+func TestReplaceSystem(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	player := ecs.NewObject(Position(0))
+	world.AddObject(player)
+	world.AddSystem(ecs.System{Name: "first", Func: func(p Position) Position { return p }})
+	world.AddSystem(ecs.System{Name: "move", Func: func(p Position) Position { return p + 1 }})
+	world.AddSystem(ecs.System{Name: "last", Func: func(p Position) Position { return p }})
+
+	if err := world.ReplaceSystem("missing", ecs.System{Func: func(Position) {}}); err == nil {
+		t.Error("replaced a system that doesn't exist")
+	}
+	if err := world.ReplaceSystem("move", ecs.System{Func: 42}); err == nil {
+		t.Error("replaced a system with an invalid one")
+	}
+	world.DisableSystem("move")
+	if err := world.ReplaceSystem("move", ecs.System{Func: func(p Position) Position { return p + 10 }}); err != nil {
+		t.Fatal(err)
+	}
+	systems := world.Systems()
+	if len(systems) != 3 || systems[1].Name != "move" || systems[1].Enabled {
+		t.Fatalf("bad systems after replacement: %v", systems)
+	}
+	world.EnableSystem("move")
+	world.Step()
+	if p := player.Component(Position(0)).(Position); p != 10 {
+		t.Errorf("bad position after replacement: got %v, want 10", p)
+	}
+
+	// replacing a running system starts the replacement in its place
+	var (
+		ticker   = time.NewTicker(time.Millisecond)
+		replaced = make(chan struct{})
+		once     sync.Once
+	)
+	defer ticker.Stop()
+	for _, name := range []string{"first", "last"} {
+		world.RemoveSystem(name)
+	}
+	world.ReplaceSystem("move", ecs.System{Func: func(Position) {}, Ticker: ticker.C})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- world.RunContext(ctx) }()
+	<-world.Ready()
+	err := world.ReplaceSystem("move", ecs.System{
+		Func:   func(Position) { once.Do(func() { close(replaced) }) },
+		Ticker: ticker.C,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-replaced:
+	case <-time.After(time.Second):
+		t.Error("replacement never ticked")
+	}
+	cancel()
+	<-done
+}
+
+func TestAddSystemWhileRunning(t *testing.T) {
+	world := ecs.NewWorld()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	var (
+		loaded = make(chan struct{})
+		ticked = make(chan struct{})
+		once   sync.Once
+	)
+	// a startup system loads content, including a new object and a system
+	// that ticks alongside the others
+	world.AddSystem(ecs.System{Global: true, Func: func(w *ecs.World) {
+		w.AddObject(ecs.NewObject(Position(0), Velocity(1)))
+		w.AddSystem(ecs.System{
+			Name:   "move",
+			Func:   func(p Position, v Velocity) Position { return p + Position(v) },
+			Ticker: ticker.C,
+		})
+		close(loaded)
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- world.RunContext(ctx) }()
+
+	<-loaded
+	check := time.NewTicker(time.Millisecond)
+	defer check.Stop()
+	world.AddSystem(ecs.System{Ticker: check.C, Func: func(p Position) {
+		if p > 2 {
+			once.Do(func() { close(ticked) })
+		}
+	}})
+	select {
+	case <-ticked:
+	case <-time.After(time.Second):
+		t.Fatal("systems added while running never ticked")
+	}
+	if _, ok := world.LookupSystem("move"); !ok {
+		t.Error("system added while running isn't listed")
+	}
+	cancel()
+	<-done
+}
+
+func TestEvery(t *testing.T) {
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Position(0))
+	world.AddObject(player)
+
+	var ticks int32
+	world.AddSystem(ecs.System{
+		Func:  func(Position) { atomic.AddInt32(&ticks, 1) },
+		Every: time.Millisecond,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- world.RunContext(ctx) }()
+	<-world.Ready()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&ticks) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := atomic.LoadInt32(&ticks); got < 3 {
+		t.Errorf("system with an interval ticked %d times, want at least 3", got)
+	}
+	if err := world.Healthy(); err == nil {
+		t.Error("stopped world reported healthy")
+	}
+}
+
+func TestEveryNTicks(t *testing.T) {
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Position(0), Velocity(0))
+	world.AddObject(player)
+	world.AddSystem(ecs.System{Func: func(p Position) Position { return p + 1 }, EveryNTicks: 4})
+	world.AddSystem(ecs.System{Func: func(v Velocity) Velocity { return v + 1 }, EveryNTicks: 1})
+
+	for i := 0; i < 10; i++ {
+		world.Step()
+	}
+	if p := player.Component(Position(0)).(Position); p != 2 {
+		t.Errorf("bad position after 10 steps: got %v, want 2", p)
+	}
+	if v := player.Component(Velocity(0)).(Velocity); v != 10 {
+		t.Errorf("bad velocity after 10 steps: got %v, want 10", v)
+	}
+}
+
+func TestOnce(t *testing.T) {
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Position(0))
+	world.AddObject(player)
+
+	ready := false
+	world.AddSystem(ecs.System{
+		Name:  "spawn",
+		Func:  func(p Position) Position { return p + 1 },
+		RunIf: func() bool { return ready },
+		Once:  true,
+	})
+	world.Step()
+	if _, ok := world.LookupSystem("spawn"); !ok {
+		t.Fatal("system was removed without running")
+	}
+	ready = true
+	world.Step()
+	world.Step()
+	if p := player.Component(Position(0)).(Position); p != 1 {
+		t.Errorf("bad position: got %v, want 1", p)
+	}
+	if _, ok := world.LookupSystem("spawn"); ok {
+		t.Error("system wasn't removed after running once")
+	}
+}
+
+type bossDefeated struct{}
+
+func TestRunSystemOnce(t *testing.T) {
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Position(0))
+	world.AddObject(player)
+
+	if err := world.RunSystemOnce(ecs.System{Func: 42}); err == nil {
+		t.Error("expected an error for an invalid system")
+	}
+	world.OnEvent(bossDefeated{}, func(interface{}) {
+		world.RunSystemOnce(ecs.System{Func: func(p Position) Position { return p + 100 }})
+	})
+	world.Emit(bossDefeated{})
+	world.Step()
+	world.Step()
+	world.Step()
+	if p := player.Component(Position(0)).(Position); p != 100 {
+		t.Errorf("bad position: got %v, want 100", p)
+	}
+	if n := len(world.Systems()); n != 0 {
+		t.Errorf("system run once was added to the schedule: %d systems", n)
+	}
+}
+
+// goroutineID returns the ID of the calling goroutine, from its stack trace.
+func goroutineID() string {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	return strings.Fields(string(buf))[1]
+}
+
+func TestMainThread(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0), Velocity(0)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	main, other := make(map[string]bool), make(map[string]bool)
+	world.AddSystem(ecs.System{
+		Func: func(p Position) Position {
+			mu.Lock()
+			defer mu.Unlock()
+			main[goroutineID()] = true
+			if p == 5 {
+				cancel()
+			}
+			return p + 1
+		},
+		Every:      time.Millisecond,
+		MainThread: true,
+	})
+	world.AddSystem(ecs.System{
+		Func: func(v Velocity) {
+			mu.Lock()
+			defer mu.Unlock()
+			other[goroutineID()] = true
+		},
+		Every: time.Millisecond,
+	})
+	world.RunContext(ctx)
+
+	caller := goroutineID()
+	if len(main) != 1 || !main[caller] {
+		t.Errorf("main thread system ran on goroutines %v, want only %s", main, caller)
+	}
+	if len(other) == 0 || other[caller] {
+		t.Errorf("other system ran on goroutines %v, want any but %s", other, caller)
+	}
+}
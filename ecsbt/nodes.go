@@ -0,0 +1,147 @@
+package ecsbt
+
+import "time"
+
+type composite struct {
+	children []Node
+	until    Status
+}
+
+// Sequence returns a node that ticks its children in order, moving on to the
+// next when one succeeds. It fails as soon as one of them fails, and succeeds
+// once they all have. A child that is still running is resumed on the next
+// tick, rather than starting over from the first child.
+func Sequence(children ...Node) Node {
+	return &composite{children: children, until: Failure}
+}
+
+// Selector returns a node that ticks its children in order, moving on to the
+// next when one fails. It succeeds as soon as one of them succeeds, and fails
+// once they all have. Like a Sequence, it resumes a running child.
+func Selector(children ...Node) Node {
+	return &composite{children: children, until: Success}
+}
+
+func (n *composite) Tick(ctx *Context) Status {
+	i := ctx.Memory(n)
+	for ; int(*i) < len(n.children); *i++ {
+		switch status := n.children[*i].Tick(ctx); status {
+		case Running:
+			return Running
+		case n.until:
+			*i = 0
+			return status
+		}
+	}
+	*i = 0
+	if n.until == Failure {
+		return Success
+	}
+	return Failure
+}
+
+type invert struct {
+	child Node
+}
+
+// Invert returns a node that succeeds when its child fails, and fails when it
+// succeeds.
+func Invert(child Node) Node {
+	return invert{child}
+}
+
+func (n invert) Tick(ctx *Context) Status {
+	switch n.child.Tick(ctx) {
+	case Success:
+		return Failure
+	case Failure:
+		return Success
+	}
+	return Running
+}
+
+type succeed struct {
+	child Node
+}
+
+// Succeed returns a node that succeeds whenever its child finishes, even if
+// it fails.
+func Succeed(child Node) Node {
+	return succeed{child}
+}
+
+func (n succeed) Tick(ctx *Context) Status {
+	if n.child.Tick(ctx) == Running {
+		return Running
+	}
+	return Success
+}
+
+type repeat struct {
+	child Node
+	times int
+}
+
+// Repeat returns a node that ticks its child again each time it succeeds,
+// until it has succeeded the given number of times, or forever if times isn't
+// positive. It fails as soon as the child does. The child is ticked at most
+// once per tick of the tree.
+func Repeat(times int, child Node) Node {
+	return &repeat{child: child, times: times}
+}
+
+func (n *repeat) Tick(ctx *Context) Status {
+	count := ctx.Memory(n)
+	switch n.child.Tick(ctx) {
+	case Running:
+		return Running
+	case Failure:
+		*count = 0
+		return Failure
+	}
+	if *count++; n.times > 0 && int(*count) >= n.times {
+		*count = 0
+		return Success
+	}
+	return Running
+}
+
+type wait struct {
+	d time.Duration
+}
+
+// Wait returns a node that keeps running until the Deltas of the ticks it has
+// run in add up to d, and then succeeds.
+func Wait(d time.Duration) Node {
+	return &wait{d}
+}
+
+func (n *wait) Tick(ctx *Context) Status {
+	elapsed := ctx.Memory(n)
+	if *elapsed += int64(ctx.Delta); time.Duration(*elapsed) < n.d {
+		return Running
+	}
+	*elapsed = 0
+	return Success
+}
+
+// Action is a leaf node that calls a function, which does the work of the
+// node and returns its status.
+type Action func(ctx *Context) Status
+
+// Tick calls a.
+func (a Action) Tick(ctx *Context) Status {
+	return a(ctx)
+}
+
+// Condition is a leaf node that succeeds if a function returns true, and
+// fails otherwise.
+type Condition func(ctx *Context) bool
+
+// Tick calls c.
+func (c Condition) Tick(ctx *Context) Status {
+	if c(ctx) {
+		return Success
+	}
+	return Failure
+}
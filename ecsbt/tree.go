@@ -0,0 +1,123 @@
+// Package ecsbt provides behavior trees for ecs objects, giving AI more
+// structure than a pile of systems that each check what an object is doing.
+//
+// A tree is built from composite nodes, such as Sequence and Selector, which
+// decide which of their children to tick; decorators, such as Invert and
+// Repeat, which change the result of a single child; and leaves, which call
+// the game's own functions. Each object gets its own Tree component, and the
+// system returned by System ticks them all:
+//
+//     guard := ecsbt.Selector(
+//         ecsbt.Sequence(ecsbt.Condition(seesPlayer), ecsbt.Action(chase)),
+//         ecsbt.Sequence(ecsbt.Action(patrol), ecsbt.Wait(2*time.Second)),
+//     )
+//     world.AddObject(ecs.NewObject(ecsbt.NewTree(guard), Position{}))
+//     world.AddSystem(ecsbt.System(ticker))
+//
+// Nodes are shared by every tree they are part of, so any state they keep
+// between ticks, such as which child of a Sequence is running, is stored in
+// the Tree through the Context's Memory.
+package ecsbt
+
+import (
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// Status is the result of ticking a node.
+type Status int
+
+const (
+	// Running nodes haven't finished, and will be ticked again on the
+	// tree's next tick.
+	Running Status = iota
+	Success
+	Failure
+)
+
+func (s Status) String() string {
+	switch s {
+	case Success:
+		return "success"
+	case Failure:
+		return "failure"
+	}
+	return "running"
+}
+
+// A Node is part of a behavior tree. Nodes that keep state between ticks must
+// be pointers, so that they can be used as keys into the tree's memory.
+type Node interface {
+	Tick(ctx *Context) Status
+}
+
+// Context is passed to every node ticked by the system, for the object whose
+// tree is being ticked.
+type Context struct {
+	World    *ecs.World
+	Commands *ecs.Commands
+	Entity   ecs.Entity
+
+	// Delta is the amount of time that has passed since the tree was last
+	// ticked, as with ecs.Elapsed.
+	Delta time.Duration
+
+	memory map[Node]*int64
+}
+
+// Object returns the object whose tree is being ticked.
+func (ctx *Context) Object() *ecs.Object {
+	return ctx.World.GetObject(ctx.Entity)
+}
+
+// Memory returns a value that n can use to keep state between ticks of this
+// tree. It starts out at zero, and n should set it back to zero when it
+// finishes.
+func (ctx *Context) Memory(n Node) *int64 {
+	m, ok := ctx.memory[n]
+	if !ok {
+		m = new(int64)
+		ctx.memory[n] = m
+	}
+	return m
+}
+
+// Tree is a component holding an object's behavior tree, along with the
+// state its nodes keep between ticks.
+type Tree struct {
+	Root Node
+
+	// Status is the root's status as of the tree's most recent tick.
+	Status Status
+
+	memory map[Node]*int64
+}
+
+// NewTree creates a tree with the given root. Each object needs a tree of
+// its own, though they may all have the same root.
+func NewTree(root Node) Tree {
+	return Tree{Root: root, memory: make(map[Node]*int64)}
+}
+
+// System returns a system that ticks the tree of every object with one on
+// each tick of the given ticker.
+func System(ticker <-chan time.Time) ecs.System {
+	return ecs.System{
+		Func:   tick,
+		Name:   "ecsbt.Tree",
+		Ticker: ticker,
+	}
+}
+
+func tick(w *ecs.World, cmd *ecs.Commands, e ecs.Entity, t Tree, dt ecs.Elapsed) Tree {
+	if t.Root == nil {
+		return t
+	}
+	if t.memory == nil {
+		t.memory = make(map[Node]*int64)
+	}
+	ctx := &Context{World: w, Commands: cmd, Entity: e, Delta: time.Duration(dt), memory: t.memory}
+	t.Status = t.Root.Tick(ctx)
+	return t
+}
@@ -0,0 +1,132 @@
+package ecsbt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsbt"
+)
+
+type Health int
+
+type Ammo int
+
+func TestSequenceResumes(t *testing.T) {
+	var calls []string
+	step := func(name string, ticks int) ecsbt.Node {
+		n := 0
+		return ecsbt.Action(func(ctx *ecsbt.Context) ecsbt.Status {
+			calls = append(calls, name)
+			if n++; n < ticks {
+				return ecsbt.Running
+			}
+			n = 0
+			return ecsbt.Success
+		})
+	}
+
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(ecsbt.NewTree(ecsbt.Sequence(step("a", 1), step("b", 2), step("c", 1)))))
+	world.AddSystem(ecsbt.System(nil))
+	world.Step()
+	world.Step()
+
+	want := []string{"a", "b", "b", "c"}
+	if len(calls) != len(want) {
+		t.Fatalf("bad calls: got %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("bad calls: got %v, want %v", calls, want)
+		}
+	}
+}
+
+func TestSelector(t *testing.T) {
+	lowHealth := ecsbt.Condition(func(ctx *ecsbt.Context) bool {
+		return ctx.Object().Component(Health(0)).(Health) < 10
+	})
+	flee := ecsbt.Action(func(ctx *ecsbt.Context) ecsbt.Status {
+		ctx.Commands.SetComponent(ctx.Entity, Ammo(0))
+		return ecsbt.Success
+	})
+	fight := ecsbt.Action(func(ctx *ecsbt.Context) ecsbt.Status {
+		ammo := ctx.Object().Component(Ammo(0)).(Ammo)
+		if ammo == 0 {
+			return ecsbt.Failure
+		}
+		ctx.Commands.SetComponent(ctx.Entity, ammo-1)
+		return ecsbt.Success
+	})
+	root := ecsbt.Selector(ecsbt.Sequence(lowHealth, flee), fight)
+
+	world := ecs.NewWorld()
+	strong := world.AddObject(ecs.NewObject(ecsbt.NewTree(root), Health(100), Ammo(3)))
+	weak := world.AddObject(ecs.NewObject(ecsbt.NewTree(root), Health(5), Ammo(3)))
+	world.AddSystem(ecsbt.System(nil))
+	world.Step()
+	world.Step()
+
+	if got := world.GetObject(strong).Component(Ammo(0)); got != Ammo(1) {
+		t.Errorf("strong object should have fought twice, has %v ammo", got)
+	}
+	if got := world.GetObject(weak).Component(Ammo(0)); got != Ammo(0) {
+		t.Errorf("weak object should have fled, has %v ammo", got)
+	}
+	tree := world.GetObject(strong).Component(ecsbt.Tree{}).(ecsbt.Tree)
+	if tree.Status != ecsbt.Success {
+		t.Errorf("bad tree status: %v", tree.Status)
+	}
+}
+
+func TestDecorators(t *testing.T) {
+	fail := ecsbt.Action(func(*ecsbt.Context) ecsbt.Status { return ecsbt.Failure })
+	ctx := &ecsbt.Context{}
+	if got := ecsbt.Invert(fail).Tick(ctx); got != ecsbt.Success {
+		t.Errorf("Invert: got %v", got)
+	}
+	if got := ecsbt.Succeed(fail).Tick(ctx); got != ecsbt.Success {
+		t.Errorf("Succeed: got %v", got)
+	}
+}
+
+func TestRepeatAndWait(t *testing.T) {
+	count := 0
+	root := ecsbt.Repeat(2, ecsbt.Sequence(
+		ecsbt.Wait(time.Second),
+		ecsbt.Action(func(*ecsbt.Context) ecsbt.Status { count++; return ecsbt.Success }),
+	))
+
+	clock := ecs.NewTime()
+	start := time.Unix(100, 0)
+	clock.Update(start)
+	world := ecs.NewWorld()
+	world.AddResource(clock)
+	e := world.AddObject(ecs.NewObject(ecsbt.NewTree(root)))
+	world.AddSystem(ecsbt.System(nil))
+
+	var statuses []ecsbt.Status
+	for i := 0; i < 4; i++ {
+		clock.Update(start.Add(time.Duration(i) * 500 * time.Millisecond))
+		world.Step()
+		statuses = append(statuses, world.GetObject(e).Component(ecsbt.Tree{}).(ecsbt.Tree).Status)
+	}
+
+	if count != 1 {
+		t.Errorf("action ran %d times, want 1", count)
+	}
+	want := []ecsbt.Status{ecsbt.Running, ecsbt.Running, ecsbt.Running, ecsbt.Running}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("tick %d: got %v, want %v", i, statuses[i], want[i])
+		}
+	}
+	for i := 4; i < 5; i++ {
+		clock.Update(start.Add(time.Duration(i) * 500 * time.Millisecond))
+		world.Step()
+	}
+	if got := world.GetObject(e).Component(ecsbt.Tree{}).(ecsbt.Tree).Status; count != 2 || got != ecsbt.Success {
+		t.Errorf("after 2s: action ran %d times, status %v", count, got)
+	}
+}
@@ -0,0 +1,33 @@
+package ecsmath
+
+import "math"
+
+// Angle is an angle in radians.
+type Angle float64
+
+// Degrees converts an angle in degrees to an Angle.
+func Degrees(d float64) Angle {
+	return Angle(d * math.Pi / 180)
+}
+
+// Degrees returns the angle in degrees.
+func (a Angle) Degrees() float64 {
+	return float64(a) * 180 / math.Pi
+}
+
+// Normalize returns the equivalent angle in the range (-π, π].
+func (a Angle) Normalize() Angle {
+	n := math.Mod(float64(a), 2*math.Pi)
+	if n <= -math.Pi {
+		n += 2 * math.Pi
+	} else if n > math.Pi {
+		n -= 2 * math.Pi
+	}
+	return Angle(n)
+}
+
+// Vec2 returns the unit vector pointing in the direction of the angle.
+func (a Angle) Vec2() Vec2 {
+	sin, cos := math.Sincos(float64(a))
+	return Vec2{cos, sin}
+}
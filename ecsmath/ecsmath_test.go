@@ -0,0 +1,64 @@
+package ecsmath_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dradtke/ecs-go/ecsmath"
+)
+
+const epsilon = 1e-9
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+func TestVec2(t *testing.T) {
+	v := ecsmath.Vec2{X: 3, Y: 4}
+	if got, want := v.Len(), 5.0; got != want {
+		t.Errorf("bad length: got %v, want %v", got, want)
+	}
+	if got := v.Normalize().Len(); !near(got, 1) {
+		t.Errorf("normalized vector has length %v", got)
+	}
+	if got, want := v.Lerp(ecsmath.Vec2{}, 0.5), (ecsmath.Vec2{X: 1.5, Y: 2}); got != want {
+		t.Errorf("bad lerp: got %v, want %v", got, want)
+	}
+
+	r := ecsmath.Vec2{X: 1}.Rotate(ecsmath.Degrees(90))
+	if !near(r.X, 0) || !near(r.Y, 1) {
+		t.Errorf("bad rotation: got %v", r)
+	}
+}
+
+func TestVec3(t *testing.T) {
+	x, y := ecsmath.Vec3{X: 1}, ecsmath.Vec3{Y: 1}
+	if got, want := x.Cross(y), (ecsmath.Vec3{Z: 1}); got != want {
+		t.Errorf("bad cross product: got %v, want %v", got, want)
+	}
+}
+
+func TestRect(t *testing.T) {
+	r := ecsmath.RectAround(ecsmath.Vec2{}, 1)
+	if !r.Contains(ecsmath.Vec2{X: 0.5, Y: -0.5}) {
+		t.Error("rect should contain point")
+	}
+	if r.Contains(ecsmath.Vec2{X: 1, Y: 0}) {
+		t.Error("rect should not contain point on its max edge")
+	}
+	if !r.Intersects(r.Translate(ecsmath.Vec2{X: 1.5})) {
+		t.Error("rects should intersect")
+	}
+	if r.Intersects(r.Translate(ecsmath.Vec2{X: 2})) {
+		t.Error("adjacent rects should not intersect")
+	}
+}
+
+func TestAngle(t *testing.T) {
+	if got, want := ecsmath.Degrees(270).Normalize().Degrees(), -90.0; !near(got, want) {
+		t.Errorf("bad normalized angle: got %v, want %v", got, want)
+	}
+	if got, want := ecsmath.Degrees(-180).Normalize().Degrees(), 180.0; !near(got, want) {
+		t.Errorf("bad normalized angle: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package ecsmath
+
+import "math"
+
+// Rect is an axis-aligned rectangle. Min is inclusive and Max is exclusive.
+type Rect struct {
+	Min, Max Vec2
+}
+
+// RectAround returns the square centered on a point that extends radius in
+// every direction.
+func RectAround(center Vec2, radius float64) Rect {
+	r := Vec2{radius, radius}
+	return Rect{Min: center.Sub(r), Max: center.Add(r)}
+}
+
+func (r Rect) Width() float64 {
+	return r.Max.X - r.Min.X
+}
+
+func (r Rect) Height() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+func (r Rect) Center() Vec2 {
+	return r.Min.Lerp(r.Max, 0.5)
+}
+
+// Empty reports whether the rectangle contains no points.
+func (r Rect) Empty() bool {
+	return r.Min.X >= r.Max.X || r.Min.Y >= r.Max.Y
+}
+
+// Contains reports whether the point is inside the rectangle.
+func (r Rect) Contains(p Vec2) bool {
+	return r.Min.X <= p.X && p.X < r.Max.X && r.Min.Y <= p.Y && p.Y < r.Max.Y
+}
+
+// Intersects reports whether the two rectangles overlap.
+func (r Rect) Intersects(o Rect) bool {
+	return !r.Intersect(o).Empty()
+}
+
+// Intersect returns the largest rectangle contained by both rectangles.
+func (r Rect) Intersect(o Rect) Rect {
+	return Rect{
+		Min: Vec2{math.Max(r.Min.X, o.Min.X), math.Max(r.Min.Y, o.Min.Y)},
+		Max: Vec2{math.Min(r.Max.X, o.Max.X), math.Min(r.Max.Y, o.Max.Y)},
+	}
+}
+
+// Union returns the smallest rectangle that contains both rectangles.
+func (r Rect) Union(o Rect) Rect {
+	return Rect{
+		Min: Vec2{math.Min(r.Min.X, o.Min.X), math.Min(r.Min.Y, o.Min.Y)},
+		Max: Vec2{math.Max(r.Max.X, o.Max.X), math.Max(r.Max.Y, o.Max.Y)},
+	}
+}
+
+// Translate moves the rectangle by the given offset.
+func (r Rect) Translate(d Vec2) Rect {
+	return Rect{Min: r.Min.Add(d), Max: r.Max.Add(d)}
+}
@@ -0,0 +1,146 @@
+// Package ecsmath provides small value types for geometry that are intended to
+// be embedded in components:
+//
+//     type Position struct{ ecsmath.Vec2 }
+//
+// The spatial and transform modules built on top of the ecs package use these
+// types, so components that embed them interoperate with those modules.
+package ecsmath
+
+import "math"
+
+// Vec2 is a two-dimensional vector.
+type Vec2 struct {
+	X, Y float64
+}
+
+// Locator is implemented by every type that embeds a Vec2 or Vec3, which lets
+// modules find the location stored in components they don't know about.
+type Locator interface {
+	XY() Vec2
+}
+
+// XY returns the vector itself. It exists so that components that embed a
+// Vec2 implement Locator.
+func (v Vec2) XY() Vec2 {
+	return v
+}
+
+func (v Vec2) Add(o Vec2) Vec2 {
+	return Vec2{v.X + o.X, v.Y + o.Y}
+}
+
+func (v Vec2) Sub(o Vec2) Vec2 {
+	return Vec2{v.X - o.X, v.Y - o.Y}
+}
+
+func (v Vec2) Scale(s float64) Vec2 {
+	return Vec2{v.X * s, v.Y * s}
+}
+
+func (v Vec2) Dot(o Vec2) float64 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+// Len returns the vector's length.
+func (v Vec2) Len() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// LenSq returns the square of the vector's length, which avoids a square root
+// when only comparing lengths.
+func (v Vec2) LenSq() float64 {
+	return v.Dot(v)
+}
+
+// Dist returns the distance between two points.
+func (v Vec2) Dist(o Vec2) float64 {
+	return v.Sub(o).Len()
+}
+
+// Normalize returns a vector of length one pointing in the same direction, or
+// the zero vector if v is zero.
+func (v Vec2) Normalize() Vec2 {
+	l := v.Len()
+	if l == 0 {
+		return Vec2{}
+	}
+	return v.Scale(1 / l)
+}
+
+// Lerp linearly interpolates between v and o, returning v when t is 0 and o
+// when t is 1.
+func (v Vec2) Lerp(o Vec2, t float64) Vec2 {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+// Rotate rotates the vector counter-clockwise around the origin.
+func (v Vec2) Rotate(a Angle) Vec2 {
+	sin, cos := math.Sincos(float64(a))
+	return Vec2{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
+// Angle returns the angle of the vector from the positive X axis.
+func (v Vec2) Angle() Angle {
+	return Angle(math.Atan2(v.Y, v.X))
+}
+
+// Vec3 is a three-dimensional vector.
+type Vec3 struct {
+	X, Y, Z float64
+}
+
+func (v Vec3) Add(o Vec3) Vec3 {
+	return Vec3{v.X + o.X, v.Y + o.Y, v.Z + o.Z}
+}
+
+func (v Vec3) Sub(o Vec3) Vec3 {
+	return Vec3{v.X - o.X, v.Y - o.Y, v.Z - o.Z}
+}
+
+func (v Vec3) Scale(s float64) Vec3 {
+	return Vec3{v.X * s, v.Y * s, v.Z * s}
+}
+
+func (v Vec3) Dot(o Vec3) float64 {
+	return v.X*o.X + v.Y*o.Y + v.Z*o.Z
+}
+
+func (v Vec3) Cross(o Vec3) Vec3 {
+	return Vec3{
+		v.Y*o.Z - v.Z*o.Y,
+		v.Z*o.X - v.X*o.Z,
+		v.X*o.Y - v.Y*o.X,
+	}
+}
+
+// Len returns the vector's length.
+func (v Vec3) Len() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Dist returns the distance between two points.
+func (v Vec3) Dist(o Vec3) float64 {
+	return v.Sub(o).Len()
+}
+
+// Normalize returns a vector of length one pointing in the same direction, or
+// the zero vector if v is zero.
+func (v Vec3) Normalize() Vec3 {
+	l := v.Len()
+	if l == 0 {
+		return Vec3{}
+	}
+	return v.Scale(1 / l)
+}
+
+// Lerp linearly interpolates between v and o, returning v when t is 0 and o
+// when t is 1.
+func (v Vec3) Lerp(o Vec3, t float64) Vec3 {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+// XY drops the Z component.
+func (v Vec3) XY() Vec2 {
+	return Vec2{v.X, v.Y}
+}
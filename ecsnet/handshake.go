@@ -0,0 +1,221 @@
+// Package ecsnet provides building blocks for replicating ecs worlds between
+// processes.
+//
+// Before exchanging any components, both ends of a connection perform a
+// handshake that compares their component registries. Components that both
+// sides registered with the same layout can be replicated; anything else is
+// reported, so that mismatched builds either degrade to the common subset or
+// fail up front instead of desyncing silently:
+//
+//     agreement, err := ecsnet.Handshake(conn, "game.Position")
+//     if err != nil {
+//         return err
+//     }
+//     for _, name := range agreement.Mismatched {
+//         log.Printf("not replicating %s: layouts differ", name)
+//     }
+package ecsnet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// ProtocolVersion is the version of the replication protocol spoken by this
+// package. Peers speaking different versions cannot be reconciled.
+const ProtocolVersion = 1
+
+// ErrProtocolVersion is returned by Negotiate when the peers speak different
+// protocol versions.
+var ErrProtocolVersion = errors.New("protocol version mismatch")
+
+// Metrics, if set, counts handshakes as ecsnet_handshakes, labeled with a
+// result of "ok", "incompatible", or "error", and replicated changes as
+// ecsnet_changes, labeled with a direction of "sent" or "received".
+var Metrics ecs.MetricsSink
+
+// A Manifest describes the components one side of a connection knows about.
+type Manifest struct {
+	Version int
+
+	// Components maps each registered component name to a hash of its
+	// layout, as returned by ecs.RegisteredTypes.
+	Components map[string]uint64
+}
+
+// LocalManifest returns the manifest for this process.
+func LocalManifest() Manifest {
+	return Manifest{Version: ProtocolVersion, Components: ecs.RegisteredTypes()}
+}
+
+// An Agreement is the outcome of a successful negotiation. Each list is
+// sorted by name.
+type Agreement struct {
+	// Common lists components that both sides registered with the same
+	// layout. Only these can be replicated.
+	Common []string
+
+	// Mismatched lists components that both sides registered, but with
+	// different layouts.
+	Mismatched []string
+
+	// LocalOnly and RemoteOnly list components registered by only one side.
+	LocalOnly, RemoteOnly []string
+}
+
+// Allows reports whether the named component can be replicated.
+func (a *Agreement) Allows(name string) bool {
+	i := sort.SearchStrings(a.Common, name)
+	return i < len(a.Common) && a.Common[i] == name
+}
+
+// IncompatibleError is returned when a component required by the local side
+// cannot be replicated.
+type IncompatibleError struct {
+	Agreement *Agreement
+
+	// Missing lists required components that the peer didn't register, and
+	// Mismatched lists those that it registered with a different layout.
+	Missing, Mismatched []string
+}
+
+func (e *IncompatibleError) Error() string {
+	var reasons []string
+	if len(e.Missing) > 0 {
+		reasons = append(reasons, "missing "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Mismatched) > 0 {
+		reasons = append(reasons, "mismatched "+strings.Join(e.Mismatched, ", "))
+	}
+	return "incompatible peer: " + strings.Join(reasons, "; ")
+}
+
+// Negotiate compares the local and remote manifests. The required components
+// must be in the common subset; otherwise an *IncompatibleError is returned,
+// which still carries the full agreement for reporting.
+func Negotiate(local, remote Manifest, required ...string) (*Agreement, error) {
+	if local.Version != remote.Version {
+		return nil, fmt.Errorf("%w: local %d, remote %d", ErrProtocolVersion, local.Version, remote.Version)
+	}
+
+	a := new(Agreement)
+	for name, hash := range local.Components {
+		switch remoteHash, ok := remote.Components[name]; {
+		case !ok:
+			a.LocalOnly = append(a.LocalOnly, name)
+		case remoteHash != hash:
+			a.Mismatched = append(a.Mismatched, name)
+		default:
+			a.Common = append(a.Common, name)
+		}
+	}
+	for name := range remote.Components {
+		if _, ok := local.Components[name]; !ok {
+			a.RemoteOnly = append(a.RemoteOnly, name)
+		}
+	}
+	sort.Strings(a.Common)
+	sort.Strings(a.Mismatched)
+	sort.Strings(a.LocalOnly)
+	sort.Strings(a.RemoteOnly)
+
+	var incompatible IncompatibleError
+	for _, name := range required {
+		if a.Allows(name) {
+			continue
+		}
+		if _, ok := remote.Components[name]; ok {
+			incompatible.Mismatched = append(incompatible.Mismatched, name)
+		} else {
+			incompatible.Missing = append(incompatible.Missing, name)
+		}
+	}
+	if len(incompatible.Missing) > 0 || len(incompatible.Mismatched) > 0 {
+		incompatible.Agreement = a
+		return a, &incompatible
+	}
+	return a, nil
+}
+
+// Handshake sends the local manifest over rw, reads the peer's, and
+// negotiates between them. Both sides of a connection should call it before
+// exchanging anything else.
+func Handshake(rw io.ReadWriter, required ...string) (*Agreement, error) {
+	agreement, err := handshake(rw, required)
+	if Metrics != nil {
+		var incompatible *IncompatibleError
+		result := "ok"
+		switch {
+		case errors.As(err, &incompatible):
+			result = "incompatible"
+		case err != nil:
+			result = "error"
+		}
+		Metrics.Counter("ecsnet_handshakes", 1, "result", result)
+	}
+	return agreement, err
+}
+
+func handshake(rw io.ReadWriter, required []string) (*Agreement, error) {
+	local := LocalManifest()
+
+	// Send concurrently, so that unbuffered connections don't deadlock with
+	// both sides writing at once.
+	sent := make(chan error, 1)
+	go func() {
+		sent <- writeManifest(rw, local)
+	}()
+
+	remote, err := readManifest(rw)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := <-sent; err != nil {
+		return nil, fmt.Errorf("sending manifest: %w", err)
+	}
+	return Negotiate(local, remote, required...)
+}
+
+// maxManifestSize bounds the size of a peer's manifest, so that a corrupt
+// length prefix can't trigger a huge allocation.
+const maxManifestSize = 1 << 20
+
+// writeManifest writes m with a length prefix, so that decoding it never
+// reads past its end and into whatever the peer sends next.
+func writeManifest(w io.Writer, m Manifest) error {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 4))
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+	_, err := w.Write(data)
+	return err
+}
+
+func readManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return m, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxManifestSize {
+		return m, fmt.Errorf("manifest too large: %d bytes", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return m, err
+	}
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&m)
+	return m, err
+}
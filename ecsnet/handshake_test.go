@@ -0,0 +1,92 @@
+package ecsnet_test
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsnet"
+)
+
+type Position struct{ X, Y float64 }
+
+func init() {
+	ecs.RegisterName("game.Position", Position{})
+}
+
+func TestHandshake(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ecsnet.Handshake(b, "game.Position")
+		done <- err
+	}()
+
+	agreement, err := ecsnet.Handshake(a, "game.Position")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !agreement.Allows("game.Position") {
+		t.Errorf("expected game.Position to be common, got %v", agreement.Common)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	local := ecsnet.Manifest{Version: 1, Components: map[string]uint64{
+		"Position": 1, "Velocity": 2, "Health": 3,
+	}}
+	remote := ecsnet.Manifest{Version: 1, Components: map[string]uint64{
+		"Position": 1, "Velocity": 20, "Score": 4,
+	}}
+
+	agreement, err := ecsnet.Negotiate(local, remote, "Position")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ecsnet.Agreement{
+		Common:     []string{"Position"},
+		Mismatched: []string{"Velocity"},
+		LocalOnly:  []string{"Health"},
+		RemoteOnly: []string{"Score"},
+	}
+	if !reflect.DeepEqual(agreement, want) {
+		t.Errorf("got %+v, want %+v", agreement, want)
+	}
+
+	_, err = ecsnet.Negotiate(local, remote, "Position", "Velocity", "Health")
+	var incompatible *ecsnet.IncompatibleError
+	if !errors.As(err, &incompatible) {
+		t.Fatalf("expected an IncompatibleError, got %v", err)
+	}
+	if !reflect.DeepEqual(incompatible.Mismatched, []string{"Velocity"}) || !reflect.DeepEqual(incompatible.Missing, []string{"Health"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	remote.Version = 2
+	if _, err := ecsnet.Negotiate(local, remote); !errors.Is(err, ecsnet.ErrProtocolVersion) {
+		t.Errorf("expected a protocol version error, got %v", err)
+	}
+}
+
+func TestRegisteredTypesLayout(t *testing.T) {
+	type Renamed struct{ X, Y float64 }
+	type Changed struct{ X, Y, Z float64 }
+	ecs.RegisterName("test.Renamed", Renamed{})
+	ecs.RegisterName("test.Changed", Changed{})
+
+	types := ecs.RegisteredTypes()
+	if types["test.Renamed"] != types["game.Position"] {
+		t.Error("types with the same layout should have the same hash")
+	}
+	if types["test.Changed"] == types["game.Position"] {
+		t.Error("types with different layouts should have different hashes")
+	}
+}
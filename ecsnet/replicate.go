@@ -0,0 +1,340 @@
+package ecsnet
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// A Role is the side of a connection that a Replicator runs on.
+type Role int
+
+const (
+	Server Role = iota
+	Client
+)
+
+func (r Role) String() string {
+	if r == Client {
+		return "client"
+	}
+	return "server"
+}
+
+// A Change is a single difference in a replicated component since the last
+// delta. Entities are always the server's.
+type Change struct {
+	Entity ecs.Entity
+
+	// Component is the registered name of the component that changed, and
+	// Data is its encoding, as returned by ecs.MarshalComponent.
+	Component string
+	Data      []byte
+
+	// Removed is set if the component was removed from the object, and
+	// Despawned if the object was removed from the world.
+	Removed, Despawned bool
+}
+
+// A Delta holds every change to one side's replicated components over a
+// single sync.
+type Delta struct {
+	Changes []Change
+}
+
+// A Transport carries deltas between a server and a client. Send and Receive
+// may be called concurrently with each other, but not with themselves.
+type Transport interface {
+	Send(Delta) error
+	Receive() (Delta, error)
+}
+
+// StreamTransport returns a transport that exchanges gob-encoded deltas over
+// a stream, such as the net.Conn that a handshake was performed on.
+func StreamTransport(rw io.ReadWriter) Transport {
+	return &streamTransport{enc: gob.NewEncoder(rw), dec: gob.NewDecoder(rw)}
+}
+
+type streamTransport struct {
+	enc *gob.Encoder
+	dec *gob.Decoder
+}
+
+func (t *streamTransport) Send(d Delta) error {
+	return t.enc.Encode(d)
+}
+
+func (t *streamTransport) Receive() (Delta, error) {
+	var d Delta
+	err := t.dec.Decode(&d)
+	return d, err
+}
+
+// A Replicator keeps the replicated components of a client's world in sync
+// with a server's. Each replicated component type is owned by either the
+// server or the client: only the owner's changes to it are sent, and changes
+// received from the other side are ignored, so the server stays authoritative
+// over everything but what it hands to clients, such as their inputs.
+//
+// The server creates and removes objects. A client mirrors each object that
+// has a server-owned replicated component with an object of its own, and
+// sends its own components only for those mirrors:
+//
+//     r := ecsnet.NewReplicator(world, ecsnet.Client, ecsnet.StreamTransport(conn), agreement)
+//     r.Replicate(Position{}, ecsnet.Server)
+//     r.Replicate(Input{}, ecsnet.Client)
+//     world.AddSystem(r.System(ticker))
+//     go r.Receive(ctx)
+//
+// A server uses one Replicator per connected client.
+type Replicator struct {
+	world     *ecs.World
+	role      Role
+	transport Transport
+	agreement *Agreement
+	bridge    *ecs.Bridge[received]
+
+	mu     sync.Mutex
+	owners map[string]Role
+
+	// sent holds the last value sent of each owned component, keyed by the
+	// server's entity.
+	sent map[ecs.Entity]map[string]interface{}
+
+	// On a client, mirrors maps the server's entities to their local
+	// objects, and remotes maps them back.
+	mirrors map[ecs.Entity]*ecs.Object
+	remotes map[*ecs.Object]ecs.Entity
+}
+
+// received is the event through which received deltas are applied at the
+// world's tick boundary.
+type received struct {
+	r     *Replicator
+	delta Delta
+}
+
+// NewReplicator creates a replicator for one end of a connection. If
+// agreement is set, only components it allows can be replicated.
+func NewReplicator(w *ecs.World, role Role, t Transport, agreement *Agreement) *Replicator {
+	r := &Replicator{
+		world:     w,
+		role:      role,
+		transport: t,
+		agreement: agreement,
+		bridge:    ecs.NewBridge[received](w, 64),
+		owners:    make(map[string]Role),
+		sent:      make(map[ecs.Entity]map[string]interface{}),
+		mirrors:   make(map[ecs.Entity]*ecs.Object),
+		remotes:   make(map[*ecs.Object]ecs.Entity),
+	}
+	w.OnEvent(received{}, func(ev interface{}) {
+		if ev := ev.(received); ev.r == r {
+			r.apply(ev.delta)
+		}
+	})
+	return r
+}
+
+// Replicate replicates components of the same type as component, which must
+// be registered, with the given owner. Both ends of a connection should
+// agree on each component's owner.
+func (r *Replicator) Replicate(component interface{}, owner Role) error {
+	name, err := ecs.ComponentName(component)
+	if err != nil {
+		return err
+	}
+	if r.agreement != nil && !r.agreement.Allows(name) {
+		return fmt.Errorf("cannot replicate %s: not agreed on with peer", name)
+	}
+	r.mu.Lock()
+	r.owners[name] = owner
+	r.mu.Unlock()
+	return nil
+}
+
+// System returns a global system that calls Sync on every tick.
+func (r *Replicator) System(ticker <-chan time.Time) ecs.System {
+	return ecs.System{
+		Func:   func(w *ecs.World) error { return r.Sync() },
+		Name:   "ecsnet.Sync",
+		Ticker: ticker,
+		Global: true,
+	}
+}
+
+// Sync sends a delta holding every change to the components this side owns
+// since the last sync. Nothing is sent if there are no changes.
+func (r *Replicator) Sync() error {
+	r.mu.Lock()
+	delta, err := r.diff()
+	r.mu.Unlock()
+	if err != nil || len(delta.Changes) == 0 {
+		return err
+	}
+	if Metrics != nil {
+		Metrics.Counter("ecsnet_changes", float64(len(delta.Changes)), "direction", "sent")
+	}
+	return r.transport.Send(delta)
+}
+
+// diff compares the world against the last values sent. The caller must hold
+// mu.
+func (r *Replicator) diff() (Delta, error) {
+	var delta Delta
+	seen := make(map[ecs.Entity]bool)
+	for _, ob := range r.world.Objects() {
+		entity := ob.Entity()
+		if r.role == Client {
+			remote, ok := r.remotes[ob]
+			if !ok {
+				continue
+			}
+			entity = remote
+		}
+		seen[entity] = true
+
+		last := r.sent[entity]
+		current := make(map[string]interface{})
+		for _, c := range ob.Components() {
+			name, err := ecs.ComponentName(c)
+			if owner, ok := r.owners[name]; err != nil || !ok || owner != r.role {
+				continue
+			}
+			current[name] = c
+			if prev, ok := last[name]; ok && reflect.DeepEqual(prev, c) {
+				continue
+			}
+			_, data, err := ecs.MarshalComponent(c)
+			if err != nil {
+				return Delta{}, err
+			}
+			delta.Changes = append(delta.Changes, Change{Entity: entity, Component: name, Data: data})
+		}
+		for name := range last {
+			if _, ok := current[name]; !ok {
+				delta.Changes = append(delta.Changes, Change{Entity: entity, Component: name, Removed: true})
+			}
+		}
+		// Objects stay tracked once anything has been sent for them, so
+		// that their removal is sent even if they have nothing left to
+		// replicate.
+		if len(current) > 0 || last != nil {
+			r.sent[entity] = current
+		}
+	}
+	for entity := range r.sent {
+		if !seen[entity] {
+			delete(r.sent, entity)
+			if r.role == Server {
+				delta.Changes = append(delta.Changes, Change{Entity: entity, Despawned: true})
+			}
+		}
+	}
+	return delta, nil
+}
+
+// Receive receives deltas from the transport until it fails or the context
+// is done, queueing them to be applied to the world at its next tick
+// boundary. Since the transport can't be interrupted, closing the underlying
+// connection is the only way to stop a Receive that is waiting for a delta.
+func (r *Replicator) Receive(ctx context.Context) error {
+	for {
+		delta, err := r.transport.Receive()
+		if err != nil {
+			return err
+		}
+		if Metrics != nil {
+			Metrics.Counter("ecsnet_changes", float64(len(delta.Changes)), "direction", "received")
+		}
+		if err := r.bridge.Send(ctx, received{r: r, delta: delta}); err != nil {
+			return err
+		}
+	}
+}
+
+// apply applies the changes in a received delta that the other side owns.
+func (r *Replicator) apply(delta Delta) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, change := range delta.Changes {
+		if change.Despawned {
+			if r.role == Client {
+				r.despawn(change.Entity)
+			}
+			continue
+		}
+		if owner, ok := r.owners[change.Component]; !ok || owner == r.role {
+			continue
+		}
+		ob := r.object(change.Entity, !change.Removed)
+		if ob == nil {
+			continue
+		}
+		if change.Removed {
+			if c := r.current(ob, change.Component); c != nil {
+				ob.RemoveComponent(c)
+			}
+			continue
+		}
+		c, err := ecs.UnmarshalComponent(change.Component, change.Data)
+		if err != nil {
+			continue
+		}
+		ob.SetComponent(c)
+	}
+}
+
+// object returns the local object for one of the server's entities, creating
+// a mirror for it on a client if create is set.
+func (r *Replicator) object(entity ecs.Entity, create bool) *ecs.Object {
+	if r.role == Server {
+		return r.world.GetObject(entity)
+	}
+	if ob, ok := r.mirrors[entity]; ok {
+		return ob
+	}
+	if !create {
+		return nil
+	}
+	ob := ecs.NewObject()
+	r.world.AddObject(ob)
+	r.mirrors[entity] = ob
+	r.remotes[ob] = entity
+	return ob
+}
+
+func (r *Replicator) despawn(entity ecs.Entity) {
+	ob, ok := r.mirrors[entity]
+	if !ok {
+		return
+	}
+	delete(r.mirrors, entity)
+	delete(r.remotes, ob)
+	delete(r.sent, entity)
+	r.world.RemoveObject(ob.Entity())
+}
+
+// current returns the object's component with the given registered name.
+func (r *Replicator) current(ob *ecs.Object, name string) interface{} {
+	for _, c := range ob.Components() {
+		if n, err := ecs.ComponentName(c); err == nil && n == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Mirror returns the client's object mirroring one of the server's entities,
+// or nil if there is none.
+func (r *Replicator) Mirror(entity ecs.Entity) *ecs.Object {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mirrors[entity]
+}
@@ -0,0 +1,111 @@
+package ecsnet_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsnet"
+)
+
+type Input struct{ Jump bool }
+
+func init() {
+	ecs.RegisterName("game.Input", Input{})
+}
+
+// eventually steps the world until cond holds, failing the test if it
+// doesn't within a second.
+func eventually(t *testing.T, w *ecs.World, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition never held")
+		}
+		time.Sleep(time.Millisecond)
+		w.Step()
+	}
+}
+
+func TestReplicator(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverWorld, clientWorld := ecs.NewWorld(), ecs.NewWorld()
+	server := ecsnet.NewReplicator(serverWorld, ecsnet.Server, ecsnet.StreamTransport(a), nil)
+	client := ecsnet.NewReplicator(clientWorld, ecsnet.Client, ecsnet.StreamTransport(b), nil)
+	for _, r := range []*ecsnet.Replicator{server, client} {
+		if err := r.Replicate(Position{}, ecsnet.Server); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Replicate(Input{}, ecsnet.Client); err != nil {
+			t.Fatal(err)
+		}
+		go r.Receive(ctx)
+	}
+
+	player := ecs.NewObject(Position{X: 1}, Input{})
+	entity := serverWorld.AddObject(player)
+	if err := server.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, clientWorld, func() bool { return client.Mirror(entity) != nil })
+	mirror := client.Mirror(entity)
+	if got := mirror.Component(Position{}); got != (Position{X: 1}) {
+		t.Errorf("mirrored position = %v, want {1 0}", got)
+	}
+	if mirror.Component(Input{}) != nil {
+		t.Error("client-owned component was replicated from the server")
+	}
+
+	// The client's changes to server-owned components aren't sent, but its
+	// own components are.
+	mirror.SetComponent(Position{X: 99})
+	mirror.SetComponent(Input{Jump: true})
+	if err := client.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, serverWorld, func() bool { return player.Component(Input{}) == Input{Jump: true} })
+	if got := player.Component(Position{}); got != (Position{X: 1}) {
+		t.Errorf("server position = %v, want {1 0}", got)
+	}
+
+	player.SetComponent(Position{X: 2})
+	if err := server.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, clientWorld, func() bool { return mirror.Component(Position{}) == Position{X: 2} })
+
+	player.RemoveComponent(Position{})
+	if err := server.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, clientWorld, func() bool { return mirror.Component(Position{}) == nil })
+
+	player.SetComponent(Position{X: 3})
+	serverWorld.RemoveObject(entity)
+	if err := server.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, clientWorld, func() bool { return clientWorld.GetObject(mirror.Entity()) == nil })
+	if client.Mirror(entity) != nil {
+		t.Error("mirror survived its object being removed from the server")
+	}
+}
+
+func TestReplicateUnagreed(t *testing.T) {
+	agreement := &ecsnet.Agreement{Common: []string{"game.Position"}}
+	r := ecsnet.NewReplicator(ecs.NewWorld(), ecsnet.Server, nil, agreement)
+	if err := r.Replicate(Position{}, ecsnet.Server); err != nil {
+		t.Error(err)
+	}
+	if err := r.Replicate(Input{}, ecsnet.Client); err == nil {
+		t.Error("expected an error replicating a component the peer didn't agree to")
+	}
+}
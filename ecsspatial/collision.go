@@ -0,0 +1,208 @@
+package ecsspatial
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+)
+
+// AABB is a collider component for an axis-aligned box centered on the
+// object's position.
+type AABB struct {
+	HalfWidth, HalfHeight float64
+}
+
+// Circle is a collider component for a circle centered on the object's
+// position.
+type Circle struct {
+	Radius float64
+}
+
+// CollisionEnter is emitted when two objects' colliders start overlapping. A
+// is always the lower of the two entities.
+type CollisionEnter struct {
+	A, B ecs.Entity
+}
+
+// CollisionExit is emitted when two objects' colliders stop overlapping, or
+// one of them is removed from the world while they overlap. A is always the
+// lower of the two entities.
+type CollisionExit struct {
+	A, B ecs.Entity
+}
+
+type pair struct {
+	a, b ecs.Entity
+}
+
+type collider struct {
+	entity ecs.Entity
+	pos    ecsmath.Vec2
+	box    *AABB
+	circle *Circle
+}
+
+// extent returns the half-width and half-height of the collider's bounds.
+func (c collider) extent() ecsmath.Vec2 {
+	if c.box != nil {
+		return ecsmath.Vec2{X: c.box.HalfWidth, Y: c.box.HalfHeight}
+	}
+	return ecsmath.Vec2{X: c.circle.Radius, Y: c.circle.Radius}
+}
+
+func (c collider) overlaps(o collider) bool {
+	switch {
+	case c.box != nil && o.box != nil:
+		d := c.pos.Sub(o.pos)
+		return math.Abs(d.X) < c.box.HalfWidth+o.box.HalfWidth && math.Abs(d.Y) < c.box.HalfHeight+o.box.HalfHeight
+	case c.circle != nil && o.circle != nil:
+		r := c.circle.Radius + o.circle.Radius
+		return c.pos.Sub(o.pos).LenSq() < r*r
+	case c.box != nil:
+		return boxOverlapsCircle(c.pos, *c.box, o.pos, *o.circle)
+	default:
+		return boxOverlapsCircle(o.pos, *o.box, c.pos, *c.circle)
+	}
+}
+
+func boxOverlapsCircle(bp ecsmath.Vec2, b AABB, cp ecsmath.Vec2, c Circle) bool {
+	nearest := ecsmath.Vec2{
+		X: math.Max(bp.X-b.HalfWidth, math.Min(cp.X, bp.X+b.HalfWidth)),
+		Y: math.Max(bp.Y-b.HalfHeight, math.Min(cp.Y, bp.Y+b.HalfHeight)),
+	}
+	return nearest.Sub(cp).LenSq() < c.Radius*c.Radius
+}
+
+// Collisions detects overlaps between objects with an AABB or Circle
+// collider. The index finds the candidates near each collider, and only those
+// are tested against its exact shape:
+//
+//     collisions := ecsspatial.NewCollisions(index)
+//     world.AddSystem(collisions.System(ticker))
+//     world.OnEvent(ecsspatial.CollisionEnter{}, func(ev interface{}) { ... })
+//
+// Colliders that only touch at their edges don't overlap.
+type Collisions struct {
+	index *Index
+
+	mu       sync.Mutex
+	contacts map[pair]bool
+}
+
+// NewCollisions creates a collision detector that finds objects with index.
+func NewCollisions(index *Index) *Collisions {
+	return &Collisions{index: index, contacts: make(map[pair]bool)}
+}
+
+// Colliding returns the entities whose colliders currently overlap the
+// entity's, in ascending order.
+func (c *Collisions) Colliding(e ecs.Entity) []ecs.Entity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var entities []ecs.Entity
+	for p := range c.contacts {
+		switch e {
+		case p.a:
+			entities = append(entities, p.b)
+		case p.b:
+			entities = append(entities, p.a)
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i] < entities[j] })
+	return entities
+}
+
+// System returns a global system that rebuilds the index, unless it is
+// tracking the world, and then emits CollisionExit and CollisionEnter events
+// for every pair of colliders that stopped or started overlapping. Exits are
+// emitted before entries, each in ascending order of their entities.
+func (c *Collisions) System(ticker <-chan time.Time) ecs.System {
+	return ecs.System{
+		Func:   c.update,
+		Name:   "ecsspatial.Collisions",
+		Ticker: ticker,
+		Global: true,
+		Reads:  []interface{}{c.index.positionComponent(), AABB{}, Circle{}},
+	}
+}
+
+func (c *Collisions) update(w *ecs.World) {
+	if !c.index.Tracking() {
+		c.index.Rebuild(w)
+	}
+
+	colliders := make(map[ecs.Entity]collider)
+	var reach ecsmath.Vec2
+	for _, ob := range w.Objects() {
+		pos, ok := c.index.Position(ob.Entity())
+		if !ok {
+			continue
+		}
+		cl := collider{entity: ob.Entity(), pos: pos}
+		if box, ok := ob.Component(AABB{}).(AABB); ok {
+			cl.box = &box
+		} else if circle, ok := ob.Component(Circle{}).(Circle); ok {
+			cl.circle = &circle
+		} else {
+			continue
+		}
+		colliders[cl.entity] = cl
+		ext := cl.extent()
+		reach = ecsmath.Vec2{X: math.Max(reach.X, ext.X), Y: math.Max(reach.Y, ext.Y)}
+	}
+
+	// Broadphase: objects are indexed by their centers, so a collider can
+	// only overlap those whose centers are within its own bounds grown by
+	// the largest collider's.
+	current := make(map[pair]bool)
+	for _, cl := range colliders {
+		grow := cl.extent().Add(reach)
+		bounds := ecsmath.Rect{Min: cl.pos.Sub(grow), Max: cl.pos.Add(grow)}
+		for _, e := range c.index.WithinRect(bounds) {
+			other, ok := colliders[e]
+			if !ok || e <= cl.entity {
+				continue
+			}
+			if cl.overlaps(other) {
+				current[pair{cl.entity, e}] = true
+			}
+		}
+	}
+
+	c.mu.Lock()
+	var exited, entered []pair
+	for p := range c.contacts {
+		if !current[p] {
+			exited = append(exited, p)
+		}
+	}
+	for p := range current {
+		if !c.contacts[p] {
+			entered = append(entered, p)
+		}
+	}
+	c.contacts = current
+	c.mu.Unlock()
+
+	sortPairs(exited)
+	sortPairs(entered)
+	for _, p := range exited {
+		w.Emit(CollisionExit{A: p.a, B: p.b})
+	}
+	for _, p := range entered {
+		w.Emit(CollisionEnter{A: p.a, B: p.b})
+	}
+}
+
+func sortPairs(pairs []pair) {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+}
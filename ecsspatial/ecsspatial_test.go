@@ -0,0 +1,203 @@
+package ecsspatial_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+	"github.com/dradtke/ecs-go/ecsspatial"
+)
+
+type Position struct{ ecsmath.Vec2 }
+
+type Enemy struct{}
+
+func at(x, y float64) Position {
+	return Position{ecsmath.Vec2{X: x, Y: y}}
+}
+
+func TestNearby(t *testing.T) {
+	world := ecs.NewWorld()
+	index := ecsspatial.New(10, Position{})
+
+	a := world.AddObject(ecs.NewObject(at(0, 0)))
+	b := world.AddObject(ecs.NewObject(at(-9, 0)))
+	world.AddObject(ecs.NewObject(at(30, 30)))
+	world.AddObject(ecs.NewObject(Enemy{}))
+	index.Rebuild(world)
+
+	if got, want := index.Nearby(ecsmath.Vec2{}, 10), []ecs.Entity{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := index.Nearby(ecsmath.Vec2{X: 100}, 5); len(got) != 0 {
+		t.Errorf("expected nothing nearby, got %v", got)
+	}
+}
+
+func TestProximity(t *testing.T) {
+	world := ecs.NewWorld()
+	prox := ecsspatial.NewProximity(ecsspatial.New(5, Position{}))
+	world.AddSystem(prox.System(nil))
+
+	var events []interface{}
+	record := func(ev interface{}) { events = append(events, ev) }
+	world.OnEvent(ecsspatial.EnterRange{}, record)
+	world.OnEvent(ecsspatial.ExitRange{}, record)
+
+	guard := world.AddObject(ecs.NewObject(at(0, 0), ecsspatial.Sensor{
+		Radius:     10,
+		Hysteresis: 2,
+		Filter:     func(ob *ecs.Object) bool { return ob.Component(Enemy{}) != nil },
+	}))
+	enemy := ecs.NewObject(at(20, 0), Enemy{})
+	world.AddObject(enemy)
+	world.AddObject(ecs.NewObject(at(1, 1)))
+
+	step := func(x float64, want ...interface{}) {
+		t.Helper()
+		enemy.RemoveComponent(Position{})
+		enemy.AddComponent(at(x, 0))
+		events = nil
+		world.Run()
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("at %v: got events %v, want %v", x, events, want)
+		}
+	}
+
+	step(20)
+	step(10, ecsspatial.EnterRange{Sensor: guard, Other: enemy.Entity()})
+	step(11)
+	step(12)
+	step(12.5, ecsspatial.ExitRange{Sensor: guard, Other: enemy.Entity()})
+	step(11)
+	step(5, ecsspatial.EnterRange{Sensor: guard, Other: enemy.Entity()})
+
+	if got, want := prox.InRange(guard), []ecs.Entity{enemy.Entity()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v in range, want %v", got, want)
+	}
+
+	world.RemoveObject(enemy.Entity())
+	events = nil
+	world.Run()
+	if want := []interface{}{ecsspatial.ExitRange{Sensor: guard, Other: enemy.Entity()}}; !reflect.DeepEqual(events, want) {
+		t.Errorf("removing an object in range: got %v, want %v", events, want)
+	}
+}
+
+func TestProximitySensorRemoved(t *testing.T) {
+	world := ecs.NewWorld()
+	prox := ecsspatial.NewProximity(ecsspatial.New(5, Position{}))
+	world.AddSystem(prox.System(nil))
+
+	var events []interface{}
+	world.OnEvent(ecsspatial.ExitRange{}, func(ev interface{}) { events = append(events, ev) })
+
+	guard := world.AddObject(ecs.NewObject(at(0, 0), ecsspatial.Sensor{Radius: 10}))
+	a := world.AddObject(ecs.NewObject(at(1, 0)))
+	b := world.AddObject(ecs.NewObject(at(2, 0)))
+	world.Run()
+
+	world.RemoveObject(guard)
+	world.Run()
+	want := []interface{}{
+		ecsspatial.ExitRange{Sensor: guard, Other: a},
+		ecsspatial.ExitRange{Sensor: guard, Other: b},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("removing a sensor: got %v, want %v", events, want)
+	}
+	if got := prox.InRange(guard); len(got) != 0 {
+		t.Errorf("removed sensor still has %v in range", got)
+	}
+
+	info, _ := world.LookupSystem("ecsspatial.Proximity")
+	if want := []reflect.Type{reflect.TypeOf(Position{}), reflect.TypeOf(ecsspatial.Sensor{})}; !reflect.DeepEqual(info.Reads, want) {
+		t.Errorf("bad reads: got %v, want %v", info.Reads, want)
+	}
+}
+
+func TestWithinRect(t *testing.T) {
+	world := ecs.NewWorld()
+	index := ecsspatial.New(10, Position{})
+
+	a := world.AddObject(ecs.NewObject(at(0, 0)))
+	b := world.AddObject(ecs.NewObject(at(25, 5)))
+	world.AddObject(ecs.NewObject(at(30, 0)))
+	world.AddObject(ecs.NewObject(at(5, -1)))
+	index.Rebuild(world)
+
+	r := ecsmath.Rect{Max: ecsmath.Vec2{X: 30, Y: 10}}
+	if got, want := index.WithinRect(r), []ecs.Entity{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	world := ecs.NewWorld()
+	index := ecsspatial.New(10, Position{})
+	a := world.AddObject(ecs.NewObject(at(0, 0)))
+	index.Track(world)
+
+	b := world.AddObject(ecs.NewObject(at(5, 5)))
+	if got, want := index.Nearby(ecsmath.Vec2{}, 10), []ecs.Entity{a, b}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after adding: got %v, want %v", got, want)
+	}
+
+	world.AddSystem(ecs.System{Func: func(p Position) Position {
+		p.X += 100
+		return p
+	}})
+	world.Step()
+	if got := index.Nearby(ecsmath.Vec2{}, 10); len(got) != 0 {
+		t.Errorf("after moving: expected nothing nearby, got %v", got)
+	}
+	if got, want := index.Nearby(ecsmath.Vec2{X: 100}, 10), []ecs.Entity{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after moving: got %v, want %v", got, want)
+	}
+
+	world.RemoveObject(a)
+	if _, ok := index.Position(a); ok {
+		t.Error("removed object is still indexed")
+	}
+	if got, want := index.Nearby(ecsmath.Vec2{X: 100}, 10), []ecs.Entity{b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after removing: got %v, want %v", got, want)
+	}
+}
+
+func TestCollisions(t *testing.T) {
+	world := ecs.NewWorld()
+	index := ecsspatial.New(10, Position{})
+	collisions := ecsspatial.NewCollisions(index)
+	world.AddSystem(collisions.System(nil))
+
+	var events []interface{}
+	record := func(ev interface{}) { events = append(events, ev) }
+	world.OnEvent(ecsspatial.CollisionEnter{}, record)
+	world.OnEvent(ecsspatial.CollisionExit{}, record)
+
+	wall := world.AddObject(ecs.NewObject(at(0, 0), ecsspatial.AABB{HalfWidth: 1, HalfHeight: 50}))
+	ball := ecs.NewObject(at(20, 0), ecsspatial.Circle{Radius: 2})
+	world.AddObject(ball)
+	other := world.AddObject(ecs.NewObject(at(22, 3), ecsspatial.Circle{Radius: 2}))
+	world.AddObject(ecs.NewObject(at(2, 0)))
+
+	step := func(x float64, want ...interface{}) {
+		t.Helper()
+		ball.SetComponent(at(x, 0))
+		events = nil
+		world.Step()
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("ball at %v: got %v, want %v", x, events, want)
+		}
+	}
+
+	step(20, ecsspatial.CollisionEnter{A: ball.Entity(), B: other})
+	step(10, ecsspatial.CollisionExit{A: ball.Entity(), B: other})
+	step(2.5, ecsspatial.CollisionEnter{A: wall, B: ball.Entity()})
+	step(2.5)
+	if got, want := collisions.Colliding(wall), []ecs.Entity{ball.Entity()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("colliding with wall: got %v, want %v", got, want)
+	}
+	step(3, ecsspatial.CollisionExit{A: wall, B: ball.Entity()})
+}
@@ -0,0 +1,223 @@
+// Package ecsspatial provides spatial queries over the objects in an ecs
+// world, and systems built on them.
+//
+// An Index buckets objects into a uniform grid by their position component,
+// so that finding the objects near a point only looks at nearby cells rather
+// than every object in the world:
+//
+//     index := ecsspatial.New(32, Position{})
+//     world.AddResource(index)
+//     world.AddSystem(index.System(ticker))
+//
+//     for _, e := range index.Nearby(origin, 100) { ... }
+//
+// Instead of rebuilding the index on every tick, it can track the world's
+// positions as they change, so that queries are always up to date and only
+// moved objects are re-indexed:
+//
+//     index := ecsspatial.New(32, Position{})
+//     index.Track(world)
+//     world.AddResource(index)
+package ecsspatial
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+)
+
+type cell struct {
+	X, Y int
+}
+
+// Index is a resource that answers proximity queries about objects with a
+// position component. It reflects the world as of its last Rebuild, or as of
+// now if it is tracking the world.
+type Index struct {
+	// CellSize is the width and height of each grid cell in world units.
+	// Queries are fastest when it is close to the typical query radius.
+	CellSize float64
+
+	position reflect.Type
+
+	mu        sync.RWMutex
+	cells     map[cell][]ecs.Entity
+	positions map[ecs.Entity]ecsmath.Vec2
+	tracking  bool
+}
+
+// New creates an empty index. position is the component type that holds each
+// object's location in world units.
+func New(cellSize float64, position ecsmath.Locator) *Index {
+	return &Index{
+		CellSize:  cellSize,
+		position:  reflect.TypeOf(position),
+		cells:     make(map[cell][]ecs.Entity),
+		positions: make(map[ecs.Entity]ecsmath.Vec2),
+	}
+}
+
+func (x *Index) cellAt(p ecsmath.Vec2) cell {
+	return cell{int(math.Floor(p.X / x.CellSize)), int(math.Floor(p.Y / x.CellSize))}
+}
+
+// Rebuild re-indexes every object in the world that has a position.
+func (x *Index) Rebuild(w *ecs.World) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for c, entities := range x.cells {
+		x.cells[c] = entities[:0]
+	}
+	for e := range x.positions {
+		delete(x.positions, e)
+	}
+	for _, ob := range w.Objects() {
+		if p, ok := x.locate(ob); ok {
+			x.insert(ob.Entity(), p)
+		}
+	}
+	for c, entities := range x.cells {
+		if len(entities) == 0 {
+			delete(x.cells, c)
+		}
+	}
+}
+
+// Track keeps the index in step with the world from now on, using hooks on
+// the position component to re-index objects as they are added, moved, or
+// removed, so that it never needs rebuilding. Positions changed through a
+// Column aren't seen, since they aren't reported to hooks.
+func (x *Index) Track(w *ecs.World) {
+	x.Rebuild(w)
+	x.mu.Lock()
+	x.tracking = true
+	x.mu.Unlock()
+
+	position := reflect.Zero(x.position).Interface()
+	move := func(e ecs.Entity, c interface{}) {
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		x.remove(e)
+		x.insert(e, c.(ecsmath.Locator).XY())
+	}
+	w.OnAdd(position, move)
+	w.OnChange(position, move)
+	w.OnRemove(position, func(e ecs.Entity, c interface{}) {
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		x.remove(e)
+	})
+}
+
+// Tracking reports whether the index is tracking a world.
+func (x *Index) Tracking() bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.tracking
+}
+
+// insert indexes an entity at a position. The caller must hold mu.
+func (x *Index) insert(e ecs.Entity, p ecsmath.Vec2) {
+	x.positions[e] = p
+	cc := x.cellAt(p)
+	x.cells[cc] = append(x.cells[cc], e)
+}
+
+// remove removes an entity from the index, if it is there. The caller must
+// hold mu.
+func (x *Index) remove(e ecs.Entity) {
+	p, ok := x.positions[e]
+	if !ok {
+		return
+	}
+	delete(x.positions, e)
+	cc := x.cellAt(p)
+	entities := x.cells[cc]
+	for i, other := range entities {
+		if other == e {
+			entities[i] = entities[len(entities)-1]
+			entities = entities[:len(entities)-1]
+			break
+		}
+	}
+	if len(entities) == 0 {
+		delete(x.cells, cc)
+	} else {
+		x.cells[cc] = entities
+	}
+}
+
+func (x *Index) locate(ob *ecs.Object) (ecsmath.Vec2, bool) {
+	for _, c := range ob.Components() {
+		if reflect.TypeOf(c) == x.position {
+			return c.(ecsmath.Locator).XY(), true
+		}
+	}
+	return ecsmath.Vec2{}, false
+}
+
+// Position returns the indexed position of an entity.
+func (x *Index) Position(e ecs.Entity) (ecsmath.Vec2, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	p, ok := x.positions[e]
+	return p, ok
+}
+
+// Nearby returns the entities within radius of p, in ascending order.
+func (x *Index) Nearby(p ecsmath.Vec2, radius float64) []ecs.Entity {
+	return x.search(ecsmath.RectAround(p, radius), func(q ecsmath.Vec2) bool {
+		return q.Sub(p).LenSq() <= radius*radius
+	})
+}
+
+// WithinRect returns the entities inside r, in ascending order.
+func (x *Index) WithinRect(r ecsmath.Rect) []ecs.Entity {
+	return x.search(r, r.Contains)
+}
+
+// search returns the entities in the cells overlapping bounds whose positions
+// satisfy keep, in ascending order.
+func (x *Index) search(bounds ecsmath.Rect, keep func(ecsmath.Vec2) bool) []ecs.Entity {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	min, max := x.cellAt(bounds.Min), x.cellAt(bounds.Max)
+	var entities []ecs.Entity
+	for cx := min.X; cx <= max.X; cx++ {
+		for cy := min.Y; cy <= max.Y; cy++ {
+			for _, e := range x.cells[cell{cx, cy}] {
+				if keep(x.positions[e]) {
+					entities = append(entities, e)
+				}
+			}
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i] < entities[j] })
+	return entities
+}
+
+// positionComponent returns a zero position component, for declaring that
+// systems read positions, so that they won't run alongside systems that
+// write them.
+func (x *Index) positionComponent() interface{} {
+	return reflect.Zero(x.position).Interface()
+}
+
+// System returns a global system that rebuilds the index on every tick. An
+// index that is tracking the world doesn't need it.
+func (x *Index) System(ticker <-chan time.Time) ecs.System {
+	return ecs.System{
+		Func:   x.Rebuild,
+		Name:   "ecsspatial.Rebuild",
+		Ticker: ticker,
+		Global: true,
+		Reads:  []interface{}{x.positionComponent()},
+	}
+}
@@ -0,0 +1,164 @@
+package ecsspatial
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// Sensor is a component that makes an object aware of other objects nearby.
+// Objects come into range when they are within Radius, but only go out of
+// range once they are further than Radius+Hysteresis, so that objects
+// hovering at the edge don't flicker in and out.
+type Sensor struct {
+	Radius     float64
+	Hysteresis float64
+
+	// Filter, if set, reports whether another object should be sensed.
+	// Otherwise every object with a position is sensed. Since it is a
+	// function, it isn't saved in snapshots.
+	Filter func(*ecs.Object) bool `json:"-"`
+}
+
+// EnterRange is emitted when an object comes into range of a sensor.
+type EnterRange struct {
+	Sensor, Other ecs.Entity
+}
+
+// ExitRange is emitted when an object goes out of range of a sensor, or is
+// removed from the world while in range. It is also emitted for everything in
+// range of a sensor that is removed or loses its Sensor component.
+type ExitRange struct {
+	Sensor, Other ecs.Entity
+}
+
+// Proximity tracks which objects are in range of each sensor.
+type Proximity struct {
+	index *Index
+
+	mu       sync.Mutex
+	contacts map[ecs.Entity]map[ecs.Entity]bool
+}
+
+// NewProximity creates a proximity tracker that finds objects with index.
+func NewProximity(index *Index) *Proximity {
+	return &Proximity{index: index, contacts: make(map[ecs.Entity]map[ecs.Entity]bool)}
+}
+
+// InRange returns the entities currently in range of a sensor, in ascending
+// order.
+func (p *Proximity) InRange(sensor ecs.Entity) []ecs.Entity {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var entities []ecs.Entity
+	for e := range p.contacts[sensor] {
+		entities = append(entities, e)
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i] < entities[j] })
+	return entities
+}
+
+// System returns a global system that rebuilds the index, unless it is
+// tracking the world, and then emits EnterRange and ExitRange events for
+// every sensor whose surroundings changed. Each sensor's events are emitted
+// together, exits before entries, in ascending order of the other entity.
+func (p *Proximity) System(ticker <-chan time.Time) ecs.System {
+	return ecs.System{
+		Func:   p.update,
+		Name:   "ecsspatial.Proximity",
+		Ticker: ticker,
+		Global: true,
+		Reads:  []interface{}{p.index.positionComponent(), Sensor{}},
+	}
+}
+
+func (p *Proximity) update(w *ecs.World) {
+	if !p.index.Tracking() {
+		p.index.Rebuild(w)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	objects := w.Objects()
+	var byEntity map[ecs.Entity]*ecs.Object
+	seen := make(map[ecs.Entity]bool)
+	for _, ob := range objects {
+		s, ok := ob.Component(Sensor{}).(Sensor)
+		if !ok {
+			continue
+		}
+		pos, ok := p.index.Position(ob.Entity())
+		if !ok {
+			continue
+		}
+		seen[ob.Entity()] = true
+
+		prev := p.contacts[ob.Entity()]
+		next := make(map[ecs.Entity]bool)
+		var entered []ecs.Entity
+		for _, e := range p.index.Nearby(pos, s.Radius+s.Hysteresis) {
+			if e == ob.Entity() {
+				continue
+			}
+			if s.Filter != nil {
+				if byEntity == nil {
+					byEntity = make(map[ecs.Entity]*ecs.Object, len(objects))
+					for _, ob := range objects {
+						byEntity[ob.Entity()] = ob
+					}
+				}
+				other := byEntity[e]
+				if other == nil || !s.Filter(other) {
+					continue
+				}
+			}
+			if prev[e] {
+				// already in range, and still within the hysteresis band
+				next[e] = true
+				continue
+			}
+			if other, _ := p.index.Position(e); other.Sub(pos).LenSq() <= s.Radius*s.Radius {
+				next[e] = true
+				entered = append(entered, e)
+			}
+		}
+
+		p.emitExits(w, ob.Entity(), prev, next)
+		for _, e := range entered {
+			w.Emit(EnterRange{Sensor: ob.Entity(), Other: e})
+		}
+		p.contacts[ob.Entity()] = next
+	}
+
+	// sensors that were removed or lost their Sensor component are out of
+	// range of everything
+	var gone []ecs.Entity
+	for e := range p.contacts {
+		if !seen[e] {
+			gone = append(gone, e)
+		}
+	}
+	sort.Slice(gone, func(i, j int) bool { return gone[i] < gone[j] })
+	for _, e := range gone {
+		p.emitExits(w, e, p.contacts[e], nil)
+		delete(p.contacts, e)
+	}
+}
+
+// emitExits emits ExitRange for each entity that was in range of the sensor
+// and no longer is, in ascending order.
+func (p *Proximity) emitExits(w *ecs.World, sensor ecs.Entity, prev, next map[ecs.Entity]bool) {
+	var exited []ecs.Entity
+	for e := range prev {
+		if !next[e] {
+			exited = append(exited, e)
+		}
+	}
+	sort.Slice(exited, func(i, j int) bool { return exited[i] < exited[j] })
+	for _, e := range exited {
+		w.Emit(ExitRange{Sensor: sensor, Other: e})
+	}
+}
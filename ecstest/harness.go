@@ -0,0 +1,113 @@
+// Package ecstest provides a harness for testing simulations built with the
+// ecs package. When a test using the harness fails, it reports the random
+// seed and tick at which the failure was detected, and saves a snapshot of
+// the world, so that flaky failures can be reproduced:
+//
+//     func TestCombat(t *testing.T) {
+//         h := ecstest.New(t)
+//         h.World.AddSystem(ecs.System{Func: Combat})
+//         h.World.AddObject(ecs.NewObject(Health(10)))
+//         h.Step(100)
+//         ...
+//     }
+//
+// A failing run can be repeated by setting the SeedEnv environment variable
+// to the reported seed.
+package ecstest
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// SeedEnv is the environment variable used to override the harness's seed.
+const SeedEnv = "ECS_SEED"
+
+// Harness wraps a world under test. The world is put in deterministic mode
+// with the harness's seed, so that a run can be repeated exactly; see
+// ecs.World.Deterministic.
+type Harness struct {
+	World *ecs.World
+
+	// Rand is the world's random number generator, seeded with Seed. It is
+	// a resource of the world, so systems that need randomness should accept
+	// a *rand.Rand parameter instead of using the math/rand functions.
+	Rand *rand.Rand
+	Seed int64
+
+	// Tick is the number of steps the world has been run.
+	Tick int
+
+	t testing.TB
+}
+
+// New creates a harness around a new world, seeded from SeedEnv if it is set
+// and the current time otherwise.
+func New(t testing.TB, opts ...ecs.Option) *Harness {
+	t.Helper()
+
+	seed := time.Now().UnixNano()
+	if s := os.Getenv(SeedEnv); s != "" {
+		var err error
+		if seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			t.Fatalf("ecstest: invalid %s: %s", SeedEnv, err)
+		}
+	}
+
+	world := ecs.NewWorld(opts...)
+	world.Deterministic(seed)
+	h := &Harness{
+		World: world,
+		Rand:  world.Resource(&rand.Rand{}).(*rand.Rand),
+		Seed:  seed,
+		t:     t,
+	}
+	t.Cleanup(h.report)
+	return h
+}
+
+// Step ticks the world n times with ecs.World.Step, so that each system
+// ticks once per step, one at a time, in the order it was added.
+func (h *Harness) Step(n int) {
+	for i := 0; i < n; i++ {
+		h.World.Step()
+		h.Tick++
+	}
+}
+
+// report logs how to reproduce a failed test, along with the path of a
+// snapshot of the world as it was when the test finished.
+func (h *Harness) report() {
+	if !h.t.Failed() {
+		return
+	}
+
+	snapshot, err := h.saveSnapshot()
+	if err != nil {
+		snapshot = "unavailable: " + err.Error()
+	}
+	h.t.Logf("ecstest: failed at tick %d; reproduce with %s=%d; snapshot %s", h.Tick, SeedEnv, h.Seed, snapshot)
+}
+
+func (h *Harness) saveSnapshot() (string, error) {
+	dir, err := os.MkdirTemp("", "ecstest-")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "world.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := h.World.Save(f); err != nil {
+		return "", err
+	}
+	return path, f.Close()
+}
@@ -0,0 +1,117 @@
+package ecstest_test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecstest"
+)
+
+type Roll int
+
+func init() {
+	ecs.Register(Roll(0))
+}
+
+// fakeT records failures and logs instead of reporting them.
+type fakeT struct {
+	testing.TB
+	failed   bool
+	logs     []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper()           {}
+func (t *fakeT) Failed() bool      { return t.failed }
+func (t *fakeT) Cleanup(fn func()) { t.cleanups = append(t.cleanups, fn) }
+func (t *fakeT) Logf(f string, args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprintf(f, args...))
+}
+
+func (t *fakeT) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestHarnessReportsFailure(t *testing.T) {
+	t.Setenv(ecstest.SeedEnv, "42")
+
+	ft := &fakeT{TB: t}
+	h := ecstest.New(ft)
+	h.World.AddObject(ecs.NewObject(Roll(0)))
+	h.World.AddSystem(ecs.System{Func: func(r *rand.Rand, _ Roll) Roll {
+		return Roll(r.Intn(6) + 1)
+	}})
+	h.Step(3)
+
+	ft.failed = true
+	ft.finish()
+
+	if len(ft.logs) != 1 {
+		t.Fatalf("expected one log line, got %v", ft.logs)
+	}
+	if !strings.Contains(ft.logs[0], "at tick 3") || !strings.Contains(ft.logs[0], "ECS_SEED=42") {
+		t.Errorf("report is missing the tick or seed: %s", ft.logs[0])
+	}
+
+	path := ft.logs[0][strings.LastIndex(ft.logs[0], " ")+1:]
+	defer os.RemoveAll(filepath.Dir(path))
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open snapshot: %s", err)
+	}
+	defer f.Close()
+	if err := ecs.NewWorld().Load(f); err != nil {
+		t.Errorf("failed to load snapshot: %s", err)
+	}
+}
+
+func TestHarnessSeed(t *testing.T) {
+	t.Setenv(ecstest.SeedEnv, "7")
+
+	a, b := ecstest.New(t), ecstest.New(t)
+	if a.Rand.Int63() != b.Rand.Int63() {
+		t.Error("harnesses with the same seed should generate the same numbers")
+	}
+}
+
+func TestHarnessStepRepeatable(t *testing.T) {
+	t.Setenv(ecstest.SeedEnv, "9")
+
+	run := func() ([]interface{}, int) {
+		h := ecstest.New(t)
+		for i := 0; i < 10; i++ {
+			h.World.AddObject(ecs.NewObject(Roll(0)))
+		}
+		ticks := 0
+		h.World.AddSystem(ecs.System{
+			Func: func(r *rand.Rand, roll Roll) Roll {
+				return roll + Roll(r.Intn(6)+1)
+			},
+			Parallel: true,
+		})
+		h.World.AddSystem(ecs.System{Global: true, Func: func() { ticks++ }})
+		h.Step(5)
+
+		var state []interface{}
+		for _, ob := range h.World.Objects() {
+			state = append(state, ob.Components()...)
+		}
+		return state, ticks
+	}
+
+	a, ticks := run()
+	if ticks != 5 {
+		t.Errorf("system ticked %d times in 5 steps, want 5", ticks)
+	}
+	if b, _ := run(); !reflect.DeepEqual(a, b) {
+		t.Errorf("harnesses with the same seed diverged: %v and %v", a, b)
+	}
+}
@@ -0,0 +1,143 @@
+package ecstile
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+)
+
+// Track keeps a record of which tile each object with a position is on, using
+// hooks on the position component to update it as objects are added, moved,
+// or removed. Positions changed through a Column aren't seen, since they
+// aren't reported to hooks.
+func (m *Tilemap) Track(w *ecs.World) {
+	m.mu.Lock()
+	m.tracking = true
+	m.occupants = make(map[Coord][]ecs.Entity)
+	m.cells = make(map[ecs.Entity]Coord)
+	for _, ob := range w.Objects() {
+		if p, ok := m.locate(ob); ok {
+			m.occupy(ob.Entity(), m.CoordAt(p))
+		}
+	}
+	m.mu.Unlock()
+
+	position := reflect.Zero(m.position).Interface()
+	move := func(e ecs.Entity, c interface{}) {
+		to := m.CoordAt(c.(ecsmath.Locator).XY())
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if from, ok := m.cells[e]; ok && from == to {
+			return
+		}
+		m.vacate(e)
+		m.occupy(e, to)
+	}
+	w.OnAdd(position, move)
+	w.OnChange(position, move)
+	w.OnRemove(position, func(e ecs.Entity, c interface{}) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.vacate(e)
+	})
+}
+
+// occupy records an entity as being on a tile. The caller must hold mu.
+func (m *Tilemap) occupy(e ecs.Entity, c Coord) {
+	m.cells[e] = c
+	m.occupants[c] = append(m.occupants[c], e)
+}
+
+// vacate removes an entity from the tile it is on, if any. The caller must
+// hold mu.
+func (m *Tilemap) vacate(e ecs.Entity) {
+	c, ok := m.cells[e]
+	if !ok {
+		return
+	}
+	delete(m.cells, e)
+	entities := m.occupants[c]
+	for i, other := range entities {
+		if other == e {
+			entities = append(entities[:i:i], entities[i+1:]...)
+			break
+		}
+	}
+	if len(entities) == 0 {
+		delete(m.occupants, c)
+	} else {
+		m.occupants[c] = entities
+	}
+}
+
+// Occupants returns the entities on a tile, in ascending order. It is only
+// meaningful while the tilemap is tracking a world.
+func (m *Tilemap) Occupants(c Coord) []ecs.Entity {
+	m.mu.RLock()
+	entities := append([]ecs.Entity(nil), m.occupants[c]...)
+	m.mu.RUnlock()
+	sort.Slice(entities, func(i, j int) bool { return entities[i] < entities[j] })
+	return entities
+}
+
+// Occupied reports whether any entity is on a tile. It is only meaningful
+// while the tilemap is tracking a world.
+func (m *Tilemap) Occupied(c Coord) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.occupants[c]) > 0
+}
+
+// CoordOf returns the tile an entity is on, if the tilemap is tracking it.
+func (m *Tilemap) CoordOf(e ecs.Entity) (Coord, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	c, ok := m.cells[e]
+	return c, ok
+}
+
+// Walkable reports whether a tile doesn't block movement, according to Solid.
+func (m *Tilemap) Walkable(c Coord) bool {
+	return m.Solid == nil || !m.Solid(m.Get(c))
+}
+
+// Free reports whether a tile is walkable and unoccupied.
+func (m *Tilemap) Free(c Coord) bool {
+	return m.Walkable(c) && !m.Occupied(c)
+}
+
+var (
+	orthogonal = []Coord{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	diagonal   = []Coord{{1, -1}, {1, 1}, {-1, 1}, {-1, -1}}
+)
+
+// Neighbors returns the walkable tiles next to c, including diagonal ones if
+// diagonals is set. A diagonal neighbor is only included if both of the
+// tiles it cuts between are walkable, so that paths don't squeeze between
+// two walls that touch at a corner.
+func (m *Tilemap) Neighbors(c Coord, diagonals bool) []Coord {
+	var neighbors []Coord
+	for _, d := range orthogonal {
+		if n := (Coord{c.X + d.X, c.Y + d.Y}); m.Walkable(n) {
+			neighbors = append(neighbors, n)
+		}
+	}
+	if !diagonals {
+		return neighbors
+	}
+	for _, d := range diagonal {
+		n := Coord{c.X + d.X, c.Y + d.Y}
+		if m.Walkable(n) && m.Walkable(Coord{c.X + d.X, c.Y}) && m.Walkable(Coord{c.X, c.Y + d.Y}) {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors
+}
+
+// Center returns the center of a tile in world units, such as to place an
+// object on it.
+func (m *Tilemap) Center(c Coord) ecsmath.Vec2 {
+	return m.Bounds(c).Center()
+}
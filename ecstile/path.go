@@ -0,0 +1,247 @@
+package ecstile
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// PathRequest is a component that asks the Pathfinder for a path between two
+// tiles. Once the path has been found, or found not to exist, the request is
+// replaced by a Path component.
+type PathRequest struct {
+	From, To Coord
+
+	// Diagonal allows the path to move diagonally between tiles.
+	Diagonal bool
+}
+
+// Path is a component holding the result of a PathRequest.
+type Path struct {
+	// Steps lists the tiles along the path, starting with the request's From
+	// and ending with its To. It is empty if no path was found.
+	Steps []Coord
+
+	// Cost is the total cost of the path's steps.
+	Cost float64
+}
+
+// Found reports whether a path was found.
+func (p Path) Found() bool {
+	return len(p.Steps) > 0
+}
+
+// DefaultMaxNodes is the number of tiles a Pathfinder searches before giving
+// up, unless its MaxNodes is set.
+const DefaultMaxNodes = 10000
+
+// Pathfinder is a resource that finds paths across a tilemap with A*. Its
+// system hands the world's path requests to a pool of background workers, so
+// that long searches don't hold up a tick:
+//
+//     paths := ecstile.NewPathfinder(tiles, 4)
+//     world.AddResource(paths)
+//     world.AddSystem(paths.System(ticker))
+//
+//     world.AddObject(ecs.NewObject(Position{}, ecstile.PathRequest{From: here, To: there}))
+type Pathfinder struct {
+	tiles   *Tilemap
+	workers chan struct{}
+
+	// Cost, if set, returns the cost of moving between two neighboring
+	// tiles, such as to make units avoid tiles with other objects on them.
+	// A cost that is negative or infinite makes the move impossible. For
+	// the paths found to be the cheapest, costs must be at least the
+	// distance between the tiles, which is 1, or √2 for diagonal moves;
+	// that is also the cost used if Cost isn't set.
+	//
+	// Cost is called from the workers, so it may run concurrently with the
+	// world's systems, and must only read state that is safe to read
+	// concurrently, such as the tilemap or Object.Component.
+	Cost func(from, to Coord) float64
+
+	// MaxNodes, if positive, is the number of tiles searched before giving
+	// up on finding a path, rather than DefaultMaxNodes. Since a tilemap is
+	// unbounded, a search for an unreachable tile would otherwise never
+	// end.
+	MaxNodes int
+
+	mu      sync.Mutex
+	pending map[ecs.Entity]PathRequest
+}
+
+// NewPathfinder creates a pathfinder for a tilemap, which searches for at
+// most workers paths at once.
+func NewPathfinder(tiles *Tilemap, workers int) *Pathfinder {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Pathfinder{
+		tiles:   tiles,
+		workers: make(chan struct{}, workers),
+		pending: make(map[ecs.Entity]PathRequest),
+	}
+}
+
+// System returns a global system that starts searching for a path for every
+// PathRequest in the world that isn't already being searched for. The Path
+// is added, and the request removed, at the tick boundary after the search
+// finishes. If the request changes in the meantime, the result is discarded
+// and the new request searched for instead.
+func (pf *Pathfinder) System(ticker <-chan time.Time) ecs.System {
+	return ecs.System{
+		Func:   pf.request,
+		Name:   "ecstile.Pathfinder",
+		Ticker: ticker,
+		Global: true,
+	}
+}
+
+// Pending returns the number of paths being searched for.
+func (pf *Pathfinder) Pending() int {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	return len(pf.pending)
+}
+
+func (pf *Pathfinder) request(w *ecs.World) {
+	for _, ob := range w.Objects() {
+		req, ok := ob.Component(PathRequest{}).(PathRequest)
+		if !ok {
+			continue
+		}
+		e := ob.Entity()
+		pf.mu.Lock()
+		if prev, ok := pf.pending[e]; ok && prev == req {
+			pf.mu.Unlock()
+			continue
+		}
+		pf.pending[e] = req
+		pf.mu.Unlock()
+
+		go func() {
+			pf.workers <- struct{}{}
+			path := pf.FindPath(req)
+			<-pf.workers
+
+			err := w.Send(func(w *ecs.World) {
+				pf.mu.Lock()
+				if pf.pending[e] == req {
+					delete(pf.pending, e)
+				}
+				pf.mu.Unlock()
+
+				ob := w.GetObject(e)
+				if ob == nil || ob.Component(PathRequest{}) != req {
+					return
+				}
+				ob.RemoveComponent(PathRequest{})
+				ob.SetComponent(path)
+			})
+			if err != nil {
+				// the world is being recorded or replayed, so the path
+				// can't be delivered; forget the request so that it is
+				// tried again on a later tick
+				pf.mu.Lock()
+				if pf.pending[e] == req {
+					delete(pf.pending, e)
+				}
+				pf.mu.Unlock()
+			}
+		}()
+	}
+}
+
+// FindPath searches for the cheapest path for a request immediately, on the
+// calling goroutine.
+func (pf *Pathfinder) FindPath(req PathRequest) Path {
+	maxNodes := pf.MaxNodes
+	if maxNodes <= 0 {
+		maxNodes = DefaultMaxNodes
+	}
+
+	type node struct {
+		cost float64
+		prev Coord
+	}
+	visited := map[Coord]node{req.From: {}}
+	open := &pathQueue{{coord: req.From, priority: pf.estimate(req.From, req.To)}}
+	closed := make(map[Coord]bool)
+
+	for open.Len() > 0 && len(closed) < maxNodes {
+		current := heap.Pop(open).(pathItem).coord
+		if closed[current] {
+			continue
+		}
+		if current == req.To {
+			var steps []Coord
+			for c := current; c != req.From; c = visited[c].prev {
+				steps = append(steps, c)
+			}
+			steps = append(steps, req.From)
+			for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+				steps[i], steps[j] = steps[j], steps[i]
+			}
+			return Path{Steps: steps, Cost: visited[current].cost}
+		}
+		closed[current] = true
+
+		for _, next := range pf.tiles.Neighbors(current, req.Diagonal) {
+			if closed[next] {
+				continue
+			}
+			step := pf.cost(current, next)
+			if step < 0 || math.IsInf(step, 1) {
+				continue
+			}
+			cost := visited[current].cost + step
+			if n, ok := visited[next]; ok && n.cost <= cost {
+				continue
+			}
+			visited[next] = node{cost: cost, prev: current}
+			heap.Push(open, pathItem{coord: next, priority: cost + pf.estimate(next, req.To)})
+		}
+	}
+	return Path{}
+}
+
+func (pf *Pathfinder) cost(from, to Coord) float64 {
+	if pf.Cost != nil {
+		return pf.Cost(from, to)
+	}
+	if from.X != to.X && from.Y != to.Y {
+		return math.Sqrt2
+	}
+	return 1
+}
+
+// estimate returns the octile distance between two tiles, which is the
+// cheapest a path between them can be when moves cost their distance.
+func (pf *Pathfinder) estimate(from, to Coord) float64 {
+	dx := math.Abs(float64(to.X - from.X))
+	dy := math.Abs(float64(to.Y - from.Y))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+type pathItem struct {
+	coord    Coord
+	priority float64
+}
+
+// pathQueue is a min-heap of tiles to search, ordered by priority.
+type pathQueue []pathItem
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathItem)) }
+
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,212 @@
+// Package ecstile provides a tilemap resource for tile-based games built with
+// the ecs package.
+//
+// Tiles are stored in fixed-size chunks that are allocated on demand, so maps
+// can be large and sparse. Changes made with Set are announced as TileChanged
+// events by the tilemap's system:
+//
+//     tiles := ecstile.New(32, Position{})
+//     world.AddResource(tiles)
+//     world.AddSystem(tiles.System(ticker))
+//
+// A tilemap can also track which objects stand on each tile, for games where
+// objects occupy cells, such as roguelikes and strategy games:
+//
+//     tiles.Solid = func(t ecstile.Tile) bool { return t == Wall }
+//     tiles.Track(world)
+//
+//     if tiles.Free(tiles.CoordAt(target)) { ... }
+package ecstile
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+	"github.com/dradtke/ecs-go/ecsspatial"
+)
+
+// ChunkSize is the width and height, in tiles, of each chunk.
+const ChunkSize = 16
+
+// Tile identifies the kind of a tile. Its meaning is up to the game, except
+// that zero is the value of every tile that hasn't been set.
+type Tile uint16
+
+// Coord is the location of a tile.
+type Coord struct {
+	X, Y int
+}
+
+// TileChanged is emitted when a tile is changed with Set.
+type TileChanged struct {
+	Coord    Coord
+	Old, New Tile
+}
+
+type chunk [ChunkSize * ChunkSize]Tile
+
+// Tilemap is a resource holding an unbounded grid of tiles.
+type Tilemap struct {
+	// TileSize is the width and height of each tile in world units.
+	TileSize float64
+
+	// Solid, if set, reports whether tiles of a kind block movement.
+	Solid func(Tile) bool
+
+	// Index, if set, is a spatial index over the same position component,
+	// which EntitiesOn queries rather than checking every object. If nil,
+	// the world's *ecsspatial.Index resource is used, if it has one.
+	Index *ecsspatial.Index
+
+	position reflect.Type
+
+	mu      sync.RWMutex
+	chunks  map[Coord]*chunk
+	changes []TileChanged
+
+	// When tracking a world, occupants maps each tile to the entities on it,
+	// and cells maps them back.
+	tracking  bool
+	occupants map[Coord][]ecs.Entity
+	cells     map[ecs.Entity]Coord
+}
+
+// New creates an empty tilemap. position is the component type that holds
+// each object's location in world units, and is used to find the objects on
+// a given tile.
+func New(tileSize float64, position ecsmath.Locator) *Tilemap {
+	return &Tilemap{
+		TileSize: tileSize,
+		position: reflect.TypeOf(position),
+		chunks:   make(map[Coord]*chunk),
+	}
+}
+
+// chunkOf returns the coordinates of the chunk containing a tile, and the
+// tile's index within it.
+func chunkOf(c Coord) (Coord, int) {
+	cx, cy := floorDiv(c.X, ChunkSize), floorDiv(c.Y, ChunkSize)
+	x, y := c.X-cx*ChunkSize, c.Y-cy*ChunkSize
+	return Coord{cx, cy}, y*ChunkSize + x
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// Get returns the tile at the given coordinates.
+func (m *Tilemap) Get(c Coord) Tile {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cc, i := chunkOf(c)
+	if ch, ok := m.chunks[cc]; ok {
+		return ch[i]
+	}
+	return 0
+}
+
+// Set changes the tile at the given coordinates. A TileChanged event is
+// emitted the next time the tilemap's system ticks.
+func (m *Tilemap) Set(c Coord, t Tile) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cc, i := chunkOf(c)
+	ch, ok := m.chunks[cc]
+	if !ok {
+		if t == 0 {
+			return
+		}
+		ch = new(chunk)
+		m.chunks[cc] = ch
+	}
+	if old := ch[i]; old != t {
+		ch[i] = t
+		m.changes = append(m.changes, TileChanged{Coord: c, Old: old, New: t})
+	}
+}
+
+// Chunks returns the number of chunks that have been allocated.
+func (m *Tilemap) Chunks() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.chunks)
+}
+
+// CoordAt returns the coordinates of the tile containing a point in world
+// units.
+func (m *Tilemap) CoordAt(p ecsmath.Vec2) Coord {
+	return Coord{int(math.Floor(p.X / m.TileSize)), int(math.Floor(p.Y / m.TileSize))}
+}
+
+// Bounds returns the area covered by a tile in world units.
+func (m *Tilemap) Bounds(c Coord) ecsmath.Rect {
+	min := ecsmath.Vec2{X: float64(c.X) * m.TileSize, Y: float64(c.Y) * m.TileSize}
+	return ecsmath.Rect{Min: min, Max: min.Add(ecsmath.Vec2{X: m.TileSize, Y: m.TileSize})}
+}
+
+// EntitiesOn returns every entity in the world whose position is on the given
+// tile, in ascending order. If the tilemap is tracking the world, this is the
+// same as Occupants. Otherwise the spatial index is queried, reflecting the
+// world as of its last Rebuild unless it is tracking the world too, or if
+// there is no index, every object is checked.
+func (m *Tilemap) EntitiesOn(w *ecs.World, c Coord) []ecs.Entity {
+	m.mu.RLock()
+	tracking := m.tracking
+	m.mu.RUnlock()
+	if tracking {
+		return m.Occupants(c)
+	}
+	index := m.Index
+	if index == nil {
+		index, _ = w.Resource(index).(*ecsspatial.Index)
+	}
+	if index != nil {
+		return index.WithinRect(m.Bounds(c))
+	}
+	var entities []ecs.Entity
+	for _, ob := range w.Objects() {
+		if p, ok := m.locate(ob); ok && m.CoordAt(p) == c {
+			entities = append(entities, ob.Entity())
+		}
+	}
+	return entities
+}
+
+func (m *Tilemap) locate(ob *ecs.Object) (ecsmath.Vec2, bool) {
+	for _, c := range ob.Components() {
+		if reflect.TypeOf(c) == m.position {
+			return c.(ecsmath.Locator).XY(), true
+		}
+	}
+	return ecsmath.Vec2{}, false
+}
+
+// System returns a global system that emits a TileChanged event for every
+// tile changed since its last tick.
+func (m *Tilemap) System(ticker <-chan time.Time) ecs.System {
+	return ecs.System{
+		Func:   m.sync,
+		Name:   "ecstile.Sync",
+		Ticker: ticker,
+		Global: true,
+	}
+}
+
+func (m *Tilemap) sync(w *ecs.World) {
+	m.mu.Lock()
+	changes := m.changes
+	m.changes = nil
+	m.mu.Unlock()
+
+	for _, change := range changes {
+		w.Emit(change)
+	}
+}
@@ -0,0 +1,210 @@
+package ecstile_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+	"github.com/dradtke/ecs-go/ecsspatial"
+	"github.com/dradtke/ecs-go/ecstile"
+)
+
+type Position struct{ ecsmath.Vec2 }
+
+const (
+	Floor ecstile.Tile = iota + 1
+	Lava
+)
+
+func TestTilemap(t *testing.T) {
+	tiles := ecstile.New(10, Position{})
+
+	tiles.Set(ecstile.Coord{X: -1, Y: -1}, Floor)
+	tiles.Set(ecstile.Coord{X: 15, Y: 15}, Lava)
+	tiles.Set(ecstile.Coord{X: 16, Y: 0}, Floor)
+
+	if got, want := tiles.Get(ecstile.Coord{X: -1, Y: -1}), Floor; got != want {
+		t.Errorf("bad tile: got %v, want %v", got, want)
+	}
+	if got, want := tiles.Get(ecstile.Coord{X: 15, Y: 15}), Lava; got != want {
+		t.Errorf("bad tile: got %v, want %v", got, want)
+	}
+	if got := tiles.Get(ecstile.Coord{X: 100, Y: 100}); got != 0 {
+		t.Errorf("unset tile should be zero, got %v", got)
+	}
+	if got, want := tiles.Chunks(), 3; got != want {
+		t.Errorf("wrong number of chunks: got %d, want %d", got, want)
+	}
+}
+
+func TestTileChanges(t *testing.T) {
+	world := ecs.NewWorld()
+	tiles := ecstile.New(10, Position{})
+	world.AddResource(tiles)
+	world.AddSystem(tiles.System(nil))
+
+	var changes []ecstile.TileChanged
+	world.OnEvent(ecstile.TileChanged{}, func(ev interface{}) {
+		changes = append(changes, ev.(ecstile.TileChanged))
+	})
+
+	tiles.Set(ecstile.Coord{X: 1, Y: 2}, Floor)
+	tiles.Set(ecstile.Coord{X: 1, Y: 2}, Lava)
+	world.Run()
+
+	want := []ecstile.TileChanged{
+		{Coord: ecstile.Coord{X: 1, Y: 2}, Old: 0, New: Floor},
+		{Coord: ecstile.Coord{X: 1, Y: 2}, Old: Floor, New: Lava},
+	}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("bad changes: got %v, want %v", changes, want)
+	}
+}
+
+func TestEntitiesOn(t *testing.T) {
+	world := ecs.NewWorld()
+	tiles := ecstile.New(10, Position{})
+
+	player := world.AddObject(ecs.NewObject(Position{ecsmath.Vec2{X: 15, Y: 25}}))
+	world.AddObject(ecs.NewObject(Position{ecsmath.Vec2{X: 25, Y: 25}}))
+
+	got := tiles.EntitiesOn(world, ecstile.Coord{X: 1, Y: 2})
+	if len(got) != 1 || got[0] != player {
+		t.Errorf("bad entities on tile: got %v, want [%v]", got, player)
+	}
+}
+
+func TestEntitiesOnIndex(t *testing.T) {
+	world := ecs.NewWorld()
+	tiles := ecstile.New(10, Position{})
+	index := ecsspatial.New(32, Position{})
+	world.AddResource(index)
+
+	player := world.AddObject(ecs.NewObject(Position{ecsmath.Vec2{X: 15, Y: 25}}))
+	world.AddObject(ecs.NewObject(Position{ecsmath.Vec2{X: 20, Y: 25}}))
+	index.Rebuild(world)
+	// not seen until the index is rebuilt
+	world.AddObject(ecs.NewObject(Position{ecsmath.Vec2{X: 16, Y: 26}}))
+
+	got := tiles.EntitiesOn(world, ecstile.Coord{X: 1, Y: 2})
+	if len(got) != 1 || got[0] != player {
+		t.Errorf("bad entities on tile: got %v, want [%v]", got, player)
+	}
+}
+
+func TestOccupancy(t *testing.T) {
+	const Wall ecstile.Tile = 9
+	world := ecs.NewWorld()
+	tiles := ecstile.New(10, Position{})
+	tiles.Solid = func(t ecstile.Tile) bool { return t == Wall }
+	tiles.Set(ecstile.Coord{X: 1, Y: 0}, Wall)
+
+	at := func(c ecstile.Coord) Position { return Position{tiles.Center(c)} }
+	a := world.AddObject(ecs.NewObject(at(ecstile.Coord{X: 0, Y: 0})))
+	tiles.Track(world)
+	b := ecs.NewObject(at(ecstile.Coord{X: 0, Y: 0}))
+	world.AddObject(b)
+
+	if got, want := tiles.Occupants(ecstile.Coord{}), []ecs.Entity{a, b.Entity()}; !reflect.DeepEqual(got, want) {
+		t.Errorf("occupants: got %v, want %v", got, want)
+	}
+
+	b.SetComponent(at(ecstile.Coord{X: 0, Y: 1}))
+	if c, _ := tiles.CoordOf(b.Entity()); c != (ecstile.Coord{X: 0, Y: 1}) {
+		t.Errorf("moved object is on %v", c)
+	}
+	if got, want := tiles.EntitiesOn(world, ecstile.Coord{}), []ecs.Entity{a}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after moving: got %v, want %v", got, want)
+	}
+
+	world.RemoveObject(a)
+	if tiles.Occupied(ecstile.Coord{}) || !tiles.Free(ecstile.Coord{}) {
+		t.Error("tile still occupied after removing its object")
+	}
+	if tiles.Free(ecstile.Coord{X: 1, Y: 0}) || tiles.Free(ecstile.Coord{X: 0, Y: 1}) {
+		t.Error("wall or occupied tile reported free")
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	const Wall ecstile.Tile = 9
+	tiles := ecstile.New(10, Position{})
+	tiles.Solid = func(t ecstile.Tile) bool { return t == Wall }
+	tiles.Set(ecstile.Coord{X: 1, Y: 0}, Wall)
+
+	got := tiles.Neighbors(ecstile.Coord{}, true)
+	want := []ecstile.Coord{{X: 0, Y: -1}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: -1, Y: 1}, {X: -1, Y: -1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFindPath(t *testing.T) {
+	const Wall ecstile.Tile = 9
+	tiles := ecstile.New(10, Position{})
+	tiles.Solid = func(t ecstile.Tile) bool { return t == Wall }
+	for y := -1; y <= 1; y++ {
+		tiles.Set(ecstile.Coord{X: 1, Y: y}, Wall)
+	}
+	paths := ecstile.NewPathfinder(tiles, 1)
+
+	path := paths.FindPath(ecstile.PathRequest{From: ecstile.Coord{}, To: ecstile.Coord{X: 2, Y: 0}})
+	if got, want := len(path.Steps), 7; got != want || path.Cost != 6 {
+		t.Fatalf("bad path around wall: %v with cost %v, want %d steps", path.Steps, path.Cost, want)
+	}
+	if path.Steps[0] != (ecstile.Coord{}) || path.Steps[6] != (ecstile.Coord{X: 2, Y: 0}) {
+		t.Errorf("path doesn't go from start to goal: %v", path.Steps)
+	}
+
+	diagonal := paths.FindPath(ecstile.PathRequest{From: ecstile.Coord{X: 5}, To: ecstile.Coord{X: 8, Y: 3}, Diagonal: true})
+	if len(diagonal.Steps) != 4 || math.Abs(diagonal.Cost-3*math.Sqrt2) > 1e-9 {
+		t.Errorf("bad diagonal path: %v with cost %v", diagonal.Steps, diagonal.Cost)
+	}
+
+	expensive := map[ecstile.Coord]bool{{X: 0, Y: -1}: true}
+	paths.Cost = func(from, to ecstile.Coord) float64 {
+		if expensive[to] {
+			return 100
+		}
+		return 1
+	}
+	if path := paths.FindPath(ecstile.PathRequest{From: ecstile.Coord{}, To: ecstile.Coord{X: 2, Y: 0}}); path.Steps[1] != (ecstile.Coord{X: 0, Y: 1}) {
+		t.Errorf("path didn't avoid expensive tile: %v", path.Steps)
+	}
+
+	tiles.Set(ecstile.Coord{X: 2, Y: 0}, Wall)
+	paths.MaxNodes = 100
+	if path := paths.FindPath(ecstile.PathRequest{From: ecstile.Coord{}, To: ecstile.Coord{X: 2, Y: 0}}); path.Found() {
+		t.Errorf("found path to a wall: %v", path.Steps)
+	}
+}
+
+func TestPathfinderSystem(t *testing.T) {
+	world := ecs.NewWorld()
+	tiles := ecstile.New(10, Position{})
+	paths := ecstile.NewPathfinder(tiles, 2)
+	world.AddResource(paths)
+	world.AddSystem(paths.System(nil))
+
+	e := world.AddObject(ecs.NewObject(ecstile.PathRequest{From: ecstile.Coord{}, To: ecstile.Coord{X: 3, Y: 4}}))
+	world.Step()
+	deadline := time.Now().Add(5 * time.Second)
+	for paths.Pending() > 0 || world.GetObject(e).Component(ecstile.Path{}) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("path wasn't found in time")
+		}
+		time.Sleep(time.Millisecond)
+		world.Step()
+	}
+
+	ob := world.GetObject(e)
+	if ob.Component(ecstile.PathRequest{}) != nil {
+		t.Error("request wasn't removed")
+	}
+	if path := ob.Component(ecstile.Path{}).(ecstile.Path); len(path.Steps) != 8 {
+		t.Errorf("bad path: %v", path.Steps)
+	}
+}
@@ -0,0 +1,59 @@
+package ecs
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Elapsed is a system parameter that receives the time that has passed since
+// the system last ticked, or zero on its first tick. If the world has a *Time
+// resource, this is simulated time, so it is zero while the clock is paused
+// and scaled along with it; otherwise it is wall time, measured between the
+// times the system's ticker fired. Unlike the difference between consecutive
+// time.Time parameters, it stays correct across pauses and changes of scale.
+//
+//     func Cooldown(c Cooldown, dt ecs.Elapsed) Cooldown {
+//         c.Remaining -= time.Duration(dt)
+//         return c
+//     }
+type Elapsed time.Duration
+
+var (
+	elapsedType = reflect.TypeOf(Elapsed(0))
+	timeResType = reflect.TypeOf(&Time{})
+)
+
+// systemClock measures the time between a system's ticks.
+type systemClock struct {
+	started   bool
+	simulated bool
+	lastSim   time.Duration
+	lastWall  time.Time
+
+	elapsed int64 // Elapsed for the current tick
+}
+
+// advance records a tick of the system at wall time now. It is only called
+// from the system's own tick, but elapsed may be read by its workers.
+func (c *systemClock) advance(w *World, now time.Time) {
+	var elapsed time.Duration
+	if r, ok := w.resource(timeResType); ok {
+		sim := r.Interface().(*Time).Elapsed()
+		if c.started && c.simulated {
+			elapsed = sim - c.lastSim
+		}
+		c.simulated, c.lastSim = true, sim
+	} else {
+		if c.started && !c.simulated {
+			elapsed = now.Sub(c.lastWall)
+		}
+		c.simulated = false
+	}
+	c.started, c.lastWall = true, now
+	atomic.StoreInt64(&c.elapsed, int64(elapsed))
+}
+
+func (c *systemClock) current() Elapsed {
+	return Elapsed(atomic.LoadInt64(&c.elapsed))
+}
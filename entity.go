@@ -0,0 +1,240 @@
+package ecs
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// An Entity identifies an object. Its low 32 bits are an index, and its high
+// 32 bits a generation, which is zero unless the index has been recycled by a
+// world with RecycleEntities set. Since the generation is bumped each time
+// an index is reused, an Entity held after its object was removed never
+// refers to the object that reuses its index.
+type Entity uint64
+
+const entityIndexBits = 32
+
+// Index returns the part of the entity that is reused when it is recycled.
+func (e Entity) Index() uint64 {
+	return uint64(e) & (1<<entityIndexBits - 1)
+}
+
+// Generation returns how many times the entity's index has been recycled.
+func (e Entity) Generation() uint32 {
+	return uint32(uint64(e) >> entityIndexBits)
+}
+
+func makeEntity(index uint64, generation uint32) Entity {
+	return Entity(uint64(generation)<<entityIndexBits | index)
+}
+
+// entityPool holds the indexes released by worlds with RecycleEntities set,
+// to be handed out again before new ones are allocated. Like the counter
+// they come from, it is shared by every world, so that an entity is never
+// in use by two worlds at once.
+var entityPool struct {
+	mu sync.Mutex
+
+	// free lists released indexes, most recent last. An index is only
+	// still free if it is in generations, which holds the generation it
+	// will be handed out with, since reserveEntity may claim one without
+	// removing it from free.
+	free        []uint64
+	generations map[uint64]uint32
+
+	// size is the number of entries in generations, read atomically so
+	// that allocating doesn't need the lock while the pool is empty.
+	size int64
+}
+
+// newEntity allocates an entity, reusing a released index if there is one.
+func newEntity() Entity {
+	if e, ok := popEntity(); ok {
+		return e
+	}
+	return Entity(atomic.AddUint64(&gid, 1))
+}
+
+// newEntities allocates n entities at once.
+func newEntities(n int) []Entity {
+	entities := make([]Entity, 0, n)
+	for len(entities) < n {
+		e, ok := popEntity()
+		if !ok {
+			break
+		}
+		entities = append(entities, e)
+	}
+	if rest := uint64(n - len(entities)); rest > 0 {
+		first := atomic.AddUint64(&gid, rest) - rest + 1
+		for i := uint64(0); i < rest; i++ {
+			entities = append(entities, Entity(first+i))
+		}
+	}
+	return entities
+}
+
+func popEntity() (Entity, bool) {
+	if atomic.LoadInt64(&entityPool.size) == 0 {
+		return 0, false
+	}
+	entityPool.mu.Lock()
+	defer entityPool.mu.Unlock()
+	for len(entityPool.free) > 0 {
+		index := entityPool.free[len(entityPool.free)-1]
+		entityPool.free = entityPool.free[:len(entityPool.free)-1]
+		gen, ok := entityPool.generations[index]
+		if !ok {
+			// claimed by reserveEntity
+			continue
+		}
+		delete(entityPool.generations, index)
+		atomic.AddInt64(&entityPool.size, -1)
+		return makeEntity(index, gen), true
+	}
+	return 0, false
+}
+
+// releaseEntity makes an entity's index available to be reused with the
+// next generation. An index whose generation would wrap around is retired
+// instead.
+func releaseEntity(e Entity) {
+	gen := e.Generation() + 1
+	if gen == 0 {
+		return
+	}
+	entityPool.mu.Lock()
+	defer entityPool.mu.Unlock()
+	if entityPool.generations == nil {
+		entityPool.generations = make(map[uint64]uint32)
+	}
+	if _, ok := entityPool.generations[e.Index()]; ok {
+		return
+	}
+	entityPool.free = append(entityPool.free, e.Index())
+	entityPool.generations[e.Index()] = gen
+	atomic.AddInt64(&entityPool.size, 1)
+}
+
+// reserveEntity ensures that neither entity nor any entity with a lower
+// index will be allocated again, such as when loading objects that were
+// saved with their entities.
+func reserveEntity(entity Entity) {
+	index := entity.Index()
+	for {
+		cur := atomic.LoadUint64(&gid)
+		if cur >= index || atomic.CompareAndSwapUint64(&gid, cur, index) {
+			break
+		}
+	}
+
+	if atomic.LoadInt64(&entityPool.size) == 0 {
+		return
+	}
+	entityPool.mu.Lock()
+	defer entityPool.mu.Unlock()
+	if _, ok := entityPool.generations[index]; ok {
+		delete(entityPool.generations, index)
+		atomic.AddInt64(&entityPool.size, -1)
+	}
+}
+
+// objectPool holds objects removed from a world with RecycleEntities set, to
+// be reused for objects it spawns.
+type objectPool struct {
+	mu      sync.Mutex
+	objects []*Object
+
+	// retired holds removed objects that a system tick might still hold,
+	// along with the most recent tick to have started when each was
+	// removed. They only join objects once every such tick has finished,
+	// so that a late write-back can't land on an object that was reused.
+	retired []retiredObject
+}
+
+type retiredObject struct {
+	ob    *Object
+	epoch uint64
+}
+
+// maxPooledObjects caps the size of a world's object pool, so that a burst
+// of removals doesn't pin memory forever.
+const maxPooledObjects = 4096
+
+// get returns an object from the pool, or nil if there isn't one. oldest is
+// the earliest system tick still running, as returned by iterations.oldest.
+func (p *objectPool) get(oldest uint64) *Object {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.release(oldest)
+	if len(p.objects) == 0 {
+		return nil
+	}
+	ob := p.objects[len(p.objects)-1]
+	p.objects[len(p.objects)-1] = nil
+	p.objects = p.objects[:len(p.objects)-1]
+	atomic.StoreInt32(&ob.despawning, 0)
+	return ob
+}
+
+// release moves the retired objects that no running tick can hold into the
+// pool, clearing what is left of their old state.
+func (p *objectPool) release(oldest uint64) {
+	n := 0
+	for _, r := range p.retired {
+		if r.epoch >= oldest {
+			p.retired[n] = r
+			n++
+			continue
+		}
+		if len(p.objects) >= maxPooledObjects {
+			continue
+		}
+		r.ob.mu.Lock()
+		r.ob.world = nil
+		r.ob.components = nil
+		r.ob.mask = nil
+		r.ob.dense = nil
+		r.ob.mu.Unlock()
+		p.objects = append(p.objects, r.ob)
+	}
+	for i := n; i < len(p.retired); i++ {
+		p.retired[i] = retiredObject{}
+	}
+	p.retired = p.retired[:n]
+}
+
+func (p *objectPool) put(ob *Object, epoch uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.objects)+len(p.retired) < maxPooledObjects {
+		p.retired = append(p.retired, retiredObject{ob, epoch})
+	}
+}
+
+// newObject returns an object for the world to spawn, reusing one from its
+// pool if it can.
+func (w *World) newObject(entity Entity, cs []interface{}) *Object {
+	if w.RecycleEntities {
+		if ob := w.objectPool.get(w.iterations.oldest()); ob != nil {
+			ob.entity, ob.components = entity, cs
+			return ob
+		}
+	}
+	return &Object{entity: entity, components: cs}
+}
+
+// recycle releases a removed object's entity, and keeps the object for
+// reuse, if the world has RecycleEntities set. The object isn't reused until
+// every system tick that was running when it was removed has finished.
+func (w *World) recycle(ob *Object) {
+	if !w.RecycleEntities {
+		return
+	}
+	releaseEntity(ob.entity)
+
+	ob.mu.Lock()
+	ob.world = nil
+	ob.mu.Unlock()
+	w.objectPool.put(ob, w.iterations.latest())
+}
@@ -0,0 +1,91 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestRecycleEntities(t *testing.T) {
+	world := ecs.NewWorld()
+	world.RecycleEntities = true
+
+	entities := world.SpawnBatch(2, func(i int) []interface{} {
+		return []interface{}{Position(i)}
+	})
+	old := world.GetObject(entities[0])
+	world.Despawn(entities[0])
+	world.Step()
+
+	reused := world.SpawnBatch(1, func(int) []interface{} {
+		return []interface{}{Velocity(1)}
+	})[0]
+	if reused.Index() != entities[0].Index() {
+		t.Errorf("index wasn't recycled: got %d, want %d", reused.Index(), entities[0].Index())
+	}
+	if got := reused.Generation(); got != entities[0].Generation()+1 {
+		t.Errorf("bad generation: got %d, want %d", got, entities[0].Generation()+1)
+	}
+	if world.GetObject(entities[0]) != nil {
+		t.Error("stale entity still refers to an object")
+	}
+	ob := world.GetObject(reused)
+	if ob != old {
+		t.Error("removed object wasn't reused")
+	}
+	if ecs.Has[Position](ob) || !ecs.Has[Velocity](ob) {
+		t.Errorf("reused object has stale components: %v", ob.Components())
+	}
+	if got := world.Query().With(Position(0)).Count(); got != 1 {
+		t.Errorf("bad position count: got %d, want 1", got)
+	}
+}
+
+func TestEntityWithoutRecycling(t *testing.T) {
+	world := ecs.NewWorld()
+	e := world.AddObject(ecs.NewObject(Position(0)))
+	world.RemoveObject(e)
+	if next := world.AddObject(ecs.NewObject(Position(0))); next.Index() == e.Index() || next.Generation() != 0 {
+		t.Errorf("entity was recycled without RecycleEntities: %d after %d", next, e)
+	}
+}
+
+type recycledEvent struct{}
+
+func TestRecycleWaitsForRunningTicks(t *testing.T) {
+	world := ecs.NewWorld()
+	world.RecycleEntities = true
+	e := world.AddObject(ecs.NewObject(Position(0)))
+	world.AddObject(ecs.NewObject(Velocity(0)))
+
+	started := make(chan struct{})
+	spawned := make(chan struct{})
+	var reused ecs.Entity
+	world.OnEvent(recycledEvent{}, func(interface{}) {
+		reused = world.Commands().Spawn(Position(100))
+		close(spawned)
+	})
+
+	world.AddSystem(ecs.System{Func: func(Position) Position {
+		close(started)
+		<-spawned
+		return Position(1)
+	}})
+	world.AddSystem(ecs.System{Func: func(cmds *ecs.Commands, _ Velocity) {
+		<-started
+		cmds.Despawn(e)
+		world.Emit(recycledEvent{})
+	}})
+	world.Run()
+
+	if world.GetObject(e) != nil {
+		t.Error("expected object to be removed once every system finished")
+	}
+	ob := world.GetObject(reused)
+	if ob == nil {
+		t.Fatal("spawned object is missing")
+	}
+	if got := ob.Component(Position(0)); got != Position(100) {
+		t.Errorf("write-back landed on a recycled object: got %v, want 100", got)
+	}
+}
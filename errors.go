@@ -0,0 +1,47 @@
+package ecs
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A SystemError is returned by RunContext for each system that failed.
+type SystemError struct {
+	System string
+	Err    error
+}
+
+func (e *SystemError) Error() string {
+	return fmt.Sprintf("system %q: %s", e.System, e.Err)
+}
+
+func (e *SystemError) Unwrap() error {
+	return e.Err
+}
+
+// A PanicError is the error of a system that failed by panicking.
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// failure records the error that stopped a system for good, if any.
+type failure struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (f *failure) set(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func (f *failure) get() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Prioritized can be implemented by event types to control the order in which
+// events are delivered. When events are delivered, those with a higher
+// priority are delivered first, and events with the same priority are
+// delivered in the order they were emitted. Events that don't implement
+// Prioritized have a priority of zero.
+type Prioritized interface {
+	Priority() int
+}
+
+// queuedEvent is an event waiting to be delivered.
+type queuedEvent struct {
+	Event    interface{}
+	Priority int
+}
+
+// Emit queues an event to be delivered to every handler registered for its
+// type with OnEvent. Events emitted by a system are delivered once its current
+// tick has finished, on the same goroutine that ran the system.
+func (w *World) Emit(ev interface{}) {
+	priority := 0
+	if p, ok := ev.(Prioritized); ok {
+		priority = p.Priority()
+	}
+	w.EmitPriority(ev, priority)
+}
+
+// EmitPriority queues an event like Emit, but with the given priority instead
+// of its type's priority.
+func (w *World) EmitPriority(ev interface{}, priority int) {
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	w.events = append(w.events, queuedEvent{Event: ev, Priority: priority})
+}
+
+// OnEvent registers a handler that will be invoked with each emitted event
+// that has the same type as ev.
+func (w *World) OnEvent(ev interface{}, handler func(ev interface{})) {
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	t := reflect.TypeOf(ev)
+	w.handlers[t] = append(w.handlers[t], handler)
+}
+
+// flushEvents delivers all queued events, including those received over
+// bridges, to their handlers in priority order. Bridges are received from
+// before taking eventsMu, so a busy sender never holds up Emit. Handlers are
+// invoked without holding any locks, so they are free to emit more events,
+// which will be delivered on the next flush. While the world is being
+// replayed, the recorded events for the tick boundary are delivered instead
+// of those received over bridges.
+func (w *World) flushEvents(boundary uint64) {
+	f := w.replay.Load()
+	var received []queuedEvent
+	if f == nil {
+		w.eventsMu.Lock()
+		bridges := w.bridges
+		w.eventsMu.Unlock()
+		for _, b := range bridges {
+			received = b.receive(received)
+		}
+	}
+
+	w.eventsMu.Lock()
+	if f != nil {
+		f.deliver(w, boundary)
+	} else {
+		w.events = append(w.events, received...)
+		if r := w.replayRecorder.Load(); r != nil {
+			for _, qe := range received {
+				r.recordEvent(boundary, qe)
+			}
+		}
+	}
+	events := w.events
+	w.events = nil
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Priority > events[j].Priority
+	})
+	handlers := make([][]func(interface{}), len(events))
+	for i, ev := range events {
+		handlers[i] = w.handlers[reflect.TypeOf(ev.Event)]
+	}
+	w.eventsMu.Unlock()
+
+	if len(events) > 0 {
+		w.metrics().Counter("ecs_events_flushed", float64(len(events)))
+	}
+	for i, ev := range events {
+		for _, handler := range handlers[i] {
+			handler(ev.Event)
+		}
+	}
+}
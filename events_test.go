@@ -0,0 +1,48 @@
+package ecs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type (
+	Damage struct{ Amount int }
+	Heal   struct{ Amount int }
+)
+
+func (Damage) Priority() int { return 10 }
+
+func TestEventPriority(t *testing.T) {
+	world := ecs.NewWorld()
+
+	var delivered []interface{}
+	record := func(ev interface{}) {
+		delivered = append(delivered, ev)
+	}
+	world.OnEvent(Damage{}, record)
+	world.OnEvent(Heal{}, record)
+
+	world.AddObject(ecs.NewObject(Player{}))
+	world.AddSystem(ecs.System{Func: func(w *ecs.World, _ Player) {
+		w.Emit(Heal{Amount: 1})
+		w.Emit(Damage{Amount: 1})
+		w.Emit(Heal{Amount: 2})
+		w.EmitPriority(Heal{Amount: 3}, 20)
+		w.Emit(Damage{Amount: 2})
+	}})
+
+	world.Run()
+
+	want := []interface{}{
+		Heal{Amount: 3},
+		Damage{Amount: 1},
+		Damage{Amount: 2},
+		Heal{Amount: 1},
+		Heal{Amount: 2},
+	}
+	if !reflect.DeepEqual(delivered, want) {
+		t.Errorf("bad delivery order:\ngot  %v\nwant %v", delivered, want)
+	}
+}
@@ -0,0 +1,275 @@
+// Package asteroids is a small, headless asteroids game built with the ecs
+// package, intended as a reference for structuring a game around it. It
+// exercises most of the library: states, events, prefabs, the spatial index
+// for collisions, and saving and loading.
+//
+// The ship is flown by a simple autopilot that turns steadily and fires
+// whenever it can, so the game plays itself:
+//
+//     game := asteroids.New(seed)
+//     for game.Stage() == asteroids.Playing {
+//         game.Step()
+//     }
+//     fmt.Println("final score:", game.Score())
+//
+// The game's plain function systems are called without reflection, using code
+// generated by ecsgen.
+package asteroids
+
+//go:generate go run github.com/dradtke/ecs-go/cmd/ecsgen
+
+import (
+	"io"
+	"iter"
+	"math"
+	"math/rand"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+	"github.com/dradtke/ecs-go/ecsspatial"
+)
+
+// The size of the playing field. Objects that leave one side reappear on the
+// other.
+const (
+	Width  = 400
+	Height = 400
+)
+
+const (
+	asteroidsPerWave = 4
+	fireCooldown     = 5
+	bulletSpeed      = 6
+	bulletTTL        = 40
+	turnRate         = ecsmath.Angle(0.05)
+)
+
+type Position struct{ ecsmath.Vec2 }
+
+type Velocity struct{ ecsmath.Vec2 }
+
+// Ship is the player's ship.
+type Ship struct {
+	Heading  ecsmath.Angle
+	Cooldown int
+}
+
+// Score is the player's score, kept on the ship.
+type Score struct {
+	Points int
+}
+
+// Asteroid sizes run from 3, the largest, down to 1. Shooting an asteroid
+// splits it into two of the next size down.
+type Asteroid struct {
+	Size int
+}
+
+// Radius returns how far an asteroid extends from its position.
+func (a Asteroid) Radius() float64 {
+	return float64(a.Size) * 6
+}
+
+// Bullet is fired by the ship, and disappears once its TTL runs out.
+type Bullet struct {
+	TTL int
+}
+
+// Stage is the state of the game.
+type Stage int
+
+const (
+	Playing Stage = iota
+	GameOver
+)
+
+// AsteroidDestroyed is emitted whenever a bullet hits an asteroid.
+type AsteroidDestroyed struct {
+	Asteroid ecs.Entity
+	Size     int
+}
+
+// ShipDestroyed is emitted when an asteroid hits the ship.
+type ShipDestroyed struct {
+	Score int
+}
+
+func init() {
+	ecs.RegisterName("asteroids.Position", Position{})
+	ecs.RegisterName("asteroids.Velocity", Velocity{})
+	ecs.RegisterName("asteroids.Ship", Ship{})
+	ecs.RegisterName("asteroids.Score", Score{})
+	ecs.RegisterName("asteroids.Asteroid", Asteroid{})
+	ecs.RegisterName("asteroids.Bullet", Bullet{})
+	ecs.RegisterName("ecsspatial.Sensor", ecsspatial.Sensor{})
+}
+
+var (
+	shipPrefab     = ecs.NewPrefab(Position{}, Velocity{}, Ship{}, Score{})
+	asteroidPrefab = ecs.NewPrefab(Position{}, Velocity{}, Asteroid{Size: 3})
+	bulletPrefab   = ecs.NewPrefab(Position{}, Velocity{}, Bullet{TTL: bulletTTL})
+)
+
+// Game is a game of asteroids.
+type Game struct {
+	World *ecs.World
+
+	rand   *rand.Rand
+	stages *ecs.States[Stage]
+	wave   int
+
+	// destroyed holds the asteroids hit during the current step, so an
+	// asteroid hit by two bullets at once only splits once.
+	destroyed map[ecs.Entity]bool
+}
+
+// New starts a new game, with asteroids placed according to seed.
+func New(seed int64) *Game {
+	g := &Game{
+		World:     ecs.NewWorld(),
+		rand:      rand.New(rand.NewSource(seed)),
+		stages:    ecs.NewStates(Playing),
+		destroyed: make(map[ecs.Entity]bool),
+	}
+	w := g.World
+	w.AddResource(g.stages)
+
+	prox := ecsspatial.NewProximity(ecsspatial.New(32, Position{}))
+	w.OnEvent(ecsspatial.EnterRange{}, g.collide)
+
+	w.MustAddSystem(g.stages.OnUpdate(Playing, autopilotSystem()))
+	w.MustAddSystem(g.stages.OnUpdate(Playing, movementSystem()))
+	w.MustAddSystem(expireSystem())
+	w.MustAddSystem(g.stages.OnUpdate(Playing, prox.System(nil)))
+	w.MustAddSystem(g.stages.OnUpdate(Playing, ecs.System{Name: "waves", Func: g.spawnWave}))
+
+	w.Spawn(shipPrefab, Position{ecsmath.Vec2{X: Width / 2, Y: Height / 2}})
+	return g
+}
+
+// Step advances the game by one frame.
+func (g *Game) Step() {
+	clear(g.destroyed)
+	g.World.Step()
+}
+
+// Stage returns the current stage of the game.
+func (g *Game) Stage() Stage {
+	return g.stages.Current()
+}
+
+// Score returns the player's score.
+func (g *Game) Score() int {
+	for _, score := range ecs.Each[Score](g.World) {
+		return score.Points
+	}
+	return 0
+}
+
+// Save writes the state of the game, which can be restored with Load.
+func (g *Game) Save(out io.Writer) error {
+	return g.World.SaveJSON(out)
+}
+
+// Load restores the state of the game from a save.
+func (g *Game) Load(in io.Reader) error {
+	return g.World.LoadJSON(in)
+}
+
+// autopilot turns the ship and fires bullets ahead of it.
+//
+//ecs:system
+func autopilot(cmds *ecs.Commands, ship Ship, p Position) Ship {
+	ship.Heading = (ship.Heading + turnRate).Normalize()
+	if ship.Cooldown > 0 {
+		ship.Cooldown--
+		return ship
+	}
+	ship.Cooldown = fireCooldown
+	bullet := bulletPrefab.Instantiate(p, Velocity{ship.Heading.Vec2().Scale(bulletSpeed)})
+	cmds.Spawn(bullet.Components()...)
+	return ship
+}
+
+//ecs:system
+func movement(p Position, v Velocity) Position {
+	p.X = wrap(p.X+v.X, Width)
+	p.Y = wrap(p.Y+v.Y, Height)
+	return p
+}
+
+func wrap(x, max float64) float64 {
+	x = math.Mod(x, max)
+	if x < 0 {
+		x += max
+	}
+	return x
+}
+
+//ecs:system
+func expire(cmds *ecs.Commands, e ecs.Entity, b Bullet) Bullet {
+	b.TTL--
+	if b.TTL <= 0 {
+		cmds.Despawn(e)
+	}
+	return b
+}
+
+// spawnWave starts a new wave of asteroids once the last one is cleared.
+func (g *Game) spawnWave(cmds *ecs.Commands, ship Ship, p Position, asteroids iter.Seq2[ecs.Entity, Asteroid]) {
+	for range asteroids {
+		return
+	}
+	g.wave++
+	for i := 0; i < asteroidsPerWave; i++ {
+		// start at a distance from the ship, heading in a random direction
+		at := p.Add(ecsmath.Angle(g.rand.Float64() * 2 * math.Pi).Vec2().Scale(Width / 3))
+		heading := ecsmath.Angle(g.rand.Float64() * 2 * math.Pi)
+		speed := 0.5 + g.rand.Float64()*float64(g.wave)/2
+		g.spawnAsteroid(cmds, 3, Position{at}, Velocity{heading.Vec2().Scale(speed)})
+	}
+}
+
+func (g *Game) spawnAsteroid(cmds *ecs.Commands, size int, p Position, v Velocity) {
+	a := Asteroid{Size: size}
+	asteroid := asteroidPrefab.Instantiate(a, p, v, ecsspatial.Sensor{Radius: a.Radius()})
+	cmds.Spawn(asteroid.Components()...)
+}
+
+// collide handles asteroids' sensors detecting bullets and the ship.
+func (g *Game) collide(ev interface{}) {
+	contact := ev.(ecsspatial.EnterRange)
+	w := g.World
+	asteroidOb, otherOb := w.GetObject(contact.Sensor), w.GetObject(contact.Other)
+	if asteroidOb == nil || otherOb == nil || g.destroyed[contact.Sensor] {
+		return
+	}
+	asteroid, ok := ecs.Get[Asteroid](asteroidOb)
+	if !ok {
+		return
+	}
+
+	switch {
+	case ecs.Has[Bullet](otherOb):
+		g.destroyed[contact.Sensor] = true
+		cmds := w.Commands()
+		cmds.Despawn(contact.Other)
+		cmds.Despawn(contact.Sensor)
+		if asteroid.Size > 1 {
+			p, _ := ecs.Get[Position](asteroidOb)
+			v, _ := ecs.Get[Velocity](asteroidOb)
+			for _, turn := range []ecsmath.Angle{-0.5, 0.5} {
+				g.spawnAsteroid(cmds, asteroid.Size-1, p, Velocity{v.Rotate(turn).Scale(1.5)})
+			}
+		}
+		w.Query().ForEach(func(s Score) Score {
+			s.Points += 100 / asteroid.Size
+			return s
+		})
+		w.Emit(AsteroidDestroyed{Asteroid: contact.Sensor, Size: asteroid.Size})
+
+	case ecs.Has[Ship](otherOb):
+		g.stages.Set(GameOver)
+		w.Emit(ShipDestroyed{Score: g.Score()})
+	}
+}
@@ -0,0 +1,127 @@
+package asteroids_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsspatial"
+	"github.com/dradtke/ecs-go/examples/asteroids"
+)
+
+// play runs a game until it ends or the step limit is reached.
+func play(g *asteroids.Game, limit int) int {
+	steps := 0
+	for g.Stage() == asteroids.Playing && steps < limit {
+		g.Step()
+		steps++
+	}
+	return steps
+}
+
+func TestGame(t *testing.T) {
+	g := asteroids.New(1)
+
+	var destroyed []asteroids.AsteroidDestroyed
+	g.World.OnEvent(asteroids.AsteroidDestroyed{}, func(ev interface{}) {
+		destroyed = append(destroyed, ev.(asteroids.AsteroidDestroyed))
+	})
+	var shipDestroyed bool
+	g.World.OnEvent(asteroids.ShipDestroyed{}, func(interface{}) {
+		shipDestroyed = true
+	})
+
+	steps := play(g, 5000)
+	t.Logf("game lasted %d steps, scored %d, destroyed %d asteroids", steps, g.Score(), len(destroyed))
+
+	if len(destroyed) == 0 || g.Score() == 0 {
+		t.Fatal("expected the autopilot to destroy some asteroids")
+	}
+	sizes := make(map[int]bool)
+	for _, d := range destroyed {
+		sizes[d.Size] = true
+	}
+	if !sizes[3] || !sizes[2] {
+		t.Errorf("expected large asteroids to split into smaller ones, destroyed sizes %v", sizes)
+	}
+	if (g.Stage() == asteroids.GameOver) != shipDestroyed {
+		t.Error("game should end exactly when the ship is destroyed")
+	}
+	if bullets := g.World.Query().With(asteroids.Bullet{}).Count(); bullets > 40/5+1 {
+		t.Errorf("bullets should expire, but %d are still around", bullets)
+	}
+}
+
+func TestGameIsDeterministic(t *testing.T) {
+	a, b := asteroids.New(7), asteroids.New(7)
+	play(a, 300)
+	play(b, 300)
+	if a.Score() != b.Score() || a.Stage() != b.Stage() {
+		t.Errorf("games with the same seed diverged: scores %d and %d", a.Score(), b.Score())
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	g := asteroids.New(2)
+	play(g, 200)
+
+	var saved bytes.Buffer
+	if err := g.Save(&saved); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := asteroids.New(3)
+	if err := loaded.Load(bytes.NewReader(saved.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loaded.Score(), g.Score(); got != want {
+		t.Errorf("bad score after loading: got %d, want %d", got, want)
+	}
+
+	var resaved bytes.Buffer
+	if err := loaded.Save(&resaved); err != nil {
+		t.Fatal(err)
+	}
+	// the next entity can differ, since entities are allocated globally
+	objects := func(save []byte) string {
+		var snap struct{ Objects json.RawMessage }
+		if err := json.Unmarshal(save, &snap); err != nil {
+			t.Fatal(err)
+		}
+		return string(snap.Objects)
+	}
+	if objects(saved.Bytes()) != objects(resaved.Bytes()) {
+		t.Error("saving a loaded game should save the same objects")
+	}
+
+	// the loaded game keeps playing from where the original left off
+	before := loaded.World.Query().With(asteroids.Asteroid{}).Count() + loaded.Score()
+	play(loaded, 100)
+	if after := loaded.World.Query().With(asteroids.Asteroid{}).Count() + loaded.Score(); after == before {
+		t.Error("loaded game should keep playing")
+	}
+}
+
+func TestGameOver(t *testing.T) {
+	g := asteroids.New(4)
+	g.Step()
+
+	// drop an asteroid on the ship
+	var ship ecs.Entity
+	for e := range g.World.Query().With(asteroids.Ship{}).All() {
+		ship = e
+	}
+	p := g.World.GetObject(ship).Component(asteroids.Position{})
+	g.World.Commands().Spawn(p, asteroids.Asteroid{Size: 3}, asteroids.Velocity{}, ecsspatial.Sensor{Radius: 18})
+	play(g, 10)
+
+	if g.Stage() != asteroids.GameOver {
+		t.Fatal("expected the game to end")
+	}
+	before := g.World.GetObject(ship).Component(asteroids.Ship{})
+	g.Step()
+	if after := g.World.GetObject(ship).Component(asteroids.Ship{}); after != before {
+		t.Error("the ship should stop once the game is over")
+	}
+}
@@ -0,0 +1,48 @@
+// Code generated by ecsgen. DO NOT EDIT.
+
+package asteroids
+
+import (
+	"reflect"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// autopilotInvoker calls autopilot without reflection.
+type autopilotInvoker func(*ecs.Commands, Ship, Position) Ship
+
+func (fn autopilotInvoker) Invoke(args, results []reflect.Value) {
+	r0 := fn(args[0].Interface().(*ecs.Commands), args[1].Interface().(Ship), args[2].Interface().(Position))
+	results[0] = reflect.ValueOf(r0)
+}
+
+// autopilotSystem returns autopilot as a system that the world calls without reflection.
+func autopilotSystem() ecs.System {
+	return ecs.System{Name: "autopilot", Func: autopilotInvoker(autopilot)}
+}
+
+// movementInvoker calls movement without reflection.
+type movementInvoker func(Position, Velocity) Position
+
+func (fn movementInvoker) Invoke(args, results []reflect.Value) {
+	r0 := fn(args[0].Interface().(Position), args[1].Interface().(Velocity))
+	results[0] = reflect.ValueOf(r0)
+}
+
+// movementSystem returns movement as a system that the world calls without reflection.
+func movementSystem() ecs.System {
+	return ecs.System{Name: "movement", Func: movementInvoker(movement)}
+}
+
+// expireInvoker calls expire without reflection.
+type expireInvoker func(*ecs.Commands, ecs.Entity, Bullet) Bullet
+
+func (fn expireInvoker) Invoke(args, results []reflect.Value) {
+	r0 := fn(args[0].Interface().(*ecs.Commands), args[1].Interface().(ecs.Entity), args[2].Interface().(Bullet))
+	results[0] = reflect.ValueOf(r0)
+}
+
+// expireSystem returns expire as a system that the world calls without reflection.
+func expireSystem() ecs.System {
+	return ecs.System{Name: "expire", Func: expireInvoker(expire)}
+}
@@ -0,0 +1,65 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Plan describes how the world finds the objects that match a query.
+type Plan struct {
+	// Components are the component types an object must have to match.
+	Components []reflect.Type
+
+	// Strategy names the method used to find matching objects.
+	Strategy string
+
+	// Scanned is the number of objects that will be examined.
+	Scanned int
+
+	// Matched is the number of objects that currently match.
+	Matched int
+}
+
+func (p Plan) String() string {
+	names := make([]string, len(p.Components))
+	for i, t := range p.Components {
+		names[i] = t.String()
+	}
+	return fmt.Sprintf("%s over %d objects for [%s], %d matched", p.Strategy, p.Scanned, strings.Join(names, ", "), p.Matched)
+}
+
+// Explain describes how the world would find the objects that have a component
+// of each of the given types, mirroring a database's EXPLAIN. Matching objects
+// are found by examining every object in the world, so the cost of a query
+// grows with the size of the world regardless of how many objects match,
+// until the query has been run often enough for the world to build a match
+// set for it; see QueryCacheConfig.
+func (w *World) Explain(components ...interface{}) Plan {
+	types := make([]reflect.Type, len(components))
+	for i, c := range components {
+		types[i] = reflect.TypeOf(c)
+	}
+
+	required := typesMask(types)
+	cached, ok := w.cache.cached(required, nil, false)
+
+	w.objectsMu.RLock()
+	defer w.objectsMu.RUnlock()
+
+	plan := Plan{
+		Components: types,
+		Strategy:   "full scan",
+		Scanned:    len(w.objects),
+	}
+	if ok {
+		plan.Strategy = "match set"
+		plan.Scanned = cached
+	}
+	for _, ob := range w.objects {
+		if ob.hasTypes(required) {
+			plan.Matched++
+		}
+	}
+	return plan
+}
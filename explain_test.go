@@ -0,0 +1,25 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestExplain(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(1), Velocity(2)))
+	world.AddObject(ecs.NewObject(Position(1)))
+	world.AddObject(ecs.NewObject(Target{}))
+
+	plan := world.Explain(Position(0), Velocity(0))
+	if got, want := plan.Scanned, 3; got != want {
+		t.Errorf("bad scanned count: got %d, want %d", got, want)
+	}
+	if got, want := plan.Matched, 1; got != want {
+		t.Errorf("bad matched count: got %d, want %d", got, want)
+	}
+	if got, want := plan.String(), "full scan over 3 objects for [ecs_test.Position, ecs_test.Velocity], 1 matched"; got != want {
+		t.Errorf("bad description:\ngot  %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package ecs
+
+import "reflect"
+
+// AddFinalizer registers a function to be run whenever an object with a
+// component of the same type as component is removed from the world. It
+// receives the removed object's entity and its instance of the component, and
+// is intended for releasing external resources, such as textures or network
+// sessions, that are tied to the component.
+//
+// Finalizers run after the object has been removed, in the order the
+// object's components were added, and then in the order they were registered.
+func (w *World) AddFinalizer(component interface{}, fn func(entity Entity, component interface{})) {
+	w.finalizersMu.Lock()
+	defer w.finalizersMu.Unlock()
+	t := reflect.TypeOf(component)
+	w.finalizers[t] = append(w.finalizers[t], fn)
+}
+
+func (w *World) finalize(ob *Object) {
+	w.finalizersMu.RLock()
+	defer w.finalizersMu.RUnlock()
+	for _, c := range ob.Components() {
+		for _, fn := range w.finalizers[reflect.TypeOf(c)] {
+			fn(ob.entity, c)
+		}
+	}
+}
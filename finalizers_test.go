@@ -0,0 +1,31 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestFinalizer(t *testing.T) {
+	type Texture struct{ ID int }
+
+	world := ecs.NewWorld()
+
+	released := make(map[int]ecs.Entity)
+	world.AddFinalizer(Texture{}, func(entity ecs.Entity, c interface{}) {
+		released[c.(Texture).ID] = entity
+	})
+
+	withTexture := world.AddObject(ecs.NewObject(Texture{ID: 7}, Position(1)))
+	withoutTexture := world.AddObject(ecs.NewObject(Position(2)))
+
+	world.RemoveObject(withoutTexture)
+	if len(released) != 0 {
+		t.Fatalf("finalizer ran for object without a texture: %v", released)
+	}
+
+	world.RemoveObject(withTexture)
+	if got, want := released[7], withTexture; got != want {
+		t.Errorf("bad finalized entity: got %v, want %v", got, want)
+	}
+}
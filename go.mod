@@ -1,3 +1,3 @@
 module github.com/dradtke/ecs-go
 
-go 1.12
+go 1.23
@@ -0,0 +1,362 @@
+package ecs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// systemState is the part of a system's runtime state that survives
+// hibernation.
+type systemState struct {
+	Name     string
+	Disabled bool
+	Ticks    uint64
+
+	// Due counts the ticks of the system's Ticker or interval, which
+	// decides the ticks it runs on with EveryNTicks.
+	Due uint64
+
+	// Failures and ResumeAt are the system's retry backoff after failed
+	// ticks.
+	Failures int32
+	ResumeAt int64
+}
+
+func (s *scheduledSystem) state() systemState {
+	return systemState{
+		Name:     s.name(),
+		Disabled: !s.enabled(),
+		Ticks:    atomic.LoadUint64(&s.ticks),
+		Due:      atomic.LoadUint64(&s.due),
+		Failures: atomic.LoadInt32(&s.retries.failures),
+		ResumeAt: atomic.LoadInt64(&s.retries.resumeAt),
+	}
+}
+
+func (s *scheduledSystem) restore(state systemState) {
+	if state.Disabled {
+		atomic.StoreInt32(&s.disabled, 1)
+	}
+	atomic.StoreUint64(&s.ticks, state.Ticks)
+	atomic.StoreUint64(&s.due, state.Due)
+	atomic.StoreInt32(&s.retries.failures, state.Failures)
+	atomic.StoreInt64(&s.retries.resumeAt, state.ResumeAt)
+}
+
+// systemStates returns the state of each of the world's systems, or an error
+// if two share a name, since Resume couldn't tell which state is whose.
+func (w *World) systemStates() ([]systemState, error) {
+	var states []systemState
+	names := make(map[string]bool)
+	for _, s := range w.systemList() {
+		if s.removed() {
+			continue
+		}
+		state := s.state()
+		if names[state.Name] {
+			return nil, fmt.Errorf("cannot hibernate with more than one system named %q", state.Name)
+		}
+		names[state.Name] = true
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// hibernatedResources encodes each of the world's resources, or returns an
+// error listing those that can't be. Services provided under an interface,
+// and the random number generator of a deterministic world, which is
+// restored from its seed, are left out.
+func (w *World) hibernatedResources() ([][]byte, error) {
+	w.resourcesMu.RLock()
+	resources := make(map[reflect.Type]reflect.Value, len(w.resources))
+	for t, v := range w.resources {
+		resources[t] = v
+	}
+	w.resourcesMu.RUnlock()
+
+	types := make([]reflect.Type, 0, len(resources))
+	for t := range resources {
+		if t.Kind() == reflect.Interface || (w.rng != nil && t == reflect.TypeOf(w.rng)) {
+			continue
+		}
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].String() < types[j].String()
+	})
+
+	var (
+		encoded [][]byte
+		failed  []string
+	)
+	for _, t := range types {
+		var buf bytes.Buffer
+		if err := encodeComponent(gob.NewEncoder(&buf), resources[t].Interface()); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s)", t, err))
+			continue
+		}
+		encoded = append(encoded, buf.Bytes())
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("cannot hibernate resources: %s", strings.Join(failed, ", "))
+	}
+	return encoded, nil
+}
+
+// worldState is the part of the world's own state, and the settings it was
+// configured with, that survives hibernation.
+type worldState struct {
+	Ticks     uint64
+	Despawns  []hibernatedDespawn
+	Temporary []Entity
+
+	StrictTypes      bool
+	UniqueComponents bool
+	RecycleEntities  bool
+	TickRate         time.Duration
+	HealthTimeout    time.Duration
+	QueryCache       QueryCacheConfig
+	Parallelism      int
+	CommandCapacity  int
+	CommandPolicy    OverflowPolicy
+
+	// Deterministic is set for deterministic worlds, along with the seed
+	// and the number of values drawn from their random number generator.
+	Deterministic bool
+	Seed          int64
+	Drawn         uint64
+}
+
+type hibernatedDespawn struct {
+	Entity    Entity
+	Recursive bool
+}
+
+// hibernationState returns the world's state to be hibernated, or an error
+// if it has deferred work that can't be saved.
+func (w *World) hibernationState() (worldState, error) {
+	if n := w.commands.Len(); n > 0 {
+		return worldState{}, fmt.Errorf("cannot hibernate with %d queued commands", n)
+	}
+	w.inboxMu.Lock()
+	sent := len(w.inbox)
+	w.inboxMu.Unlock()
+	if sent > 0 {
+		return worldState{}, fmt.Errorf("cannot hibernate with %d commands sent to the world", sent)
+	}
+	w.onceMu.Lock()
+	once := len(w.once)
+	w.onceMu.Unlock()
+	if once > 0 {
+		return worldState{}, fmt.Errorf("cannot hibernate with %d systems queued to run once", once)
+	}
+
+	state := worldState{
+		Ticks:            w.Tick(),
+		StrictTypes:      w.StrictTypes,
+		UniqueComponents: w.UniqueComponents,
+		RecycleEntities:  w.RecycleEntities,
+		TickRate:         w.TickRate,
+		HealthTimeout:    w.HealthTimeout,
+		QueryCache:       w.QueryCache,
+		Parallelism:      cap(w.slots),
+		CommandCapacity:  w.commands.capacity,
+		CommandPolicy:    w.commands.policy,
+		Deterministic:    w.deterministic,
+		Seed:             w.seed,
+	}
+	if w.source != nil {
+		state.Drawn = w.source.drawn
+	}
+	w.despawnMu.Lock()
+	for _, d := range w.despawns {
+		state.Despawns = append(state.Despawns, hibernatedDespawn{d.entity, d.recursive})
+	}
+	w.despawnMu.Unlock()
+	w.temporaryMu.Lock()
+	state.Temporary = append(state.Temporary, w.temporary...)
+	w.temporaryMu.Unlock()
+	return state, nil
+}
+
+// restoreState applies hibernated state to a newly resumed world.
+func (w *World) restoreState(state worldState) {
+	atomic.StoreUint64(&w.ticks, state.Ticks)
+	w.StrictTypes = state.StrictTypes
+	w.UniqueComponents = state.UniqueComponents
+	w.RecycleEntities = state.RecycleEntities
+	w.TickRate = state.TickRate
+	w.HealthTimeout = state.HealthTimeout
+	w.QueryCache = state.QueryCache
+	WithParallelism(state.Parallelism)(w)
+	WithCommandCapacity(state.CommandCapacity, state.CommandPolicy)(w)
+	if state.Deterministic {
+		w.Deterministic(state.Seed)
+		for w.source.drawn < state.Drawn {
+			w.source.Uint64()
+		}
+	}
+	for _, d := range state.Despawns {
+		w.queueDespawn(d.Entity, d.Recursive)
+	}
+	w.temporary = state.Temporary
+}
+
+// Hibernate writes the world's full state to a file so that it can be
+// restored with Resume, for example across a server restart. In addition to
+// every object, as with Save, this includes events that have been emitted but
+// not yet delivered, despawns and temporary objects waiting for a tick
+// boundary, the world's tick count, the world's resources, each system's
+// scheduling state, such as its tick count, whether it is enabled, and its
+// retry backoff, and the world's settings, including its options and whether
+// it is deterministic. Callbacks, such as OnError and Logger, aren't saved.
+// Event and resource types must be registered just like component types.
+//
+// Commands are code, so they can't be saved: an error is returned if any are
+// waiting in the world's command buffer, or were sent with Send or
+// RunSystemOnce, since the last tick boundary. An error is also returned if
+// two systems share a name, or if any resource can't be encoded, except for
+// services provided under an interface with Provide, which must be provided
+// again after resuming.
+//
+// The file is replaced atomically, so a crash while hibernating leaves any
+// previous file intact. The world should not be running while it hibernates.
+func (w *World) Hibernate(path string) (err error) {
+	state, err := w.hibernationState()
+	if err != nil {
+		return err
+	}
+	states, err := w.systemStates()
+	if err != nil {
+		return err
+	}
+	resources, err := w.hibernatedResources()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	enc := gob.NewEncoder(bw)
+
+	if err := w.encodeObjects(enc); err != nil {
+		return err
+	}
+
+	w.eventsMu.Lock()
+	events := w.events
+	w.eventsMu.Unlock()
+	if err := enc.Encode(len(events)); err != nil {
+		return err
+	}
+	for _, ev := range events {
+		if err := encodeComponent(enc, ev.Event); err != nil {
+			return err
+		}
+		if err := enc.Encode(ev.Priority); err != nil {
+			return err
+		}
+	}
+
+	if err := enc.Encode(states); err != nil {
+		return err
+	}
+	if err := enc.Encode(state); err != nil {
+		return err
+	}
+	if err := enc.Encode(resources); err != nil {
+		return err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Resume creates a world from a file written by Hibernate. Since systems are
+// code, they aren't stored in the file and must be added again, but any
+// system added with the same name as a hibernated one picks up where it left
+// off. Resources are restored, and can be retrieved with World.Resource; any
+// code they hold, such as the OnEnter and OnExit systems of States, must be
+// registered with them again.
+func Resume(path string) (*World, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	w := NewWorld()
+	dec := gob.NewDecoder(bufio.NewReader(f))
+
+	if err := w.decodeObjects(dec); err != nil {
+		return nil, err
+	}
+
+	var n int
+	if err := dec.Decode(&n); err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		ev, err := decodeComponent(dec)
+		if err != nil {
+			return nil, err
+		}
+		var priority int
+		if err := dec.Decode(&priority); err != nil {
+			return nil, err
+		}
+		w.events = append(w.events, queuedEvent{Event: ev, Priority: priority})
+	}
+
+	var states []systemState
+	if err := dec.Decode(&states); err != nil {
+		return nil, err
+	}
+	w.resumed = make(map[string]systemState, len(states))
+	for _, state := range states {
+		w.resumed[state.Name] = state
+	}
+
+	var state worldState
+	if err := dec.Decode(&state); err != nil {
+		return nil, err
+	}
+	w.restoreState(state)
+
+	var resources [][]byte
+	if err := dec.Decode(&resources); err != nil {
+		return nil, err
+	}
+	for _, data := range resources {
+		r, err := decodeComponent(gob.NewDecoder(bytes.NewReader(data)))
+		if err != nil {
+			return nil, err
+		}
+		w.AddResource(r)
+	}
+
+	return w, nil
+}
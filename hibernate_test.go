@@ -0,0 +1,205 @@
+package ecs_test
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Alarm struct{ Message string }
+
+func init() {
+	ecs.Register(Alarm{})
+	ecs.Register(&Score{})
+	ecs.Register(&ecs.States[string]{})
+}
+
+func TestHibernate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "world.bin")
+
+	movement := func(p Position, v Velocity) Position {
+		return Position(int(p) + int(v))
+	}
+
+	world := ecs.NewWorld()
+	player := world.AddObject(ecs.NewObject(Position(1), Velocity(2)))
+	world.AddSystem(ecs.System{Func: movement, Name: "movement"})
+	world.AddSystem(ecs.System{Func: movement, Name: "paused"})
+	world.DisableSystem("paused")
+	world.Run()
+	world.Emit(Alarm{Message: "wake up"})
+
+	if err := world.Hibernate(path); err != nil {
+		t.Fatalf("failed to hibernate: %s", err)
+	}
+
+	resumed, err := ecs.Resume(path)
+	if err != nil {
+		t.Fatalf("failed to resume: %s", err)
+	}
+	resumed.AddSystem(ecs.System{Func: movement, Name: "movement"})
+	resumed.AddSystem(ecs.System{Func: movement, Name: "paused"})
+
+	if info, _ := resumed.LookupSystem("movement"); info.Ticks != 1 || !info.Enabled {
+		t.Errorf("bad movement state: %+v", info)
+	}
+	if info, _ := resumed.LookupSystem("paused"); info.Enabled {
+		t.Error("paused system should still be disabled")
+	}
+
+	var alarms []Alarm
+	resumed.OnEvent(Alarm{}, func(ev interface{}) {
+		alarms = append(alarms, ev.(Alarm))
+	})
+	resumed.Run()
+
+	if got, want := resumed.GetObject(player).Component(Position(0)), Position(5); got != want {
+		t.Errorf("bad position: got %v, want %v", got, want)
+	}
+	if len(alarms) != 1 || alarms[0].Message != "wake up" {
+		t.Errorf("pending event not delivered after resuming: %v", alarms)
+	}
+}
+
+func TestHibernateWorldState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "world.bin")
+
+	world := ecs.NewWorld(ecs.WithCommandCapacity(2, ecs.Drop))
+	world.RecycleEntities = true
+	world.TickRate = time.Second / 30
+	world.Deterministic(3)
+	doomed := world.AddObject(ecs.NewObject(Position(0)))
+	world.Step()
+	temp := world.Commands().SpawnTemporary(Position(1))
+	world.Step()
+	world.Resource(&rand.Rand{}).(*rand.Rand).Int()
+	world.Despawn(doomed)
+
+	if err := world.Hibernate(path); err != nil {
+		t.Fatalf("failed to hibernate: %s", err)
+	}
+	resumed, err := ecs.Resume(path)
+	if err != nil {
+		t.Fatalf("failed to resume: %s", err)
+	}
+
+	if got, want := resumed.Tick(), world.Tick(); got != want {
+		t.Errorf("bad tick: got %d, want %d", got, want)
+	}
+	if !resumed.RecycleEntities || resumed.TickRate != world.TickRate {
+		t.Errorf("settings weren't restored: %v, %v", resumed.RecycleEntities, resumed.TickRate)
+	}
+	rng, _ := resumed.Resource(&rand.Rand{}).(*rand.Rand)
+	if rng == nil {
+		t.Fatal("deterministic world resumed without its random number generator")
+	}
+	if got, want := rng.Int(), world.Resource(&rand.Rand{}).(*rand.Rand).Int(); got != want {
+		t.Errorf("random number generator wasn't restored: got %d, want %d", got, want)
+	}
+	for i := 0; i < 3; i++ {
+		resumed.Commands().Spawn(Velocity(i))
+	}
+	if got := resumed.Commands().Len(); got != 2 {
+		t.Errorf("command capacity wasn't restored: %d commands queued", got)
+	}
+
+	if resumed.GetObject(temp) == nil {
+		t.Fatal("temporary object wasn't restored")
+	}
+	resumed.Step()
+	if resumed.GetObject(doomed) != nil {
+		t.Error("queued despawn wasn't applied after resuming")
+	}
+	if resumed.GetObject(temp) != nil {
+		t.Error("temporary object wasn't removed after resuming")
+	}
+}
+
+func TestHibernateRejectsCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "world.bin")
+
+	world := ecs.NewWorld()
+	world.Commands().Spawn(Position(0))
+	if err := world.Hibernate(path); err == nil {
+		t.Error("expected an error hibernating with queued commands")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("hibernation file was written: %v", err)
+	}
+}
+
+func TestHibernateSchedulingState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "world.bin")
+	retry := ecs.RetryPolicy{Backoff: time.Hour}
+
+	world := ecs.NewWorld()
+	world.Deterministic(1)
+	world.OnError = func(string, []interface{}, error) {}
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddResource(&Score{Points: 7})
+	phase := ecs.NewStates("menu")
+	world.AddResource(phase)
+	clock := ecs.NewTime()
+	clock.SetScale(2)
+	world.AddResource(clock)
+	world.AddSystem(ecs.System{Name: "flaky", Func: func(Position) error { return errFlaky }, Retry: retry})
+	world.Step()
+	phase.Set("playing")
+	world.Step()
+
+	if err := world.Hibernate(path); err != nil {
+		t.Fatalf("failed to hibernate: %s", err)
+	}
+	resumed, err := ecs.Resume(path)
+	if err != nil {
+		t.Fatalf("failed to resume: %s", err)
+	}
+
+	calls := 0
+	resumed.AddSystem(ecs.System{Name: "flaky", Func: func(Position) error {
+		calls++
+		return nil
+	}, Retry: retry})
+	resumed.Step()
+	if calls != 0 {
+		t.Errorf("system ticked %d times while it should have been backing off", calls)
+	}
+
+	if score, _ := resumed.Resource(&Score{}).(*Score); score == nil || score.Points != 7 {
+		t.Errorf("score resource wasn't restored: %v", score)
+	}
+	if states, _ := resumed.Resource(&ecs.States[string]{}).(*ecs.States[string]); states == nil || states.Current() != "playing" {
+		t.Errorf("states resource wasn't restored: %v", states)
+	}
+	if clock, _ := resumed.Resource(&ecs.Time{}).(*ecs.Time); clock == nil || clock.Scale() != 2 {
+		t.Errorf("time resource wasn't restored: %v", clock)
+	}
+}
+
+func TestHibernateRejectsUnsavedState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "world.bin")
+
+	world := ecs.NewWorld()
+	world.AddSystem(ecs.System{Name: "twin", Func: func(Position) {}})
+	world.AddSystem(ecs.System{Name: "twin", Func: func(Velocity) {}})
+	if err := world.Hibernate(path); err == nil {
+		t.Error("expected an error hibernating with systems sharing a name")
+	}
+
+	world = ecs.NewWorld()
+	world.AddResource(make(chan int))
+	if err := world.Hibernate(path); err == nil || !strings.Contains(err.Error(), "chan int") {
+		t.Errorf("expected an error naming the resource that can't be saved, got %v", err)
+	}
+}
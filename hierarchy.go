@@ -0,0 +1,22 @@
+package ecs
+
+// Parent is a component that places an object beneath another one in a
+// hierarchy, such as a turret mounted on a tank.
+type Parent struct {
+	Entity Entity
+}
+
+// Children returns the entities of every object whose Parent component refers
+// to the given entity.
+func (w *World) Children(parent Entity) []Entity {
+	w.objectsMu.RLock()
+	defer w.objectsMu.RUnlock()
+
+	var children []Entity
+	for _, ob := range w.objects {
+		if p, ok := ob.Component(Parent{}).(Parent); ok && p.Entity == parent {
+			children = append(children, ob.entity)
+		}
+	}
+	return children
+}
@@ -0,0 +1,86 @@
+package ecs
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// hookKey identifies the hooks for one kind of change to one component type.
+type hookKey struct {
+	kind ChangeKind
+	t    reflect.Type
+}
+
+// OnAdd registers a function to be run whenever a component of the same type
+// as component is added to an object in the world, including when an object
+// with one is added to the world. It receives the object's entity and the
+// new component, and is suited to keeping external structures, such as a
+// spatial index, in step with the world.
+//
+// Hooks are run synchronously from whichever goroutine made the change,
+// after the change has been made, in the order they were registered. They
+// should return quickly, and must not change components of the same type,
+// since the system that made the change may still hold its lock.
+func (w *World) OnAdd(component interface{}, fn func(entity Entity, component interface{})) {
+	w.addHook(ComponentAdded, component, fn)
+}
+
+// OnRemove registers a function to be run whenever a component of the same
+// type as component is removed from an object in the world, including when
+// an object with one is removed or evicted from the world. It receives the
+// object's entity and the removed component, and is suited to releasing
+// resources tied to the component, such as a sprite's texture. Unlike a
+// finalizer, it also runs when only the component is removed; see
+// AddFinalizer.
+//
+// See OnAdd for when hooks are run.
+func (w *World) OnRemove(component interface{}, fn func(entity Entity, component interface{})) {
+	w.addHook(ComponentRemoved, component, fn)
+}
+
+// OnChange registers a function to be run whenever an object's component of
+// the same type as component is replaced, either by a system's return value
+// or by SetComponent. It receives the object's entity and the new component.
+// Changes made through a Column are not reported.
+//
+// See OnAdd for when hooks are run.
+func (w *World) OnChange(component interface{}, fn func(entity Entity, component interface{})) {
+	w.addHook(ComponentChanged, component, fn)
+}
+
+func (w *World) addHook(kind ChangeKind, component interface{}, fn func(Entity, interface{})) {
+	key := hookKey{kind, reflect.TypeOf(component)}
+	w.hooksMu.Lock()
+	w.hooks[key] = append(w.hooks[key], fn)
+	w.hooksMu.Unlock()
+
+	// changes are only reported while something is watching
+	atomic.AddInt32(&w.watching, 1)
+}
+
+// runHooks runs the hooks registered for a change.
+func (w *World) runHooks(change Change) {
+	if change.Kind == QueryMatched {
+		return
+	}
+	w.hooksMu.RLock()
+	hooks := w.hooks[hookKey{change.Kind, reflect.TypeOf(change.Component)}]
+	w.hooksMu.RUnlock()
+	for _, fn := range hooks {
+		fn(change.Entity, change.Component)
+	}
+}
+
+// hookObject runs the hooks of the given kind for every component of an
+// object that has been added to or removed from the world.
+func (w *World) hookObject(ob *Object, kind ChangeKind) {
+	w.hooksMu.RLock()
+	empty := len(w.hooks) == 0
+	w.hooksMu.RUnlock()
+	if empty {
+		return
+	}
+	for _, c := range ob.Components() {
+		w.runHooks(Change{Entity: ob.entity, Kind: kind, Component: c})
+	}
+}
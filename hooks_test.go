@@ -0,0 +1,61 @@
+package ecs_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestHooks(t *testing.T) {
+	world := ecs.NewWorld()
+	var events []string
+	record := func(kind string) func(ecs.Entity, interface{}) {
+		return func(e ecs.Entity, c interface{}) {
+			events = append(events, kind+":"+fmt.Sprint(c))
+		}
+	}
+	world.OnAdd(Position(0), record("add"))
+	world.OnRemove(Position(0), record("remove"))
+	world.OnChange(Position(0), record("change"))
+	world.OnAdd(Velocity(0), record("add velocity"))
+
+	ob := ecs.NewObject(Position(1))
+	world.AddObject(ob)
+	world.AddSystem(ecs.System{Func: func(p Position) Position { return p + 1 }})
+	world.Step()
+	ob.SetComponent(Position(5))
+	ob.RemoveComponent(Position(0))
+	ob.AddComponent(Position(7))
+	world.RemoveObject(ob.Entity())
+
+	want := []string{"add:1", "change:2", "change:5", "remove:5", "add:7", "remove:7"}
+	if len(events) != len(want) {
+		t.Fatalf("bad events: got %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("bad events: got %v, want %v", events, want)
+		}
+	}
+}
+
+func TestHooksDense(t *testing.T) {
+	world := ecs.NewWorld()
+	ecs.StoreDense[Position](world)
+	index := make(map[ecs.Entity]Position)
+	world.OnAdd(Position(0), func(e ecs.Entity, c interface{}) { index[e] = c.(Position) })
+	world.OnChange(Position(0), func(e ecs.Entity, c interface{}) { index[e] = c.(Position) })
+	world.OnRemove(Position(0), func(e ecs.Entity, _ interface{}) { delete(index, e) })
+
+	entities := world.SpawnBatch(3, func(i int) []interface{} {
+		return []interface{}{Position(i)}
+	})
+	world.GetObject(entities[1]).SetComponent(Position(10))
+	world.Despawn(entities[0])
+	world.Step()
+
+	if len(index) != 2 || index[entities[1]] != 10 || index[entities[2]] != 2 {
+		t.Errorf("bad index: %v", index)
+	}
+}
@@ -0,0 +1,136 @@
+package ecs_test
+
+import (
+	"context"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Drawable interface {
+	Draw() string
+}
+
+type (
+	Circle struct{}
+	Square struct{}
+)
+
+func (Circle) Draw() string { return "circle" }
+func (Square) Draw() string { return "square" }
+
+func interfaceWorld(strict bool) *ecs.World {
+	world := ecs.NewWorld()
+	world.StrictTypes = strict
+	ecs.StoreDense[Square](world)
+	world.AddObject(ecs.NewObject(Position(0), Circle{}))
+	world.AddObject(ecs.NewObject(Position(1), Square{}))
+	world.AddObject(ecs.NewObject(Position(2)))
+	return world
+}
+
+func TestInterfaceSystem(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		world := interfaceWorld(strict)
+		var drawn []string
+		world.AddSystem(ecs.System{Func: func(d Drawable, _ Position) {
+			drawn = append(drawn, d.Draw())
+		}})
+		world.Step()
+		if len(drawn) != 2 || drawn[0] != "circle" || drawn[1] != "square" {
+			t.Errorf("strict %t: bad drawn: %v", strict, drawn)
+		}
+	}
+}
+
+func TestInterfaceQuery(t *testing.T) {
+	world := interfaceWorld(false)
+	if got := world.Query().With((*Drawable)(nil)).Count(); got != 2 {
+		t.Errorf("bad drawable count: got %d, want 2", got)
+	}
+	if got := world.Query().With(Position(0)).Without((*Drawable)(nil)).Count(); got != 1 {
+		t.Errorf("bad undrawable count: got %d, want 1", got)
+	}
+
+	var drawn []string
+	err := world.Query().With((*Drawable)(nil)).ForEach(func(d Drawable) {
+		drawn = append(drawn, d.Draw())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drawn) != 2 {
+		t.Errorf("bad drawn: %v", drawn)
+	}
+}
+
+func TestInterfaceIterators(t *testing.T) {
+	world := interfaceWorld(false)
+
+	var seq, fn []string
+	world.AddSystem(ecs.System{Global: true, Func: func(all iter.Seq2[ecs.Entity, Drawable], next func(int) (Drawable, int, bool)) {
+		for _, d := range all {
+			seq = append(seq, d.Draw())
+		}
+		for i := 0; ; i++ {
+			d, j, ok := next(i)
+			if !ok {
+				break
+			}
+			fn = append(fn, d.Draw())
+			i = j
+		}
+	}})
+	world.Step()
+	if len(seq) != 2 || len(fn) != 2 {
+		t.Errorf("bad iteration: seq %v, func %v", seq, fn)
+	}
+	for e, d := range ecs.Each[Drawable](world) {
+		if d == nil || e == 0 {
+			t.Errorf("bad Each result: %d, %v", e, d)
+		}
+	}
+}
+
+type Meter interface {
+	Reading() int
+}
+
+// Gauge's copies share their levels, so only the component locks keep a
+// system changing them from racing with others reading them.
+type Gauge struct{ Levels []int }
+
+func (g Gauge) Reading() int { return g.Levels[0] }
+
+func TestInterfaceLocks(t *testing.T) {
+	world := ecs.NewWorld()
+	for i := 0; i < 10; i++ {
+		world.AddObject(ecs.NewObject(Gauge{Levels: make([]int, 1)}))
+	}
+
+	ticker := func() <-chan time.Time {
+		tk := time.NewTicker(time.Millisecond)
+		t.Cleanup(tk.Stop)
+		return tk.C
+	}
+	var total int
+	world.AddSystem(ecs.System{Func: func(m Meter) { total += m.Reading() }, Ticker: ticker()})
+	world.AddSystem(ecs.System{Func: func(g Gauge) Gauge {
+		g.Levels[0]++
+		return g
+	}, Ticker: ticker()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	queried := make(chan struct{})
+	go func() {
+		defer close(queried)
+		for ctx.Err() == nil {
+			world.Query().ForEach(func(m Meter) { _ = m.Reading() })
+		}
+	}()
+	world.RunContext(ctx)
+	<-queried
+}
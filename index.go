@@ -0,0 +1,51 @@
+package ecs
+
+import "reflect"
+
+// indexedType reports whether the world keeps an index of components of type
+// t, which must be updated whenever one is added, replaced, or removed.
+func indexedType(t reflect.Type) bool {
+	return t == nameType || t == tagsType || t == relationsType
+}
+
+// index updates the world's indexes for an object that has been added to or
+// removed from the world, if it has any indexed components.
+func (w *World) index(ob *Object) {
+	m := ob.componentMask()
+	if m.has(nameID) {
+		w.indexName(ob)
+	}
+	if m.has(tagsID) {
+		w.indexTags(ob)
+	}
+	if m.has(relationsID) {
+		w.indexRelations(ob)
+	}
+}
+
+// reindex updates the world's indexes after one of the object's indexed
+// components may have changed.
+func (ob *Object) reindex() {
+	if ob.world != nil {
+		ob.world.indexName(ob)
+		ob.world.indexTags(ob)
+		ob.world.indexRelations(ob)
+	}
+}
+
+// reindexAll rebuilds the world's indexes from scratch, such as after its
+// objects have been replaced.
+func (w *World) reindexAll() {
+	w.names.mu.Lock()
+	w.names.objects, w.names.names = nil, nil
+	w.names.mu.Unlock()
+	w.tags.mu.Lock()
+	w.tags.objects, w.tags.tags = nil, nil
+	w.tags.mu.Unlock()
+	w.relations.mu.Lock()
+	w.relations.objects, w.relations.relations = nil, nil
+	w.relations.mu.Unlock()
+	for _, ob := range w.Objects() {
+		w.index(ob)
+	}
+}
@@ -0,0 +1,78 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var injectables = make(map[reflect.Type]bool)
+
+// RegisterInjectable declares an interface as one that systems receive from
+// the world, such as a database handle or an asset manager, rather than from
+// the objects they visit. iface is a nil pointer to the interface:
+//
+//     ecs.RegisterInjectable((*Store)(nil))
+//
+//     world.Provide(postgres, (*Store)(nil))
+//     world.AddSystem(ecs.System{Func: func(db Store, p Player) { ... }})
+//
+// A system parameter of an injectable type is never matched against
+// components, and AddSystem fails unless the world already provides it, so
+// a forgotten Provide is caught up front rather than leaving the system to
+// silently match nothing. Interfaces passed to Provide are registered
+// automatically.
+func RegisterInjectable(iface interface{}) {
+	t, err := interfaceType(iface)
+	if err != nil {
+		panic("ecs: " + err.Error())
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	injectables[t] = true
+}
+
+func injectable(t reflect.Type) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return injectables[t]
+}
+
+// interfaceType returns the interface that iface is a nil pointer to.
+func interfaceType(iface interface{}) (reflect.Type, error) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return nil, fmt.Errorf("%T is not a nil pointer to an interface", iface)
+	}
+	return t.Elem(), nil
+}
+
+// Provide adds a service to the world that systems can accept as a parameter
+// of any of the given interfaces, each a nil pointer to an interface that
+// the service implements, which are registered as injectable. Without any
+// interfaces, the service is added as a resource of its own type, as with
+// AddResource. Providing another service for the same interface replaces
+// the first.
+func (w *World) Provide(service interface{}, as ...interface{}) error {
+	if len(as) == 0 {
+		w.AddResource(service)
+		return nil
+	}
+	types := make([]reflect.Type, len(as))
+	for i, iface := range as {
+		t, err := interfaceType(iface)
+		if err != nil {
+			return err
+		}
+		if !reflect.TypeOf(service).Implements(t) {
+			return fmt.Errorf("%T does not implement %s", service, t)
+		}
+		types[i] = t
+	}
+	for _, t := range types {
+		RegisterInjectable(reflect.New(t).Interface())
+		w.resourcesMu.Lock()
+		w.resources[t] = reflect.ValueOf(service).Convert(t)
+		w.resourcesMu.Unlock()
+	}
+	return nil
+}
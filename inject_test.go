@@ -0,0 +1,59 @@
+package ecs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Store interface {
+	Save(key string, value int)
+}
+
+type Clock interface {
+	Now() int
+}
+
+type memoryStore map[string]int
+
+func (s memoryStore) Save(key string, value int) { s[key] = value }
+
+func init() {
+	ecs.RegisterInjectable((*Clock)(nil))
+}
+
+func TestProvide(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Player{}, Position(7)))
+
+	store := memoryStore{}
+	if err := world.Provide(store, (*Store)(nil)); err != nil {
+		t.Fatal(err)
+	}
+	err := world.AddSystem(ecs.System{Func: func(db Store, e ecs.Entity, p Position, _ Player) {
+		db.Save("position", int(p))
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	world.Step()
+	if store["position"] != 7 {
+		t.Errorf("store = %v, want position 7", store)
+	}
+
+	if err := world.Provide(store, (*Clock)(nil)); err == nil {
+		t.Error("expected an error providing a service for an interface it doesn't implement")
+	}
+	if err := world.Provide(store, store); err == nil {
+		t.Error("expected an error providing a service as a non-interface")
+	}
+}
+
+func TestProvideMissing(t *testing.T) {
+	world := ecs.NewWorld()
+	err := world.AddSystem(ecs.System{Func: func(c Clock, p Position) {}})
+	if err == nil || !strings.Contains(err.Error(), "nothing provides") {
+		t.Errorf("expected an error for an unprovided service, got %v", err)
+	}
+}
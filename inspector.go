@@ -0,0 +1,304 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InspectorHandler returns an HTTP handler for inspecting and debugging a
+// running world. It should only be served on trusted networks, since it
+// allows anyone who can reach it to change the world:
+//
+//     GET    /entities                             list objects, a page at a time
+//     GET    /entities/{entity}                    show one object
+//     DELETE /entities/{entity}                    despawn an object
+//     PUT    /entities/{entity}/components/{type}  set a component from JSON
+//     DELETE /entities/{entity}/components/{type}  remove a component
+//     GET    /systems                              list every system
+//     POST   /systems/{name}/enable                enable a system
+//     POST   /systems/{name}/disable               disable a system
+//     GET    /scheduler                            show scheduler state
+//
+// Objects are listed in pages of up to 100, or the number given by the limit
+// parameter, in order of entity. Each page includes a next cursor, to be
+// passed as the after parameter to fetch the following page, unless it is the
+// last. The with and without parameters filter objects by comma-separated
+// component names.
+//
+// Components are identified by their registered names, and only registered
+// components can be set. Changes are queued in the world's command buffer,
+// so they are applied at the next tick boundary rather than immediately.
+func InspectorHandler(w *World) http.Handler {
+	in := inspector{w}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /entities", in.listEntities)
+	mux.HandleFunc("GET /entities/{entity}", in.getEntity)
+	mux.HandleFunc("DELETE /entities/{entity}", in.despawn)
+	mux.HandleFunc("PUT /entities/{entity}/components/{type}", in.setComponent)
+	mux.HandleFunc("DELETE /entities/{entity}/components/{type}", in.removeComponent)
+	mux.HandleFunc("GET /systems", in.listSystems)
+	mux.HandleFunc("POST /systems/{name}/enable", in.enableSystem)
+	mux.HandleFunc("POST /systems/{name}/disable", in.disableSystem)
+	mux.HandleFunc("GET /scheduler", in.scheduler)
+	return mux
+}
+
+type inspector struct {
+	w *World
+}
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+type inspectedPage struct {
+	Entities []jsonObject `json:"entities"`
+	Next     string       `json:"next,omitempty"`
+}
+
+type inspectedSystem struct {
+	Name     string        `json:"name"`
+	Enabled  bool          `json:"enabled"`
+	Global   bool          `json:"global"`
+	Parallel bool          `json:"parallel"`
+	LastTick time.Duration `json:"last_tick_ns"`
+	Ticks    uint64        `json:"ticks"`
+	Reads    []string      `json:"reads"`
+	Writes   []string      `json:"writes"`
+}
+
+type inspectedScheduler struct {
+	Objects         int `json:"objects"`
+	Systems         int `json:"systems"`
+	EnabledSystems  int `json:"enabled_systems"`
+	PendingCommands int `json:"pending_commands"`
+}
+
+func writeJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// typeName returns the registered name of t, falling back to its Go name for
+// display purposes.
+func typeName(t reflect.Type) string {
+	if name, err := registeredName(t); err == nil {
+		return name
+	}
+	return t.String()
+}
+
+// inspect renders an object as JSON. Each component is read under its type's
+// lock, so that systems writing to it can't race with the encoding.
+func (in inspector) inspect(ob *Object) (jsonObject, error) {
+	components := ob.Components()
+	o := jsonObject{Entity: ob.entity, Components: make([]jsonComponent, len(components))}
+	for i, c := range components {
+		t := reflect.TypeOf(c)
+		l := in.w.componentLock(t)
+		l.RLock()
+		value, err := json.Marshal(c)
+		l.RUnlock()
+		if err != nil {
+			return o, fmt.Errorf("encoding %s: %w", t, err)
+		}
+		o.Components[i] = jsonComponent{Type: typeName(t), Value: value}
+	}
+	return o, nil
+}
+
+// entity parses the entity in the request path, writing an error and
+// returning false if it isn't valid.
+func (in inspector) entity(rw http.ResponseWriter, r *http.Request) (Entity, bool) {
+	id, err := strconv.ParseUint(r.PathValue("entity"), 10, 64)
+	if err != nil {
+		http.Error(rw, "invalid entity", http.StatusBadRequest)
+		return 0, false
+	}
+	return Entity(id), true
+}
+
+func (in inspector) listEntities(rw http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	var after Entity
+	if s := params.Get("after"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			http.Error(rw, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		after = Entity(id)
+	}
+	limit := defaultPageSize
+	if s := params.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(rw, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	q := in.w.Query()
+	for param, add := range map[string]func(...interface{}) *Query{"with": q.With, "without": q.Without} {
+		if s := params.Get(param); s != "" {
+			for _, name := range strings.Split(s, ",") {
+				t, err := registeredType(name)
+				if err != nil {
+					http.Error(rw, err.Error(), http.StatusBadRequest)
+					return
+				}
+				add(reflect.Zero(t).Interface())
+			}
+		}
+	}
+
+	objects, more := q.Page(after, limit)
+	page := inspectedPage{Entities: make([]jsonObject, len(objects))}
+	for i, ob := range objects {
+		o, err := in.inspect(ob)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		page.Entities[i] = o
+	}
+	if more {
+		page.Next = strconv.FormatUint(uint64(objects[len(objects)-1].entity), 10)
+	}
+	writeJSON(rw, page)
+}
+
+func (in inspector) getEntity(rw http.ResponseWriter, r *http.Request) {
+	entity, ok := in.entity(rw, r)
+	if !ok {
+		return
+	}
+	ob := in.w.GetObject(entity)
+	if ob == nil {
+		http.NotFound(rw, r)
+		return
+	}
+	o, err := in.inspect(ob)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(rw, o)
+}
+
+func (in inspector) despawn(rw http.ResponseWriter, r *http.Request) {
+	entity, ok := in.entity(rw, r)
+	if !ok {
+		return
+	}
+	if in.w.GetObject(entity) == nil {
+		http.NotFound(rw, r)
+		return
+	}
+	in.w.commands.Despawn(entity)
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (in inspector) setComponent(rw http.ResponseWriter, r *http.Request) {
+	entity, ok := in.entity(rw, r)
+	if !ok {
+		return
+	}
+	t, err := registeredType(r.PathValue("type"))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if in.w.GetObject(entity) == nil {
+		http.NotFound(rw, r)
+		return
+	}
+	v := reflect.New(t)
+	if err := json.NewDecoder(r.Body).Decode(v.Interface()); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	in.w.commands.SetComponent(entity, v.Elem().Interface())
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (in inspector) removeComponent(rw http.ResponseWriter, r *http.Request) {
+	entity, ok := in.entity(rw, r)
+	if !ok {
+		return
+	}
+	t, err := registeredType(r.PathValue("type"))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if in.w.GetObject(entity) == nil {
+		http.NotFound(rw, r)
+		return
+	}
+	in.w.commands.RemoveComponent(entity, reflect.Zero(t).Interface())
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (in inspector) listSystems(rw http.ResponseWriter, r *http.Request) {
+	infos := in.w.Systems()
+	out := make([]inspectedSystem, len(infos))
+	for i, info := range infos {
+		out[i] = inspectedSystem{
+			Name:     info.Name,
+			Enabled:  info.Enabled,
+			Global:   info.Global,
+			Parallel: info.Parallel,
+			LastTick: info.LastTick,
+			Ticks:    info.Ticks,
+			Reads:    make([]string, len(info.Reads)),
+			Writes:   make([]string, len(info.Writes)),
+		}
+		for j, t := range info.Reads {
+			out[i].Reads[j] = typeName(t)
+		}
+		for j, t := range info.Writes {
+			out[i].Writes[j] = typeName(t)
+		}
+	}
+	writeJSON(rw, out)
+}
+
+func (in inspector) enableSystem(rw http.ResponseWriter, r *http.Request) {
+	in.setSystemEnabled(rw, r, true)
+}
+
+func (in inspector) disableSystem(rw http.ResponseWriter, r *http.Request) {
+	in.setSystemEnabled(rw, r, false)
+}
+
+func (in inspector) setSystemEnabled(rw http.ResponseWriter, r *http.Request, enabled bool) {
+	if !in.w.setSystemEnabled(r.PathValue("name"), enabled) {
+		http.NotFound(rw, r)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (in inspector) scheduler(rw http.ResponseWriter, r *http.Request) {
+	state := inspectedScheduler{
+		Objects:         len(in.w.Objects()),
+		PendingCommands: in.w.commands.Len(),
+	}
+	for _, info := range in.w.Systems() {
+		state.Systems++
+		if info.Enabled {
+			state.EnabledSystems++
+		}
+	}
+	writeJSON(rw, state)
+}
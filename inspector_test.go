@@ -0,0 +1,105 @@
+package ecs_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestInspector(t *testing.T) {
+	world := ecs.NewWorld()
+	ob := ecs.NewObject(Position(1), Velocity(2))
+	world.AddObject(ob)
+	world.AddSystem(ecs.System{Name: "movement", Func: func(p Position, v Velocity) Position { return p + Position(v) }})
+
+	server := httptest.NewServer(ecs.InspectorHandler(world))
+	defer server.Close()
+
+	do := func(method, path, body string, wantStatus int) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != wantStatus {
+			t.Errorf("%s %s: got status %d, want %d", method, path, resp.StatusCode, wantStatus)
+		}
+		return resp
+	}
+
+	entity := fmt.Sprintf("/entities/%d", ob.Entity())
+	resp := do("GET", entity, "", http.StatusOK)
+	var got struct {
+		Components []struct {
+			Type  string
+			Value json.RawMessage
+		}
+	}
+	json.NewDecoder(resp.Body).Decode(&got)
+	resp.Body.Close()
+	if len(got.Components) != 2 || got.Components[0].Type != "ecs_test.Position" || string(got.Components[0].Value) != "1" {
+		t.Errorf("bad entity: %+v", got)
+	}
+
+	do("GET", "/entities/12345678", "", http.StatusNotFound).Body.Close()
+	do("PUT", entity+"/components/ecs_test.Position", "10", http.StatusAccepted).Body.Close()
+	do("PUT", entity+"/components/nope", "10", http.StatusBadRequest).Body.Close()
+	do("POST", "/systems/movement/disable", "", http.StatusNoContent).Body.Close()
+
+	world.Run()
+	if got, want := ob.Component(Position(0)), Position(10); got != want {
+		t.Errorf("component should have been set without moving: got %v, want %v", got, want)
+	}
+
+	do("DELETE", entity, "", http.StatusAccepted).Body.Close()
+	world.Run()
+	if world.GetObject(ob.Entity()) != nil {
+		t.Error("object should have been despawned")
+	}
+
+	for i := 0; i < 3; i++ {
+		world.AddObject(ecs.NewObject(Position(i)))
+	}
+	world.AddObject(ecs.NewObject(Velocity(0)))
+	var pages, entities int
+	for path := "/entities?limit=2&with=ecs_test.Position"; ; {
+		resp := do("GET", path, "", http.StatusOK)
+		var page struct {
+			Entities []json.RawMessage
+			Next     string
+		}
+		json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		pages++
+		entities += len(page.Entities)
+		if page.Next == "" {
+			break
+		}
+		path = "/entities?limit=2&with=ecs_test.Position&after=" + page.Next
+	}
+	if pages != 2 || entities != 3 {
+		t.Errorf("expected 3 entities over 2 pages, got %d over %d", entities, pages)
+	}
+	do("GET", "/entities?limit=0", "", http.StatusBadRequest).Body.Close()
+
+	resp = do("GET", "/systems", "", http.StatusOK)
+	var systems []struct {
+		Name    string
+		Enabled bool
+		Reads   []string
+	}
+	json.NewDecoder(resp.Body).Decode(&systems)
+	resp.Body.Close()
+	if len(systems) != 1 || systems[0].Name != "movement" || systems[0].Enabled || len(systems[0].Reads) != 2 {
+		t.Errorf("bad systems: %+v", systems)
+	}
+}
@@ -0,0 +1,79 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	interpolatorsMu sync.RWMutex
+	interpolators   = make(map[reflect.Type]func(a, b interface{}, t float64) interface{})
+	float64Type     = reflect.TypeOf(float64(0))
+)
+
+// RegisterInterpolator registers a function used by Interpolate to blend
+// between two values of the component type T, returning a when t is 0 and b
+// when t is 1. This lets features like tweening, render interpolation, and
+// network smoothing work with any component type.
+func RegisterInterpolator[T any](fn func(a, b T, t float64) T) {
+	ct := reflect.TypeOf((*T)(nil)).Elem()
+
+	interpolatorsMu.Lock()
+	defer interpolatorsMu.Unlock()
+	interpolators[ct] = func(a, b interface{}, t float64) interface{} {
+		return fn(a.(T), b.(T), t)
+	}
+}
+
+// Interpolate blends between two components of the same type, returning a
+// when t is 0 and b when t is 1. It uses, in order of preference, a function
+// registered with RegisterInterpolator, a method of the form
+//
+//     func (a T) Lerp(b T, t float64) T
+//
+// or linear interpolation if T is a numeric type. Integers are rounded
+// towards zero. An error is returned if either value is nil or their types
+// differ.
+func Interpolate(a, b interface{}, t float64) (interface{}, error) {
+	if a == nil || b == nil {
+		return nil, errors.New("cannot interpolate a nil value")
+	}
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	ct := av.Type()
+	if bv.Type() != ct {
+		return nil, fmt.Errorf("cannot interpolate between %s and %s", ct, bv.Type())
+	}
+	tv := reflect.ValueOf(t)
+
+	interpolatorsMu.RLock()
+	f, ok := interpolators[ct]
+	interpolatorsMu.RUnlock()
+	if ok {
+		return f(a, b, t), nil
+	}
+
+	if m, ok := ct.MethodByName("Lerp"); ok {
+		mt := m.Type
+		if mt.NumIn() == 3 && mt.In(1) == ct && mt.In(2) == float64Type && mt.NumOut() == 1 && mt.Out(0) == ct {
+			return m.Func.Call([]reflect.Value{av, bv, tv})[0].Interface(), nil
+		}
+	}
+
+	result := reflect.New(ct).Elem()
+	switch ct.Kind() {
+	case reflect.Float32, reflect.Float64:
+		af, bf := av.Float(), bv.Float()
+		result.SetFloat(af + (bf-af)*t)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ai, bi := float64(av.Int()), float64(bv.Int())
+		result.SetInt(int64(ai + (bi-ai)*t))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		au, bu := float64(av.Uint()), float64(bv.Uint())
+		result.SetUint(uint64(au + (bu-au)*t))
+	default:
+		return nil, fmt.Errorf("no interpolator registered for %s", ct)
+	}
+	return result.Interface(), nil
+}
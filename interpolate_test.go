@@ -0,0 +1,51 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+)
+
+func TestInterpolate(t *testing.T) {
+	type (
+		Color struct{ R, G, B uint8 }
+		Point struct{ ecsmath.Vec2 }
+	)
+
+	ecs.RegisterInterpolator(func(a, b Color, t float64) Color {
+		if t < 0.5 {
+			return a
+		}
+		return b
+	})
+
+	tests := []struct {
+		a, b, want interface{}
+	}{
+		{Position(0), Position(10), Position(5)},
+		{Color{R: 1}, Color{G: 1}, Color{G: 1}},
+		{ecsmath.Vec2{}, ecsmath.Vec2{X: 2, Y: 4}, ecsmath.Vec2{X: 1, Y: 2}},
+	}
+	for _, test := range tests {
+		got, err := ecs.Interpolate(test.a, test.b, 0.5)
+		if err != nil {
+			t.Errorf("failed to interpolate %T: %s", test.a, err)
+		} else if got != test.want {
+			t.Errorf("bad interpolation for %T: got %v, want %v", test.a, got, test.want)
+		}
+	}
+
+	if _, err := ecs.Interpolate(Point{}, Point{}, 0.5); err == nil {
+		t.Error("expected an error interpolating a type without an interpolator")
+	}
+	if _, err := ecs.Interpolate(nil, Position(1), 0.5); err == nil {
+		t.Error("expected an error interpolating a nil value")
+	}
+	if _, err := ecs.Interpolate(Position(0), nil, 0.5); err == nil {
+		t.Error("expected an error interpolating a nil value")
+	}
+	if _, err := ecs.Interpolate(Position(0), Color{}, 0.5); err == nil {
+		t.Error("expected an error interpolating mismatched types")
+	}
+}
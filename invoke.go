@@ -0,0 +1,98 @@
+package ecs
+
+import "reflect"
+
+// An Invoker is a system function that can call itself without reflection.
+// The world calls Invoke with the function's arguments, rather than going
+// through reflect.Value.Call, which is considerably slower and allocates on
+// every call. Invoke must store each of the function's results in results,
+// using ErrorResult for a trailing error.
+//
+// The typed system functions, such as SystemFunc2, are Invokers, and the
+// ecsgen command generates Invokers for functions with any signature.
+type Invoker interface {
+	Invoke(args, results []reflect.Value)
+}
+
+var nilError = reflect.Zero(errorType)
+
+// ErrorResult returns err as a reflect.Value of type error, for an Invoker
+// to store as its function's last result.
+func ErrorResult(err error) reflect.Value {
+	if err == nil {
+		return nilError
+	}
+	return reflect.ValueOf(&err).Elem()
+}
+
+// directlyCallable reports whether an Invoker of type t can be used in place
+// of reflection. Interface results other than a trailing error are left to
+// reflection, which preserves their static type.
+func directlyCallable(t reflect.Type) bool {
+	for i := 0; i < t.NumOut(); i++ {
+		if out := t.Out(i); out.Kind() == reflect.Interface && (out != errorType || i != t.NumOut()-1) {
+			return false
+		}
+	}
+	return true
+}
+
+// SystemFunc1 is a system function that updates one component. Converting a
+// function to a SystemFunc, as in
+//
+//     world.AddSystem(ecs.System{Func: ecs.SystemFunc2[Position, Velocity](movement)})
+//
+// lets the world call it directly on every object rather than through
+// reflection, which makes ticking large numbers of objects much cheaper.
+// Parameters may be anything a system can otherwise accept, such as an
+// Entity, *World, or resource, and are matched just the same.
+type SystemFunc1[A any] func(A) A
+
+// SystemFunc2 is a system function that updates its first component based on
+// another.
+type SystemFunc2[A, B any] func(A, B) A
+
+// SystemFunc3 is a system function that updates its first component based on
+// two others.
+type SystemFunc3[A, B, C any] func(A, B, C) A
+
+// SystemFunc4 is a system function that updates its first component based on
+// three others.
+type SystemFunc4[A, B, C, D any] func(A, B, C, D) A
+
+// ReadFunc1 is a system function that reads one component.
+type ReadFunc1[A any] func(A)
+
+// ReadFunc2 is a system function that reads two components.
+type ReadFunc2[A, B any] func(A, B)
+
+// ReadFunc3 is a system function that reads three components.
+type ReadFunc3[A, B, C any] func(A, B, C)
+
+func (fn SystemFunc1[A]) Invoke(args, results []reflect.Value) {
+	results[0] = reflect.ValueOf(fn(args[0].Interface().(A)))
+}
+
+func (fn SystemFunc2[A, B]) Invoke(args, results []reflect.Value) {
+	results[0] = reflect.ValueOf(fn(args[0].Interface().(A), args[1].Interface().(B)))
+}
+
+func (fn SystemFunc3[A, B, C]) Invoke(args, results []reflect.Value) {
+	results[0] = reflect.ValueOf(fn(args[0].Interface().(A), args[1].Interface().(B), args[2].Interface().(C)))
+}
+
+func (fn SystemFunc4[A, B, C, D]) Invoke(args, results []reflect.Value) {
+	results[0] = reflect.ValueOf(fn(args[0].Interface().(A), args[1].Interface().(B), args[2].Interface().(C), args[3].Interface().(D)))
+}
+
+func (fn ReadFunc1[A]) Invoke(args, results []reflect.Value) {
+	fn(args[0].Interface().(A))
+}
+
+func (fn ReadFunc2[A, B]) Invoke(args, results []reflect.Value) {
+	fn(args[0].Interface().(A), args[1].Interface().(B))
+}
+
+func (fn ReadFunc3[A, B, C]) Invoke(args, results []reflect.Value) {
+	fn(args[0].Interface().(A), args[1].Interface().(B), args[2].Interface().(C))
+}
@@ -0,0 +1,53 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestSystemFunc(t *testing.T) {
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Position(1), Velocity(2))
+	world.AddObject(player)
+	world.AddObject(ecs.NewObject(Position(5)))
+
+	var seen []ecs.Entity
+	world.AddSystem(ecs.System{Func: ecs.SystemFunc2[Position, Velocity](func(p Position, v Velocity) Position {
+		return p + Position(v)
+	})})
+	world.AddSystem(ecs.System{Func: ecs.ReadFunc2[ecs.Entity, Velocity](func(e ecs.Entity, v Velocity) {
+		seen = append(seen, e)
+	})})
+	world.Step()
+
+	if got := player.Component(Position(0)); got != Position(3) {
+		t.Errorf("bad position: got %v, want 3", got)
+	}
+	if len(seen) != 1 || seen[0] != player.Entity() {
+		t.Errorf("read function should only see the player, got %v", seen)
+	}
+}
+
+func TestSystemFuncAllocations(t *testing.T) {
+	const objects = 1000
+
+	allocs := func(f interface{}) float64 {
+		world := ecs.NewWorld()
+		for i := 0; i < objects; i++ {
+			world.AddObject(ecs.NewObject(Position(i), Velocity(1)))
+		}
+		world.AddSystem(ecs.System{Func: f})
+		world.Step()
+		return testing.AllocsPerRun(10, world.Step)
+	}
+
+	movement := func(p Position, v Velocity) Position { return p + Position(v) }
+	reflective := allocs(movement)
+	direct := allocs(ecs.SystemFunc2[Position, Velocity](movement))
+	t.Logf("%.0f allocations per step with reflection, %.0f with a typed function", reflective, direct)
+
+	if reflective-direct < objects/2 {
+		t.Errorf("typed functions should save at least one allocation per object: %.0f vs %.0f", direct, reflective)
+	}
+}
@@ -0,0 +1,35 @@
+package ecs
+
+import "time"
+
+func init() {
+	Register(Lifetime(0))
+}
+
+// Lifetime is a component that limits how long an object stays in the world,
+// for things like bullets, particles, and temporary effects. The system
+// returned by LifetimeSystem counts it down and despawns the object once it
+// runs out:
+//
+//     world.AddObject(ecs.NewObject(ecs.Lifetime(3*time.Second), Bullet{}))
+//     world.AddSystem(ecs.LifetimeSystem(ticker))
+type Lifetime time.Duration
+
+// LifetimeSystem returns a system that counts down every Lifetime on each tick
+// of the given ticker by the Elapsed time since its last tick, and despawns
+// objects whose lifetime has run out through the world's command buffer, so
+// they are removed at the tick boundary. If the buffer drops the command, the
+// object is despawned on the system's next tick instead.
+func LifetimeSystem(ticker <-chan time.Time) System {
+	return System{
+		Func: func(cmd *Commands, e Entity, l Lifetime, dt Elapsed) Lifetime {
+			if l -= Lifetime(dt); l <= 0 {
+				cmd.Despawn(e)
+				return 0
+			}
+			return l
+		},
+		Name:   "Lifetimes",
+		Ticker: ticker,
+	}
+}
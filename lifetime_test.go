@@ -0,0 +1,36 @@
+package ecs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestLifetime(t *testing.T) {
+	clock := ecs.NewTime()
+	start := time.Unix(100, 0)
+	clock.Update(start)
+
+	world := ecs.NewWorld()
+	world.AddResource(clock)
+	short := world.AddObject(ecs.NewObject(ecs.Lifetime(time.Second), Position(1)))
+	long := world.AddObject(ecs.NewObject(ecs.Lifetime(3*time.Second), Position(2)))
+	forever := world.AddObject(ecs.NewObject(Position(3)))
+	world.AddSystem(ecs.LifetimeSystem(nil))
+
+	world.Step()
+	clock.Update(start.Add(time.Second))
+	world.Step()
+	world.Step()
+
+	if world.GetObject(short) != nil {
+		t.Error("expired object wasn't despawned")
+	}
+	if world.GetObject(long) == nil || world.GetObject(forever) == nil {
+		t.Fatal("despawned an object that hadn't expired")
+	}
+	if got, want := world.GetObject(long).Component(ecs.Lifetime(0)), ecs.Lifetime(2*time.Second); got != want {
+		t.Errorf("bad remaining lifetime: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package ecs
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LoadProgress is emitted by a Loader at the end of every tick in which it
+// added objects to the world. The load is complete once Loaded equals Total.
+type LoadProgress struct {
+	Loader        *Loader
+	Loaded, Total int
+}
+
+// Loader populates a world with a large number of objects over multiple
+// ticks, rather than blocking while adding all of them at once. This allows
+// for load screens that show progress while the rest of the world's systems
+// keep running.
+type Loader struct {
+	// Objects is the full list of objects to be added.
+	Objects []*Object
+
+	// Budget is the maximum amount of time to spend adding objects on each
+	// tick. At least one object is added per tick regardless of the budget.
+	Budget time.Duration
+
+	// Ticker determines how often the loader runs, as with System.Ticker.
+	Ticker <-chan time.Time
+
+	// loaded is the number of objects added so far. It is read atomically,
+	// since Done may be called from outside the loader's system.
+	loaded int64
+}
+
+// System returns a global system that performs the load. It should be added
+// to the world with AddSystem.
+func (l *Loader) System() System {
+	return System{
+		Func:   l.load,
+		Name:   "Loader",
+		Ticker: l.Ticker,
+		Global: true,
+	}
+}
+
+// Done reports whether every object has been added to the world.
+func (l *Loader) Done() bool {
+	return int(atomic.LoadInt64(&l.loaded)) == len(l.Objects)
+}
+
+func (l *Loader) load(w *World) {
+	loaded := int(atomic.LoadInt64(&l.loaded))
+	if loaded == len(l.Objects) {
+		return
+	}
+
+	start := time.Now()
+	for loaded < len(l.Objects) {
+		w.AddObject(l.Objects[loaded])
+		loaded++
+		atomic.StoreInt64(&l.loaded, int64(loaded))
+		if time.Since(start) >= l.Budget {
+			break
+		}
+	}
+
+	w.Emit(LoadProgress{Loader: l, Loaded: loaded, Total: len(l.Objects)})
+}
@@ -0,0 +1,80 @@
+package ecs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestLoader(t *testing.T) {
+	objects := make([]*ecs.Object, 1000)
+	for i := range objects {
+		objects[i] = ecs.NewObject(Position(i))
+	}
+
+	world := ecs.NewWorld()
+	loader := &ecs.Loader{
+		Objects: objects,
+		Budget:  time.Nanosecond,
+		Ticker:  MaxTicker(time.Millisecond, 10),
+	}
+	world.AddSystem(loader.System())
+
+	var progress []ecs.LoadProgress
+	world.OnEvent(ecs.LoadProgress{}, func(ev interface{}) {
+		progress = append(progress, ev.(ecs.LoadProgress))
+	})
+
+	world.Run()
+
+	if got, want := len(progress), 10; got != want {
+		t.Fatalf("wrong number of progress events: got %d, want %d", got, want)
+	}
+	for i, p := range progress {
+		if p.Total != len(objects) {
+			t.Errorf("bad total: got %d, want %d", p.Total, len(objects))
+		}
+		if i > 0 && p.Loaded <= progress[i-1].Loaded {
+			t.Errorf("progress did not advance: %d after %d", p.Loaded, progress[i-1].Loaded)
+		}
+	}
+	if loader.Done() {
+		t.Error("loader should not have finished within its budget")
+	}
+	if world.GetObject(objects[0].Entity()) == nil {
+		t.Error("first object should have been loaded")
+	}
+}
+
+func TestLoaderDonePolling(t *testing.T) {
+	objects := make([]*ecs.Object, 100)
+	for i := range objects {
+		objects[i] = ecs.NewObject(Position(i))
+	}
+
+	world := ecs.NewWorld()
+	loader := &ecs.Loader{
+		Objects: objects,
+		Budget:  time.Nanosecond,
+		Ticker:  MaxTicker(time.Millisecond, len(objects)),
+	}
+	world.AddSystem(loader.System())
+
+	// a load screen polls Done from its own goroutine while the world runs
+	done := make(chan struct{})
+	go func() {
+		world.Run()
+		close(done)
+	}()
+	for !loader.Done() {
+		select {
+		case <-done:
+			if !loader.Done() {
+				t.Fatal("world stopped before the load finished")
+			}
+		case <-time.After(100 * time.Microsecond):
+		}
+	}
+	<-done
+}
@@ -0,0 +1,155 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// componentLock guards every component of a single type. Locks are numbered in
+// the order they were created, and systems always acquire them in that order
+// to avoid deadlock.
+type componentLock struct {
+	sync.RWMutex
+	id int
+}
+
+// systemLock is a component lock along with whether the system needs to hold
+// it exclusively.
+type systemLock struct {
+	*componentLock
+	write bool
+}
+
+func (w *World) componentLock(t reflect.Type) *componentLock {
+	w.locksMu.Lock()
+	defer w.locksMu.Unlock()
+	return w.componentLockLocked(t)
+}
+
+// componentLockLocked is componentLock for a caller that holds locksMu.
+func (w *World) componentLockLocked(t reflect.Type) *componentLock {
+	l, ok := w.locks[t]
+	if !ok {
+		l = &componentLock{id: len(w.locks)}
+		w.locks[t] = l
+	}
+	return l
+}
+
+// systemLocks returns the locks a system needs to hold while ticking, based on
+// the component types it reads and writes, sorted into acquisition order.
+//
+// A component accessed through an interface is guarded by the locks of the
+// concrete types that implement it, as well as its own, and a concrete type
+// is guarded by the locks of the interfaces it implements. Since this is
+// worked out from the types the world knows about when the locks are
+// created, which are those locked by other systems and queries, those seen
+// on objects, and those registered with Register, whichever of two systems
+// is added second takes the locks that keep it from running alongside the
+// first.
+func (w *World) systemLocks(s System) []systemLock {
+	reads, writes := s.access()
+
+	byType := make(map[reflect.Type]bool)
+	for _, t := range reads {
+		if _, ok := byType[t]; !ok {
+			byType[t] = false
+		}
+	}
+	for _, t := range writes {
+		byType[t] = true
+	}
+
+	w.locksMu.Lock()
+	defer w.locksMu.Unlock()
+
+	interfaces := false
+	for t := range byType {
+		interfaces = interfaces || t.Kind() == reflect.Interface
+	}
+	shared := make(map[reflect.Type]bool)
+	for _, t := range w.lockTypes(interfaces) {
+		for ct, write := range byType {
+			if sharesLock(ct, t) {
+				shared[t] = shared[t] || write
+			}
+		}
+	}
+	for t, write := range shared {
+		byType[t] = byType[t] || write
+	}
+
+	locks := make([]systemLock, 0, len(byType))
+	for t, write := range byType {
+		locks = append(locks, systemLock{componentLock: w.componentLockLocked(t), write: write})
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].id < locks[j].id
+	})
+	return locks
+}
+
+// lockTypes returns the types that may share locks with the ones a system
+// accesses: those already locked, and if the system accesses an interface,
+// every other component type the world knows about. The caller must hold
+// locksMu.
+func (w *World) lockTypes(all bool) []reflect.Type {
+	seen := make(map[reflect.Type]bool, len(w.locks))
+	var types []reflect.Type
+	add := func(t reflect.Type) {
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	for t := range w.locks {
+		add(t)
+	}
+	if !all {
+		return types
+	}
+	componentIDs.Range(func(k, _ interface{}) bool {
+		add(k.(reflect.Type))
+		return true
+	})
+	registryMu.RLock()
+	for t := range namesByType {
+		add(t)
+	}
+	registryMu.RUnlock()
+	return types
+}
+
+// sharesLock reports whether a and b, one an interface and the other not,
+// must be guarded together because the concrete type implements the
+// interface. Injected services are never components, so they share nothing.
+func sharesLock(a, b reflect.Type) bool {
+	if a.Kind() != reflect.Interface {
+		a, b = b, a
+	}
+	if a.Kind() != reflect.Interface || b.Kind() == reflect.Interface || injectable(a) {
+		return false
+	}
+	return b.Implements(a)
+}
+
+func lockAll(locks []systemLock) {
+	for _, l := range locks {
+		if l.write {
+			l.Lock()
+		} else {
+			l.RLock()
+		}
+	}
+}
+
+func unlockAll(locks []systemLock) {
+	for i := len(locks) - 1; i >= 0; i-- {
+		if l := locks[i]; l.write {
+			l.Unlock()
+		} else {
+			l.RUnlock()
+		}
+	}
+}
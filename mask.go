@@ -0,0 +1,170 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A componentMask is a set of component types, as a bitset indexed by their
+// component IDs, so that checking whether an object has every type a system
+// needs is a few AND operations rather than a comparison of every component
+// against every parameter.
+type componentMask []uint64
+
+// unnamedBit is set in the mask of an object with a component of an unnamed
+// type, such as a pointer or slice. Unless a world has StrictTypes set, such
+// a component can be passed to a parameter of a different, named type with
+// the same underlying type, so masks can't rule those objects out.
+const unnamedBit = 0
+
+var (
+	componentIDsMu  sync.Mutex
+	componentIDs    sync.Map // reflect.Type -> int
+	nextComponentID = unnamedBit + 1
+)
+
+// componentID returns the small integer that identifies component type t in
+// masks, assigning one if t hasn't been seen before.
+func componentID(t reflect.Type) int {
+	if id, ok := componentIDs.Load(t); ok {
+		return id.(int)
+	}
+	componentIDsMu.Lock()
+	defer componentIDsMu.Unlock()
+	if id, ok := componentIDs.Load(t); ok {
+		return id.(int)
+	}
+	id := nextComponentID
+	nextComponentID++
+	componentIDs.Store(t, id)
+	return id
+}
+
+// maskOf returns the mask of a set of components, along with the types of
+// any in dense storage. It is never nil, so that a computed mask can be told
+// apart from one that hasn't been computed yet.
+func maskOf(components []interface{}, dense []reflect.Type) componentMask {
+	m := make(componentMask, 1)
+	add := func(t reflect.Type) {
+		m.set(componentID(t))
+		if t.Name() == "" {
+			m.set(unnamedBit)
+		}
+	}
+	for _, c := range components {
+		add(reflect.TypeOf(c))
+	}
+	for _, t := range dense {
+		add(t)
+	}
+	return m
+}
+
+func (m *componentMask) set(id int) {
+	word := id / 64
+	if word >= len(*m) {
+		grown := make(componentMask, word+1)
+		copy(grown, *m)
+		*m = grown
+	}
+	(*m)[word] |= 1 << (id % 64)
+}
+
+func (m componentMask) has(id int) bool {
+	word := id / 64
+	return word < len(m) && m[word]&(1<<(id%64)) != 0
+}
+
+// containsAll reports whether m has every type in other.
+func (m componentMask) containsAll(other componentMask) bool {
+	for i, bits := range other {
+		if bits == 0 {
+			continue
+		}
+		if i >= len(m) || m[i]&bits != bits {
+			return false
+		}
+	}
+	return true
+}
+
+// intersects reports whether m and other have any type in common.
+func (m componentMask) intersects(other componentMask) bool {
+	for i := 0; i < len(m) && i < len(other); i++ {
+		if m[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// componentMask returns the mask of the object's components, computing it if
+// it has changed since it was last needed.
+func (ob *Object) componentMask() componentMask {
+	ob.mu.RLock()
+	m := ob.mask
+	ob.mu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if ob.mask == nil {
+		ob.mask = maskOf(ob.components, ob.dense)
+	}
+	return ob.mask
+}
+
+// hasTypes reports whether the object has a component of each type in
+// required.
+func (ob *Object) hasTypes(required componentMask) bool {
+	return ob.componentMask().containsAll(required)
+}
+
+// typesMask returns the mask of a list of types.
+func typesMask(types []reflect.Type) componentMask {
+	var m componentMask
+	for _, t := range types {
+		m.set(componentID(t))
+	}
+	return m
+}
+
+// maskable reports whether a parameter of type t can only receive components
+// of exactly that type, or of an unnamed type, so that masks can tell which
+// objects can't fill it in.
+func (w *World) maskable(t reflect.Type) bool {
+	if t.Kind() == reflect.Interface {
+		return false
+	}
+	return w.StrictTypes || t.Name() != ""
+}
+
+// requiredMask returns the component types an object needs to be passed to
+// the system on this tick. Parameters that are currently filled in by
+// resources aren't included.
+func (a *arena) requiredMask(w *World) componentMask {
+	var m componentMask
+	for _, p := range a.params {
+		if p.kind != paramDynamic || p.t == entityType || !w.maskable(p.t) {
+			continue
+		}
+		if _, ok := w.resource(p.t); ok {
+			continue
+		}
+		m.set(componentID(p.t))
+	}
+	return m
+}
+
+// couldMatch reports whether the object might have the components in
+// required, as returned by requiredMask, so that objects that certainly
+// don't can be skipped without looking at their components.
+func (w *World) couldMatch(ob *Object, required componentMask) bool {
+	m := ob.componentMask()
+	if m.containsAll(required) {
+		return true
+	}
+	return !w.StrictTypes && m.has(unnamedBit)
+}
@@ -0,0 +1,62 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestMatchingFollowsComponentChanges(t *testing.T) {
+	world := ecs.NewWorld()
+	ob := ecs.NewObject(Position(0))
+	world.AddObject(ob)
+
+	var calls int
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		calls++
+		return p + Position(v)
+	}})
+
+	world.Run()
+	if calls != 0 {
+		t.Fatalf("system called %d times before the object had a velocity", calls)
+	}
+
+	ob.AddComponent(Velocity(2))
+	world.Run()
+	if calls != 1 {
+		t.Fatalf("system called %d times after adding a velocity, want 1", calls)
+	}
+
+	ob.RemoveComponent(Velocity(0))
+	world.Run()
+	if calls != 1 {
+		t.Fatalf("system called %d times after removing the velocity, want 1", calls)
+	}
+
+	if got := world.Query().With(Position(0)).Without(Velocity(0)).Count(); got != 1 {
+		t.Errorf("bad query count: got %d, want 1", got)
+	}
+	ob.SetComponent(Velocity(1))
+	if got := world.Query().With(Position(0)).Without(Velocity(0)).Count(); got != 0 {
+		t.Errorf("bad query count after setting a velocity: got %d, want 0", got)
+	}
+}
+
+func TestMatchingUnnamedComponents(t *testing.T) {
+	type Path []int
+
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject([]int{1, 2, 3}))
+
+	// an unnamed component is assignable to a named parameter with the
+	// same underlying type, so masks must not rule it out
+	var got Path
+	world.AddSystem(ecs.System{Func: func(p Path) {
+		got = p
+	}})
+	world.Run()
+	if len(got) != 3 {
+		t.Errorf("bad path: got %v, want [1 2 3]", got)
+	}
+}
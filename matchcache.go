@@ -0,0 +1,314 @@
+package ecs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// QueryCacheConfig controls the match sets a world builds for its systems and
+// the queries it runs often. A match set holds every object that matches a
+// particular combination of component types, and is kept up to date as
+// objects gain and lose components, so that running the query no longer
+// means examining every object in the world. Changing the value of a
+// component an object already has doesn't touch any match set. The zero
+// value enables caching with default limits.
+type QueryCacheConfig struct {
+	// Threshold is how many times a query's combination of component types
+	// must be matched before a match set is built for it. If zero, 8 is used.
+	// Systems tick too often for this to be worth waiting for, so a system's
+	// match set is built the first time it ticks.
+	Threshold int
+
+	// MaxObjects caps the number of objects held across all match sets.
+	// When the cap is reached, the least recently used sets are discarded.
+	// If zero, 65536 is used, and if negative, nothing is cached.
+	MaxObjects int
+}
+
+const (
+	defaultQueryCacheThreshold  = 8
+	defaultQueryCacheMaxObjects = 1 << 16
+
+	// maxQueryCacheShapes limits how many distinct combinations are counted
+	// towards the threshold, so that a program generating many one-off
+	// queries doesn't grow the count without bound.
+	maxQueryCacheShapes = 1024
+)
+
+// A matchKey identifies what a match set holds: objects with every type in
+// with and none in without, and, if loose, objects that might be passed to a
+// system by assignability; see couldMatch.
+type matchKey struct {
+	with, without string
+	loose         bool
+}
+
+func newMatchKey(with, without componentMask, loose bool) matchKey {
+	return matchKey{with: maskString(with), without: maskString(without), loose: loose}
+}
+
+// maskString returns a comparable form of a mask, ignoring trailing zeroes.
+func maskString(m componentMask) string {
+	for len(m) > 0 && m[len(m)-1] == 0 {
+		m = m[:len(m)-1]
+	}
+	var b strings.Builder
+	for _, word := range m {
+		fmt.Fprintf(&b, "%x.", word)
+	}
+	return b.String()
+}
+
+type matchSet struct {
+	with, without componentMask
+	loose         bool
+
+	// entries are kept in the order their objects were added to the world,
+	// and members holds the sequence number each was added with.
+	entries  []matchEntry
+	members  map[*Object]uint64
+	lastUsed uint64
+
+	// list is the objects in entries, built when first needed after the set
+	// changes, so that a system ticking over an unchanged set doesn't copy
+	// it every tick. It is never modified once built.
+	list []*Object
+}
+
+type matchEntry struct {
+	ob  *Object
+	seq uint64
+}
+
+func newMatchSet(with, without componentMask, loose bool, objects []*Object) *matchSet {
+	s := &matchSet{
+		with:    with,
+		without: without,
+		loose:   loose,
+		entries: make([]matchEntry, len(objects)),
+		members: make(map[*Object]uint64, len(objects)),
+	}
+	for i, ob := range objects {
+		seq := atomic.LoadUint64(&ob.seq)
+		s.entries[i] = matchEntry{ob: ob, seq: seq}
+		s.members[ob] = seq
+	}
+	return s
+}
+
+// objects returns the objects in the set, which the caller must not modify.
+func (s *matchSet) objects() []*Object {
+	if s.list == nil {
+		s.list = make([]*Object, len(s.entries))
+		for i, e := range s.entries {
+			s.list[i] = e.ob
+		}
+	}
+	return s.list
+}
+
+func (s *matchSet) member(ob *Object) bool {
+	m := ob.componentMask()
+	if m.containsAll(s.with) && !m.intersects(s.without) {
+		return true
+	}
+	return s.loose && m.has(unnamedBit)
+}
+
+// search returns where an object with sequence number seq is or would be.
+func (s *matchSet) search(seq uint64) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return s.entries[i].seq >= seq
+	})
+}
+
+// update adds or removes an object according to whether it belongs in the
+// set, and returns the change in the set's size.
+func (s *matchSet) update(ob *Object) int {
+	seq := atomic.LoadUint64(&ob.seq)
+	want := seq != 0 && s.member(ob)
+	old, found := s.members[ob]
+	if found && want && old == seq {
+		return 0
+	}
+	delta := 0
+	if found {
+		i := s.search(old)
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		delete(s.members, ob)
+		delta--
+	}
+	if want {
+		i := s.search(seq)
+		s.entries = append(s.entries, matchEntry{})
+		copy(s.entries[i+1:], s.entries[i:])
+		s.entries[i] = matchEntry{ob: ob, seq: seq}
+		s.members[ob] = seq
+		delta++
+	}
+	s.list = nil
+	return delta
+}
+
+// matchCache holds a world's match sets.
+type matchCache struct {
+	mu    sync.Mutex
+	uses  map[matchKey]int
+	sets  map[matchKey]*matchSet
+	size  int
+	clock uint64
+}
+
+func (w *World) queryCacheLimits() (threshold, maxObjects int) {
+	threshold, maxObjects = w.QueryCache.Threshold, w.QueryCache.MaxObjects
+	if threshold <= 0 {
+		threshold = defaultQueryCacheThreshold
+	}
+	if maxObjects == 0 {
+		maxObjects = defaultQueryCacheMaxObjects
+	}
+	return threshold, maxObjects
+}
+
+// matching returns the objects in the world with every type in with and none
+// in without, in the order they were added to the world. If loose is set, it
+// also includes objects that might be passed to a system by assignability;
+// see couldMatch. Objects are found with a match set if there is one, and
+// one is built once the same combination has been asked for often enough.
+// The returned slice may be shared, and must not be modified.
+func (w *World) matching(with, without componentMask, loose bool) []*Object {
+	threshold, _ := w.queryCacheLimits()
+	return w.matchingAfter(with, without, loose, threshold)
+}
+
+// systemMatching returns the objects that might be passed to a system
+// needing the types in required, building a match set for them straight
+// away.
+func (w *World) systemMatching(required componentMask) []*Object {
+	return w.matchingAfter(required, nil, !w.StrictTypes, 1)
+}
+
+// matchingAfter is matching, building a match set once the combination has
+// been asked for threshold times.
+func (w *World) matchingAfter(with, without componentMask, loose bool, threshold int) []*Object {
+	if len(maskString(with)) == 0 {
+		// nothing to narrow the search with
+		return w.filterObjects(with, without, loose)
+	}
+	_, maxObjects := w.queryCacheLimits()
+	if maxObjects < 0 {
+		return w.filterObjects(with, without, loose)
+	}
+
+	c := &w.cache
+	key := newMatchKey(with, without, loose)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock++
+
+	if s, ok := c.sets[key]; ok {
+		s.lastUsed = c.clock
+		return s.objects()
+	}
+
+	if c.uses == nil {
+		c.uses = make(map[matchKey]int)
+		c.sets = make(map[matchKey]*matchSet)
+	}
+	if _, ok := c.uses[key]; !ok && len(c.uses) >= maxQueryCacheShapes {
+		c.uses = make(map[matchKey]int)
+	}
+	c.uses[key]++
+	objects := w.filterObjects(with, without, loose)
+	if c.uses[key] < threshold || len(objects) > maxObjects {
+		return objects
+	}
+
+	for c.size+len(objects) > maxObjects {
+		c.evictLocked(w)
+	}
+	s := newMatchSet(with, without, loose, objects)
+	s.lastUsed = c.clock
+	s.list = objects
+	c.sets[key] = s
+	c.size += len(objects)
+	delete(c.uses, key)
+	w.metrics().Counter("ecs_match_sets_built", 1)
+	return objects
+}
+
+// filterObjects finds matching objects by examining every object.
+func (w *World) filterObjects(with, without componentMask, loose bool) []*Object {
+	s := matchSet{with: with, without: without, loose: loose}
+	w.objectsMu.RLock()
+	defer w.objectsMu.RUnlock()
+	var objects []*Object
+	for _, ob := range w.objects {
+		if s.member(ob) {
+			objects = append(objects, ob)
+		}
+	}
+	return objects
+}
+
+// evictLocked discards the least recently used match set. The caller must
+// hold the cache's lock.
+func (c *matchCache) evictLocked(w *World) {
+	var (
+		oldest    matchKey
+		oldestSet *matchSet
+	)
+	for key, s := range c.sets {
+		if oldestSet == nil || s.lastUsed < oldestSet.lastUsed {
+			oldest, oldestSet = key, s
+		}
+	}
+	if oldestSet == nil {
+		return
+	}
+	delete(c.sets, oldest)
+	c.size -= len(oldestSet.entries)
+	w.metrics().Counter("ecs_match_sets_evicted", 1)
+}
+
+// cached returns the number of objects in the match set for a combination of
+// types, if there is one.
+func (c *matchCache) cached(with, without componentMask, loose bool) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.sets[newMatchKey(with, without, loose)]
+	if !ok {
+		return 0, false
+	}
+	return len(s.entries), true
+}
+
+// update adds objects to, or removes them from, every match set according to
+// their current components and whether they are still in the world. It is
+// called after anything that might change either.
+func (c *matchCache) update(w *World, obs ...*Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.sets {
+		for _, ob := range obs {
+			c.size += s.update(ob)
+		}
+	}
+	_, maxObjects := w.queryCacheLimits()
+	for c.size > maxObjects && len(c.sets) > 0 {
+		c.evictLocked(w)
+	}
+}
+
+// reset discards every match set, such as when all of the world's objects are
+// replaced.
+func (c *matchCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sets = nil
+	c.uses = nil
+	c.size = 0
+}
@@ -0,0 +1,156 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestQueryCache(t *testing.T) {
+	sink := newRecordingSink()
+	world := ecs.NewWorld()
+	world.Metrics = sink
+	world.QueryCache.Threshold = 2
+
+	var obs []*ecs.Object
+	for i := 0; i < 10; i++ {
+		ob := ecs.NewObject(Position(i))
+		if i%2 == 0 {
+			ob.AddComponent(Velocity(1))
+		}
+		world.AddObject(ob)
+		obs = append(obs, ob)
+	}
+
+	query := func() *ecs.Query {
+		return world.Query().With(Position(0), Velocity(0))
+	}
+	if got := world.Explain(Position(0), Velocity(0)).Strategy; got != "full scan" {
+		t.Errorf("bad strategy before the query is used: %s", got)
+	}
+	query().Count()
+	query().Count()
+	if got := world.Explain(Position(0), Velocity(0)).Strategy; got != "match set" {
+		t.Errorf("bad strategy after the query is used: %s", got)
+	}
+	if got := sink.values["ecs_match_sets_built"]; got != 1 {
+		t.Errorf("bad number of match sets built: got %v, want 1", got)
+	}
+
+	// the match set must follow changes to objects and components
+	obs[1].AddComponent(Velocity(1))
+	obs[0].RemoveComponent(Velocity(0))
+	world.RemoveObject(obs[2].Entity())
+	late := ecs.NewObject(Position(10), Velocity(1))
+	world.AddObject(late)
+
+	var got []ecs.Entity
+	for _, ob := range query().Objects() {
+		got = append(got, ob.Entity())
+	}
+	want := []ecs.Entity{obs[1].Entity(), obs[4].Entity(), obs[6].Entity(), obs[8].Entity(), late.Entity()}
+	if len(got) != len(want) {
+		t.Fatalf("bad matches: got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("bad matches: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestQueryCacheCap(t *testing.T) {
+	sink := newRecordingSink()
+	world := ecs.NewWorld()
+	world.Metrics = sink
+	world.QueryCache = ecs.QueryCacheConfig{Threshold: 1, MaxObjects: 3}
+
+	for i := 0; i < 2; i++ {
+		world.AddObject(ecs.NewObject(Position(i), Velocity(i)))
+	}
+	world.Query().With(Position(0)).Count()
+	world.Query().With(Velocity(0)).Count()
+	if got := sink.values["ecs_match_sets_evicted"]; got != 1 {
+		t.Errorf("bad number of match sets evicted: got %v, want 1", got)
+	}
+	if got := world.Explain(Velocity(0)).Strategy; got != "match set" {
+		t.Errorf("most recent query should be cached, but strategy is %s", got)
+	}
+
+	// growing past the cap evicts too
+	world.AddObject(ecs.NewObject(Velocity(2)))
+	world.AddObject(ecs.NewObject(Velocity(3)))
+	if got := world.Explain(Velocity(0)).Strategy; got != "full scan" {
+		t.Errorf("oversized match set should be evicted, but strategy is %s", got)
+	}
+	if got := world.Query().With(Velocity(0)).Count(); got != 4 {
+		t.Errorf("bad count: got %d, want 4", got)
+	}
+}
+
+func TestSystemMatchSet(t *testing.T) {
+	world := ecs.NewWorld()
+	world.QueryCache.Threshold = 1
+	ob := ecs.NewObject(Position(0))
+	world.AddObject(ob)
+	world.AddObject(ecs.NewObject(Velocity(5)))
+
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}})
+	world.Step()
+	ob.AddComponent(Velocity(2))
+	world.Step()
+	world.Step()
+
+	if got := ob.Component(Position(0)); got != Position(4) {
+		t.Errorf("bad position: got %v, want 4", got)
+	}
+}
+
+func TestSystemMatchSetBuiltOnFirstTick(t *testing.T) {
+	sink := newRecordingSink()
+	world := ecs.NewWorld()
+	world.Metrics = sink
+	ob := ecs.NewObject(Position(0), Velocity(1))
+	world.AddObject(ob)
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}})
+	world.Step()
+	if got := sink.values["ecs_match_sets_built"]; got != 1 {
+		t.Errorf("bad number of match sets built: got %v, want 1", got)
+	}
+
+	// changing values doesn't disturb the set, but gaining components does
+	ob.SetComponent(Velocity(2))
+	late := ecs.NewObject(Position(10))
+	world.AddObject(late)
+	late.AddComponent(Velocity(3))
+	world.Step()
+	if got := ob.Component(Position(0)); got != Position(3) {
+		t.Errorf("bad position: got %v, want 3", got)
+	}
+	if got := late.Component(Position(0)); got != Position(13) {
+		t.Errorf("bad late position: got %v, want 13", got)
+	}
+	if got := sink.values["ecs_match_sets_built"]; got != 1 {
+		t.Errorf("match set was rebuilt: built %v", got)
+	}
+}
+
+func TestQueryObjectsCopied(t *testing.T) {
+	world := ecs.NewWorld()
+	world.QueryCache.Threshold = 1
+	a, b := ecs.NewObject(Position(0)), ecs.NewObject(Position(1))
+	world.AddObject(a)
+	world.AddObject(b)
+
+	objects := world.Query().With(Position(0)).Objects()
+	objects[0] = b
+	if got := world.Query().With(Position(0)).Objects(); got[0] != a {
+		t.Error("modifying query results changed the match set")
+	}
+}
@@ -0,0 +1,234 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// A Resolver decides what a component should be when two merged objects both
+// have one of the same type. It receives the surviving object's component and
+// the merged-away object's component, and must return a component of the same
+// type.
+type Resolver func(kept, merged interface{}) interface{}
+
+// KeepExisting resolves conflicts in favour of the surviving object's
+// component.
+func KeepExisting(kept, merged interface{}) interface{} { return kept }
+
+// KeepMerged resolves conflicts in favour of the merged-away object's
+// component.
+func KeepMerged(kept, merged interface{}) interface{} { return merged }
+
+// A MergePolicy decides how World.Merge resolves conflicting components. The
+// zero value keeps the surviving object's components.
+type MergePolicy struct {
+	// Default resolves conflicts for types without their own resolver. If
+	// nil, KeepExisting is used.
+	Default Resolver
+
+	resolvers map[reflect.Type]Resolver
+}
+
+// With returns a copy of the policy that resolves conflicts between
+// components of the same type as component with r:
+//
+//     policy := ecs.MergePolicy{}.With(Stack{}, func(kept, merged interface{}) interface{} {
+//         return Stack{Count: kept.(Stack).Count + merged.(Stack).Count}
+//     })
+func (p MergePolicy) With(component interface{}, r Resolver) MergePolicy {
+	resolvers := make(map[reflect.Type]Resolver, len(p.resolvers)+1)
+	for t, r := range p.resolvers {
+		resolvers[t] = r
+	}
+	resolvers[reflect.TypeOf(component)] = r
+	p.resolvers = resolvers
+	return p
+}
+
+func (p MergePolicy) resolver(t reflect.Type) Resolver {
+	if r, ok := p.resolvers[t]; ok {
+		return r
+	}
+	if p.Default != nil {
+		return p.Default
+	}
+	return KeepExisting
+}
+
+// Merge folds the object with entity b into the one with entity a, such as
+// when stacking two identical items or when a reconnecting player turns out
+// to already have an object. Components that only b has are moved to a, and
+// components they both have are resolved according to policy. Then b is
+// removed from the world, and every reference to b in an exported Entity
+// field of any object's components, such as a Parent, is rewritten to refer
+// to a.
+//
+// Since b's components live on in a, it is removed without running
+// finalizers. Merge takes every object's lock in turn, so it must not be
+// called from within a system; use Commands.Merge there instead.
+func (w *World) Merge(a, b Entity, policy MergePolicy) error {
+	if a == b {
+		return errors.New("cannot merge an entity with itself")
+	}
+	kept, merged := w.GetObject(a), w.GetObject(b)
+	if kept == nil {
+		return fmt.Errorf("no object with entity %d", a)
+	}
+	if merged == nil {
+		return fmt.Errorf("no object with entity %d", b)
+	}
+
+	// resolve everything before changing anything, so that a misbehaving
+	// resolver leaves both objects intact
+	var set []interface{}
+	for _, c := range merged.Components() {
+		t := reflect.TypeOf(c)
+		existing := kept.Component(c)
+		if existing == nil {
+			set = append(set, c)
+			continue
+		}
+		resolved := policy.resolver(t)(existing, c)
+		if reflect.TypeOf(resolved) != t {
+			return fmt.Errorf("resolving %s: resolver returned %T", t, resolved)
+		}
+		set = append(set, resolved)
+	}
+
+	w.detachObject(b)
+	for _, c := range set {
+		kept.SetComponent(c)
+	}
+	for _, ob := range w.Objects() {
+		ob.rewriteRefs(b, a)
+	}
+	w.metrics().Counter("ecs_objects_removed", 1)
+	return nil
+}
+
+// rewriteRefs rewrites references to entity from in the object's components
+// to refer to entity to instead.
+func (ob *Object) rewriteRefs(from, to Entity) {
+	var changed []interface{}
+	ob.mu.Lock()
+	for i, c := range ob.components {
+		if c == nil || !containsEntity(reflect.TypeOf(c)) {
+			continue
+		}
+		if v, ok := rewriteValue(reflect.ValueOf(c), from, to); ok {
+			ob.components[i] = v.Interface()
+			changed = append(changed, ob.components[i])
+		}
+	}
+	ob.mu.Unlock()
+	for _, c := range ob.denseComponents() {
+		t := reflect.TypeOf(c)
+		if !containsEntity(t) {
+			continue
+		}
+		if v, ok := rewriteValue(reflect.ValueOf(c), from, to); ok {
+			ob.world.column(t).put(ob.entity, v.Interface())
+			changed = append(changed, v.Interface())
+		}
+	}
+	if ob.world != nil {
+		for _, c := range changed {
+			if indexedType(reflect.TypeOf(c)) {
+				ob.reindex()
+			}
+			ob.world.notifyWatchers(Change{Entity: ob.entity, Kind: ComponentChanged, Component: c})
+		}
+	}
+}
+
+// rewriteValue returns a copy of v with every reachable Entity equal to from
+// replaced with to, and whether there were any. Pointers and maps are left
+// alone, since they may be shared with other components.
+func rewriteValue(v reflect.Value, from, to Entity) (reflect.Value, bool) {
+	t := v.Type()
+	if t == entityType {
+		if Entity(v.Uint()) == from {
+			return reflect.ValueOf(to), true
+		}
+		return v, false
+	}
+	if !containsEntity(t) {
+		return v, false
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var cp reflect.Value
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			f, ok := rewriteValue(v.Field(i), from, to)
+			if !ok {
+				continue
+			}
+			if !cp.IsValid() {
+				cp = reflect.New(t).Elem()
+				cp.Set(v)
+			}
+			cp.Field(i).Set(f)
+		}
+		return cp, cp.IsValid()
+
+	case reflect.Array, reflect.Slice:
+		var cp reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			e, ok := rewriteValue(v.Index(i), from, to)
+			if !ok {
+				continue
+			}
+			if !cp.IsValid() {
+				if v.Kind() == reflect.Array {
+					cp = reflect.New(t).Elem()
+				} else {
+					cp = reflect.MakeSlice(t, v.Len(), v.Len())
+				}
+				reflect.Copy(cp, v)
+			}
+			cp.Index(i).Set(e)
+		}
+		return cp, cp.IsValid()
+	}
+	return v, false
+}
+
+var entityFields sync.Map // reflect.Type -> bool
+
+// containsEntity reports whether values of type t can hold an Entity that
+// rewriteValue would find.
+func containsEntity(t reflect.Type) bool {
+	if v, ok := entityFields.Load(t); ok {
+		return v.(bool)
+	}
+	found := searchEntity(t, make(map[reflect.Type]bool))
+	entityFields.Store(t, found)
+	return found
+}
+
+func searchEntity(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == entityType {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() && searchEntity(f.Type, seen) {
+				return true
+			}
+		}
+	case reflect.Array, reflect.Slice:
+		return searchEntity(t.Elem(), seen)
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Stack struct{ Count int }
+
+type Inventory struct {
+	Owner ecs.Entity
+	Slots []ecs.Entity
+	note  ecs.Entity
+}
+
+func TestMerge(t *testing.T) {
+	world := ecs.NewWorld()
+	a := world.AddObject(ecs.NewObject(Stack{Count: 3}, Position(1)))
+	b := world.AddObject(ecs.NewObject(Stack{Count: 4}, Position(2), Velocity(5)))
+	child := world.AddObject(ecs.NewObject(ecs.Parent{Entity: b}))
+	slots := []ecs.Entity{b, child}
+	bag := world.AddObject(ecs.NewObject(Inventory{Owner: b, Slots: slots, note: b}))
+
+	policy := ecs.MergePolicy{}.With(Stack{}, func(kept, merged interface{}) interface{} {
+		return Stack{Count: kept.(Stack).Count + merged.(Stack).Count}
+	})
+	if err := world.Merge(a, b, policy); err != nil {
+		t.Fatalf("failed to merge: %s", err)
+	}
+
+	if world.GetObject(b) != nil {
+		t.Error("merged object should be removed")
+	}
+	ob := world.GetObject(a)
+	if got := ob.Component(Stack{}); got != (Stack{Count: 7}) {
+		t.Errorf("bad stack: got %v", got)
+	}
+	if got := ob.Component(Position(0)); got != Position(1) {
+		t.Errorf("conflicts should keep the existing component by default, got %v", got)
+	}
+	if got := ob.Component(Velocity(0)); got != Velocity(5) {
+		t.Errorf("velocity should be moved over, got %v", got)
+	}
+
+	if p := world.GetObject(child).Component(ecs.Parent{}).(ecs.Parent); p.Entity != a {
+		t.Errorf("parent not rewritten: got %d, want %d", p.Entity, a)
+	}
+	inv := world.GetObject(bag).Component(Inventory{}).(Inventory)
+	if inv.Owner != a || inv.Slots[0] != a || inv.Slots[1] != child {
+		t.Errorf("inventory not rewritten: %+v", inv)
+	}
+	if slots[0] != b {
+		t.Error("slices should be copied rather than rewritten in place")
+	}
+	if inv.note != b {
+		t.Error("unexported fields should be left alone")
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	world := ecs.NewWorld()
+	a := world.AddObject(ecs.NewObject(Stack{Count: 1}))
+	b := world.AddObject(ecs.NewObject(Stack{Count: 2}))
+
+	if err := world.Merge(a, a, ecs.MergePolicy{}); err == nil {
+		t.Error("expected an error merging an entity with itself")
+	}
+	if err := world.Merge(a, 12345678, ecs.MergePolicy{}); err == nil {
+		t.Error("expected an error merging a missing entity")
+	}
+
+	bad := ecs.MergePolicy{Default: func(kept, merged interface{}) interface{} { return 0 }}
+	if err := world.Merge(a, b, bad); err == nil {
+		t.Error("expected an error from a resolver returning the wrong type")
+	}
+	if world.GetObject(b) == nil || world.GetObject(a).Component(Stack{}) != (Stack{Count: 1}) {
+		t.Error("a failed merge should leave both objects intact")
+	}
+
+	if err := world.Merge(a, b, ecs.MergePolicy{Default: ecs.KeepMerged}); err != nil {
+		t.Fatalf("failed to merge: %s", err)
+	}
+	if got := world.GetObject(a).Component(Stack{}); got != (Stack{Count: 2}) {
+		t.Errorf("KeepMerged should keep b's component, got %v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package ecs
+
+// A MetricsSink receives measurements from a world, to be forwarded to a
+// metrics system such as statsd, expvar, or Prometheus. Labels are given as
+// alternating keys and values, like slog attributes.
+//
+// Sinks are called from whichever goroutine took the measurement, including
+// the workers of parallel systems, so they must be safe for concurrent use.
+//
+// The world reports:
+//
+//     ecs_system_tick_seconds   histogram  system     duration of each tick
+//     ecs_system_errors         counter    system     errors returned by systems
+//     ecs_system_panics         counter    system     panics recovered from systems
+//     ecs_system_timeouts       counter    system     ticks that ran past the system's Timeout
+//     ecs_objects               gauge                 objects, at each tick boundary
+//     ecs_objects_added         counter               objects added
+//     ecs_objects_removed       counter               objects removed
+//     ecs_objects_evicted       counter               objects moved to the archive
+//     ecs_objects_rehydrated    counter               objects restored from the archive
+//     ecs_commands_applied      counter               deferred commands applied
+//     ecs_commands_dropped      counter               deferred commands dropped
+//     ecs_commands_received     counter               commands queued with Send applied
+//     ecs_events_flushed        counter               events delivered to handlers
+//     ecs_components_repaired   counter    component  invariant violations repaired
+//     ecs_match_sets_built      counter               match sets built for frequent queries
+//     ecs_match_sets_evicted    counter               match sets discarded to stay under the cap
+type MetricsSink interface {
+	Counter(name string, delta float64, labels ...string)
+	Gauge(name string, value float64, labels ...string)
+	Histogram(name string, value float64, labels ...string)
+}
+
+type nopSink struct{}
+
+func (nopSink) Counter(string, float64, ...string)   {}
+func (nopSink) Gauge(string, float64, ...string)     {}
+func (nopSink) Histogram(string, float64, ...string) {}
+
+func (w *World) metrics() MetricsSink {
+	if w.Metrics != nil {
+		return w.Metrics
+	}
+	return nopSink{}
+}
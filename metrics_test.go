@@ -0,0 +1,80 @@
+package ecs_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+// recordingSink sums every measurement by name, keeping the latest gauge.
+type recordingSink struct {
+	mu     sync.Mutex
+	values map[string]float64
+	counts map[string]int
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{values: make(map[string]float64), counts: make(map[string]int)}
+}
+
+func (s *recordingSink) record(name string, value float64, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		s.values[name] += value
+	} else {
+		s.values[name] = value
+	}
+	s.counts[name]++
+}
+
+func (s *recordingSink) Counter(name string, delta float64, labels ...string) {
+	s.record(name, delta, true)
+}
+
+func (s *recordingSink) Gauge(name string, value float64, labels ...string) {
+	s.record(name, value, false)
+}
+
+func (s *recordingSink) Histogram(name string, value float64, labels ...string) {
+	s.record(name, value, true)
+}
+
+func TestMetrics(t *testing.T) {
+	sink := newRecordingSink()
+	world := ecs.NewWorld()
+	world.Metrics = sink
+	world.OnError = func(string, []interface{}, error) {}
+
+	world.AddObject(ecs.NewObject(Position(1)))
+	world.AddObject(ecs.NewObject(Position(2)))
+	world.AddObject(ecs.NewObject(Position(3)))
+	world.AddSystem(ecs.System{
+		Name: "check",
+		Func: func(e ecs.Entity, p Position, c *ecs.Commands) error {
+			if p == 2 {
+				c.Despawn(e)
+				return errors.New("even")
+			}
+			return nil
+		},
+	})
+	world.Run()
+
+	for name, want := range map[string]float64{
+		"ecs_objects_added":    3,
+		"ecs_objects_removed":  1,
+		"ecs_system_errors":    1,
+		"ecs_commands_applied": 1,
+		"ecs_objects":          2,
+	} {
+		if got := sink.values[name]; got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	if got := sink.counts["ecs_system_tick_seconds"]; got != 1 {
+		t.Errorf("expected one tick duration, got %d", got)
+	}
+}
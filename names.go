@@ -0,0 +1,84 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Name is a component that gives an object a name, such as "boss" or
+// "spawn-point", so that code and scenes can refer to it without knowing its
+// entity. The world indexes objects by name, so Find is fast.
+type Name string
+
+// ErrDuplicateName is reported when an object is given a name that another
+// object in the world already has.
+var ErrDuplicateName = errors.New("duplicate name")
+
+var (
+	nameType = reflect.TypeOf(Name(""))
+	nameID   = componentID(nameType)
+)
+
+func init() {
+	Register(Name(""))
+}
+
+// nameIndex maps names to the objects that have them.
+type nameIndex struct {
+	mu      sync.RWMutex
+	objects map[Name]*Object
+	names   map[*Object]Name
+}
+
+// Find returns the object in the world with the given name, or nil if there
+// isn't one. Names are unique: if an object is given a name another object
+// already has, it keeps its Name component, but isn't indexed under it, and
+// the duplicate is reported to OnError as ErrDuplicateName with an empty
+// system name.
+func (w *World) Find(name string) *Object {
+	w.names.mu.RLock()
+	defer w.names.mu.RUnlock()
+	return w.names.objects[Name(name)]
+}
+
+// indexName updates the index for an object whose name may have changed, or
+// which may have been added to or removed from the world.
+func (w *World) indexName(ob *Object) {
+	name, named := ob.Component(Name("")).(Name)
+	inWorld := atomic.LoadUint64(&ob.seq) != 0
+
+	w.names.mu.Lock()
+	old, indexed := w.names.names[ob]
+	if indexed && (!named || !inWorld || old != name) {
+		delete(w.names.objects, old)
+		delete(w.names.names, ob)
+	}
+	if !named || !inWorld || (indexed && old == name) {
+		w.names.mu.Unlock()
+		return
+	}
+	if owner, ok := w.names.objects[name]; ok && owner != ob {
+		w.names.mu.Unlock()
+		w.reportDuplicateName(ob, name, owner)
+		return
+	}
+	if w.names.objects == nil {
+		w.names.objects = make(map[Name]*Object)
+		w.names.names = make(map[*Object]Name)
+	}
+	w.names.objects[name] = ob
+	w.names.names[ob] = name
+	w.names.mu.Unlock()
+}
+
+func (w *World) reportDuplicateName(ob *Object, name Name, owner *Object) {
+	err := fmt.Errorf("%w: %q is already the name of entity %d", ErrDuplicateName, name, owner.entity)
+	if w.OnError != nil {
+		(w.OnError)("", []interface{}{name}, err)
+		return
+	}
+	w.logger().Warn("duplicate name not indexed", "entity", ob.entity, "error", err)
+}
@@ -0,0 +1,78 @@
+package ecs_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestFind(t *testing.T) {
+	world := ecs.NewWorld()
+	var errs []error
+	world.OnError = func(_ string, _ []interface{}, err error) {
+		errs = append(errs, err)
+	}
+
+	boss := ecs.NewObject(ecs.Name("boss"), Position(0))
+	world.AddObject(boss)
+	if got := world.Find("boss"); got != boss {
+		t.Errorf("bad object for boss: got %v", got)
+	}
+	if got := world.Find("minion"); got != nil {
+		t.Errorf("expected no minion, got %v", got)
+	}
+
+	// names are unique
+	impostor := ecs.NewObject(ecs.Name("boss"))
+	world.AddObject(impostor)
+	if len(errs) != 1 || !errors.Is(errs[0], ecs.ErrDuplicateName) {
+		t.Errorf("expected a duplicate name error, got %v", errs)
+	}
+	if got := world.Find("boss"); got != boss {
+		t.Error("duplicate name replaced the original")
+	}
+
+	// renaming, including by a system, updates the index
+	boss.SetComponent(ecs.Name("final boss"))
+	if world.Find("boss") != nil || world.Find("final boss") != boss {
+		t.Error("index wasn't updated after renaming")
+	}
+	world.AddSystem(ecs.System{Func: func(n ecs.Name, _ Position) ecs.Name {
+		return n + "!"
+	}})
+	world.Step()
+	if world.Find("final boss!") != boss {
+		t.Error("index wasn't updated after a system renamed the object")
+	}
+
+	impostor.RemoveComponent(ecs.Name(""))
+	impostor.AddComponent(ecs.Name("boss"))
+	if world.Find("boss") != impostor {
+		t.Error("freed name wasn't indexed")
+	}
+	world.RemoveObject(boss.Entity())
+	if world.Find("final boss!") != nil {
+		t.Error("removed object is still indexed")
+	}
+}
+
+func TestSceneNames(t *testing.T) {
+	ecs.Register(Position(0))
+	world := ecs.NewWorld()
+	entities, err := world.LoadScene(strings.NewReader(`{"entities": [
+		{"name": "spawn", "components": {"ecs_test.Position": 3}},
+		{"components": {"ecs_test.Position": 4}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ob := world.Find("spawn")
+	if ob == nil || ob.Entity() != entities[0] {
+		t.Fatalf("bad object for spawn: %v", ob)
+	}
+	if got := ob.Component(Position(0)); got != Position(3) {
+		t.Errorf("bad position: got %v, want 3", got)
+	}
+}
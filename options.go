@@ -0,0 +1,50 @@
+package ecs
+
+// Option configures a world when it is created.
+type Option func(*World)
+
+// WithParallelism limits the number of goroutines that may be ticking the
+// world's systems at any one time, including the extra goroutines used by
+// parallel systems. This is independent of GOMAXPROCS, which makes it possible
+// to divide CPU time predictably between several worlds in the same process.
+// A limit of zero or less means no limit, which is the default.
+func WithParallelism(n int) Option {
+	return func(w *World) {
+		if n > 0 {
+			w.slots = make(chan struct{}, n)
+		} else {
+			w.slots = nil
+		}
+	}
+}
+
+func (w *World) acquireSlot() {
+	if w.slots != nil {
+		w.slots <- struct{}{}
+	}
+}
+
+// tryAcquireSlots acquires up to n slots without waiting, and returns how
+// many it got.
+func (w *World) tryAcquireSlots(n int) int {
+	if w.slots == nil {
+		return n
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case w.slots <- struct{}{}:
+		default:
+			return i
+		}
+	}
+	return n
+}
+
+func (w *World) releaseSlots(n int) {
+	if w.slots == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		<-w.slots
+	}
+}
@@ -0,0 +1,36 @@
+package ecs_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestWithParallelism(t *testing.T) {
+	var active, maxActive int32
+	work := func() {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&active, -1)
+	}
+
+	world := ecs.NewWorld(ecs.WithParallelism(2))
+	for i := 0; i < 5; i++ {
+		world.AddObject(ecs.NewObject(Position(i)))
+		world.AddSystem(ecs.System{Func: func(Position) { work() }, Parallel: true})
+	}
+
+	world.Run()
+
+	if got := atomic.LoadInt32(&maxActive); got > 2 {
+		t.Errorf("too many goroutines ticking at once: got %d, want at most 2", got)
+	}
+}
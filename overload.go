@@ -0,0 +1,68 @@
+package ecs
+
+import "sync/atomic"
+
+// SystemSkipped is emitted whenever a system's tick is skipped to shed load.
+// Producer is empty if the system was skipped because of its own priority,
+// or else names the system it depends on that was skipped first.
+type SystemSkipped struct {
+	System   string
+	Producer string
+}
+
+// ShedLoad makes the world skip the ticks of every system with a priority
+// below the given one, until StopShedding is called. It is meant to be
+// called by whatever monitors the world's frame budget.
+//
+// A system's priority is inherited by the systems it depends on, so a
+// high-priority system never loses its inputs to a low-priority producer.
+// Conversely, once a producer has been skipped, the systems that depend on it
+// are skipped too until it ticks again, so that they never run against stale
+// data.
+func (w *World) ShedLoad(priority int) {
+	atomic.StoreInt64(&w.shedBelow, int64(priority))
+	atomic.StoreInt32(&w.shedding, 1)
+}
+
+// StopShedding stops skipping systems because of their priority.
+func (w *World) StopShedding() {
+	atomic.StoreInt32(&w.shedding, 0)
+}
+
+// shed reports whether a system's tick should be skipped to shed load, and if
+// so, which producer caused it.
+func (w *World) shed(s *scheduledSystem) (producer string, skip bool) {
+	for _, name := range s.DependsOn {
+		if p := w.findSystem(name); p != nil && atomic.LoadInt32(&p.shed) != 0 {
+			return name, true
+		}
+	}
+	if atomic.LoadInt32(&w.shedding) != 0 {
+		if int64(w.effectivePriority(s, make(map[*scheduledSystem]bool))) < atomic.LoadInt64(&w.shedBelow) {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// effectivePriority returns the highest priority of s and every system that
+// depends on it, directly or indirectly.
+func (w *World) effectivePriority(s *scheduledSystem, seen map[*scheduledSystem]bool) int {
+	seen[s] = true
+	priority := s.Priority
+	name := s.name()
+	for _, d := range w.systemList() {
+		if seen[d] {
+			continue
+		}
+		for _, dep := range d.DependsOn {
+			if dep == name {
+				if p := w.effectivePriority(d, seen); p > priority {
+					priority = p
+				}
+				break
+			}
+		}
+	}
+	return priority
+}
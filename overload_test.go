@@ -0,0 +1,69 @@
+package ecs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestShedLoad(t *testing.T) {
+	world := ecs.NewWorld()
+
+	// every tick reports either that it ran or why it was skipped
+	out := make(chan string, 1)
+	world.OnEvent(ecs.SystemSkipped{}, func(ev interface{}) {
+		skipped := ev.(ecs.SystemSkipped)
+		out <- "skip " + skipped.System + " " + skipped.Producer
+	})
+
+	tickers := make(map[string]chan time.Time)
+	add := func(name string, priority int, dependsOn ...string) {
+		ticker := make(chan time.Time)
+		tickers[name] = ticker
+		world.AddSystem(ecs.System{
+			Name:      name,
+			Global:    true,
+			Ticker:    ticker,
+			Priority:  priority,
+			DependsOn: dependsOn,
+			Func:      func() { out <- "ran " + name },
+		})
+	}
+	add("physics", 0)
+	add("render", 0, "physics")
+	add("pathfinding", 0)
+	add("ai", 5, "pathfinding")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		world.RunContext(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	tick := func(name, want string) {
+		t.Helper()
+		tickers[name] <- time.Now()
+		if got := <-out; got != want {
+			t.Errorf("ticking %s: got %q, want %q", name, got, want)
+		}
+	}
+
+	world.ShedLoad(1)
+	tick("physics", "skip physics ")
+	tick("render", "skip render physics")
+	tick("pathfinding", "ran pathfinding")
+	tick("ai", "ran ai")
+
+	// dependents keep skipping until their producer catches up
+	world.StopShedding()
+	tick("render", "skip render physics")
+	tick("physics", "ran physics")
+	tick("render", "ran render")
+}
@@ -0,0 +1,46 @@
+package ecs
+
+import (
+	"reflect"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// call invokes the system function, recovering from any panic. ok is false if
+// the function panicked.
+func (s System) call(w *World, f reflect.Value, args []reflect.Value, ob *Object) (results []reflect.Value, ok bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			atomic.AddInt64(&s.counters.panics, 1)
+			w.handleSystemPanic(s.name(), entityOf(ob), v, debug.Stack())
+			if s.DisableOnPanic {
+				s.failure.set(&PanicError{Value: v})
+				s.scheduled.setEnabled(w, false)
+			}
+		}
+	}()
+	if s.arena.invoker != nil {
+		results = args[len(args):cap(args)]
+		s.arena.invoker.Invoke(args, results)
+		return results, true
+	}
+	return f.Call(args), true
+}
+
+func (w *World) handleSystemPanic(name string, entity Entity, value interface{}, stack []byte) {
+	w.metrics().Counter("ecs_system_panics", 1, "system", name)
+	if w.OnPanic != nil {
+		(w.OnPanic)(name, entity, value, stack)
+		return
+	}
+
+	w.logger().Error("system panicked", "system", name, "entity", entity, "panic", value, "stack", string(stack))
+}
+
+// entityOf returns the entity of ob, or zero for global systems.
+func entityOf(ob *Object) Entity {
+	if ob == nil {
+		return 0
+	}
+	return ob.entity
+}
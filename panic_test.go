@@ -0,0 +1,72 @@
+package ecs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	world := ecs.NewWorld()
+	bad := world.AddObject(ecs.NewObject(Position(0)))
+	good := ecs.NewObject(Position(1))
+	world.AddObject(good)
+
+	world.AddSystem(ecs.System{
+		Name: "divide",
+		Func: func(p Position) Position {
+			return 10 / p
+		},
+		DisableOnPanic: true,
+	})
+
+	var panics []ecs.Entity
+	world.OnPanic = func(name string, entity ecs.Entity, value interface{}, stack []byte) {
+		if name != "divide" {
+			t.Errorf("bad system name: %s", name)
+		}
+		if !strings.Contains(string(stack), "panic") {
+			t.Errorf("stack trace missing: %s", stack)
+		}
+		panics = append(panics, entity)
+	}
+
+	world.Run()
+
+	if len(panics) != 1 || panics[0] != bad {
+		t.Errorf("expected one panic from entity %v, got %v", bad, panics)
+	}
+	if got, want := good.Component(Position(0)), Position(10); got != want {
+		t.Errorf("other objects should still be processed: got %v, want %v", got, want)
+	}
+	if info, _ := world.LookupSystem("divide"); info.Enabled {
+		t.Error("system should have been disabled after panicking")
+	}
+}
+
+func TestPanicSystemSharingName(t *testing.T) {
+	world := ecs.NewWorld()
+	world.OnPanic = func(string, ecs.Entity, interface{}, []byte) {}
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	var healthy, panicking int
+	world.AddSystem(ecs.System{Name: "divide", Func: func(Position) { healthy++ }})
+	world.AddSystem(ecs.System{
+		Name: "divide",
+		Func: func(Position) {
+			panicking++
+			panic("boom")
+		},
+		DisableOnPanic: true,
+	})
+	world.Step()
+	world.Step()
+
+	if healthy != 2 {
+		t.Errorf("system sharing a panicking system's name ticked %d times, want 2", healthy)
+	}
+	if panicking != 1 {
+		t.Errorf("panicking system ticked %d times, want 1", panicking)
+	}
+}
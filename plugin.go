@@ -0,0 +1,32 @@
+package ecs
+
+import "fmt"
+
+// Plugin packages up a reusable subsystem, such as physics or input handling,
+// so that it can be added to a world in a single call. Build should register
+// everything the subsystem needs, including systems, resources, finalizers,
+// and event handlers, and return the first error it runs into, such as one
+// from AddSystem.
+type Plugin interface {
+	Build(w *World) error
+}
+
+// AddPlugin adds every part of a plugin to the world, returning the error
+// from its Build method, if any.
+func (w *World) AddPlugin(p Plugin) error {
+	if err := p.Build(w); err != nil {
+		return fmt.Errorf("building plugin %T: %w", p, err)
+	}
+	return nil
+}
+
+// AddPlugins adds several plugins to the world, in order, stopping at the
+// first one that fails to build.
+func (w *World) AddPlugins(ps ...Plugin) error {
+	for _, p := range ps {
+		if err := w.AddPlugin(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
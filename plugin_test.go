@@ -0,0 +1,46 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Score struct{ Points int }
+
+type scoringPlugin struct{}
+
+func (scoringPlugin) Build(w *ecs.World) error {
+	w.AddResource(&Score{})
+	return w.AddSystem(ecs.System{Func: func(score *Score, _ Target) {
+		score.Points++
+	}})
+}
+
+func TestPlugin(t *testing.T) {
+	world := ecs.NewWorld()
+	if err := world.AddPlugin(scoringPlugin{}); err != nil {
+		t.Fatalf("failed to add plugin: %s", err)
+	}
+	world.AddObject(ecs.NewObject(Target{}))
+	world.AddObject(ecs.NewObject(Target{}))
+
+	world.Run()
+
+	if got, want := world.Resource(&Score{}).(*Score).Points, 2; got != want {
+		t.Errorf("bad score: got %d, want %d", got, want)
+	}
+}
+
+type brokenPlugin struct{}
+
+func (brokenPlugin) Build(w *ecs.World) error {
+	return w.AddSystem(ecs.System{Func: "not a function"})
+}
+
+func TestPluginError(t *testing.T) {
+	world := ecs.NewWorld()
+	if err := world.AddPlugins(scoringPlugin{}, brokenPlugin{}); err == nil {
+		t.Error("expected an error from a plugin whose system is invalid")
+	}
+}
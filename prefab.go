@@ -0,0 +1,60 @@
+package ecs
+
+import "reflect"
+
+// Prefab is a blueprint for objects that share the same starting components,
+// such as a particular kind of enemy. Every object spawned from a prefab
+// receives its own deep copy of the prefab's components.
+type Prefab struct {
+	components []interface{}
+}
+
+// NewPrefab creates a prefab from a set of component prototypes. The
+// prototypes are copied, so later changes to them don't affect the prefab.
+func NewPrefab(cs ...interface{}) *Prefab {
+	p := &Prefab{components: make([]interface{}, len(cs))}
+	for i, c := range cs {
+		p.components[i] = deepCopy(c)
+	}
+	return p
+}
+
+// PrefabOf creates a prefab from the current components of an existing
+// object, which can be used to clone it.
+func PrefabOf(ob *Object) *Prefab {
+	return NewPrefab(ob.Components()...)
+}
+
+// Components returns the prefab's component prototypes.
+func (p *Prefab) Components() []interface{} {
+	return p.components
+}
+
+// Instantiate creates a new object with copies of the prefab's components.
+// Each override replaces the prefab's component of the same type, or is added
+// to the object if the prefab has no component of that type.
+func (p *Prefab) Instantiate(overrides ...interface{}) *Object {
+	cs := make([]interface{}, len(p.components), len(p.components)+len(overrides))
+	for i, c := range p.components {
+		cs[i] = deepCopy(c)
+	}
+
+ol:
+	for _, override := range overrides {
+		t := reflect.TypeOf(override)
+		for i, c := range cs {
+			if reflect.TypeOf(c) == t {
+				cs[i] = override
+				continue ol
+			}
+		}
+		cs = append(cs, override)
+	}
+
+	return NewObject(cs...)
+}
+
+// Spawn instantiates a prefab and adds the new object to the world.
+func (w *World) Spawn(p *Prefab, overrides ...interface{}) Entity {
+	return w.AddObject(p.Instantiate(overrides...))
+}
@@ -0,0 +1,40 @@
+package ecs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestPrefab(t *testing.T) {
+	type Inventory struct {
+		Items []string
+	}
+
+	world := ecs.NewWorld()
+	goblin := ecs.NewPrefab(Position(0), Velocity(1), Inventory{Items: []string{"club"}})
+
+	first := world.GetObject(world.Spawn(goblin, Position(10)))
+	second := world.GetObject(world.Spawn(goblin, Target{}))
+
+	if got, want := first.Components(), []interface{}{Position(10), Velocity(1), Inventory{Items: []string{"club"}}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad components: got %v, want %v", got, want)
+	}
+	if got, want := second.Components(), []interface{}{Position(0), Velocity(1), Inventory{Items: []string{"club"}}, Target{}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad components: got %v, want %v", got, want)
+	}
+
+	first.Component(Inventory{}).(Inventory).Items[0] = "sword"
+	if got, want := second.Component(Inventory{}).(Inventory).Items[0], "club"; got != want {
+		t.Errorf("spawned objects share inventory: got %s, want %s", got, want)
+	}
+
+	clone := ecs.PrefabOf(first).Instantiate()
+	if clone.Entity() == first.Entity() {
+		t.Error("clone should have its own entity")
+	}
+	if got, want := clone.Components(), first.Components(); !reflect.DeepEqual(got, want) {
+		t.Errorf("bad clone: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package ecs
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TickStats describes a single tick of a system.
+type TickStats struct {
+	Duration time.Duration
+
+	// Matched is the number of objects the system was invoked on. It is
+	// always zero for global systems.
+	Matched int
+
+	// Errors counts the errors the system returned, not including
+	// ErrSkipEntity and ErrStopSystem, and Panics counts the panics that
+	// were recovered from it.
+	Errors int
+	Panics int
+}
+
+// tickCounters accumulates a scheduled system's TickStats during a tick.
+type tickCounters struct {
+	matched, errors, panics int64
+}
+
+func (c *tickCounters) reset() {
+	atomic.StoreInt64(&c.matched, 0)
+	atomic.StoreInt64(&c.errors, 0)
+	atomic.StoreInt64(&c.panics, 0)
+}
+
+func (c *tickCounters) stats(d time.Duration) TickStats {
+	return TickStats{
+		Duration: d,
+		Matched:  int(atomic.LoadInt64(&c.matched)),
+		Errors:   int(atomic.LoadInt64(&c.errors)),
+		Panics:   int(atomic.LoadInt64(&c.panics)),
+	}
+}
+
+// A Histogram summarizes a series of non-negative samples. Samples are
+// counted in buckets whose upper bounds are successive powers of two, so
+// quantiles are accurate to within a factor of two.
+type Histogram struct {
+	Count    int
+	Sum      float64
+	Min, Max float64
+
+	// Buckets[i] counts the samples in (2^(i-1), 2^i], except for
+	// Buckets[0], which counts samples of at most 1.
+	Buckets []int
+}
+
+func (h *Histogram) add(v float64) {
+	if h.Count == 0 || v < h.Min {
+		h.Min = v
+	}
+	if h.Count == 0 || v > h.Max {
+		h.Max = v
+	}
+	h.Count++
+	h.Sum += v
+
+	i := 0
+	if v > 1 {
+		i = int(math.Ceil(math.Log2(v)))
+	}
+	for len(h.Buckets) <= i {
+		h.Buckets = append(h.Buckets, 0)
+	}
+	h.Buckets[i]++
+}
+
+// Mean returns the average of the samples.
+func (h Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+// Quantile returns an upper bound for the q-quantile of the samples, for q
+// between 0 and 1.
+func (h Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q * float64(h.Count)))
+	seen := 0
+	for i, n := range h.Buckets {
+		seen += n
+		if seen >= rank {
+			return math.Min(math.Ldexp(1, i), h.Max)
+		}
+	}
+	return h.Max
+}
+
+func (h Histogram) clone() Histogram {
+	h.Buckets = append([]int(nil), h.Buckets...)
+	return h
+}
+
+// SystemProfile aggregates the TickStats of a single system.
+type SystemProfile struct {
+	Name  string
+	Ticks int
+
+	// Duration is measured in microseconds.
+	Duration Histogram
+	Matched  Histogram
+
+	Errors int
+	Panics int
+}
+
+// A Profiler collects per-system statistics. Its Record method can be used
+// as a World's OnSystemTick callback:
+//
+//     profiler := ecs.NewProfiler()
+//     world.OnSystemTick = profiler.Record
+type Profiler struct {
+	mu       sync.Mutex
+	profiles map[string]*SystemProfile
+}
+
+// NewProfiler creates a profiler with nothing recorded.
+func NewProfiler() *Profiler {
+	return &Profiler{profiles: make(map[string]*SystemProfile)}
+}
+
+// Record adds the stats for one tick of the named system.
+func (p *Profiler) Record(name string, stats TickStats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sp, ok := p.profiles[name]
+	if !ok {
+		sp = &SystemProfile{Name: name}
+		p.profiles[name] = sp
+	}
+	sp.Ticks++
+	sp.Duration.add(float64(stats.Duration) / float64(time.Microsecond))
+	sp.Matched.add(float64(stats.Matched))
+	sp.Errors += stats.Errors
+	sp.Panics += stats.Panics
+}
+
+// Profiles returns the profile of every system recorded so far, slowest
+// first by total time spent.
+func (p *Profiler) Profiles() []SystemProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	profiles := make([]SystemProfile, 0, len(p.profiles))
+	for _, sp := range p.profiles {
+		profile := *sp
+		profile.Duration = sp.Duration.clone()
+		profile.Matched = sp.Matched.clone()
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Duration.Sum != profiles[j].Duration.Sum {
+			return profiles[i].Duration.Sum > profiles[j].Duration.Sum
+		}
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// Reset discards everything recorded so far.
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.profiles = make(map[string]*SystemProfile)
+}
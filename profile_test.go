@@ -0,0 +1,82 @@
+package ecs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestOnSystemTick(t *testing.T) {
+	world := ecs.NewWorld()
+	world.OnError = func(string, []interface{}, error) {}
+	world.OnPanic = func(string, ecs.Entity, interface{}, []byte) {}
+
+	profiler := ecs.NewProfiler()
+	var stats []ecs.TickStats
+	world.OnSystemTick = func(name string, s ecs.TickStats) {
+		if name == "check" {
+			stats = append(stats, s)
+		}
+		profiler.Record(name, s)
+	}
+
+	for i := 0; i < 5; i++ {
+		world.AddObject(ecs.NewObject(Position(i)))
+	}
+	world.AddObject(ecs.NewObject(Velocity(1)))
+	world.AddSystem(ecs.System{
+		Name: "check",
+		Func: func(p Position) error {
+			switch p {
+			case 1:
+				return errors.New("odd")
+			case 2:
+				panic("even")
+			case 3:
+				return ecs.ErrSkipEntity
+			}
+			return nil
+		},
+	})
+	world.AddSystem(ecs.System{Name: "global", Global: true, Func: func() {}})
+
+	world.Run()
+	world.Run()
+
+	if len(stats) != 2 {
+		t.Fatalf("expected stats for two ticks, got %d", len(stats))
+	}
+	if s := stats[0]; s.Matched != 5 || s.Errors != 1 || s.Panics != 1 {
+		t.Errorf("bad stats: %+v", s)
+	}
+
+	profiles := profiler.Profiles()
+	if len(profiles) != 2 {
+		t.Fatalf("expected two profiles, got %d", len(profiles))
+	}
+	for _, p := range profiles {
+		if p.Ticks != 2 {
+			t.Errorf("%s: expected two ticks, got %d", p.Name, p.Ticks)
+		}
+		if p.Name == "check" {
+			if p.Errors != 2 || p.Panics != 2 || p.Matched.Max != 5 || p.Matched.Mean() != 5 {
+				t.Errorf("bad profile: %+v", p)
+			}
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	profiler := ecs.NewProfiler()
+	for i := 1; i <= 100; i++ {
+		profiler.Record("s", ecs.TickStats{Matched: i})
+	}
+	h := profiler.Profiles()[0].Matched
+	if got := h.Quantile(0.5); got < 50 || got > 64 {
+		t.Errorf("median should be bounded by its bucket: got %v", got)
+	}
+	if got := h.Quantile(1); got != 100 {
+		t.Errorf("max quantile should be the max: got %v", got)
+	}
+}
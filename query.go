@@ -0,0 +1,307 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+var objectType = reflect.TypeOf(&Object{})
+
+// A Query finds the objects in a world with, and without, certain components.
+// It offers the same matching as a system's parameters, but can be used
+// anywhere, such as in tests, editors, or network code.
+//
+//     world.Query().With(Enemy{}).Without(Dead{}).ForEach(func(p Position) {
+//         ...
+//     })
+type Query struct {
+	w       *World
+	with    []reflect.Type
+	without []reflect.Type
+
+	// withMask and withoutMask are the sets of with and without.
+	withMask, withoutMask componentMask
+
+	withTags, withoutTags []string
+
+	withRelations, withoutRelations []Relation
+
+	// withInterfaces and withoutInterfaces are the interface types in with
+	// and without, which masks can't represent.
+	withInterfaces, withoutInterfaces []reflect.Type
+}
+
+// Query starts a new query that matches every object.
+func (w *World) Query() *Query {
+	return &Query{w: w}
+}
+
+// With restricts the query to objects that have a component of each of the
+// given types. A nil pointer to an interface, such as (*Renderable)(nil),
+// matches objects with any component that implements it.
+func (q *Query) With(components ...interface{}) *Query {
+	for _, c := range components {
+		t := queryType(c)
+		q.with = append(q.with, t)
+		if t.Kind() == reflect.Interface {
+			q.withInterfaces = append(q.withInterfaces, t)
+			continue
+		}
+		q.withMask.set(componentID(t))
+	}
+	return q
+}
+
+// Without restricts the query to objects that have no component of any of
+// the given types. As with With, a nil pointer to an interface excludes
+// objects with any component that implements it.
+func (q *Query) Without(components ...interface{}) *Query {
+	for _, c := range components {
+		t := queryType(c)
+		q.without = append(q.without, t)
+		if t.Kind() == reflect.Interface {
+			q.withoutInterfaces = append(q.withoutInterfaces, t)
+			continue
+		}
+		q.withoutMask.set(componentID(t))
+	}
+	return q
+}
+
+// queryType returns the type a component passed to With or Without stands
+// for: its own, or the interface it points to.
+func queryType(c interface{}) reflect.Type {
+	t := reflect.TypeOf(c)
+	if t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+		return t.Elem()
+	}
+	return t
+}
+
+// candidates returns the objects that match the query, using the relation or
+// tag index if the query has relations or tags, or otherwise a match set if
+// the world has built one.
+func (q *Query) candidates() []*Object {
+	var objects []*Object
+	switch {
+	case len(q.withRelations) > 0:
+		r := q.withRelations[0]
+		objects = q.w.Sources(r.Kind, r.Target)
+	case len(q.withTags) > 0:
+		objects = q.w.tagged(q.withTags)
+	default:
+		objects = q.w.matching(q.withMask, q.withoutMask, false)
+		if len(q.withoutTags) == 0 && len(q.withoutRelations) == 0 && len(q.withInterfaces) == 0 && len(q.withoutInterfaces) == 0 {
+			return objects
+		}
+	}
+
+	// the objects may be shared with a match set, so filter into a copy
+	var filtered []*Object
+	for _, ob := range objects {
+		if q.matches(ob) {
+			filtered = append(filtered, ob)
+		}
+	}
+	return filtered
+}
+
+func (q *Query) matches(ob *Object) bool {
+	m := ob.componentMask()
+	if !m.containsAll(q.withMask) || m.intersects(q.withoutMask) || !q.matchesTags(ob) || !q.matchesRelations(ob) {
+		return false
+	}
+	for _, t := range q.withInterfaces {
+		if !ob.implements(t) {
+			return false
+		}
+	}
+	for _, t := range q.withoutInterfaces {
+		if ob.implements(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Objects returns every object that currently matches the query.
+func (q *Query) Objects() []*Object {
+	// copy, since the candidates may be shared with a match set
+	return append([]*Object(nil), q.candidates()...)
+}
+
+// Count returns the number of objects that currently match the query.
+func (q *Query) Count() int {
+	return len(q.candidates())
+}
+
+// Page returns up to limit matching objects with entities greater than after,
+// in ascending order of entity, and reports whether there are more. Passing
+// the last returned entity as after fetches the next page, so large worlds can
+// be browsed a page at a time; objects added or removed between pages are
+// neither skipped nor repeated. Pass zero to start from the beginning.
+func (q *Query) Page(after Entity, limit int) (objects []*Object, more bool) {
+	for _, ob := range q.candidates() {
+		if ob.entity > after {
+			objects = append(objects, ob)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].entity < objects[j].entity
+	})
+	if len(objects) > limit {
+		// copy, so the page doesn't pin every match in memory
+		return append([]*Object(nil), objects[:limit]...), true
+	}
+	return objects, false
+}
+
+// ForEach calls fn for every object that matches the query. fn's parameters
+// are filled in like a system's, and may be an Entity, an *Object, or any
+// component type; objects without one of the components fn accepts are
+// skipped. Like a system, fn may return components to be written back to the
+// object, and may return an error as its last result, which stops the
+// iteration and is returned by ForEach.
+//
+// ForEach holds the same locks a system with fn's signature would, so it is
+// safe to call while the world is running, but must not be called from within
+// a system that accepts any of the same components.
+func (q *Query) ForEach(fn interface{}) error {
+	f, err := queryFunc(fn)
+	if err != nil {
+		return err
+	}
+
+	locks := q.w.systemLocks(System{Func: fn})
+	lockAll(locks)
+	defer unlockAll(locks)
+
+	args := make([]reflect.Value, f.Type().NumIn())
+	for _, ob := range q.candidates() {
+		if err := q.call(f, args, ob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EachParallel is like ForEach, but calls fn from a pool of up to workers
+// goroutines, or GOMAXPROCS if workers isn't positive. Use it instead of
+// starting goroutines over Objects, which would race with the world's
+// systems. fn must be safe to call concurrently, though it is never called
+// concurrently for the same object.
+//
+// Iteration stops early if ctx is cancelled, or fn returns an error, and the
+// first such error is returned.
+func (q *Query) EachParallel(ctx context.Context, workers int, fn interface{}) error {
+	f, err := queryFunc(fn)
+	if err != nil {
+		return err
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	locks := q.w.systemLocks(System{Func: fn})
+	lockAll(locks)
+	defer unlockAll(locks)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	objects := make(chan *Object)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			args := make([]reflect.Value, f.Type().NumIn())
+			for ob := range objects {
+				if err := q.call(f, args, ob); err != nil {
+					errOnce.Do(func() { firstErr = err })
+					cancel()
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, ob := range q.candidates() {
+		select {
+		case objects <- ob:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(objects)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// only report cancellation by the caller, not by a worker's error
+	return context.Cause(ctx)
+}
+
+func queryFunc(fn interface{}) (reflect.Value, error) {
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func {
+		return f, fmt.Errorf("invalid query function of type %T", fn)
+	}
+	t := f.Type()
+	if t.IsVariadic() {
+		return f, errors.New("invalid query function: cannot be variadic")
+	}
+	for i := 0; i < t.NumOut(); i++ {
+		if t.Out(i) == errorType && i != t.NumOut()-1 {
+			return f, errors.New("invalid query function: only the last result can be an error")
+		}
+	}
+	return f, nil
+}
+
+// call invokes a query function on an object, if it matches the query and
+// has every component the function accepts, and writes back its results.
+func (q *Query) call(f reflect.Value, args []reflect.Value, ob *Object) error {
+	if !q.matches(ob) {
+		return nil
+	}
+	t := f.Type()
+	for i := range args {
+		switch in := t.In(i); in {
+		case entityType:
+			args[i] = reflect.ValueOf(ob.entity)
+		case objectType:
+			args[i] = reflect.ValueOf(ob)
+		default:
+			cv, ok := q.w.acceptedComponent(ob, in)
+			if !ok {
+				return nil
+			}
+			args[i] = cv
+		}
+	}
+
+	results := f.Call(args)
+	if n := len(results); n > 0 && t.Out(n-1) == errorType {
+		if err := results[n-1]; !err.IsNil() {
+			return err.Interface().(error)
+		}
+		results = results[:n-1]
+	}
+	for _, result := range results {
+		if _, err := q.w.writeBack(ob, result, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
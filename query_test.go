@@ -0,0 +1,150 @@
+package ecs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestQuery(t *testing.T) {
+	type Dead struct{}
+
+	world := ecs.NewWorld()
+	alive := ecs.NewObject(Player{}, Position(1), Velocity(2))
+	world.AddObject(alive)
+	world.AddObject(ecs.NewObject(Player{}, Position(5), Dead{}))
+	world.AddObject(ecs.NewObject(Position(10)))
+
+	q := world.Query().With(Player{}).Without(Dead{})
+	if got := q.Count(); got != 1 {
+		t.Errorf("expected one match, got %d", got)
+	}
+	if got := q.Objects(); len(got) != 1 || got[0] != alive {
+		t.Errorf("bad matches: %v", got)
+	}
+
+	var visited []ecs.Entity
+	err := q.ForEach(func(e ecs.Entity, p Position, v Velocity) Position {
+		visited = append(visited, e)
+		return p + Position(v)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(visited) != 1 || visited[0] != alive.Entity() {
+		t.Errorf("bad visits: %v", visited)
+	}
+	if got, want := alive.Component(Position(0)), Position(3); got != want {
+		t.Errorf("result should be written back: got %v, want %v", got, want)
+	}
+
+	// objects without a component fn accepts are skipped
+	var positions int
+	world.Query().ForEach(func(p Position, v Velocity) { positions++ })
+	if positions != 1 {
+		t.Errorf("expected one object with both components, got %d", positions)
+	}
+
+	stop := errors.New("stop")
+	var n int
+	err = world.Query().With(Position(0)).ForEach(func(ob *ecs.Object) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("error should stop iteration: got %v after %d calls", err, n)
+	}
+
+	if err := world.Query().ForEach(42); err == nil {
+		t.Error("expected an error for a non-function")
+	}
+}
+
+func TestQueryPage(t *testing.T) {
+	world := ecs.NewWorld()
+	var want []ecs.Entity
+	for i := 0; i < 5; i++ {
+		want = append(want, world.AddObject(ecs.NewObject(Position(i))))
+		world.AddObject(ecs.NewObject(Velocity(i)))
+	}
+
+	var got []ecs.Entity
+	var after ecs.Entity
+	pages := 0
+	for {
+		objects, more := world.Query().With(Position(0)).Page(after, 2)
+		pages++
+		for _, ob := range objects {
+			got = append(got, ob.Entity())
+		}
+		if !more {
+			break
+		}
+		after = objects[len(objects)-1].Entity()
+	}
+
+	if pages != 3 {
+		t.Errorf("expected 3 pages, got %d", pages)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEachParallel(t *testing.T) {
+	world := ecs.NewWorld()
+	for i := 0; i < 100; i++ {
+		world.AddObject(ecs.NewObject(Position(i), Velocity(1)))
+	}
+
+	var active, maxActive int32
+	err := world.Query().EachParallel(context.Background(), 4, func(p Position, v Velocity) Position {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&active, -1)
+		return p + Position(v)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := atomic.LoadInt32(&maxActive); got > 4 {
+		t.Errorf("too many workers: got %d, want at most 4", got)
+	}
+	sum := 0
+	for _, p := range ecs.Each[Position](world) {
+		sum += int(p)
+	}
+	if want := 99*100/2 + 100; sum != want {
+		t.Errorf("positions not all written back: got sum %d, want %d", sum, want)
+	}
+
+	boom := errors.New("boom")
+	err = world.Query().EachParallel(context.Background(), 4, func(p Position) error {
+		if p == 50 {
+			return boom
+		}
+		return nil
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("expected the function's error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := world.Query().EachParallel(ctx, 4, func(Position) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package ecs
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"sync"
+)
+
+var (
+	registryMu  sync.RWMutex
+	typesByName = make(map[string]reflect.Type)
+	namesByType = make(map[reflect.Type]string)
+	codecs      = make(map[reflect.Type]Codec)
+)
+
+// A Codec converts components of a single type to and from bytes, so that
+// they can be serialized with Protocol Buffers, FlatBuffers, or a custom
+// binary format. Snapshots, both binary and JSON, and replication through
+// ecsnet all use a component's codec if it has one.
+//
+// Components without a registered codec are encoded with gob, or
+// encoding/json for JSON snapshots. BinaryCodec adapts types that implement
+// encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+type Codec interface {
+	Encode(component interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// Register records the type of a component under its default name, the
+// package-qualified name of its type, so that it can be saved and loaded.
+func Register(component interface{}) {
+	RegisterName(reflect.TypeOf(component).String(), component)
+}
+
+// RegisterName records the type of a component under the given name, so that
+// it can be saved and loaded. Registering the same type under two names, or
+// two types under the same name, panics.
+func RegisterName(name string, component interface{}) {
+	t := reflect.TypeOf(component)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if other, ok := typesByName[name]; ok && other != t {
+		panic(fmt.Sprintf("ecs: registering duplicate types for %q: %s != %s", name, other, t))
+	}
+	if other, ok := namesByType[t]; ok && other != name {
+		panic(fmt.Sprintf("ecs: registering duplicate names for %s: %q != %q", t, other, name))
+	}
+	typesByName[name] = t
+	namesByType[t] = name
+}
+
+// RegisterCodec sets the codec used to encode components of the same type as
+// component. A nil codec removes the registered one. The type must also be
+// registered with Register or RegisterName.
+//
+// Registering a codec changes how the type is saved. JSON snapshots saved
+// before the codec was registered can still be loaded, but binary snapshots
+// and archives can't.
+func RegisterCodec(component interface{}, codec Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if codec == nil {
+		delete(codecs, reflect.TypeOf(component))
+		return
+	}
+	codecs[reflect.TypeOf(component)] = codec
+}
+
+// registeredCodec returns the codec registered for components of type t with
+// RegisterCodec, if any.
+func registeredCodec(t reflect.Type) Codec {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return codecs[t]
+}
+
+func registeredName(t reflect.Type) (string, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	name, ok := namesByType[t]
+	if !ok {
+		return "", fmt.Errorf("unregistered component type %s", t)
+	}
+	return name, nil
+}
+
+func registeredType(name string) (reflect.Type, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	t, ok := typesByName[name]
+	if !ok {
+		return nil, fmt.Errorf("unregistered component name %q", name)
+	}
+	return t, nil
+}
+
+// RegisteredTypes returns the names of all registered component types, each
+// mapped to a hash of its type's layout. Two builds that agree on a name's
+// hash can exchange components of that type.
+func RegisteredTypes() map[string]uint64 {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make(map[string]uint64, len(typesByName))
+	for name, t := range typesByName {
+		h := fnv.New64a()
+		writeLayout(h, t, make(map[reflect.Type]bool))
+		types[name] = h.Sum64()
+	}
+	return types
+}
+
+// writeLayout writes a description of t's structure that ignores its name
+// and package, so that moving or renaming a type doesn't change its hash.
+func writeLayout(w io.Writer, t reflect.Type, seen map[reflect.Type]bool) {
+	if seen[t] {
+		fmt.Fprint(w, "cycle;")
+		return
+	}
+	fmt.Fprintf(w, "%s;", t.Kind())
+	switch t.Kind() {
+	case reflect.Struct:
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fmt.Fprintf(w, "%s:", f.Name)
+			writeLayout(w, f.Type, seen)
+		}
+		delete(seen, t)
+		fmt.Fprint(w, "end;")
+	case reflect.Array:
+		fmt.Fprintf(w, "%d:", t.Len())
+		writeLayout(w, t.Elem(), seen)
+	case reflect.Ptr, reflect.Slice:
+		seen[t] = true
+		writeLayout(w, t.Elem(), seen)
+		delete(seen, t)
+	case reflect.Map:
+		writeLayout(w, t.Key(), seen)
+		writeLayout(w, t.Elem(), seen)
+	}
+}
@@ -0,0 +1,267 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// A RelationKind names a kind of relationship between objects, such as
+// "likes", "targets", or "member-of". Unlike Parent, which forms a tree, an
+// object can have any number of relations, of any number of kinds, to any
+// number of other objects, forming a general graph.
+type RelationKind string
+
+// AnyRelation matches relations of every kind when looking relations up.
+const AnyRelation RelationKind = ""
+
+// A Relation relates an object to a target entity.
+type Relation struct {
+	Kind   RelationKind
+	Target Entity
+}
+
+// Relations is a component holding an object's relations to other objects:
+//
+//     const Targets ecs.RelationKind = "targets"
+//
+//     turret.Relate(Targets, enemy.Entity())
+//     for _, ob := range world.Sources(Targets, enemy.Entity()) {
+//         ...
+//     }
+//
+// The world indexes objects by their relations, so finding every object with
+// a relation to a particular target is fast. When an object is removed from
+// the world, every relation targeting it is removed as well, and when
+// objects are merged, relations targeting the merged object are moved to the
+// one that remains.
+//
+// Relations are kept sorted and without duplicates, and a Relations value is
+// never modified once it belongs to an object; use Relate and Unrelate to
+// change them.
+type Relations []Relation
+
+var (
+	relationsType = reflect.TypeOf(Relations(nil))
+	relationsID   = componentID(relationsType)
+
+	// relateMu serializes Relate and Unrelate, which replace the Relations
+	// component based on its current value.
+	relateMu sync.Mutex
+)
+
+func init() {
+	Register(Relations(nil))
+}
+
+func (r Relation) less(other Relation) bool {
+	if r.Kind != other.Kind {
+		return r.Kind < other.Kind
+	}
+	return r.Target < other.Target
+}
+
+// Has reports whether there is a relation of the given kind to target. If
+// kind is AnyRelation, a relation of any kind will do.
+func (rs Relations) Has(kind RelationKind, target Entity) bool {
+	if kind == AnyRelation {
+		for _, r := range rs {
+			if r.Target == target {
+				return true
+			}
+		}
+		return false
+	}
+	want := Relation{kind, target}
+	i := sort.Search(len(rs), func(i int) bool { return !rs[i].less(want) })
+	return i < len(rs) && rs[i] == want
+}
+
+// Targets returns the targets of the relations of the given kind, or of
+// every kind if kind is AnyRelation.
+func (rs Relations) Targets(kind RelationKind) []Entity {
+	var targets []Entity
+	for _, r := range rs {
+		if kind == AnyRelation || r.Kind == kind {
+			targets = append(targets, r.Target)
+		}
+	}
+	return targets
+}
+
+// Relate adds a relation of the given kind from the object to target.
+func (ob *Object) Relate(kind RelationKind, target Entity) {
+	relateMu.Lock()
+	defer relateMu.Unlock()
+	current := ob.Relations()
+	if current.Has(kind, target) {
+		return
+	}
+	updated := append(append(Relations(nil), current...), Relation{kind, target})
+	sort.Slice(updated, func(i, j int) bool { return updated[i].less(updated[j]) })
+	ob.SetComponent(updated)
+}
+
+// Unrelate removes the object's relation of the given kind to target, or
+// every relation to target if kind is AnyRelation. The Relations component is
+// removed along with the last relation.
+func (ob *Object) Unrelate(kind RelationKind, target Entity) {
+	relateMu.Lock()
+	defer relateMu.Unlock()
+	ob.unrelateLocked(kind, target)
+}
+
+func (ob *Object) unrelateLocked(kind RelationKind, target Entity) {
+	current := ob.Relations()
+	var updated Relations
+	for _, r := range current {
+		if r.Target != target || (kind != AnyRelation && r.Kind != kind) {
+			updated = append(updated, r)
+		}
+	}
+	switch {
+	case len(updated) == len(current):
+	case len(updated) == 0:
+		ob.RemoveComponent(Relations(nil))
+	default:
+		ob.SetComponent(updated)
+	}
+}
+
+// Relations returns the object's relations, which must not be modified.
+func (ob *Object) Relations() Relations {
+	rs, _ := ob.Component(Relations(nil)).(Relations)
+	return rs
+}
+
+// RelatedTo reports whether the object has a relation of the given kind to
+// target, or of any kind if kind is AnyRelation.
+func (ob *Object) RelatedTo(kind RelationKind, target Entity) bool {
+	return ob.Relations().Has(kind, target)
+}
+
+// Sources returns every object in the world with a relation of the given kind
+// to target, or of any kind if kind is AnyRelation, in the order they were
+// added to the world.
+func (w *World) Sources(kind RelationKind, target Entity) []*Object {
+	w.relations.mu.RLock()
+	set := w.relations.objects[Relation{kind, target}]
+	objects := make([]*Object, 0, len(set))
+	for ob := range set {
+		objects = append(objects, ob)
+	}
+	w.relations.mu.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return atomic.LoadUint64(&objects[i].seq) < atomic.LoadUint64(&objects[j].seq)
+	})
+	return objects
+}
+
+// WithRelation restricts the query to objects with a relation of the given
+// kind to target, or of any kind if kind is AnyRelation.
+func (q *Query) WithRelation(kind RelationKind, target Entity) *Query {
+	q.withRelations = append(q.withRelations, Relation{kind, target})
+	return q
+}
+
+// WithoutRelation restricts the query to objects without a relation of the
+// given kind to target, or of any kind if kind is AnyRelation.
+func (q *Query) WithoutRelation(kind RelationKind, target Entity) *Query {
+	q.withoutRelations = append(q.withoutRelations, Relation{kind, target})
+	return q
+}
+
+// matchesRelations reports whether the object's relations satisfy the query.
+func (q *Query) matchesRelations(ob *Object) bool {
+	if len(q.withRelations) == 0 && len(q.withoutRelations) == 0 {
+		return true
+	}
+	rs := ob.Relations()
+	for _, r := range q.withRelations {
+		if !rs.Has(r.Kind, r.Target) {
+			return false
+		}
+	}
+	for _, r := range q.withoutRelations {
+		if rs.Has(r.Kind, r.Target) {
+			return false
+		}
+	}
+	return true
+}
+
+// relationIndex maps relations to the objects that have them. Each object is
+// indexed under each of its relations, and under each of their targets with
+// AnyRelation.
+type relationIndex struct {
+	mu        sync.RWMutex
+	objects   map[Relation]map[*Object]struct{}
+	relations map[*Object][]Relation
+}
+
+// indexRelations updates the index for an object whose relations may have
+// changed, or which may have been added to or removed from the world.
+func (w *World) indexRelations(ob *Object) {
+	var keys []Relation
+	if atomic.LoadUint64(&ob.seq) != 0 {
+		for _, r := range ob.Relations() {
+			keys = append(keys, r)
+			if wildcard := (Relation{AnyRelation, r.Target}); !containsRelation(keys, wildcard) {
+				keys = append(keys, wildcard)
+			}
+		}
+	}
+
+	w.relations.mu.Lock()
+	defer w.relations.mu.Unlock()
+	for _, key := range w.relations.relations[ob] {
+		if !containsRelation(keys, key) {
+			delete(w.relations.objects[key], ob)
+			if len(w.relations.objects[key]) == 0 {
+				delete(w.relations.objects, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		delete(w.relations.relations, ob)
+		return
+	}
+	if w.relations.objects == nil {
+		w.relations.objects = make(map[Relation]map[*Object]struct{})
+		w.relations.relations = make(map[*Object][]Relation)
+	}
+	for _, key := range keys {
+		set, ok := w.relations.objects[key]
+		if !ok {
+			set = make(map[*Object]struct{})
+			w.relations.objects[key] = set
+		}
+		set[ob] = struct{}{}
+	}
+	w.relations.relations[ob] = keys
+}
+
+func containsRelation(rs []Relation, r Relation) bool {
+	for _, other := range rs {
+		if other == r {
+			return true
+		}
+	}
+	return false
+}
+
+// unrelateTarget removes every relation targeting an entity that has been
+// removed from the world.
+func (w *World) unrelateTarget(target Entity) {
+	sources := w.Sources(AnyRelation, target)
+	if len(sources) == 0 {
+		return
+	}
+	relateMu.Lock()
+	defer relateMu.Unlock()
+	for _, ob := range sources {
+		ob.unrelateLocked(AnyRelation, target)
+	}
+}
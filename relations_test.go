@@ -0,0 +1,89 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+const (
+	likes   ecs.RelationKind = "likes"
+	targets ecs.RelationKind = "targets"
+)
+
+func TestRelations(t *testing.T) {
+	world := ecs.NewWorld()
+	alice := ecs.NewObject(Position(0))
+	bob := ecs.NewObject(Position(1))
+	turret := ecs.NewObject(Velocity(0))
+	alice.Relate(likes, bob.Entity())
+	world.AddObject(alice)
+	world.AddObject(bob)
+	world.AddObject(turret)
+	bob.Relate(likes, alice.Entity())
+	turret.Relate(targets, bob.Entity())
+	turret.Relate(targets, bob.Entity())
+
+	if got := turret.Relations(); len(got) != 1 {
+		t.Errorf("bad relations: got %v, want 1", got)
+	}
+	if !alice.RelatedTo(likes, bob.Entity()) || alice.RelatedTo(targets, bob.Entity()) {
+		t.Error("bad RelatedTo results")
+	}
+	if !turret.RelatedTo(ecs.AnyRelation, bob.Entity()) {
+		t.Error("wildcard RelatedTo didn't match")
+	}
+
+	if got := world.Sources(likes, bob.Entity()); len(got) != 1 || got[0] != alice {
+		t.Errorf("bad sources: %v", got)
+	}
+	if got := world.Sources(ecs.AnyRelation, bob.Entity()); len(got) != 2 || got[0] != alice || got[1] != turret {
+		t.Errorf("bad wildcard sources: %v", got)
+	}
+	if got := world.Query().WithRelation(likes, bob.Entity()).With(Position(0)).Count(); got != 1 {
+		t.Errorf("bad relation query count: got %d, want 1", got)
+	}
+	if got := world.Query().With(Position(0)).WithoutRelation(ecs.AnyRelation, bob.Entity()).Count(); got != 1 {
+		t.Errorf("bad negated relation query count: got %d, want 1", got)
+	}
+
+	turret.Unrelate(targets, bob.Entity())
+	if ecs.Has[ecs.Relations](turret) {
+		t.Error("empty relations weren't removed")
+	}
+	if got := world.Sources(ecs.AnyRelation, bob.Entity()); len(got) != 1 {
+		t.Errorf("bad sources after unrelating: got %d, want 1", len(got))
+	}
+}
+
+func TestRelationsTargetRemoved(t *testing.T) {
+	world := ecs.NewWorld()
+	leader := ecs.NewObject(Position(0))
+	follower := ecs.NewObject(Position(1))
+	world.AddObject(leader)
+	world.AddObject(follower)
+	follower.Relate(likes, leader.Entity())
+	follower.Relate(targets, leader.Entity())
+
+	world.RemoveObject(leader.Entity())
+	if ecs.Has[ecs.Relations](follower) {
+		t.Errorf("relations to removed target weren't removed: %v", follower.Relations())
+	}
+	if got := world.Sources(ecs.AnyRelation, leader.Entity()); len(got) != 0 {
+		t.Errorf("removed target still has sources: %v", got)
+	}
+}
+
+func TestRelationsSourceRemoved(t *testing.T) {
+	world := ecs.NewWorld()
+	target := ecs.NewObject(Position(0))
+	source := ecs.NewObject(Position(1))
+	world.AddObject(target)
+	world.AddObject(source)
+	source.Relate(targets, target.Entity())
+
+	world.RemoveObject(source.Entity())
+	if got := world.Sources(targets, target.Entity()); len(got) != 0 {
+		t.Errorf("removed source still indexed: %v", got)
+	}
+}
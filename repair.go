@@ -0,0 +1,95 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A RepairFunc checks a component for invariant violations. If the component
+// is valid it returns a nil error; otherwise it returns the repaired
+// component along with an error describing what was wrong.
+type RepairFunc func(entity Entity, component interface{}) (interface{}, error)
+
+// Repaired is emitted whenever a repair function fixes a component.
+type Repaired struct {
+	Entity    Entity
+	Original  interface{}
+	Repaired  interface{}
+	Violation error
+}
+
+type repairs struct {
+	mu  sync.RWMutex
+	fns map[reflect.Type][]RepairFunc
+}
+
+// AddRepair registers a function that checks, and if necessary repairs, every
+// component of the same type as component at each tick boundary, such as by
+// clamping a position into the world's bounds or zeroing a velocity that has
+// become NaN. Each repair is reported with a Repaired event, so that servers
+// can heal themselves while still recording what went wrong.
+//
+// Repairs run in the order they were registered, each one seeing the result
+// of the last.
+func (w *World) AddRepair(component interface{}, fn RepairFunc) {
+	w.repairs.mu.Lock()
+	defer w.repairs.mu.Unlock()
+	if w.repairs.fns == nil {
+		w.repairs.fns = make(map[reflect.Type][]RepairFunc)
+	}
+	t := reflect.TypeOf(component)
+	w.repairs.fns[t] = append(w.repairs.fns[t], fn)
+}
+
+// repair runs every repair function over the world's components. Components
+// are checked under their type's lock, since systems on other tickers may
+// still be running.
+func (w *World) repair() {
+	w.repairs.mu.RLock()
+	defer w.repairs.mu.RUnlock()
+	if len(w.repairs.fns) == 0 {
+		return
+	}
+
+	for _, ob := range w.Objects() {
+		for i, c := range ob.Components() {
+			t := reflect.TypeOf(c)
+			fns := w.repairs.fns[t]
+			if len(fns) == 0 {
+				continue
+			}
+
+			l := w.componentLock(t)
+			l.Lock()
+			// reread it, in case a system changed it before the lock was taken
+			c, ok := ob.componentAt(i, t)
+			if !ok {
+				l.Unlock()
+				continue
+			}
+			var repaired []Repaired
+			for _, fn := range fns {
+				fixed, err := fn(ob.entity, c)
+				if err == nil {
+					continue
+				}
+				if reflect.TypeOf(fixed) != t {
+					w.logger().Error("repair returned wrong type", "entity", ob.entity, "component", t, "repaired", reflect.TypeOf(fixed))
+					continue
+				}
+				repaired = append(repaired, Repaired{Entity: ob.entity, Original: c, Repaired: fixed, Violation: err})
+				c = fixed
+			}
+			if len(repaired) > 0 {
+				ob.replaceAt(i, t, c)
+			}
+			l.Unlock()
+
+			for _, r := range repaired {
+				w.logger().Warn("component repaired", "entity", r.Entity, "component", t, "violation", r.Violation)
+				w.metrics().Counter("ecs_components_repaired", 1, "component", typeName(t))
+				w.Emit(r)
+			}
+		}
+	}
+}
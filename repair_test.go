@@ -0,0 +1,44 @@
+package ecs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestRepair(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddRepair(Position(0), func(_ ecs.Entity, c interface{}) (interface{}, error) {
+		if p := c.(Position); p > 100 {
+			return Position(100), errors.New("out of bounds")
+		}
+		return c, nil
+	})
+
+	var repairs []ecs.Repaired
+	world.OnEvent(ecs.Repaired{}, func(ev interface{}) {
+		repairs = append(repairs, ev.(ecs.Repaired))
+	})
+
+	fast := ecs.NewObject(Position(95), Velocity(10))
+	slow := ecs.NewObject(Position(0), Velocity(1))
+	world.AddObject(fast)
+	world.AddObject(slow)
+	world.AddSystem(ecs.System{Func: func(p Position, v Velocity) Position { return p + Position(v) }})
+
+	world.Run()
+
+	if got, want := fast.Component(Position(0)), Position(100); got != want {
+		t.Errorf("position should have been clamped: got %v, want %v", got, want)
+	}
+	if got, want := slow.Component(Position(0)), Position(1); got != want {
+		t.Errorf("valid position should be untouched: got %v, want %v", got, want)
+	}
+	if len(repairs) != 1 {
+		t.Fatalf("expected one repair, got %d", len(repairs))
+	}
+	if r := repairs[0]; r.Entity != fast.Entity() || r.Original != Position(105) || r.Repaired != Position(100) || r.Violation == nil {
+		t.Errorf("bad repair: %+v", r)
+	}
+}
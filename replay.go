@@ -0,0 +1,312 @@
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrReplayDiverged is returned by Replay when the re-simulated world doesn't
+// match a snapshot in the recording.
+var ErrReplayDiverged = errors.New("replay diverged from recording")
+
+// A replayRecord is one entry in a recording. The first entry is a header,
+// with the world's seed, tick, and state when recording began. Each further
+// entry is an event received over a bridge, a periodic snapshot, or the end
+// of the recording, stamped with the tick boundary it happened at.
+type replayRecord struct {
+	Seed     *int64        `json:"seed,omitempty"`
+	Tick     uint64        `json:"tick,omitempty"`
+	Boundary uint64        `json:"boundary"`
+	Event    *jsonEvent    `json:"event,omitempty"`
+	Snapshot *jsonSnapshot `json:"snapshot,omitempty"`
+	End      bool          `json:"end,omitempty"`
+}
+
+type jsonEvent struct {
+	Type     string          `json:"type"`
+	Value    json.RawMessage `json:"value"`
+	Priority int             `json:"priority,omitempty"`
+}
+
+// A ReplayRecorder records a deterministic world's inputs, so that a run can be
+// re-simulated with Replay, such as to reproduce a bug from a replay a player
+// submitted. Since every system of a deterministic world is deterministic, its
+// only inputs are its initial state and the events it receives over bridges,
+// which are recorded along with the tick boundary they were received at. Events
+// emitted by the world's own systems aren't recorded, since they are emitted
+// again when the run is replayed. Every event type must be registered with
+// Register or RegisterName.
+type ReplayRecorder struct {
+	w             *World
+	snapshotEvery uint64
+
+	mu           sync.Mutex
+	enc          *json.Encoder
+	err          error
+	lastSnapshot uint64
+	closed       bool
+}
+
+// RecordReplay starts recording the world's inputs to out, and writes the
+// world's current state as the starting point. It should be called before the
+// world first ticks, since the state of its random number generator can't be
+// recorded. If snapshotEvery is greater than zero, a snapshot of the world is
+// also recorded every snapshotEvery ticks, which Replay checks the
+// re-simulated world against.
+//
+// Recording stops when the ReplayRecorder is closed. An error is returned if
+// the world isn't deterministic, is already being recorded, or has commands
+// sent with Send waiting to be applied, which couldn't be recorded. While
+// recording, Send returns ErrRecording.
+func (w *World) RecordReplay(out io.Writer, snapshotEvery int) (*ReplayRecorder, error) {
+	if !w.deterministic {
+		return nil, errors.New("only deterministic worlds can be recorded")
+	}
+	w.inboxMu.Lock()
+	sent := len(w.inbox)
+	w.inboxMu.Unlock()
+	if sent > 0 {
+		return nil, fmt.Errorf("cannot record with %d commands sent to the world", sent)
+	}
+	snap, err := w.jsonSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	r := &ReplayRecorder{w: w, enc: json.NewEncoder(out)}
+	if snapshotEvery > 0 {
+		r.snapshotEvery = uint64(snapshotEvery)
+	}
+	seed := w.seed
+	header := replayRecord{Seed: &seed, Tick: w.Tick(), Boundary: atomic.LoadUint64(&w.boundaries), Snapshot: &snap}
+	if err := r.enc.Encode(header); err != nil {
+		return nil, err
+	}
+	if !w.replayRecorder.CompareAndSwap(nil, r) {
+		return nil, errors.New("world is already being recorded")
+	}
+	return r, nil
+}
+
+// Close stops recording, and returns the first error encountered while
+// recording, if any.
+func (r *ReplayRecorder) Close() error {
+	r.w.replayRecorder.CompareAndSwap(r, nil)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		r.write(replayRecord{Boundary: atomic.LoadUint64(&r.w.boundaries), End: true})
+	}
+	return r.err
+}
+
+// write encodes a record, keeping the first error. The caller must hold mu.
+func (r *ReplayRecorder) write(rec replayRecord) {
+	if r.err == nil && !r.closed || rec.End {
+		if err := r.enc.Encode(rec); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+}
+
+// recordEvent records an event received over a bridge.
+func (r *ReplayRecorder) recordEvent(boundary uint64, ev queuedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	je, err := marshalEvent(ev)
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return
+	}
+	r.write(replayRecord{Boundary: boundary, Event: &je})
+}
+
+// recordSnapshot records a periodic snapshot, if one is due.
+func (r *ReplayRecorder) recordSnapshot(boundary uint64) {
+	if r.snapshotEvery == 0 {
+		return
+	}
+	tick := r.w.Tick()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tick == 0 || tick%r.snapshotEvery != 0 || tick == r.lastSnapshot {
+		return
+	}
+	r.lastSnapshot = tick
+	snap, err := r.w.jsonSnapshot()
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return
+	}
+	r.write(replayRecord{Tick: tick, Boundary: boundary, Snapshot: &snap})
+}
+
+func marshalEvent(ev queuedEvent) (jsonEvent, error) {
+	name, err := registeredName(reflect.TypeOf(ev.Event))
+	if err != nil {
+		return jsonEvent{}, err
+	}
+	value, err := json.Marshal(ev.Event)
+	if err != nil {
+		return jsonEvent{}, err
+	}
+	return jsonEvent{Type: name, Value: value, Priority: ev.Priority}, nil
+}
+
+func unmarshalEvent(je jsonEvent) (queuedEvent, error) {
+	t, err := registeredType(je.Type)
+	if err != nil {
+		return queuedEvent{}, err
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(je.Value, v.Interface()); err != nil {
+		return queuedEvent{}, err
+	}
+	return queuedEvent{Event: v.Elem().Interface(), Priority: je.Priority}, nil
+}
+
+// Replay re-simulates a run recorded with RecordReplay. The world should have
+// the same systems, event handlers, and resources, other than its random number
+// generator, as the recorded world did when recording began; Replay makes it
+// deterministic with the recorded seed, replaces its objects with the recorded
+// ones, and runs it with RunContext, delivering each recorded event at the tick
+// boundary it was originally received at, until the end of the recording.
+// Events sent over the world's bridges are ignored meanwhile, and Send returns
+// ErrRecording.
+//
+// If the recording has periodic snapshots, the world is checked against each of
+// them, and the replay stops with an error wrapping ErrReplayDiverged at the
+// first mismatch. Objects are compared by their components, since entities
+// allocated during the replay may differ from the recorded ones, although
+// entities stored in components aren't exempt, so replays are best run in a
+// fresh process. Otherwise, Replay returns the errors of any systems that
+// failed.
+func (w *World) Replay(in io.Reader) error {
+	dec := json.NewDecoder(in)
+	var header replayRecord
+	if err := dec.Decode(&header); err != nil {
+		return err
+	}
+	if header.Seed == nil || header.Snapshot == nil {
+		return errors.New("replay is missing its header")
+	}
+	w.Deterministic(*header.Seed)
+	if err := w.loadJSONSnapshot(*header.Snapshot); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&w.ticks, header.Tick)
+	atomic.StoreUint64(&w.boundaries, header.Boundary)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed := &replayFeed{dec: dec, stop: cancel}
+	feed.advance()
+	w.replay.Store(feed)
+	errs := w.run(ctx, nil)
+	w.replay.Store(nil)
+
+	if feed.err != nil {
+		return feed.err
+	}
+	return errors.Join(errs...)
+}
+
+// replayFeed delivers the entries of a recording being replayed.
+type replayFeed struct {
+	dec  *json.Decoder
+	next *replayRecord
+	stop context.CancelFunc
+	err  error
+}
+
+// advance reads the next entry, treating the end of the input as the end of
+// the recording.
+func (f *replayFeed) advance() {
+	var rec replayRecord
+	if err := f.dec.Decode(&rec); err != nil {
+		f.next = nil
+		if err != io.EOF {
+			f.fail(err)
+		}
+		f.stop()
+		return
+	}
+	f.next = &rec
+}
+
+func (f *replayFeed) fail(err error) {
+	if f.err == nil {
+		f.err = err
+	}
+	f.stop()
+}
+
+// deliver queues the recorded events for a tick boundary. The caller must
+// hold eventsMu.
+func (f *replayFeed) deliver(w *World, boundary uint64) {
+	for f.next != nil && f.next.Boundary <= boundary && f.next.Event != nil {
+		ev, err := unmarshalEvent(*f.next.Event)
+		if err != nil {
+			f.fail(err)
+			return
+		}
+		w.events = append(w.events, ev)
+		f.advance()
+	}
+}
+
+// check compares the world against the recorded snapshots for a tick
+// boundary, and stops the replay at the end of the recording.
+func (f *replayFeed) check(w *World, boundary uint64) {
+	for f.next != nil && f.next.Boundary <= boundary && f.next.Event == nil {
+		rec := f.next
+		if rec.End {
+			f.next = nil
+			f.stop()
+			return
+		}
+		if rec.Snapshot != nil {
+			snap, err := w.jsonSnapshot()
+			if err != nil {
+				f.fail(err)
+				return
+			}
+			if !sameObjects(snap, *rec.Snapshot) {
+				f.fail(fmt.Errorf("%w at tick %d", ErrReplayDiverged, rec.Tick))
+				return
+			}
+		}
+		f.advance()
+	}
+}
+
+// sameObjects reports whether two snapshots hold the same components, in
+// the same order, regardless of the objects' entities.
+func sameObjects(a, b jsonSnapshot) bool {
+	if len(a.Objects) != len(b.Objects) {
+		return false
+	}
+	for i := range a.Objects {
+		ac, bc := a.Objects[i].Components, b.Objects[i].Components
+		if len(ac) != len(bc) {
+			return false
+		}
+		for j := range ac {
+			if ac[j].Type != bc[j].Type || !bytes.Equal(ac[j].Value, bc[j].Value) || !bytes.Equal(ac[j].Data, bc[j].Data) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package ecs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type nudge struct{ Amount int }
+
+func init() {
+	ecs.Register(nudge{})
+}
+
+// newReplayWorld creates a world whose state depends on its random numbers
+// and on the nudges it receives, and which stops itself after 30 ticks.
+func newReplayWorld(drift int) (*ecs.World, context.Context) {
+	world := ecs.NewWorld()
+	world.Deterministic(99)
+	ctx, cancel := context.WithCancel(context.Background())
+	world.OnEvent(nudge{}, func(ev interface{}) {
+		for _, ob := range world.Query().With(Position(0)).Objects() {
+			ob.SetComponent(ob.Component(Position(0)).(Position) + Position(ev.(nudge).Amount))
+		}
+	})
+	world.AddSystem(ecs.System{
+		Func:        func(r *rand.Rand, p Position) Position { return p + Position(r.Intn(5)+drift) },
+		EveryNTicks: 1,
+	})
+	world.AddSystem(ecs.System{Global: true, EveryNTicks: 1, Func: func(w *ecs.World) {
+		if w.Tick() == 30 {
+			cancel()
+		}
+	}})
+	return world, ctx
+}
+
+func recordReplay(t *testing.T, snapshotEvery int) ([]byte, []interface{}) {
+	world, ctx := newReplayWorld(0)
+	world.TickRate = time.Millisecond
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddObject(ecs.NewObject(Position(100)))
+	bridge := ecs.NewBridge[nudge](world, 16)
+
+	var buf bytes.Buffer
+	rec, err := world.RecordReplay(&buf, snapshotEvery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for i := 1; i <= 5; i++ {
+			time.Sleep(2 * time.Millisecond)
+			bridge.TrySend(nudge{Amount: i * 1000})
+		}
+	}()
+	world.RunContext(ctx)
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var state []interface{}
+	for _, ob := range world.Objects() {
+		state = append(state, ob.Components()...)
+	}
+	return buf.Bytes(), state
+}
+
+func TestReplay(t *testing.T) {
+	recording, want := recordReplay(t, 10)
+	if !bytes.Contains(recording, []byte(`"event"`)) {
+		t.Fatal("no events were recorded")
+	}
+
+	world, _ := newReplayWorld(0)
+	if err := world.Replay(bytes.NewReader(recording)); err != nil {
+		t.Fatal(err)
+	}
+	var got []interface{}
+	for _, ob := range world.Objects() {
+		got = append(got, ob.Components()...)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replay produced a different state: got %v, want %v", got, want)
+	}
+}
+
+func TestReplayDiverged(t *testing.T) {
+	recording, _ := recordReplay(t, 10)
+
+	world, _ := newReplayWorld(1)
+	if err := world.Replay(bytes.NewReader(recording)); !errors.Is(err, ecs.ErrReplayDiverged) {
+		t.Errorf("expected divergence, got %v", err)
+	}
+}
+
+func TestRecordReplayRequiresDeterminism(t *testing.T) {
+	if _, err := ecs.NewWorld().RecordReplay(new(bytes.Buffer), 0); err == nil {
+		t.Error("recorded a nondeterministic world")
+	}
+}
+
+func TestRecordReplayRejectsSend(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Deterministic(1)
+	if err := world.Send(func(*ecs.World) {}); err != nil {
+		t.Fatalf("failed to send before recording: %s", err)
+	}
+	if _, err := world.RecordReplay(&bytes.Buffer{}, 0); err == nil {
+		t.Error("expected an error recording with a command waiting to be applied")
+	}
+
+	world.Step()
+	rec, err := world.RecordReplay(&bytes.Buffer{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := world.Send(func(*ecs.World) {}); !errors.Is(err, ecs.ErrRecording) {
+		t.Errorf("expected ErrRecording sending while recording, got %v", err)
+	}
+	rec.Close()
+	if err := world.Send(func(*ecs.World) {}); err != nil {
+		t.Errorf("failed to send after recording: %s", err)
+	}
+}
@@ -0,0 +1,86 @@
+package ecs
+
+import "reflect"
+
+// resParam is implemented by Res and ResMut, so that systems can accept them
+// as parameters.
+type resParam interface {
+	resourceType() reflect.Type
+	mutable() bool
+	bind(w *World, r reflect.Value, dry *dryRun) reflect.Value
+}
+
+var resParamType = reflect.TypeOf((*resParam)(nil)).Elem()
+
+// Res is a system parameter giving read-only access to the resource of type
+// T. Systems that only read a resource should accept it this way, so that
+// they can run alongside other readers of it.
+//
+// If the world has no resource of type T, the system is skipped, just as it
+// is for objects that lack a component it accepts.
+type Res[T any] struct {
+	value T
+}
+
+// Get returns the resource.
+func (r Res[T]) Get() T {
+	return r.value
+}
+
+func (Res[T]) resourceType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (Res[T]) mutable() bool {
+	return false
+}
+
+func (Res[T]) bind(w *World, r reflect.Value, dry *dryRun) reflect.Value {
+	return reflect.ValueOf(Res[T]{value: r.Interface().(T)})
+}
+
+// ResMut is a system parameter giving read-write access to the resource of
+// type T. Systems that accept one hold exclusive access to the resource while
+// they tick, and can replace it with Set.
+//
+// If the world has no resource of type T, the system is skipped. For dry-run
+// systems, Set doesn't replace the world's resource, but is reported to
+// World.OnDryRun, and only seen by Get within the same call.
+type ResMut[T any] struct {
+	w   *World
+	dry *dryRun
+}
+
+// Get returns the resource, including any changes made with Set.
+func (r ResMut[T]) Get() T {
+	if r.dry != nil {
+		if v, ok := r.dry.resource(r.resourceType()); ok {
+			return v.Interface().(T)
+		}
+	}
+	v, _ := r.w.resource(r.resourceType())
+	return v.Interface().(T)
+}
+
+// Set replaces the resource.
+func (r ResMut[T]) Set(value T) {
+	if r.dry != nil {
+		r.dry.setResource(r.resourceType(), reflect.ValueOf(value))
+		return
+	}
+	r.w.resourcesMu.Lock()
+	r.w.resources[r.resourceType()] = reflect.ValueOf(value)
+	r.w.resourcesMu.Unlock()
+}
+
+func (ResMut[T]) resourceType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (ResMut[T]) mutable() bool {
+	return true
+}
+
+func (ResMut[T]) bind(w *World, r reflect.Value, dry *dryRun) reflect.Value {
+	return reflect.ValueOf(ResMut[T]{w: w, dry: dry})
+}
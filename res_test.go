@@ -0,0 +1,63 @@
+package ecs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Gravity int
+
+type Total int
+
+func TestResParams(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddResource(Gravity(2))
+	world.AddResource(Total(0))
+
+	ob := ecs.NewObject(Velocity(1))
+	world.AddObject(ob)
+	world.AddObject(ecs.NewObject(Velocity(3)))
+
+	world.AddSystem(ecs.System{
+		Name: "fall",
+		Func: func(v Velocity, g ecs.Res[Gravity], total ecs.ResMut[Total]) Velocity {
+			total.Set(total.Get() + Total(v))
+			return v + Velocity(g.Get())
+		},
+	})
+	world.Run()
+
+	if got, want := ob.Component(Velocity(0)), Velocity(3); got != want {
+		t.Errorf("bad velocity: got %v, want %v", got, want)
+	}
+	if got, want := world.Resource(Total(0)), Total(4); got != want {
+		t.Errorf("bad total: got %v, want %v", got, want)
+	}
+
+	info, _ := world.LookupSystem("fall")
+	if want := []reflect.Type{reflect.TypeOf(Velocity(0)), reflect.TypeOf(Gravity(0))}; !reflect.DeepEqual(info.Reads, want) {
+		t.Errorf("bad reads: got %v, want %v", info.Reads, want)
+	}
+	if want := []reflect.Type{reflect.TypeOf(Total(0)), reflect.TypeOf(Velocity(0))}; !reflect.DeepEqual(info.Writes, want) {
+		t.Errorf("bad writes: got %v, want %v", info.Writes, want)
+	}
+}
+
+func TestResMissing(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Velocity(1)))
+
+	var ran bool
+	world.AddSystem(ecs.System{Func: func(v Velocity, g ecs.Res[Gravity]) { ran = true }})
+	world.Run()
+	if ran {
+		t.Error("system should be skipped without its resource")
+	}
+
+	err := world.AddSystem(ecs.System{Global: true, Func: func(g ecs.ResMut[Gravity]) {}})
+	if err == nil {
+		t.Error("global systems should require their resources")
+	}
+}
@@ -0,0 +1,105 @@
+package ecs
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// AddResource adds a value that isn't tied to any object, such as a game's
+// settings or score, to the world. Any system parameter with the same type as
+// the resource receives it, so resources are usually pointers in order for
+// systems to share changes to them. Adding a resource replaces any existing
+// resource of the same type.
+func (w *World) AddResource(r interface{}) {
+	w.resourcesMu.Lock()
+	w.resources[reflect.TypeOf(r)] = reflect.ValueOf(r)
+	w.resourcesMu.Unlock()
+
+	if t, ok := r.(transitioner); ok {
+		w.eventsMu.Lock()
+		w.transitions = replaceTransitioner(w.transitions, t)
+		w.eventsMu.Unlock()
+	}
+}
+
+// replaceTransitioner returns transitions with t in place of the transitioner
+// of the same resource type, or added if there isn't one. The slice is copied,
+// since tickBoundary uses it without holding eventsMu.
+func replaceTransitioner(transitions []transitioner, t transitioner) []transitioner {
+	replaced := append([]transitioner(nil), transitions...)
+	for i, other := range replaced {
+		if reflect.TypeOf(other) == reflect.TypeOf(t) {
+			replaced[i] = t
+			return replaced
+		}
+	}
+	return append(replaced, t)
+}
+
+// Resource returns the world's resource with the same type as r, or nil if
+// there isn't one.
+func (w *World) Resource(r interface{}) interface{} {
+	if v, ok := w.resource(reflect.TypeOf(r)); ok {
+		return v.Interface()
+	}
+	return nil
+}
+
+func (w *World) resource(t reflect.Type) (reflect.Value, bool) {
+	w.resourcesMu.RLock()
+	defer w.resourcesMu.RUnlock()
+	v, ok := w.resources[t]
+	return v, ok
+}
+
+// transitioner is implemented by resources with changes that are deferred
+// until the end of a tick.
+type transitioner interface {
+	transition(w *World)
+}
+
+// tickBoundary is called whenever a system finishes a tick, outside of any
+// locks held by the system, to apply deferred changes.
+func (w *World) tickBoundary() {
+	boundary := atomic.AddUint64(&w.boundaries, 1)
+	w.eventsMu.Lock()
+	transitions := w.transitions
+	w.eventsMu.Unlock()
+
+	w.temporaryMu.Lock()
+	expired := w.temporary
+	w.temporary = nil
+	w.temporaryMu.Unlock()
+
+	w.applyInbox()
+	w.commands.apply()
+	for _, e := range expired {
+		w.queueDespawn(e, false)
+	}
+	w.applyDespawns()
+	w.repair()
+	w.runOnceSystems()
+	for _, t := range transitions {
+		t.transition(w)
+	}
+	w.flushEvents(boundary)
+	if r := w.replayRecorder.Load(); r != nil {
+		r.recordSnapshot(boundary)
+	}
+	if f := w.replay.Load(); f != nil {
+		f.check(w, boundary)
+	}
+
+	if w.Metrics != nil {
+		w.objectsMu.RLock()
+		n := len(w.objects)
+		w.objectsMu.RUnlock()
+		w.Metrics.Gauge("ecs_objects", float64(n))
+	}
+}
+
+// runSystemNow ticks a system that isn't part of the world's schedule.
+func (w *World) runSystemNow(s System) {
+	ss := w.schedule(s)
+	ss.tick(w, w.tickTime(w.Tick()), 0)
+}
@@ -0,0 +1,291 @@
+package ecs
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A RetryPolicy determines how a system recovers from the errors it returns,
+// for systems driven by flaky external calls. It applies only to errors that
+// would be reported to OnError: ErrSkipEntity, ErrStopSystem, and ErrFatal
+// are never retried. The zero policy never retries.
+//
+//     world.AddSystem(ecs.System{
+//         Func:   SyncInventory,
+//         Ticker: time.NewTicker(time.Second).C,
+//         Retry: ecs.RetryPolicy{
+//             EntityRetries: 2,
+//             Backoff:       100 * time.Millisecond,
+//             MaxBackoff:    time.Minute,
+//             DisableAfter:  10,
+//         },
+//     })
+type RetryPolicy struct {
+	// EntityRetries is how many more times the system is called on an
+	// object after it returns an error, before the error is reported.
+	EntityRetries int
+
+	// TickRetries is how many more times a tick is run if the system
+	// returned an error for any object, after EntityRetries are exhausted.
+	// Retried ticks visit every object again, so the system should be
+	// idempotent.
+	TickRetries int
+
+	// Backoff is how long to wait before the first retry, doubling with each
+	// further one up to MaxBackoff, if set. The world's locks on the
+	// system's components are released while a tick or object waits to be
+	// retried, and objects waiting for a retry are retried at the end of the
+	// tick, after the others. Waiting stops if the world stops running or
+	// the system is removed, and the object's last error is reported.
+	//
+	// Backoff also applies between ticks: after a tick fails, retries and
+	// all, the system's ticks are skipped until the backoff for the number
+	// of consecutive failed ticks has passed. This is measured from the
+	// time of the failed tick, as passed to systems.
+	//
+	// In a deterministic world, or one driven by Step, backoff is counted in
+	// ticks rather than waited for: time is measured as it is for
+	// deterministic worlds, and a retry with a backoff is put off until the
+	// first tick at or after the backoff has passed, skipping the system's
+	// ticks in between, so retries play out the same way every time.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// DisableAfter, if set, disables the system, as with
+	// World.DisableSystem, after this many consecutive failed ticks.
+	DisableAfter int
+}
+
+// delay returns the backoff before retry n, counting from zero.
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.Backoff
+	for i := 0; i < n && d > 0; i++ {
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+		if d > d<<1 {
+			// overflowed
+			break
+		}
+		d <<= 1
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// retryState tracks a system's failures for its RetryPolicy.
+type retryState struct {
+	// failed is set when the system returns an error it has no entity
+	// retries left for during the current attempt at a tick.
+	failed int32
+
+	// failures counts consecutive failed ticks, and resumeAt is the tick
+	// time, in Unix nanoseconds, from which the system may tick again after
+	// the last one.
+	failures int32
+	resumeAt int64
+
+	// deferred is set when a retry with a backoff has been put off until a
+	// later tick because backoff is simulated. attempt is the attempt at the
+	// tick to pick up from, and objects is set if it is the postponed
+	// objects that are waiting, rather than a retry of the whole tick.
+	deferred, objects bool
+	attempt           int
+
+	// postponed holds the objects waiting for a retry with a backoff.
+	mu        sync.Mutex
+	postponed []entityRetry
+}
+
+
+// entityRetry is an object whose retry was postponed until the end of the
+// tick, along with the arguments and error of its last attempt.
+type entityRetry struct {
+	ob      *Object
+	shard   Shard
+	attempt int
+	args    []interface{}
+	err     error
+}
+
+func (r *retryState) postpone(retry entityRetry) {
+	r.mu.Lock()
+	r.postponed = append(r.postponed, retry)
+	r.mu.Unlock()
+}
+
+// restorePostponed puts back retries taken with takePostponed that are being
+// deferred to a later tick.
+func (r *retryState) restorePostponed(retries []entityRetry) {
+	r.mu.Lock()
+	r.postponed = append(retries, r.postponed...)
+	r.mu.Unlock()
+}
+
+func (r *retryState) takePostponed() []entityRetry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	retries := r.postponed
+	r.postponed = nil
+	return retries
+}
+
+// retryable reports whether v, a system's error result, should be retried.
+func retryable(v reflect.Value) bool {
+	if v.IsNil() {
+		return false
+	}
+	err := v.Interface().(error)
+	return !errors.Is(err, ErrSkipEntity) && !errors.Is(err, ErrStopSystem) && !errors.Is(err, ErrFatal)
+}
+
+// retryClock returns the time that the system's backoff is measured against
+// on tick n, and whether backoff is simulated: in a world driven by Step or
+// Deterministic, it is the simulated time of the tick, as in a deterministic
+// world, and otherwise it is now.
+func (w *World) retryClock(now time.Time, n uint64) (time.Time, bool) {
+	if w.deterministic || atomic.LoadInt32(&w.stepping) > 0 {
+		return w.simulatedTime(n), true
+	}
+	return now, false
+}
+
+// backingOff reports whether the system's ticks are being skipped after a
+// failed tick, or until a deferred retry is due.
+func (s *scheduledSystem) backingOff(clock time.Time) bool {
+	return clock.UnixNano() < atomic.LoadInt64(&s.retries.resumeAt)
+}
+
+// deferRetry puts off a retry until the first tick at or after clock plus d,
+// in a world where backoff is simulated.
+func (s *scheduledSystem) deferRetry(clock time.Time, d time.Duration, attempt int, objects bool) {
+	s.retries.deferred, s.retries.attempt, s.retries.objects = true, attempt, objects
+	atomic.StoreInt64(&s.retries.resumeAt, clock.Add(d).UnixNano())
+}
+
+// tickWithRetries runs a tick, retrying it according to the system's
+// RetryPolicy, or picks up a retry deferred by an earlier tick. The caller
+// must hold the system's locks.
+func (s *scheduledSystem) tickWithRetries(w *World, now, clock time.Time, simulated bool) {
+	var attempt int
+	objects := false
+	if s.retries.deferred {
+		attempt, objects = s.retries.attempt, s.retries.objects
+		s.retries.deferred = false
+	}
+	for ; ; attempt++ {
+		if !objects {
+			atomic.StoreInt32(&s.retries.failed, 0)
+			s.System.tick(w, now)
+		}
+		if !s.retryObjects(w, now, clock, simulated, objects, attempt) {
+			return
+		}
+		objects = false
+		failed := atomic.LoadInt32(&s.retries.failed) != 0
+		if !failed || attempt >= s.Retry.TickRetries || !s.enabled() || s.failure.get() != nil {
+			s.finishRetries(w, clock, failed)
+			return
+		}
+		w.logger().Debug("retrying system tick", "system", s.name(), "attempt", attempt+1)
+		if d := s.Retry.delay(attempt); d > 0 {
+			if simulated {
+				s.deferRetry(clock, d, attempt+1, false)
+				return
+			}
+			if !s.backoff(w, d) {
+				s.finishRetries(w, clock, failed)
+				return
+			}
+		}
+	}
+}
+
+// retryObjects retries the objects postponed during a tick, waiting for
+// their backoff first, until none are left. If backoff is simulated, the
+// retries are deferred to a later tick instead, and it returns false; due is
+// set when that tick comes, as the first retries' backoff has passed. The
+// caller must hold the system's locks.
+func (s *scheduledSystem) retryObjects(w *World, now, clock time.Time, simulated, due bool, attempt int) bool {
+	for ; ; due = false {
+		retries := s.retries.takePostponed()
+		if len(retries) == 0 {
+			return true
+		}
+		var d time.Duration
+		for _, r := range retries {
+			if rd := s.Retry.delay(r.attempt - 1); rd > d {
+				d = rd
+			}
+		}
+		if simulated && d > 0 && !due {
+			s.retries.restorePostponed(retries)
+			s.deferRetry(clock, d, attempt, true)
+			return false
+		}
+		if !simulated && !s.backoff(w, d) {
+			for _, r := range retries {
+				atomic.AddInt64(&s.counters.errors, 1)
+				atomic.StoreInt32(&s.retries.failed, 1)
+				w.handleSystemError(s.name(), r.args, r.err)
+			}
+			return true
+		}
+
+		argValues := s.arena.getArgs()
+		required := s.arena.requiredMask(w)
+		for _, r := range retries {
+			if r.ob != nil && w.GetObject(r.ob.entity) != r.ob {
+				continue
+			}
+			if s.System.tickAttempt(w, argValues, r.ob, required, now, r.shard, r.attempt) {
+				s.retries.takePostponed()
+				break
+			}
+		}
+		s.arena.putArgs(argValues)
+	}
+}
+
+// backoff waits for d with the system's locks released, and reports whether
+// it waited the whole time, rather than stopping because the world stopped
+// running or the system was removed. The caller must hold the system's
+// locks.
+func (s *scheduledSystem) backoff(w *World, d time.Duration) bool {
+	unlockAll(s.locks)
+	defer lockAll(s.locks)
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-w.lifecycle.context().Done():
+		return false
+	case <-s.stop:
+		return false
+	}
+}
+
+// finishRetries records whether the tick at clock failed, backing off or
+// disabling the system after a failure.
+func (s *scheduledSystem) finishRetries(w *World, clock time.Time, failed bool) {
+	if !failed {
+		atomic.StoreInt32(&s.retries.failures, 0)
+		return
+	}
+	failures := atomic.AddInt32(&s.retries.failures, 1)
+	if s.Retry.DisableAfter > 0 && int(failures) >= s.Retry.DisableAfter {
+		atomic.StoreInt32(&s.retries.failures, 0)
+		w.logger().Warn("system disabled after consecutive failures", "system", s.name(), "failures", failures)
+		s.setEnabled(w, false)
+		return
+	}
+	if d := s.Retry.delay(int(failures) - 1); d > 0 {
+		atomic.StoreInt64(&s.retries.resumeAt, clock.Add(d).UnixNano())
+	}
+}
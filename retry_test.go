@@ -0,0 +1,211 @@
+package ecs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+var errFlaky = errors.New("flaky")
+
+func TestEntityRetries(t *testing.T) {
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Position(0))
+	world.AddObject(player)
+
+	var reported []error
+	world.OnError = func(_ string, _ []interface{}, err error) { reported = append(reported, err) }
+	calls := 0
+	world.AddSystem(ecs.System{
+		Func: func(p Position) (Position, error) {
+			calls++
+			if calls < 3 {
+				return p, errFlaky
+			}
+			return p + 1, nil
+		},
+		Retry: ecs.RetryPolicy{EntityRetries: 2},
+	})
+	world.Step()
+	if calls != 3 || len(reported) != 0 {
+		t.Errorf("got %d calls and %d reported errors, want 3 and 0", calls, len(reported))
+	}
+	if p := player.Component(Position(0)).(Position); p != 1 {
+		t.Errorf("bad position: got %v, want 1", p)
+	}
+}
+
+func TestTickRetries(t *testing.T) {
+	world := ecs.NewWorld()
+	world.OnError = func(string, []interface{}, error) {}
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddObject(ecs.NewObject(Position(1)))
+
+	ticks := map[Position]int{}
+	world.AddSystem(ecs.System{
+		Func: func(p Position) error {
+			ticks[p]++
+			if p == 1 && ticks[p] < 3 {
+				return errFlaky
+			}
+			return nil
+		},
+		Retry: ecs.RetryPolicy{TickRetries: 5, Backoff: time.Microsecond},
+	})
+	// under Step, each retry waits for the next step
+	world.Step()
+	if ticks[0] != 1 || ticks[1] != 1 {
+		t.Errorf("expected the retry to wait for the next step, got %v", ticks)
+	}
+	world.Step()
+	world.Step()
+	if ticks[0] != 3 || ticks[1] != 3 {
+		t.Errorf("expected the tick to run 3 times, got %v", ticks)
+	}
+}
+
+func TestRetryBackoffAndDisable(t *testing.T) {
+	world := ecs.NewWorld()
+	world.OnError = func(string, []interface{}, error) {}
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	calls := 0
+	world.AddSystem(ecs.System{
+		Name: "flaky",
+		Func: func(Position) error {
+			calls++
+			return errFlaky
+		},
+		Retry: ecs.RetryPolicy{Backoff: time.Hour, DisableAfter: 2},
+	})
+
+	// the first failure backs off, so the next step skips the system
+	world.Step()
+	world.Step()
+	if calls != 1 {
+		t.Errorf("system ticked while backing off: %d calls", calls)
+	}
+
+	world.AddSystem(ecs.System{
+		Name:  "doomed",
+		Func:  func(Position) error { return errFlaky },
+		Retry: ecs.RetryPolicy{DisableAfter: 2},
+	})
+	world.Step()
+	world.Step()
+	if info, _ := world.LookupSystem("doomed"); info.Enabled {
+		t.Error("system wasn't disabled after consecutive failures")
+	}
+}
+
+func TestRetryBackoffDeterministic(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Deterministic(1)
+	world.OnError = func(string, []interface{}, error) {}
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	calls := 0
+	world.AddSystem(ecs.System{
+		Name: "flaky",
+		Func: func(Position) error {
+			calls++
+			if calls == 1 {
+				return errFlaky
+			}
+			return nil
+		},
+		Retry: ecs.RetryPolicy{Backoff: 100 * time.Millisecond},
+	})
+
+	// the backoff is simulated time, six ticks at the default rate, however
+	// long the steps take
+	world.Step()
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 100; i++ {
+		world.Step()
+	}
+	if calls != 95 {
+		t.Errorf("system ticked %d times, want 95", calls)
+	}
+}
+
+func TestEntityRetryBackoffSimulated(t *testing.T) {
+	world := ecs.NewWorld()
+	world.TickRate = time.Hour
+	world.Deterministic(1)
+	player := ecs.NewObject(Position(0))
+	world.AddObject(player)
+
+	calls := 0
+	world.AddSystem(ecs.System{
+		Func: func(p Position) (Position, error) {
+			calls++
+			if calls == 1 {
+				return p, errFlaky
+			}
+			return p + 1, nil
+		},
+		Retry: ecs.RetryPolicy{EntityRetries: 1, Backoff: 90 * time.Minute},
+	})
+
+	// the retry is put off for two simulated hours, rather than an hour and
+	// a half of waiting
+	world.Step()
+	world.Step()
+	if calls != 1 {
+		t.Errorf("object was retried before its backoff passed: %d calls", calls)
+	}
+	world.Step()
+	if calls != 2 {
+		t.Errorf("object wasn't retried once its backoff passed: %d calls", calls)
+	}
+	if p := player.Component(Position(0)).(Position); p != 1 {
+		t.Errorf("bad position: got %v, want 1", p)
+	}
+	world.Step()
+	if calls != 3 {
+		t.Errorf("system didn't tick normally after the retry: %d calls", calls)
+	}
+}
+
+func TestEntityRetryBackoffReleasesLocks(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	reported := make(chan error, 1)
+	world.OnError = func(_ string, _ []interface{}, err error) { reported <- err }
+	failed := make(chan struct{})
+	world.AddSystem(ecs.System{
+		Name: "flaky",
+		Func: func(p Position) (Position, error) {
+			close(failed)
+			return p, errFlaky
+		},
+		Retry: ecs.RetryPolicy{EntityRetries: 1, Backoff: time.Hour},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	world.AddSystem(ecs.System{
+		Name:  "reader",
+		RunIf: func() bool { <-failed; return true },
+		Func: func(p Position) {
+			// only reachable if the flaky system released its lock on
+			// Position while waiting to retry
+			cancel()
+		},
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- world.RunContext(ctx) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("world didn't stop while a system was backing off")
+	}
+	if err := <-reported; !errors.Is(err, errFlaky) {
+		t.Errorf("bad reported error: got %v, want %v", err, errFlaky)
+	}
+}
@@ -0,0 +1,148 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// A Checkpoint is a copy of a world's objects at a point in time, which the
+// world can be rolled back to with Rollback, as rollback netcode and server
+// reconciliation require.
+//
+// Checkpoints are cheap to take. Since the world replaces components rather
+// than modifying them in place, a checkpoint shares component values with the
+// world until they are replaced, copying only the slices that hold them.
+// Components that contain pointers, slices, or maps could be modified in
+// place by systems, though, so they are deep copied, as when cloning a
+// prefab; keeping components as plain values makes checkpoints cheaper.
+type Checkpoint struct {
+	tick    uint64
+	objects []checkpointObject
+	source  *countingSource
+}
+
+type checkpointObject struct {
+	entity     Entity
+	components []interface{}
+}
+
+// Tick returns the world's tick when the checkpoint was taken.
+func (cp *Checkpoint) Tick() uint64 {
+	return cp.tick
+}
+
+// Checkpoint takes a checkpoint of the world's objects and current tick, and
+// the state of its random number generator if it is deterministic, so that
+// systems draw the same numbers when the ticks since are resimulated. It
+// should be taken between ticks, such as between calls to Step. Other
+// resources, and pending events and commands, aren't included.
+func (w *World) Checkpoint() *Checkpoint {
+	objects := w.Objects()
+	cp := &Checkpoint{
+		tick:    w.Tick(),
+		objects: make([]checkpointObject, len(objects)),
+	}
+	if w.source != nil {
+		cp.source = w.source.clone()
+	}
+	for i, ob := range objects {
+		cp.objects[i] = checkpointObject{entity: ob.entity, components: copyOnWrite(ob.Components())}
+	}
+	return cp
+}
+
+// Rollback restores the world's objects, tick, and random number generator to
+// a checkpoint. Objects that still exist keep their *Object, objects removed
+// since the checkpoint are restored with their original entities, and objects
+// added since then are removed. As when loading a snapshot, no hooks or
+// finalizers are run. The same checkpoint can be rolled back to any number of
+// times.
+func (w *World) Rollback(cp *Checkpoint) {
+	objects := make([]*Object, len(cp.objects))
+	for i, c := range cp.objects {
+		components := copyOnWrite(c.components)
+		ob := w.GetObject(c.entity)
+		if ob == nil {
+			objects[i] = &Object{entity: c.entity, components: components}
+			continue
+		}
+		ob.mu.Lock()
+		ob.components = components
+		ob.mask = nil
+		ob.dense = nil
+		ob.mu.Unlock()
+		atomic.StoreInt32(&ob.despawning, 0)
+		objects[i] = ob
+	}
+	w.replaceObjects(objects, 0)
+	atomic.StoreUint64(&w.ticks, cp.tick)
+	if cp.source != nil && w.source != nil {
+		w.source.restore(cp.source)
+	}
+}
+
+// Resimulate rolls the world back to a checkpoint and steps it forward ticks
+// times, calling inputs, if set, before each step with the number of the tick
+// about to run. This lets a game re-run the ticks since the checkpoint with
+// corrected inputs, such as a remote player's late-arriving commands, by
+// emitting events or changing components in inputs. The world should be
+// deterministic, and shouldn't be running.
+func (w *World) Resimulate(cp *Checkpoint, ticks int, inputs func(tick uint64)) {
+	w.Rollback(cp)
+	for i := 0; i < ticks; i++ {
+		if inputs != nil {
+			inputs(w.Tick() + 1)
+		}
+		w.Step()
+	}
+}
+
+// copyOnWrite copies a list of components for a checkpoint, deep copying
+// only those that could be modified in place.
+func copyOnWrite(components []interface{}) []interface{} {
+	cp := make([]interface{}, len(components))
+	for i, c := range components {
+		if hasReferences(reflect.TypeOf(c)) {
+			c = deepCopy(c)
+		}
+		cp[i] = c
+	}
+	return cp
+}
+
+// referenceTypes caches whether component types contain pointers, slices,
+// maps, or interfaces, which values could be shared through.
+var referenceTypes sync.Map
+
+func hasReferences(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if v, ok := referenceTypes.Load(t); ok {
+		return v.(bool)
+	}
+	has := searchReferences(t, make(map[reflect.Type]bool))
+	referenceTypes.Store(t, has)
+	return has
+}
+
+func searchReferences(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	case reflect.Array:
+		return searchReferences(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if searchReferences(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package ecs_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Backpack struct {
+	Items []string
+}
+
+func TestRollback(t *testing.T) {
+	world := ecs.NewWorld()
+	a := ecs.NewObject(Position(1), Backpack{Items: []string{"sword"}})
+	b := ecs.NewObject(Position(2))
+	world.AddObject(a)
+	world.AddObject(b)
+	world.AddSystem(ecs.System{
+		Func: func(p Position) Position { return p + 10 },
+	})
+	world.Step()
+
+	cp := world.Checkpoint()
+	if cp.Tick() != 1 {
+		t.Errorf("bad checkpoint tick: got %d, want 1", cp.Tick())
+	}
+
+	world.Step()
+	world.Step()
+	a.Component(Backpack{}).(Backpack).Items[0] = "shield"
+	world.RemoveObject(b.Entity())
+	c := ecs.NewObject(Position(100))
+	world.AddObject(c)
+
+	world.Rollback(cp)
+	if world.Tick() != 1 {
+		t.Errorf("bad tick after rollback: got %d, want 1", world.Tick())
+	}
+	if got := a.Component(Position(0)); got != Position(11) {
+		t.Errorf("bad position for a: got %v, want 11", got)
+	}
+	if got := a.Component(Backpack{}).(Backpack).Items; !reflect.DeepEqual(got, []string{"sword"}) {
+		t.Errorf("bad backpack for a: got %v, want [sword]", got)
+	}
+	if world.GetObject(c.Entity()) != nil {
+		t.Error("object added after the checkpoint survived rollback")
+	}
+	restored := world.GetObject(b.Entity())
+	if restored == nil {
+		t.Fatal("object removed after the checkpoint wasn't restored")
+	}
+	if got := restored.Component(Position(0)); got != Position(12) {
+		t.Errorf("bad position for b: got %v, want 12", got)
+	}
+	if got := world.Query().With(Position(0)).Count(); got != 2 {
+		t.Errorf("bad query count after rollback: got %d, want 2", got)
+	}
+
+	// Rolling back twice restores the same state.
+	world.Step()
+	world.Rollback(cp)
+	if got := a.Component(Position(0)); got != Position(11) {
+		t.Errorf("bad position for a after second rollback: got %v, want 11", got)
+	}
+}
+
+func TestResimulate(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Deterministic(1)
+	player := ecs.NewObject(Position(0), Velocity(1))
+	world.AddObject(player)
+	world.AddSystem(ecs.System{
+		Func: func(p Position, v Velocity) Position { return p + Position(v) },
+	})
+
+	cp := world.Checkpoint()
+	for i := 0; i < 5; i++ {
+		world.Step()
+	}
+	if got := player.Component(Position(0)); got != Position(5) {
+		t.Fatalf("bad position: got %v, want 5", got)
+	}
+
+	// A late input reveals that the player's velocity changed on tick 3.
+	var ticks []uint64
+	world.Resimulate(cp, 5, func(tick uint64) {
+		ticks = append(ticks, tick)
+		if tick == 3 {
+			player.SetComponent(Velocity(2))
+		}
+	})
+	if want := []uint64{1, 2, 3, 4, 5}; !reflect.DeepEqual(ticks, want) {
+		t.Errorf("bad input ticks: got %v, want %v", ticks, want)
+	}
+	if got := player.Component(Position(0)); got != Position(8) {
+		t.Errorf("bad position after resimulating: got %v, want 8", got)
+	}
+	if world.Tick() != 5 {
+		t.Errorf("bad tick: got %d, want 5", world.Tick())
+	}
+}
+
+func TestRollbackRandom(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Deterministic(7)
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddSystem(ecs.System{
+		Func: func(r *rand.Rand, p Position) Position { return p + Position(r.Intn(1000)) },
+	})
+
+	world.Step()
+	cp := world.Checkpoint()
+	positions := func() []Position {
+		var ps []Position
+		for i := 0; i < 3; i++ {
+			world.Step()
+			ps = append(ps, world.Query().Objects()[0].Component(Position(0)).(Position))
+		}
+		return ps
+	}
+	first := positions()
+	world.Rollback(cp)
+	if second := positions(); !reflect.DeepEqual(first, second) {
+		t.Errorf("bad positions after rollback: got %v, want %v", second, first)
+	}
+}
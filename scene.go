@@ -0,0 +1,89 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// Scene is a declarative description of a set of objects, typically written
+// by hand as JSON:
+//
+//     {
+//         "entities": [
+//             {"name": "player", "components": {"Position": 1, "Velocity": 2}},
+//             {"components": {"Position": 5}}
+//         ]
+//     }
+//
+// Components are keyed by the name their type was registered under with
+// Register or RegisterName. An entity's name, if given, is added as a Name
+// component, so that it can be looked up with Find once the scene is loaded.
+type Scene struct {
+	Entities []SceneEntity `json:"entities"`
+}
+
+// SceneEntity describes a single object in a scene.
+type SceneEntity struct {
+	Name       string                     `json:"name,omitempty"`
+	Components map[string]json.RawMessage `json:"components"`
+}
+
+// ReadScene decodes a scene and creates its objects without adding them to
+// a world. This is useful for large scenes, which can be added gradually with
+// a Loader.
+func ReadScene(r io.Reader) ([]*Object, error) {
+	var scene Scene
+	if err := json.NewDecoder(r).Decode(&scene); err != nil {
+		return nil, err
+	}
+	return scene.Objects()
+}
+
+// Objects creates the objects described by the scene. Components are added to
+// each object sorted by name, so that the result is deterministic.
+func (scene Scene) Objects() ([]*Object, error) {
+	objects := make([]*Object, len(scene.Entities))
+	for i, e := range scene.Entities {
+		names := make([]string, 0, len(e.Components))
+		for name := range e.Components {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		cs := make([]interface{}, len(names))
+		for j, name := range names {
+			t, err := registeredType(name)
+			if err != nil {
+				return nil, fmt.Errorf("entity %d: %s", i, err)
+			}
+			v := reflect.New(t)
+			if err := json.Unmarshal(e.Components[name], v.Interface()); err != nil {
+				return nil, fmt.Errorf("entity %d: component %s: %s", i, name, err)
+			}
+			cs[j] = v.Elem().Interface()
+		}
+		if e.Name != "" {
+			cs = append(cs, Name(e.Name))
+		}
+		objects[i] = NewObject(cs...)
+	}
+	return objects, nil
+}
+
+// LoadScene decodes a scene and adds its objects to the world, returning their
+// entities in the order they appear in the scene. If any part of the scene is
+// invalid, no objects are added.
+func (w *World) LoadScene(r io.Reader) ([]Entity, error) {
+	objects, err := ReadScene(r)
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]Entity, len(objects))
+	for i, ob := range objects {
+		entities[i] = w.AddObject(ob)
+	}
+	return entities, nil
+}
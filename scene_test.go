@@ -0,0 +1,43 @@
+package ecs_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestLoadScene(t *testing.T) {
+	const scene = `{
+		"entities": [
+			{"components": {"ecs_test.Velocity": 2, "ecs_test.Position": 1}},
+			{"components": {"ecs_test.Position": 5}}
+		]
+	}`
+
+	world := ecs.NewWorld()
+	entities, err := world.LoadScene(strings.NewReader(scene))
+	if err != nil {
+		t.Fatalf("failed to load scene: %s", err)
+	}
+	if got, want := len(entities), 2; got != want {
+		t.Fatalf("wrong number of entities: got %d, want %d", got, want)
+	}
+
+	if got, want := world.GetObject(entities[0]).Components(), []interface{}{Position(1), Velocity(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad components: got %v, want %v", got, want)
+	}
+	if got, want := world.GetObject(entities[1]).Components(), []interface{}{Position(5)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad components: got %v, want %v", got, want)
+	}
+}
+
+func TestLoadSceneUnknownComponent(t *testing.T) {
+	const scene = `{"entities": [{"components": {"Unknown": 1}}]}`
+
+	world := ecs.NewWorld()
+	if _, err := world.LoadScene(strings.NewReader(scene)); err == nil {
+		t.Error("expected an error for an unregistered component")
+	}
+}
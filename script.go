@@ -0,0 +1,141 @@
+package ecs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// A Script implements a system outside of Go, typically in an embedded
+// interpreter or runtime such as gopher-lua or wazero, so that modders and
+// designers can add behaviors without recompiling. Tick is called once for
+// each object the system matches, with the object's components encoded as
+// JSON and keyed by the names their types were registered under, and returns
+// the components it changed, encoded the same way.
+//
+// An adapter for a Lua interpreter might convert the components to a table,
+// call the script's tick function with it, and convert the table it returns
+// back:
+//
+//     func (s *LuaScript) Tick(e ecs.Entity, in map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+//         s.mu.Lock()
+//         defer s.mu.Unlock()
+//         err := s.L.CallByParam(lua.P{Fn: s.tick, NRet: 1, Protect: true}, toTable(s.L, in))
+//         if err != nil {
+//             return nil, err
+//         }
+//         defer s.L.Pop(1)
+//         return fromTable(s.L.Get(-1))
+//     }
+//
+// Tick may be called concurrently for different objects if the system is
+// Parallel.
+type Script interface {
+	Tick(entity Entity, components map[string]json.RawMessage) (map[string]json.RawMessage, error)
+}
+
+// ScriptFunc adapts a function to a Script.
+type ScriptFunc func(entity Entity, components map[string]json.RawMessage) (map[string]json.RawMessage, error)
+
+// Tick calls f.
+func (f ScriptFunc) Tick(entity Entity, components map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	return f(entity, components)
+}
+
+// NewScriptSystem returns a system that ticks a script for every object with
+// the components in reads and writes, which must all be registered. The
+// script receives all of them, and may change those in writes by returning
+// them; components it doesn't return are left unchanged. The system is
+// matched, locked, and scheduled exactly as if it had been written in Go, and
+// errors returned by the script, or from encoding and decoding components,
+// are reported like any other system error.
+//
+// The system is named "script" unless another Name is set before it is added
+// to the world, and its other fields, such as Ticker, can be set the same way.
+func NewScriptSystem(script Script, reads, writes []interface{}) (System, error) {
+	type param struct {
+		name  string
+		t     reflect.Type
+		write bool
+	}
+	var params []param
+	seen := make(map[reflect.Type]int)
+	for i, list := range [][]interface{}{reads, writes} {
+		for _, c := range list {
+			t := reflect.TypeOf(c)
+			name, err := registeredName(t)
+			if err != nil {
+				return System{}, err
+			}
+			if j, ok := seen[t]; ok {
+				params[j].write = params[j].write || i == 1
+				continue
+			}
+			seen[t] = len(params)
+			params = append(params, param{name: name, t: t, write: i == 1})
+		}
+	}
+	if len(params) == 0 {
+		return System{}, errors.New("script system has no components")
+	}
+
+	in := []reflect.Type{entityType}
+	var out []reflect.Type
+	var written []int
+	for i, p := range params {
+		in = append(in, p.t)
+		if p.write {
+			out = append(out, p.t)
+			written = append(written, i)
+		}
+	}
+	out = append(out, errorType)
+
+	fn := reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		results := make([]reflect.Value, len(out))
+		unchanged := func() {
+			for i, p := range written {
+				results[i] = args[p+1]
+			}
+		}
+		unchanged()
+		results[len(results)-1] = nilError
+		fail := func(err error) []reflect.Value {
+			unchanged()
+			results[len(results)-1] = ErrorResult(err)
+			return results
+		}
+
+		components := make(map[string]json.RawMessage, len(params))
+		for i, p := range params {
+			data, err := json.Marshal(args[i+1].Interface())
+			if err != nil {
+				return fail(fmt.Errorf("encoding %s: %w", p.name, err))
+			}
+			components[p.name] = data
+		}
+		changed, err := script.Tick(args[0].Interface().(Entity), components)
+		if err != nil {
+			return fail(err)
+		}
+		for name, data := range changed {
+			i := -1
+			for j, p := range written {
+				if params[p].name == name {
+					i = j
+				}
+			}
+			if i < 0 {
+				return fail(fmt.Errorf("script returned %s, which it doesn't write", name))
+			}
+			v := reflect.New(params[written[i]].t)
+			if err := json.Unmarshal(data, v.Interface()); err != nil {
+				return fail(fmt.Errorf("decoding %s: %w", name, err))
+			}
+			results[i] = v.Elem()
+		}
+		return results
+	})
+	return System{Func: fn.Interface(), Name: "script"}, nil
+}
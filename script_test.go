@@ -0,0 +1,89 @@
+package ecs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestScriptSystem(t *testing.T) {
+	world := ecs.NewWorld()
+	var failures []error
+	world.OnError = func(name string, args []interface{}, err error) {
+		failures = append(failures, err)
+	}
+	mover := world.AddObject(ecs.NewObject(Position(1), Velocity(2)))
+	world.AddObject(ecs.NewObject(Position(5)))
+
+	// A stand-in for an interpreter, which adds velocity to position.
+	script := ecs.ScriptFunc(func(e ecs.Entity, in map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+		p, _ := strconv.Atoi(string(in["ecs_test.Position"]))
+		v, _ := strconv.Atoi(string(in["ecs_test.Velocity"]))
+		return map[string]json.RawMessage{
+			"ecs_test.Position": json.RawMessage(strconv.Itoa(p + v)),
+		}, nil
+	})
+	s, err := ecs.NewScriptSystem(script, []interface{}{Velocity(0)}, []interface{}{Position(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Name = "move.lua"
+	if err := world.AddSystem(s); err != nil {
+		t.Fatal(err)
+	}
+	world.Step()
+	world.Step()
+
+	if got := world.GetObject(mover).Component(Position(0)); got != Position(5) {
+		t.Errorf("position = %v, want 5", got)
+	}
+	if len(failures) != 0 {
+		t.Errorf("unexpected errors: %v", failures)
+	}
+}
+
+func TestScriptSystemErrors(t *testing.T) {
+	world := ecs.NewWorld()
+	var failures []error
+	world.OnError = func(name string, args []interface{}, err error) {
+		failures = append(failures, err)
+	}
+	e := world.AddObject(ecs.NewObject(Position(1), Velocity(2)))
+
+	errBad := errors.New("bad script")
+	replies := []map[string]json.RawMessage{
+		{"ecs_test.Velocity": json.RawMessage("9")},
+		{"ecs_test.Position": json.RawMessage(`"nope"`)},
+	}
+	script := ecs.ScriptFunc(func(ecs.Entity, map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+		if len(replies) == 0 {
+			return nil, errBad
+		}
+		reply := replies[0]
+		replies = replies[1:]
+		return reply, nil
+	})
+	s, err := ecs.NewScriptSystem(script, []interface{}{Velocity(0)}, []interface{}{Position(0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	world.AddSystem(s)
+	for i := 0; i < 3; i++ {
+		world.Step()
+	}
+
+	if len(failures) != 3 || !errors.Is(failures[2], errBad) {
+		t.Errorf("errors = %v, want 3 ending with %v", failures, errBad)
+	}
+	ob := world.GetObject(e)
+	if ob.Component(Position(0)) != Position(1) || ob.Component(Velocity(0)) != Velocity(2) {
+		t.Errorf("failed ticks changed components: %v", ob.Components())
+	}
+
+	if _, err := ecs.NewScriptSystem(script, []interface{}{struct{ Unregistered int }{}}, nil); err == nil {
+		t.Error("expected an error for an unregistered component")
+	}
+}
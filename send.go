@@ -0,0 +1,53 @@
+package ecs
+
+import "errors"
+
+// ErrRecording is returned by Send while the world is being recorded or
+// replayed. Commands are code, so they can't be recorded, and a replay of a
+// world that took them would diverge from the original run.
+var ErrRecording = errors.New("commands can't be sent to a world that is being recorded or replayed")
+
+// Send queues a command to be applied to the world at the start of its next
+// tick, for network handlers, UI callbacks, timers, and anything else outside
+// the world that needs to change it:
+//
+//     http.HandleFunc("/spawn", func(rw http.ResponseWriter, r *http.Request) {
+//         world.Send(func(w *ecs.World) {
+//             w.AddObject(ecs.NewObject(Position{}, Enemy{}))
+//         })
+//     })
+//
+// Send is safe to call from any goroutine and never blocks, unlike the
+// world's Commands, whose capacity policy may make the caller wait or apply
+// the buffer on the caller's goroutine. Commands are applied in the order
+// they were sent, at the next tick boundary, before any systems' commands,
+// or by the next call to Step. Like any command, they may emit events with
+// w.Emit, which are delivered at the same boundary.
+//
+// Commands sent to a deterministic world are applied in the order they
+// arrive, which is up to the senders, so they aren't deterministic. While the
+// world is being recorded or replayed, Send returns ErrRecording instead;
+// send events over a Bridge, which are recorded, to give such a world input.
+func (w *World) Send(cmd func(w *World)) error {
+	if w.replayRecorder.Load() != nil || w.replay.Load() != nil {
+		return ErrRecording
+	}
+	w.inboxMu.Lock()
+	w.inbox = append(w.inbox, cmd)
+	w.inboxMu.Unlock()
+	return nil
+}
+
+// applyInbox applies the commands queued by Send.
+func (w *World) applyInbox() {
+	w.inboxMu.Lock()
+	inbox := w.inbox
+	w.inbox = nil
+	w.inboxMu.Unlock()
+	for _, cmd := range inbox {
+		cmd(w)
+	}
+	if len(inbox) > 0 {
+		w.metrics().Counter("ecs_commands_received", float64(len(inbox)))
+	}
+}
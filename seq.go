@@ -0,0 +1,78 @@
+package ecs
+
+import (
+	"iter"
+	"reflect"
+)
+
+// Each returns an iterator over every object in the world with a component
+// of type T, yielding each object's entity along with the component:
+//
+//     for e, pos := range ecs.Each[Position](world) {
+//         ...
+//     }
+//
+// The iterator works from a snapshot of the world's objects taken when
+// iteration starts, so objects may be added or removed while iterating.
+func Each[T any](w *World) iter.Seq2[Entity, T] {
+	return func(yield func(Entity, T) bool) {
+		for _, ob := range w.Objects() {
+			if v, ok := Get[T](ob); ok && !yield(ob.entity, v) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over every object that matches the query, in the
+// same manner as Each.
+func (q *Query) All() iter.Seq2[Entity, *Object] {
+	return func(yield func(Entity, *Object) bool) {
+		for _, ob := range q.w.Objects() {
+			if q.matches(ob) && !yield(ob.entity, ob) {
+				return
+			}
+		}
+	}
+}
+
+// seqComponent reports whether t is the type of a range-over-func iterator
+// of entities and components, such as iter.Seq2[Entity, Position], and if so
+// returns the component type. Systems can accept these in place of the older
+// iterator functions.
+func seqComponent(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		return nil, false
+	}
+	yield := t.In(0)
+	if yield.Kind() != reflect.Func || yield.NumIn() != 2 || yield.NumOut() != 1 {
+		return nil, false
+	}
+	if yield.In(0) != entityType || yield.Out(0).Kind() != reflect.Bool {
+		return nil, false
+	}
+	return yield.In(1), true
+}
+
+// makeSeq builds an iterator of type t, as recognized by seqComponent, that
+// yields objects with a component of type ct.
+func (w *World) makeSeq(t, ct reflect.Type) reflect.Value {
+	id := componentID(ct)
+	iface := ct.Kind() == reflect.Interface
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		yield := args[0]
+		for _, ob := range w.Objects() {
+			if !iface && !ob.componentMask().has(id) {
+				continue
+			}
+			c := ob.getComponentValue(ct)
+			if !c.IsValid() {
+				continue
+			}
+			if !yield.Call([]reflect.Value{reflect.ValueOf(ob.entity), c})[0].Bool() {
+				break
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,71 @@
+package ecs_test
+
+import (
+	"iter"
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestEach(t *testing.T) {
+	world := ecs.NewWorld()
+	a := world.AddObject(ecs.NewObject(Position(1)))
+	world.AddObject(ecs.NewObject(Velocity(2)))
+	b := world.AddObject(ecs.NewObject(Position(3), Velocity(4)))
+
+	var entities []ecs.Entity
+	var positions []Position
+	for e, p := range ecs.Each[Position](world) {
+		entities = append(entities, e)
+		positions = append(positions, p)
+	}
+	if want := []ecs.Entity{a, b}; !reflect.DeepEqual(entities, want) {
+		t.Errorf("bad entities: got %v, want %v", entities, want)
+	}
+	if want := []Position{1, 3}; !reflect.DeepEqual(positions, want) {
+		t.Errorf("bad positions: got %v, want %v", positions, want)
+	}
+
+	for e := range world.Query().With(Velocity(0)).All() {
+		if e == a {
+			t.Error("query should not match objects without a velocity")
+		}
+		break
+	}
+}
+
+func TestSeqParam(t *testing.T) {
+	world := ecs.NewWorld()
+	player := ecs.NewObject(Player{}, Position(0))
+	world.AddObject(player)
+	world.AddObject(ecs.NewObject(Target{}, Position(5)))
+	world.AddObject(ecs.NewObject(Target{}, Position(8)))
+
+	// move to the furthest target
+	world.AddSystem(ecs.System{
+		Name: "seek",
+		Func: func(_ Player, targets iter.Seq2[ecs.Entity, Target], positions iter.Seq2[ecs.Entity, Position]) Position {
+			isTarget := make(map[ecs.Entity]bool)
+			for e := range targets {
+				isTarget[e] = true
+			}
+			var furthest Position
+			for e, p := range positions {
+				if isTarget[e] && p > furthest {
+					furthest = p
+				}
+			}
+			return furthest
+		},
+	})
+	world.Run()
+
+	if got, want := player.Component(Position(0)), Position(8); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	info, _ := world.LookupSystem("seek")
+	if want := []reflect.Type{reflect.TypeOf(Player{}), reflect.TypeOf(Target{}), reflect.TypeOf(Position(0))}; !reflect.DeepEqual(info.Reads, want) {
+		t.Errorf("bad reads: got %v, want %v", info.Reads, want)
+	}
+}
@@ -0,0 +1,362 @@
+package ecs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultHealthTimeout = 10 * time.Second
+
+// A Service is a component of a larger application with a managed lifetime,
+// as expected by service managers. World implements it, so a world can be
+// started and stopped alongside an application's servers and workers.
+type Service interface {
+	// Start starts the service, and returns once it is ready, or ctx is
+	// done, whichever comes first.
+	Start(ctx context.Context) error
+
+	// Stop stops the service, and returns once it has stopped, or ctx is
+	// done, whichever comes first.
+	Stop(ctx context.Context) error
+}
+
+var _ Service = (*World)(nil)
+
+// lifecycle tracks whether a world is running, and whether it is ready.
+type lifecycle struct {
+	mu      sync.Mutex
+	running bool
+	started time.Time
+
+	// ready is closed once the systems without tickers have finished their
+	// only tick. It is replaced when a new run begins.
+	ready       chan struct{}
+	readyClosed bool
+
+	// cancel and done are set while the world was started with Start.
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+
+	// ctx and group are the context and goroutines of the world's systems
+	// while it is running.
+	ctx   context.Context
+	group *runGroup
+}
+
+// runGroup runs the goroutines of a world's systems, and waits for them to
+// finish. Unlike a WaitGroup, systems can be added to it at any time until
+// the last one has finished.
+type runGroup struct {
+	mu       sync.Mutex
+	running  int
+	finished bool
+	done     chan struct{}
+
+	// run runs a system added once the group has started.
+	run func(s *scheduledSystem)
+
+	// main receives the ticks of MainThread systems, to be run by serve.
+	main chan func()
+}
+
+// newRunGroup returns a group that stays open until wait or serve is called.
+func newRunGroup(run func(s *scheduledSystem)) *runGroup {
+	return &runGroup{running: 1, done: make(chan struct{}), run: run, main: make(chan func())}
+}
+
+// goFunc runs fn in a new goroutine, unless every goroutine in the group has
+// already finished, and reports whether it did.
+func (g *runGroup) goFunc(fn func()) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.finished {
+		return false
+	}
+	g.running++
+	go func() {
+		defer g.finishOne()
+		fn()
+	}()
+	return true
+}
+
+func (g *runGroup) finishOne() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.running--
+	if g.running == 0 {
+		g.finished = true
+		close(g.done)
+	}
+}
+
+// wait waits for every goroutine in the group to finish.
+func (g *runGroup) wait() {
+	g.finishOne()
+	<-g.done
+}
+
+// serve waits like wait, meanwhile running the ticks of MainThread systems on
+// the calling goroutine.
+func (g *runGroup) serve() {
+	g.finishOne()
+	for {
+		select {
+		case fn := <-g.main:
+			fn()
+		case <-g.done:
+			return
+		}
+	}
+}
+
+func (l *lifecycle) setRun(ctx context.Context, g *runGroup) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ctx, l.group = ctx, g
+}
+
+// context returns the context the world is running with, or the background
+// context if it isn't running.
+func (l *lifecycle) context() context.Context {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ctx == nil {
+		return context.Background()
+	}
+	return l.ctx
+}
+
+// mainThread returns the channel that the ticks of MainThread systems are
+// sent to while the world is running, or nil if it isn't.
+func (l *lifecycle) mainThread() chan<- func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.group == nil {
+		return nil
+	}
+	return l.group.main
+}
+
+// launch starts running a system added to the world, if it is running, and
+// reports whether it did.
+func (l *lifecycle) launch(s *scheduledSystem) bool {
+	l.mu.Lock()
+	g := l.group
+	l.mu.Unlock()
+	if g == nil {
+		return false
+	}
+	return g.goFunc(func() { g.run(s) })
+}
+
+// readyLocked returns the ready channel, making it if needed. The caller
+// must hold the lock.
+func (l *lifecycle) readyLocked() chan struct{} {
+	if l.ready == nil || l.readyClosed {
+		l.ready = make(chan struct{})
+		l.readyClosed = false
+	}
+	return l.ready
+}
+
+func (l *lifecycle) begin(w *World) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running = true
+	l.started = time.Now()
+	l.readyLocked()
+	for _, s := range w.systemList() {
+		atomic.StoreInt64(&s.lastSeen, 0)
+	}
+}
+
+func (l *lifecycle) markReady() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running && !l.readyClosed {
+		close(l.ready)
+		l.readyClosed = true
+	}
+}
+
+func (l *lifecycle) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running = false
+}
+
+// Ready returns a channel that is closed once the world is running and every
+// system without a Ticker, which runs only once, has finished. These are
+// typically startup systems that load data or open connections, so that a
+// world can signal readiness to a load balancer or orchestrator only when it
+// can do useful work. If the world isn't running, the channel is closed when
+// it next becomes ready.
+func (w *World) Ready() <-chan struct{} {
+	w.lifecycle.mu.Lock()
+	defer w.lifecycle.mu.Unlock()
+	if w.lifecycle.running {
+		return w.lifecycle.ready
+	}
+	// wait for the next run; readyLocked replaces the channel of a finished
+	// one
+	return w.lifecycle.readyLocked()
+}
+
+// Healthy returns nil if the world is running and every enabled system with a
+// Ticker has been due to tick within the world's HealthTimeout, and an error
+// describing the problem otherwise. A system that is due but skipped, such
+// as by RunIf or while shedding load, is still considered healthy, since the
+// world is keeping up; a system that is stuck in a tick, or blocked on a
+// component lock, is not.
+func (w *World) Healthy() error {
+	w.lifecycle.mu.Lock()
+	running, started := w.lifecycle.running, w.lifecycle.started
+	w.lifecycle.mu.Unlock()
+	if !running {
+		return errors.New("world is not running")
+	}
+
+	timeout := w.HealthTimeout
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	now := time.Now()
+	var errs []error
+	for _, s := range w.systemList() {
+		if (!s.periodic() && !w.clocked(s)) || !s.enabled() {
+			continue
+		}
+		last := started
+		if seen := atomic.LoadInt64(&s.lastSeen); seen != 0 {
+			last = time.Unix(0, seen)
+		}
+		if since := now.Sub(last); since > timeout {
+			errs = append(errs, fmt.Errorf("system %s hasn't ticked for %s", s.name(), since.Round(time.Millisecond)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Serve runs the world until ctx is cancelled, or a system fails. Unlike
+// RunContext, a failed system stops the whole world, and cancellation isn't
+// reported as an error, which suits running a world in an errgroup alongside
+// the rest of an application:
+//
+//     g, ctx := errgroup.WithContext(ctx)
+//     g.Go(func() error { return world.Serve(ctx) })
+//     g.Go(func() error { return server.ListenAndServe() })
+//     return g.Wait()
+//
+// Serve returns nil if ctx was cancelled, or every system finished on its
+// own, and otherwise a *SystemError for every system that failed.
+func (w *World) Serve(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return errors.Join(w.run(ctx, cancel)...)
+}
+
+// Start runs the world in the background, as with Serve, and waits until it
+// is ready; see Ready. If ctx is done first, the world is stopped again and
+// ctx's error is returned, and if the world stops before it is ready, the
+// error it stopped with is returned. It is an error to start a world that is
+// already running.
+func (w *World) Start(ctx context.Context) error {
+	l := &w.lifecycle
+	l.mu.Lock()
+	if l.running || l.cancel != nil {
+		l.mu.Unlock()
+		return errors.New("world is already running")
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	l.cancel, l.done, l.err = cancel, done, nil
+	ready := l.readyLocked()
+	l.mu.Unlock()
+
+	go func() {
+		err := w.Serve(runCtx)
+		l.mu.Lock()
+		l.err = err
+		l.mu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-ready:
+		return nil
+	case <-done:
+		return w.stopped()
+	case <-ctx.Done():
+		cancel()
+		<-done
+		w.stopped()
+		return ctx.Err()
+	}
+}
+
+// Stop stops a world started with Start, and waits for its systems to
+// return. It returns the error the world stopped with, if any, or ctx's error
+// if ctx is done first, in which case the world continues to stop in the
+// background. Stopping a world that isn't running does nothing.
+func (w *World) Stop(ctx context.Context) error {
+	l := &w.lifecycle
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		return w.stopped()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stopped clears the state of a world started with Start once it has
+// stopped, and returns the error it stopped with.
+func (w *World) stopped() error {
+	l := &w.lifecycle
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.err
+	l.cancel, l.done, l.err = nil, nil, nil
+	return err
+}
+
+// HealthHandler returns an HTTP handler for health checks, which responds
+// with 200 OK if the world is healthy, and 503 Service Unavailable with the
+// reason if not; see Healthy.
+func (w *World) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if err := w.Healthy(); err != nil {
+			http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(rw, "ok")
+	})
+}
+
+// ReadyHandler returns an HTTP handler for readiness checks, which responds
+// with 200 OK once the world is ready, and 503 Service Unavailable before;
+// see Ready.
+func (w *World) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-w.Ready():
+			fmt.Fprintln(rw, "ok")
+		default:
+			http.Error(rw, "world is not ready", http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package ecs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestStartStop(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	var loaded int32
+	world.AddSystem(ecs.System{Name: "load", Func: func(Position) {
+		time.Sleep(10 * time.Millisecond)
+		atomic.StoreInt32(&loaded, 1)
+	}})
+	world.AddSystem(ecs.System{
+		Name:   "move",
+		Func:   func(p Position) Position { return p + 1 },
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+
+	if err := world.Healthy(); err == nil {
+		t.Error("world should not be healthy before it starts")
+	}
+	rec := httptest.NewRecorder()
+	world.ReadyHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("bad status before start: got %d", rec.Code)
+	}
+
+	if err := world.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&loaded) == 0 {
+		t.Error("world became ready before its startup system finished")
+	}
+	select {
+	case <-world.Ready():
+	default:
+		t.Error("world isn't ready after Start")
+	}
+	if err := world.Start(context.Background()); err == nil {
+		t.Error("expected an error starting a running world")
+	}
+	if err := world.Healthy(); err != nil {
+		t.Errorf("world should be healthy: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	world.HealthHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("bad health status: got %d", rec.Code)
+	}
+
+	if err := world.Stop(context.Background()); err != nil {
+		t.Errorf("expected a clean stop, got %v", err)
+	}
+	if err := world.Healthy(); err == nil {
+		t.Error("world should not be healthy after it stops")
+	}
+
+	// a stopped world can be started again
+	if err := world.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := world.Stop(context.Background()); err != nil {
+		t.Errorf("expected a clean stop, got %v", err)
+	}
+}
+
+func TestStartFailure(t *testing.T) {
+	world := ecs.NewWorld()
+	world.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	unavailable := fmt.Errorf("%w: database unavailable", ecs.ErrFatal)
+	world.AddSystem(ecs.System{Name: "connect", Func: func(Position) error { return unavailable }})
+	world.AddSystem(ecs.System{
+		Func:   func(Position) {},
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+
+	err := world.Start(context.Background())
+	var se *ecs.SystemError
+	if !errors.As(err, &se) || se.System != "connect" || !errors.Is(err, unavailable) {
+		t.Errorf("expected the startup failure, got %v", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+	ctx, cancel := context.WithCancel(context.Background())
+	world.AddSystem(ecs.System{
+		Func:   func(Position) { cancel() },
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+	if err := world.Serve(ctx); err != nil {
+		t.Errorf("expected cancellation to be a clean exit, got %v", err)
+	}
+
+	// a failed system stops the rest
+	world = ecs.NewWorld()
+	world.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddSystem(ecs.System{
+		Name:   "save",
+		Func:   func(Position) error { return ecs.ErrFatal },
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+	world.AddSystem(ecs.System{
+		Func:   func(Position) {},
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+	err := world.Serve(context.Background())
+	var se *ecs.SystemError
+	if !errors.As(err, &se) || se.System != "save" {
+		t.Errorf("expected the failed system, got %v", err)
+	}
+}
+
+func TestHealthyStale(t *testing.T) {
+	world := ecs.NewWorld()
+	world.HealthTimeout = 20 * time.Millisecond
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	block := make(chan struct{})
+	world.AddSystem(ecs.System{
+		Name:   "stuck",
+		Func:   func(Position) { <-block },
+		Ticker: time.NewTicker(time.Millisecond).C,
+	})
+	if err := world.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	err := world.Healthy()
+	if err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Errorf("expected the stuck system to be reported, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	world.HealthHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("bad health status: got %d", rec.Code)
+	}
+
+	close(block)
+	if err := world.Stop(context.Background()); err != nil {
+		t.Errorf("expected a clean stop, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package ecs
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Shard identifies which of a sharded system's shards an object belongs to.
+// Sharded systems can accept a Shard parameter to find out which shard is
+// being processed; for other systems it is always zero.
+type Shard int
+
+var shardType = reflect.TypeOf(Shard(0))
+
+// shardOf consistently maps an entity to one of n shards. The entity is mixed
+// first so that entities allocated in sequence don't all land in neighboring
+// shards.
+func shardOf(e Entity, n int) int {
+	x := uint64(e)
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return int(x % uint64(n))
+}
+
+func (s System) tickSharded(w *World, objects []*Object, required componentMask, now time.Time) {
+	shards := make([][]*Object, s.Shards)
+	for _, ob := range objects {
+		i := shardOf(ob.entity, s.Shards)
+		shards[i] = append(shards[i], ob)
+	}
+
+	workers := s.Shards
+	if n := runtime.GOMAXPROCS(0); workers > n {
+		workers = n
+	}
+	if w.deterministic {
+		// a single worker visits the shards in order
+		workers = 1
+	}
+	// this tick already holds one slot, so only extra workers need more
+	extra := w.tryAcquireSlots(workers - 1)
+	defer w.releaseSlots(extra)
+
+	// each worker claims whole shards until there are none left
+	next := int32(-1)
+	var stopped int32
+	var wg sync.WaitGroup
+	for i := 0; i <= extra; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			argValues := s.arena.getArgs()
+			defer s.arena.putArgs(argValues)
+			for {
+				shard := int(atomic.AddInt32(&next, 1))
+				if shard >= len(shards) {
+					return
+				}
+				for _, ob := range shards[shard] {
+					if atomic.LoadInt32(&stopped) != 0 {
+						return
+					}
+					if s.tickObject(w, argValues, ob, required, now, Shard(shard)) {
+						atomic.StoreInt32(&stopped, 1)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
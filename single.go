@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrNoMatch is returned when a single object was expected to match a
+	// query, but none did.
+	ErrNoMatch = errors.New("no matching object")
+
+	// ErrMultipleMatches is returned when a single object was expected to
+	// match a query, but more than one did.
+	ErrMultipleMatches = errors.New("multiple matching objects")
+)
+
+// Single returns the one object that matches the query. It returns an error
+// wrapping ErrNoMatch or ErrMultipleMatches if there isn't exactly one.
+func (q *Query) Single() (*Object, error) {
+	switch objects := q.candidates(); len(objects) {
+	case 0:
+		return nil, fmt.Errorf("%w: %v", ErrNoMatch, q.with)
+	case 1:
+		return objects[0], nil
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrMultipleMatches, q.with)
+	}
+}
+
+// Single returns the one object in the world with a component of the same
+// type as component, such as the player or the camera. It returns an error
+// wrapping ErrNoMatch or ErrMultipleMatches if there isn't exactly one.
+func (w *World) Single(component interface{}) (*Object, error) {
+	return w.Query().With(component).Single()
+}
+
+// singleParam is implemented by Single, so that systems can accept it as a
+// parameter.
+type singleParam interface {
+	componentType() reflect.Type
+	bind(ob *Object, c reflect.Value) reflect.Value
+}
+
+var singleParamType = reflect.TypeOf((*singleParam)(nil)).Elem()
+
+// Single is a system parameter holding the one object in the world with a
+// component of type T:
+//
+//     func followPlayer(camera Camera, player ecs.Single[Position]) Camera
+//
+// If there isn't exactly one such object, the system is skipped and the
+// problem is reported to World.OnError.
+type Single[T any] struct {
+	entity Entity
+	value  T
+}
+
+// Entity returns the object's entity.
+func (s Single[T]) Entity() Entity {
+	return s.entity
+}
+
+// Get returns the object's component.
+func (s Single[T]) Get() T {
+	return s.value
+}
+
+func (Single[T]) componentType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (Single[T]) bind(ob *Object, c reflect.Value) reflect.Value {
+	return reflect.ValueOf(Single[T]{entity: ob.entity, value: c.Interface().(T)})
+}
+
+// single finds the object for a Single parameter of type t.
+func (w *World) single(t reflect.Type) (reflect.Value, error) {
+	p := reflect.Zero(t).Interface().(singleParam)
+	ct := p.componentType()
+	var required componentMask
+	required.set(componentID(ct))
+	var found *Object
+	var value reflect.Value
+	for _, ob := range w.matching(required, nil, false) {
+		c := ob.getComponentValue(ct)
+		if !c.IsValid() {
+			continue
+		}
+		if found != nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrMultipleMatches, ct)
+		}
+		found, value = ob, c
+	}
+	if found == nil {
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrNoMatch, ct)
+	}
+	return p.bind(found, value), nil
+}
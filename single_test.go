@@ -0,0 +1,56 @@
+package ecs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestSingle(t *testing.T) {
+	world := ecs.NewWorld()
+	if _, err := world.Single(Player{}); !errors.Is(err, ecs.ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+
+	player := ecs.NewObject(Player{}, Position(3))
+	world.AddObject(player)
+	world.AddObject(ecs.NewObject(Target{}))
+	world.AddObject(ecs.NewObject(Target{}))
+
+	if ob, err := world.Single(Player{}); err != nil || ob != player {
+		t.Errorf("expected the player, got %v, %v", ob, err)
+	}
+	if _, err := world.Single(Target{}); !errors.Is(err, ecs.ErrMultipleMatches) {
+		t.Errorf("expected ErrMultipleMatches, got %v", err)
+	}
+	if ob, err := world.Query().With(Position(0)).Single(); err != nil || ob != player {
+		t.Errorf("expected the player, got %v, %v", ob, err)
+	}
+}
+
+func TestSingleParam(t *testing.T) {
+	type Camera struct{ Position Position }
+
+	world := ecs.NewWorld()
+	var errs []error
+	world.OnError = func(_ string, _ []interface{}, err error) { errs = append(errs, err) }
+
+	camera := ecs.NewObject(Camera{})
+	world.AddObject(camera)
+	world.AddSystem(ecs.System{Func: func(c Camera, player ecs.Single[Position]) Camera {
+		c.Position = player.Get()
+		return c
+	}})
+
+	world.Run()
+	if len(errs) != 1 || !errors.Is(errs[0], ecs.ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch to be reported, got %v", errs)
+	}
+
+	world.AddObject(ecs.NewObject(Player{}, Position(5)))
+	world.Run()
+	if got := camera.Component(Camera{}).(Camera).Position; got != 5 {
+		t.Errorf("camera should follow the player: got %v", got)
+	}
+}
@@ -0,0 +1,152 @@
+package ecs
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sync/atomic"
+)
+
+type jsonSnapshot struct {
+	NextEntity Entity       `json:"next_entity"`
+	Objects    []jsonObject `json:"objects"`
+}
+
+type jsonObject struct {
+	Entity     Entity          `json:"entity"`
+	Components []jsonComponent `json:"components"`
+}
+
+// jsonComponent holds a component's value as JSON, or, if its type has a
+// codec, the bytes the codec encoded it as.
+type jsonComponent struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Data  []byte          `json:"data,omitempty"`
+}
+
+// SaveJSON writes every object in the world, along with the next entity ID to
+// be allocated, as JSON. Every component type must have been registered with
+// Register or RegisterName.
+func (w *World) SaveJSON(out io.Writer) error {
+	snap, err := w.jsonSnapshot()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(out).Encode(snap)
+}
+
+func (w *World) jsonSnapshot() (jsonSnapshot, error) {
+	w.objectsMu.RLock()
+	defer w.objectsMu.RUnlock()
+
+	snap := jsonSnapshot{
+		NextEntity: Entity(atomic.LoadUint64(&gid) + 1),
+		Objects:    make([]jsonObject, len(w.objects)),
+	}
+	for i, ob := range w.objects {
+		snap.Objects[i] = jsonObject{
+			Entity: ob.entity,
+		}
+		components := ob.Components()
+		snap.Objects[i].Components = make([]jsonComponent, len(components))
+		for j, c := range components {
+			component, err := encodeJSONComponent(c)
+			if err != nil {
+				return jsonSnapshot{}, err
+			}
+			snap.Objects[i].Components[j] = component
+		}
+	}
+	return snap, nil
+}
+
+// LoadJSON reads objects written by SaveJSON, replacing every object currently
+// in the world. Entity IDs are preserved, and entities allocated afterwards
+// will not collide with them.
+func (w *World) LoadJSON(in io.Reader) error {
+	var snap jsonSnapshot
+	if err := json.NewDecoder(in).Decode(&snap); err != nil {
+		return err
+	}
+	return w.loadJSONSnapshot(snap)
+}
+
+func (w *World) loadJSONSnapshot(snap jsonSnapshot) error {
+	objects := make([]*Object, len(snap.Objects))
+	for i, o := range snap.Objects {
+		ob := &Object{
+			entity:     o.Entity,
+			components: make([]interface{}, len(o.Components)),
+		}
+		for j, c := range o.Components {
+			component, err := decodeJSONComponent(c)
+			if err != nil {
+				return err
+			}
+			ob.components[j] = component
+		}
+		objects[i] = ob
+	}
+
+	w.replaceObjects(objects, snap.NextEntity)
+	return nil
+}
+
+// replaceObjects swaps out the world's objects and ensures that the next
+// allocated entity ID is at least next.
+func (w *World) replaceObjects(objects []*Object, next Entity) {
+	w.objectsMu.Lock()
+	for _, ob := range w.objects {
+		atomic.StoreUint64(&ob.seq, 0)
+	}
+	for _, ob := range objects {
+		reserveEntity(ob.entity)
+		ob.world = w
+		w.nextSeq++
+		atomic.StoreUint64(&ob.seq, w.nextSeq)
+	}
+	w.objects = objects
+	w.objectsMu.Unlock()
+	for _, c := range w.columnMap() {
+		c.clear()
+	}
+	for _, ob := range objects {
+		w.moveToColumns(ob)
+	}
+	w.cache.reset()
+	w.reindexAll()
+	if next > 0 {
+		reserveEntity(next - 1)
+	}
+}
+
+func encodeJSONComponent(c interface{}) (jsonComponent, error) {
+	t := reflect.TypeOf(c)
+	name, err := registeredName(t)
+	if err != nil {
+		return jsonComponent{}, err
+	}
+	if codec := registeredCodec(t); codec != nil {
+		data, err := codec.Encode(c)
+		return jsonComponent{Type: name, Data: data}, err
+	}
+	value, err := json.Marshal(c)
+	return jsonComponent{Type: name, Value: value}, err
+}
+
+func decodeJSONComponent(c jsonComponent) (interface{}, error) {
+	t, err := registeredType(c.Type)
+	if err != nil {
+		return nil, err
+	}
+	// components saved before their codec was registered only have a value
+	if codec := registeredCodec(t); codec != nil && (c.Data != nil || c.Value == nil) {
+		return codec.Decode(c.Data)
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(c.Value, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
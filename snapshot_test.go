@@ -0,0 +1,97 @@
+package ecs_test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func init() {
+	ecs.Register(Position(0))
+	ecs.Register(Velocity(0))
+}
+
+func TestSaveAndLoadJSON(t *testing.T) {
+	world := ecs.NewWorld()
+	player := world.AddObject(ecs.NewObject(Position(1), Velocity(2)))
+	wall := world.AddObject(ecs.NewObject(Position(5)))
+
+	var buf bytes.Buffer
+	if err := world.SaveJSON(&buf); err != nil {
+		t.Fatalf("failed to save: %s", err)
+	}
+
+	loaded := ecs.NewWorld()
+	if err := loaded.LoadJSON(&buf); err != nil {
+		t.Fatalf("failed to load: %s", err)
+	}
+
+	if ob := loaded.GetObject(player); ob == nil {
+		t.Error("player not loaded")
+	} else if got, want := ob.Component(Velocity(0)), Velocity(2); got != want {
+		t.Errorf("bad velocity: got %v, want %v", got, want)
+	}
+	if ob := loaded.GetObject(wall); ob == nil {
+		t.Error("wall not loaded")
+	} else if got, want := ob.Component(Position(0)), Position(5); got != want {
+		t.Errorf("bad position: got %v, want %v", got, want)
+	}
+
+	if next := loaded.AddObject(ecs.NewObject()); next <= wall {
+		t.Errorf("new entity %v collides with loaded entities", next)
+	}
+}
+
+func TestSaveUnregisteredComponent(t *testing.T) {
+	type Unregistered struct{}
+
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Unregistered{}))
+
+	var buf bytes.Buffer
+	if err := world.SaveJSON(&buf); err == nil {
+		t.Error("expected an error saving an unregistered component")
+	}
+}
+
+type reversedCodec struct{}
+
+func (reversedCodec) Encode(c interface{}) ([]byte, error) {
+	return []byte(fmt.Sprint(-int(c.(Velocity)))), nil
+}
+
+func (reversedCodec) Decode(data []byte) (interface{}, error) {
+	n, err := strconv.Atoi(string(data))
+	return Velocity(-n), err
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	ecs.Register(Player{})
+	ecs.RegisterCodec(Velocity(0), reversedCodec{})
+	defer ecs.RegisterCodec(Velocity(0), nil)
+
+	world := ecs.NewWorld()
+	player := world.AddObject(ecs.NewObject(Player{}, Position(1), Velocity(2)))
+
+	var buf bytes.Buffer
+	if err := world.Save(&buf); err != nil {
+		t.Fatalf("failed to save: %s", err)
+	}
+
+	loaded := ecs.NewWorld()
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("failed to load: %s", err)
+	}
+
+	ob := loaded.GetObject(player)
+	if ob == nil {
+		t.Fatal("player not loaded")
+	}
+	if got, want := ob.Components(), []interface{}{Player{}, Position(1), Velocity(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bad components: got %v, want %v", got, want)
+	}
+}
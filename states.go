@@ -0,0 +1,133 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync"
+)
+
+// States is a resource that tracks which state a state machine is in, such as
+// a game moving between its main menu, gameplay, and pause screen. Systems
+// can be limited to running in a particular state with OnUpdate, or run once
+// on transitions with OnEnter and OnExit.
+//
+// States must be added to the world with AddResource. To survive
+// hibernation, its type must be registered, as with Register(&States[T]{}),
+// and its OnEnter and OnExit systems registered again after resuming.
+type States[T comparable] struct {
+	mu      sync.RWMutex
+	current T
+	next    *T
+	started bool
+	enter   map[T][]System
+	exit    map[T][]System
+}
+
+// NewStates creates a state machine that starts in the initial state. The
+// initial state's OnEnter systems are run when the world starts running.
+func NewStates[T comparable](initial T) *States[T] {
+	return &States[T]{
+		current: initial,
+		enter:   make(map[T][]System),
+		exit:    make(map[T][]System),
+	}
+}
+
+// Current returns the current state.
+func (s *States[T]) Current() T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Set requests a transition to the next state. The transition happens at the
+// end of the current tick, so every system sees a consistent state for the
+// whole of its tick. Setting the current state does nothing.
+func (s *States[T]) Set(next T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = &next
+}
+
+// OnEnter registers a system to be run once whenever the state machine
+// enters the given state.
+func (s *States[T]) OnEnter(state T, sys System) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enter[state] = append(s.enter[state], sys)
+}
+
+// OnExit registers a system to be run once whenever the state machine leaves
+// the given state.
+func (s *States[T]) OnExit(state T, sys System) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exit[state] = append(s.exit[state], sys)
+}
+
+// OnUpdate limits a system to only tick while the state machine is in the
+// given state. The returned system should be added to the world.
+func (s *States[T]) OnUpdate(state T, sys System) System {
+	runIf := sys.RunIf
+	sys.RunIf = func() bool {
+		return s.Current() == state && (runIf == nil || runIf())
+	}
+	return sys
+}
+
+func (s *States[T]) transition(w *World) {
+	s.mu.Lock()
+	var run []System
+	if !s.started {
+		s.started = true
+		run = append(run, s.enter[s.current]...)
+	}
+	if s.next != nil {
+		next := *s.next
+		s.next = nil
+		if next != s.current {
+			run = append(run, s.exit[s.current]...)
+			run = append(run, s.enter[next]...)
+			s.current = next
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sys := range run {
+		w.runSystemNow(sys)
+	}
+}
+
+// statesState is the part of a States that is kept by GobEncode.
+type statesState[T comparable] struct {
+	Current T
+	Next    *T
+	Started bool
+}
+
+// GobEncode implements gob.GobEncoder, so that the current state survives
+// hibernation. OnEnter and OnExit systems are code, so they aren't kept.
+func (s *States[T]) GobEncode() ([]byte, error) {
+	s.mu.RLock()
+	state := statesState[T]{s.current, s.next, s.started}
+	s.mu.RUnlock()
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(state)
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder.
+func (s *States[T]) GobDecode(data []byte) error {
+	var state statesState[T]
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current, s.next, s.started = state.Current, state.Next, state.Started
+	if s.enter == nil {
+		s.enter = make(map[T][]System)
+		s.exit = make(map[T][]System)
+	}
+	return nil
+}
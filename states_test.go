@@ -0,0 +1,65 @@
+package ecs_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestStates(t *testing.T) {
+	type GameState int
+	const (
+		MainMenu GameState = iota
+		Playing
+	)
+
+	var log []string
+	record := func(msg string) ecs.System {
+		return ecs.System{Func: func() { log = append(log, msg) }, Global: true}
+	}
+
+	states := ecs.NewStates(MainMenu)
+	states.OnEnter(MainMenu, record("enter menu"))
+	states.OnExit(MainMenu, record("exit menu"))
+	states.OnEnter(Playing, record("enter playing"))
+
+	world := ecs.NewWorld()
+	world.AddResource(states)
+	world.AddSystem(states.OnUpdate(MainMenu, ecs.System{
+		Func: func(states *ecs.States[GameState]) {
+			log = append(log, "update menu")
+			states.Set(Playing)
+		},
+		Global: true,
+		Ticker: MaxTicker(time.Millisecond, 3),
+	}))
+
+	world.Run()
+
+	want := []string{"enter menu", "update menu", "exit menu", "enter playing"}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("bad log:\ngot  %v\nwant %v", log, want)
+	}
+	if got, want := states.Current(), Playing; got != want {
+		t.Errorf("bad state: got %v, want %v", got, want)
+	}
+}
+
+func TestStatesAddedTwice(t *testing.T) {
+	entered := 0
+	first := ecs.NewStates("menu")
+	first.OnEnter("menu", ecs.System{Func: func() { t.Error("replaced states entered its initial state") }, Global: true})
+	second := ecs.NewStates("menu")
+	second.OnEnter("menu", ecs.System{Func: func() { entered++ }, Global: true})
+
+	world := ecs.NewWorld()
+	world.AddResource(first)
+	world.AddResource(second)
+	world.AddResource(second)
+	world.Step()
+	if entered != 1 {
+		t.Errorf("initial state entered %d times, want 1", entered)
+	}
+}
@@ -0,0 +1,128 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// WorldStats is a summary of what a world holds, for debug overlays and
+// capacity planning.
+type WorldStats struct {
+	// Entities is the number of objects in the world.
+	Entities int
+
+	// Components counts the components of each type across every object.
+	Components map[reflect.Type]int
+
+	// Systems is the number of systems in the world, of which
+	// EnabledSystems are currently enabled.
+	Systems        int
+	EnabledSystems int
+
+	// Archetypes lists each distinct combination of component types held
+	// by the world's objects, from the most common to the least.
+	Archetypes []ArchetypeStats
+
+	// Fragmentation is the number of archetypes per object, from near zero
+	// when most objects share a few combinations of types, to one when
+	// every object has its own. Highly fragmented worlds get little benefit
+	// from match sets; see QueryCacheConfig.
+	Fragmentation float64
+
+	// MatchSets is the number of match sets the world has built, and
+	// MatchSetObjects the number of objects held across all of them.
+	MatchSets       int
+	MatchSetObjects int
+}
+
+// ArchetypeStats describes one combination of component types.
+type ArchetypeStats struct {
+	// Types are the component types, sorted by name.
+	Types []reflect.Type
+
+	// Entities is the number of objects with exactly these types.
+	Entities int
+}
+
+// EntityCount returns the number of objects in the world.
+func (w *World) EntityCount() int {
+	w.objectsMu.RLock()
+	defer w.objectsMu.RUnlock()
+	return len(w.objects)
+}
+
+// Stats returns a summary of the world's objects, components, and systems.
+// It examines every object, so it is best called no more than a few times a
+// second.
+func (w *World) Stats() WorldStats {
+	stats := WorldStats{
+		Components: make(map[reflect.Type]int),
+	}
+	systems := w.systemList()
+	stats.Systems = len(systems)
+	for _, s := range systems {
+		if s.enabled() {
+			stats.EnabledSystems++
+		}
+	}
+
+	archetypes := make(map[string]*ArchetypeStats)
+	for _, ob := range w.Objects() {
+		stats.Entities++
+		ob.mu.RLock()
+		types := append(typesOf(ob.components), ob.dense...)
+		ob.mu.RUnlock()
+
+		for _, t := range types {
+			stats.Components[t]++
+		}
+		key := maskString(maskOf(nil, types))
+		a, ok := archetypes[key]
+		if !ok {
+			a = &ArchetypeStats{Types: uniqueTypes(types)}
+			archetypes[key] = a
+		}
+		a.Entities++
+	}
+
+	for _, a := range archetypes {
+		stats.Archetypes = append(stats.Archetypes, *a)
+	}
+	sort.Slice(stats.Archetypes, func(i, j int) bool {
+		a, b := stats.Archetypes[i], stats.Archetypes[j]
+		if a.Entities != b.Entities {
+			return a.Entities > b.Entities
+		}
+		return typeNames(a.Types) < typeNames(b.Types)
+	})
+	if stats.Entities > 0 {
+		stats.Fragmentation = float64(len(stats.Archetypes)) / float64(stats.Entities)
+	}
+
+	w.cache.mu.Lock()
+	stats.MatchSets, stats.MatchSetObjects = len(w.cache.sets), w.cache.size
+	w.cache.mu.Unlock()
+	return stats
+}
+
+// uniqueTypes returns the distinct types in types, sorted by name.
+func uniqueTypes(types []reflect.Type) []reflect.Type {
+	var unique []reflect.Type
+	for _, t := range types {
+		if !hasType(unique, t) {
+			unique = append(unique, t)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].String() < unique[j].String()
+	})
+	return unique
+}
+
+func typeNames(types []reflect.Type) string {
+	var names string
+	for _, t := range types {
+		names += t.String() + ","
+	}
+	return names
+}
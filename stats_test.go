@@ -0,0 +1,53 @@
+package ecs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestStats(t *testing.T) {
+	world := ecs.NewWorld()
+	ecs.StoreDense[Velocity](world)
+	for i := 0; i < 3; i++ {
+		world.AddObject(ecs.NewObject(Position(i), Velocity(1)))
+	}
+	world.AddObject(ecs.NewObject(Position(0)))
+	world.AddSystem(ecs.System{Name: "move", Func: func(p Position, v Velocity) Position {
+		return p + Position(v)
+	}})
+	world.AddSystem(ecs.System{Name: "idle", Func: func(Player) {}})
+	world.DisableSystem("idle")
+	world.Step()
+
+	if got := world.EntityCount(); got != 4 {
+		t.Errorf("bad entity count: got %d, want 4", got)
+	}
+	stats := world.Stats()
+	if stats.Entities != 4 {
+		t.Errorf("bad entities: got %d, want 4", stats.Entities)
+	}
+	position, velocity := reflect.TypeOf(Position(0)), reflect.TypeOf(Velocity(0))
+	if got := stats.Components[position]; got != 4 {
+		t.Errorf("bad position count: got %d, want 4", got)
+	}
+	if got := stats.Components[velocity]; got != 3 {
+		t.Errorf("bad velocity count: got %d, want 3", got)
+	}
+	if stats.Systems != 2 || stats.EnabledSystems != 1 {
+		t.Errorf("bad system counts: got %d and %d enabled, want 2 and 1", stats.Systems, stats.EnabledSystems)
+	}
+	if len(stats.Archetypes) != 2 {
+		t.Fatalf("bad archetypes: %v", stats.Archetypes)
+	}
+	if a := stats.Archetypes[0]; a.Entities != 3 || len(a.Types) != 2 || a.Types[0] != position || a.Types[1] != velocity {
+		t.Errorf("bad most common archetype: %v", a)
+	}
+	if got := stats.Fragmentation; got != 0.5 {
+		t.Errorf("bad fragmentation: got %v, want 0.5", got)
+	}
+	if stats.MatchSets != 1 || stats.MatchSetObjects != 3 {
+		t.Errorf("bad match sets: got %d holding %d, want 1 holding 3", stats.MatchSets, stats.MatchSetObjects)
+	}
+}
@@ -0,0 +1,467 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SystemInfo describes a system that has been added to a world.
+type SystemInfo struct {
+	// Name is the system's name, which defaults to the name of its function.
+	Name string
+
+	// Label and Stage are as set on the System.
+	Label string
+	Stage string
+
+	// Interval is the system's Every, or zero if it ticks on a Ticker of
+	// its own or only once, and EveryNTicks is its tick divisor.
+	Interval    time.Duration
+	EveryNTicks int
+
+	// Enabled reports whether the system will run on its next tick.
+	Enabled bool
+
+	Global   bool
+	Parallel bool
+
+	// LastTick is how long the system's most recent tick took to run.
+	LastTick time.Duration
+
+	// Ticks is the number of times the system has ticked.
+	Ticks uint64
+
+	// Reads lists the component types the system accepts, either directly
+	// or through an iterator, and Writes lists the component types it
+	// returns. Resources accepted through Res and ResMut are listed as reads
+	// and writes respectively.
+	Reads  []reflect.Type
+	Writes []reflect.Type
+}
+
+// scheduledSystem holds a system along with its runtime state.
+type scheduledSystem struct {
+	System
+	locks    []systemLock
+	disabled int32
+	shed     int32 // whether the last tick was skipped to shed load
+	lastTick int64
+	ticks    uint64
+
+	// lastSeen is when the system was last due to tick, in Unix
+	// nanoseconds, whether or not it actually ran.
+	lastSeen int64
+
+	// due counts the ticks of the system's own Ticker or interval.
+	due uint64
+
+	// stop is closed when the system is removed from the world, and
+	// gone is set so that a tick that was already due is skipped.
+	stop     chan struct{}
+	stopOnce sync.Once
+	gone     int32
+
+	// ran is set once a system with Once set has started its only tick.
+	ran int32
+}
+
+// schedule prepares a system to be ticked by the world.
+func (w *World) schedule(s System) *scheduledSystem {
+	s.arena = newArena(w, s)
+	s.counters = new(tickCounters)
+	s.clock = new(systemClock)
+	s.ctx = newTickContext()
+	s.failure = new(failure)
+	s.retries = new(retryState)
+	ss := &scheduledSystem{System: s, locks: w.systemLocks(s), stop: make(chan struct{})}
+	ss.scheduled = ss
+	return ss
+}
+
+func (s *scheduledSystem) enabled() bool {
+	return atomic.LoadInt32(&s.disabled) == 0
+}
+
+func (s *scheduledSystem) removed() bool {
+	return atomic.LoadInt32(&s.gone) != 0
+}
+
+// remove stops the system from ticking again.
+func (s *scheduledSystem) remove() {
+	atomic.StoreInt32(&s.gone, 1)
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// systemList returns the world's systems. The slice is never modified, so it
+// can be used without locking.
+func (w *World) systemList() []*scheduledSystem {
+	w.systemsMu.RLock()
+	defer w.systemsMu.RUnlock()
+	return w.systems
+}
+
+func (s *scheduledSystem) info() SystemInfo {
+	reads, writes := s.access()
+	return SystemInfo{
+		Name:        s.name(),
+		Label:       s.Label,
+		Stage:       s.Stage,
+		Interval:    s.Every,
+		EveryNTicks: s.EveryNTicks,
+		Enabled:     s.enabled(),
+		Global:      s.Global,
+		Parallel:    s.Parallel,
+		LastTick:    time.Duration(atomic.LoadInt64(&s.lastTick)),
+		Ticks:       atomic.LoadUint64(&s.ticks),
+		Reads:       reads,
+		Writes:      writes,
+	}
+}
+
+// Systems returns information about every system in the world, in the order
+// they were added.
+func (w *World) Systems() []SystemInfo {
+	systems := w.systemList()
+	infos := make([]SystemInfo, len(systems))
+	for i, s := range systems {
+		infos[i] = s.info()
+	}
+	return infos
+}
+
+// SystemsLabeled returns information about every system with the given
+// label, in the order they were added.
+func (w *World) SystemsLabeled(label string) []SystemInfo {
+	var infos []SystemInfo
+	for _, s := range w.systemList() {
+		if s.Label == label {
+			infos = append(infos, s.info())
+		}
+	}
+	return infos
+}
+
+// LookupSystem returns information about the system with the given name.
+func (w *World) LookupSystem(name string) (SystemInfo, bool) {
+	if s := w.findSystem(name); s != nil {
+		return s.info(), true
+	}
+	return SystemInfo{}, false
+}
+
+// EnableSystem resumes a system that was previously disabled. It reports
+// whether a system with the given name was found.
+func (w *World) EnableSystem(name string) bool {
+	return w.setSystemEnabled(name, true)
+}
+
+// DisableSystem pauses a system so that it is skipped on every tick until it
+// is enabled again. It reports whether a system with the given name was found.
+func (w *World) DisableSystem(name string) bool {
+	return w.setSystemEnabled(name, false)
+}
+
+func (w *World) setSystemEnabled(name string, enabled bool) bool {
+	s := w.findSystem(name)
+	if s == nil {
+		return false
+	}
+	s.setEnabled(w, enabled)
+	return true
+}
+
+// setEnabled enables or disables this system, rather than whichever has its
+// name.
+func (s *scheduledSystem) setEnabled(w *World, enabled bool) {
+	var disabled int32
+	if !enabled {
+		disabled = 1
+	}
+	if atomic.SwapInt32(&s.disabled, disabled) != disabled {
+		msg := "system enabled"
+		if !enabled {
+			msg = "system disabled"
+		}
+		w.logger().Info(msg, "system", s.name())
+	}
+}
+
+// RemoveSystem removes the system with the given name from the world, and
+// reports whether it was found. If the world is running, the system's
+// goroutine stops; a tick already in progress finishes, but the system never
+// starts another. The system's Ticker is left alone, so a ticker created with
+// time.NewTicker should be stopped by the caller.
+func (w *World) RemoveSystem(name string) bool {
+	w.systemsMu.Lock()
+	defer w.systemsMu.Unlock()
+	i := w.systemIndex(name)
+	if i < 0 {
+		return false
+	}
+	old := w.systems[i]
+	w.systems = append(w.systems[:i:i], w.systems[i+1:]...)
+	old.remove()
+	w.logger().Info("system removed", "system", name)
+	return true
+}
+
+// unschedule removes a system that has run its only tick.
+func (w *World) unschedule(s *scheduledSystem) {
+	w.systemsMu.Lock()
+	defer w.systemsMu.Unlock()
+	for i, other := range w.systems {
+		if other == s {
+			w.systems = append(w.systems[:i:i], w.systems[i+1:]...)
+			break
+		}
+	}
+	s.remove()
+	w.logger().Debug("system removed after running once", "system", s.name())
+}
+
+// RunSystemOnce runs a system once, at the next tick boundary, without adding
+// it to the world's schedule. It is meant for logic triggered by something
+// that happens mid-game, such as an event, and is safe to call from systems
+// and event handlers. If the world isn't running, the system runs when it
+// next starts or steps. An error is returned if the system is invalid.
+func (w *World) RunSystemOnce(s System) error {
+	if err := s.validate(w); err != nil {
+		return err
+	}
+	w.onceMu.Lock()
+	w.once = append(w.once, s)
+	w.onceMu.Unlock()
+	return nil
+}
+
+// runOnceSystems runs the systems queued by RunSystemOnce.
+func (w *World) runOnceSystems() {
+	w.onceMu.Lock()
+	once := w.once
+	w.once = nil
+	w.onceMu.Unlock()
+	for _, s := range once {
+		w.runSystemNow(s)
+	}
+}
+
+// ReplaceSystem replaces the system with the given name with s, keeping its
+// place in the order systems tick in, so that logic can be swapped without
+// restarting the world. If s has no Name, it takes the name of the system it
+// replaces, and if that system was disabled, so is s.
+//
+// If the world is running, the old system stops as with RemoveSystem, and s
+// starts running immediately, but isn't waited on by Ready. Since systems
+// that share components never tick at the same time, s won't tick until any
+// tick of the old system in progress has finished. An error is returned if
+// there is no system with the given name, or if s is invalid, in which case
+// the old system is left in place.
+func (w *World) ReplaceSystem(name string, s System) error {
+	if s.Name == "" {
+		s.Name = name
+	}
+	if err := s.validate(w); err != nil {
+		return err
+	}
+	ss := w.schedule(s)
+
+	w.systemsMu.Lock()
+	defer w.systemsMu.Unlock()
+	i := w.systemIndex(name)
+	if i < 0 {
+		return fmt.Errorf("no system named %q", name)
+	}
+	old := w.systems[i]
+	atomic.StoreInt32(&ss.disabled, atomic.LoadInt32(&old.disabled))
+	systems := append([]*scheduledSystem(nil), w.systems...)
+	systems[i] = ss
+	w.systems = systems
+	old.remove()
+	w.lifecycle.launch(ss)
+	w.logger().Info("system replaced", "system", name, "replacement", ss.name())
+	return nil
+}
+
+// systemIndex returns the index of the system with the given name, or -1.
+// The caller must hold systemsMu.
+func (w *World) systemIndex(name string) int {
+	for i, s := range w.systems {
+		if s.name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *World) findSystem(name string) *scheduledSystem {
+	for _, s := range w.systemList() {
+		if s.name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func (s System) name() string {
+	if s.arena != nil {
+		return s.arena.name
+	}
+	if s.Name != "" {
+		return s.Name
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(s.Func).Pointer()).Name()
+	if dot := strings.LastIndex(name, "."); dot > -1 {
+		name = name[dot+1:]
+	}
+	return name
+}
+
+// periodic reports whether the system ticks repeatedly, rather than once.
+func (s System) periodic() bool {
+	return s.Ticker != nil || s.Every > 0
+}
+
+// access returns the component types read and written by the system, based on
+// its function signature.
+func (s System) access() (reads, writes []reflect.Type) {
+	t := reflect.TypeOf(s.Func)
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		switch {
+		case in == worldType, in == contextType, in == entityType, in == timeType, in == elapsedType, in == commandsType, in == shardType:
+		case in.Implements(resParamType):
+			p := reflect.Zero(in).Interface().(resParam)
+			if p.mutable() {
+				writes = append(writes, p.resourceType())
+			} else {
+				reads = append(reads, p.resourceType())
+			}
+		case in.Implements(singleParamType):
+			reads = append(reads, reflect.Zero(in).Interface().(singleParam).componentType())
+		case in.Implements(columnParamType):
+			writes = append(writes, reflect.Zero(in).Interface().(columnParam).columnType())
+		case in.Kind() == reflect.Func:
+			if ct, ok := seqComponent(in); ok {
+				reads = append(reads, ct)
+				continue
+			}
+			// iterator results, minus the index, entity, and trailing bool
+			for out := 0; out < in.NumOut()-1; out++ {
+				if ot := in.Out(out); ot != intType && ot != entityType {
+					reads = append(reads, ot)
+				}
+			}
+		default:
+			reads = append(reads, in)
+		}
+	}
+	for i := 0; i < t.NumOut(); i++ {
+		if out := t.Out(i); out != errorType {
+			writes = append(writes, out)
+		}
+	}
+	for _, c := range s.Reads {
+		reads = append(reads, queryType(c))
+	}
+	for _, c := range s.Writes {
+		writes = append(writes, queryType(c))
+	}
+	return reads, writes
+}
+
+// validate checks that the system's function can be called by the world.
+// Global systems have no object to take components from or write results to,
+// so any parameter they accept that isn't provided by the world must be a
+// resource that has already been added. The same goes for injectable
+// parameters of any system.
+func (s System) validate(w *World) error {
+	if s.Func == nil {
+		return errors.New("system has no function")
+	}
+	t := reflect.TypeOf(s.Func)
+	if t.Kind() != reflect.Func {
+		return fmt.Errorf("system function has type %s, not a func", t)
+	}
+	if t.IsVariadic() {
+		return errors.New("system function cannot be variadic")
+	}
+	if s.Every < 0 {
+		return fmt.Errorf("system interval %s is negative", s.Every)
+	}
+	if s.Every > 0 && s.Ticker != nil {
+		return errors.New("system cannot have both a Ticker and an interval")
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("system timeout %s is negative", s.Timeout)
+	}
+	if s.Retry.EntityRetries < 0 || s.Retry.TickRetries < 0 || s.Retry.DisableAfter < 0 {
+		return errors.New("system retry policy has negative counts")
+	}
+	if s.Retry.Backoff < 0 || s.Retry.MaxBackoff < 0 {
+		return errors.New("system retry policy has negative backoff")
+	}
+	if s.EveryNTicks < 0 {
+		return fmt.Errorf("system tick divisor %d is negative", s.EveryNTicks)
+	}
+	if s.MainThread && (s.Parallel || s.Shards > 0) {
+		return errors.New("main thread system cannot be parallel or sharded")
+	}
+	for _, c := range append(s.Reads[:len(s.Reads):len(s.Reads)], s.Writes...) {
+		if c == nil {
+			return errors.New("system declares access to a nil component")
+		}
+	}
+
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		switch {
+		case in == contextType, in == timeType, in == elapsedType, in == commandsType, in == shardType:
+		case in.Implements(singleParamType):
+		case in == worldType, in.Implements(columnParamType):
+			if s.DryRun {
+				return fmt.Errorf("parameter %d: dry-run systems cannot accept a %s, which changes the world directly", i, in)
+			}
+		case in.Implements(resParamType):
+			rt := reflect.Zero(in).Interface().(resParam).resourceType()
+			if _, ok := w.resource(rt); s.Global && !ok {
+				return fmt.Errorf("parameter %d: there is no resource of type %s", i, rt)
+			}
+		case in.Kind() == reflect.Func:
+			if err := validateObjectIter(in); err != nil {
+				return fmt.Errorf("parameter %d: %w", i, err)
+			}
+		case injectable(in):
+			if _, ok := w.resource(in); !ok {
+				return fmt.Errorf("parameter %d: nothing provides %s", i, in)
+			}
+		case s.Global:
+			if in == entityType {
+				return fmt.Errorf("parameter %d: global systems cannot accept an Entity", i)
+			}
+			if _, ok := w.resource(in); !ok {
+				return fmt.Errorf("parameter %d: global systems can only accept resources, and there is no resource of type %s", i, in)
+			}
+		}
+	}
+
+	for i := 0; i < t.NumOut(); i++ {
+		out := t.Out(i)
+		if out == errorType {
+			if i != t.NumOut()-1 {
+				return fmt.Errorf("result %d: only the last result can be an error", i)
+			}
+			continue
+		}
+		if s.Global {
+			return fmt.Errorf("result %d: global systems cannot return components", i)
+		}
+	}
+
+	return nil
+}
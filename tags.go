@@ -0,0 +1,195 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Tags is a component holding an object's tags: free-form strings, such as
+// "enemy" or "flying", for data-driven content that doesn't warrant a
+// component type of its own. The world indexes objects by tag, so queries
+// for a rare tag don't need to examine every object.
+//
+// Tags are kept sorted and without duplicates, and a Tags value is never
+// modified once it belongs to an object; use Tag and Untag to change them.
+type Tags []string
+
+var (
+	tagsType = reflect.TypeOf(Tags(nil))
+	tagsID   = componentID(tagsType)
+
+	// tagMu serializes Tag and Untag, which replace the Tags component
+	// based on its current value.
+	tagMu sync.Mutex
+)
+
+func init() {
+	Register(Tags(nil))
+}
+
+// Has reports whether tag is one of the tags.
+func (tags Tags) Has(tag string) bool {
+	i := sort.SearchStrings(tags, tag)
+	return i < len(tags) && tags[i] == tag
+}
+
+// Tag adds tags to the object.
+func (ob *Object) Tag(tags ...string) {
+	tagMu.Lock()
+	defer tagMu.Unlock()
+	current := ob.Tags()
+	updated := append(Tags(nil), current...)
+	for _, tag := range tags {
+		if !updated.Has(tag) {
+			updated = append(updated, tag)
+			sort.Strings(updated)
+		}
+	}
+	if len(updated) != len(current) {
+		ob.SetComponent(updated)
+	}
+}
+
+// Untag removes tags from the object. The Tags component is removed along
+// with the last tag.
+func (ob *Object) Untag(tags ...string) {
+	tagMu.Lock()
+	defer tagMu.Unlock()
+	current := ob.Tags()
+	var updated Tags
+	for _, tag := range current {
+		if !contains(tags, tag) {
+			updated = append(updated, tag)
+		}
+	}
+	switch {
+	case len(updated) == len(current):
+	case len(updated) == 0:
+		ob.RemoveComponent(Tags(nil))
+	default:
+		ob.SetComponent(updated)
+	}
+}
+
+// Tags returns the object's tags, which must not be modified.
+func (ob *Object) Tags() Tags {
+	tags, _ := ob.Component(Tags(nil)).(Tags)
+	return tags
+}
+
+// HasTag reports whether the object has the given tag.
+func (ob *Object) HasTag(tag string) bool {
+	return ob.Tags().Has(tag)
+}
+
+func contains(list []string, s string) bool {
+	for _, other := range list {
+		if other == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WithTag restricts the query to objects that have every one of the given
+// tags.
+func (q *Query) WithTag(tags ...string) *Query {
+	q.withTags = append(q.withTags, tags...)
+	return q
+}
+
+// WithoutTag restricts the query to objects that have none of the given
+// tags.
+func (q *Query) WithoutTag(tags ...string) *Query {
+	q.withoutTags = append(q.withoutTags, tags...)
+	return q
+}
+
+// matchesTags reports whether the object's tags satisfy the query.
+func (q *Query) matchesTags(ob *Object) bool {
+	if len(q.withTags) == 0 && len(q.withoutTags) == 0 {
+		return true
+	}
+	tags := ob.Tags()
+	for _, tag := range q.withTags {
+		if !tags.Has(tag) {
+			return false
+		}
+	}
+	for _, tag := range q.withoutTags {
+		if tags.Has(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// tagIndex maps tags to the objects that have them.
+type tagIndex struct {
+	mu      sync.RWMutex
+	objects map[string]map[*Object]struct{}
+	tags    map[*Object]Tags
+}
+
+// indexTags updates the index for an object whose tags may have changed, or
+// which may have been added to or removed from the world.
+func (w *World) indexTags(ob *Object) {
+	var tags Tags
+	if atomic.LoadUint64(&ob.seq) != 0 {
+		tags = ob.Tags()
+	}
+
+	w.tags.mu.Lock()
+	defer w.tags.mu.Unlock()
+	for _, tag := range w.tags.tags[ob] {
+		if !tags.Has(tag) {
+			delete(w.tags.objects[tag], ob)
+			if len(w.tags.objects[tag]) == 0 {
+				delete(w.tags.objects, tag)
+			}
+		}
+	}
+	if len(tags) == 0 {
+		delete(w.tags.tags, ob)
+		return
+	}
+	if w.tags.objects == nil {
+		w.tags.objects = make(map[string]map[*Object]struct{})
+		w.tags.tags = make(map[*Object]Tags)
+	}
+	for _, tag := range tags {
+		set, ok := w.tags.objects[tag]
+		if !ok {
+			set = make(map[*Object]struct{})
+			w.tags.objects[tag] = set
+		}
+		set[ob] = struct{}{}
+	}
+	w.tags.tags[ob] = tags
+}
+
+// tagged returns the objects with the rarest of the given tags, which every
+// object with all of them is among, in the order they were added to the
+// world.
+func (w *World) tagged(tags []string) []*Object {
+	w.tags.mu.RLock()
+	var rarest map[*Object]struct{}
+	for i, tag := range tags {
+		set := w.tags.objects[tag]
+		if i == 0 || len(set) < len(rarest) {
+			rarest = set
+		}
+	}
+	objects := make([]*Object, 0, len(rarest))
+	for ob := range rarest {
+		objects = append(objects, ob)
+	}
+	w.tags.mu.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return atomic.LoadUint64(&objects[i].seq) < atomic.LoadUint64(&objects[j].seq)
+	})
+	return objects
+}
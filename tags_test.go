@@ -0,0 +1,79 @@
+package ecs_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestTags(t *testing.T) {
+	world := ecs.NewWorld()
+	bat := ecs.NewObject(Position(0))
+	bat.Tag("enemy", "flying")
+	world.AddObject(bat)
+	goblin := ecs.NewObject(Position(1))
+	world.AddObject(goblin)
+	goblin.Tag("enemy")
+	bird := ecs.NewObject(Velocity(1))
+	world.AddObject(bird)
+	bird.Tag("flying", "flying")
+
+	if got := bird.Tags(); len(got) != 1 {
+		t.Errorf("bad tags: got %v, want [flying]", got)
+	}
+	if !bat.HasTag("flying") || goblin.HasTag("flying") {
+		t.Error("bad HasTag results")
+	}
+
+	count := func(q *ecs.Query) int { return q.Count() }
+	if got := count(world.Query().WithTag("flying")); got != 2 {
+		t.Errorf("bad flying count: got %d, want 2", got)
+	}
+	if got := count(world.Query().WithTag("enemy", "flying")); got != 1 {
+		t.Errorf("bad flying enemy count: got %d, want 1", got)
+	}
+	if got := count(world.Query().With(Position(0)).WithoutTag("flying")); got != 1 {
+		t.Errorf("bad grounded count: got %d, want 1", got)
+	}
+	if got := count(world.Query().WithTag("flying").With(Velocity(0))); got != 1 {
+		t.Errorf("bad flying with velocity count: got %d, want 1", got)
+	}
+	objects := world.Query().WithTag("enemy").Objects()
+	if len(objects) != 2 || objects[0] != bat || objects[1] != goblin {
+		t.Errorf("bad enemies: %v", objects)
+	}
+
+	bat.Untag("flying")
+	world.RemoveObject(bird.Entity())
+	if got := count(world.Query().WithTag("flying")); got != 0 {
+		t.Errorf("bad flying count after untagging: got %d, want 0", got)
+	}
+	goblin.Untag("enemy")
+	if ecs.Has[ecs.Tags](goblin) {
+		t.Error("empty tags weren't removed")
+	}
+	if got := count(world.Query().WithTag("enemy")); got != 1 {
+		t.Errorf("bad enemy count: got %d, want 1", got)
+	}
+}
+
+func TestTagsSnapshot(t *testing.T) {
+	world := ecs.NewWorld()
+	ob := ecs.NewObject()
+	ob.Tag("boss")
+	world.AddObject(ob)
+
+	var buf bytes.Buffer
+	if err := world.SaveJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := ecs.NewWorld()
+	if err := loaded.LoadJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	objects := loaded.Query().WithTag("boss").Objects()
+	if len(objects) != 1 || objects[0].Entity() != ob.Entity() {
+		t.Errorf("bad tagged objects after load: %v", objects)
+	}
+}
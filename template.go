@@ -0,0 +1,131 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// AddSystemTemplate adds a copy of a system for every registered component
+// type that implements an interface, saving the need to add near-identical
+// systems for each one by hand. constraint is a nil pointer to the interface,
+// and the template's function accepts the interface in place of a component:
+//
+//     type Decayable interface {
+//         Decay(dt time.Duration) Decayable
+//     }
+//
+//     world.AddSystemTemplate(ecs.System{
+//         Name: "decay",
+//         Func: func(d Decayable, dt time.Duration) Decayable {
+//             return d.Decay(dt)
+//         },
+//     }, (*Decayable)(nil))
+//
+// Each copy accepts one concrete component type where the template accepts
+// the interface, so it is matched, locked, and scheduled exactly as if it had
+// been written for that type. Results of the interface type are written back
+// to the component; if the template returns nil or a value of a different
+// concrete type, the component is left unchanged. Copies are named after the
+// template and the component's registered name, such as
+// "decay[game.Radiation]".
+//
+// Only types registered before the template is added are included, and it is
+// an error if there are none. Since every copy would share it, the template
+// can't have a Ticker.
+func (w *World) AddSystemTemplate(s System, constraint interface{}) error {
+	ct := reflect.TypeOf(constraint)
+	if ct == nil || ct.Kind() != reflect.Ptr || ct.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("invalid template constraint of type %T: expected a nil pointer to an interface", constraint)
+	}
+	iface := ct.Elem()
+	if s.Ticker != nil {
+		return errors.New("invalid system template: cannot have a Ticker")
+	}
+	f := reflect.ValueOf(s.Func)
+	if f.Kind() != reflect.Func {
+		return fmt.Errorf("invalid system template of type %T", s.Func)
+	}
+	ft := f.Type()
+	param := -1
+	for i := 0; i < ft.NumIn(); i++ {
+		if ft.In(i) == iface {
+			if param >= 0 {
+				return fmt.Errorf("invalid system template: accepts %s more than once", iface)
+			}
+			param = i
+		}
+	}
+	if param < 0 {
+		return fmt.Errorf("invalid system template: doesn't accept %s", iface)
+	}
+
+	types := implementers(iface)
+	if len(types) == 0 {
+		return fmt.Errorf("no registered component types implement %s", iface)
+	}
+
+	name := s.name()
+	for _, t := range types {
+		instance := s
+		instance.Name = fmt.Sprintf("%s[%s]", name, typeName(t))
+		instance.Func = instantiate(f, param, iface, t)
+		if err := w.AddSystem(instance); err != nil {
+			return fmt.Errorf("%s: %w", instance.Name, err)
+		}
+	}
+	return nil
+}
+
+// implementers returns the registered component types that implement iface,
+// sorted by name.
+func implementers(iface reflect.Type) []reflect.Type {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	var types []reflect.Type
+	for _, t := range typesByName {
+		if t.Implements(iface) {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return namesByType[types[i]] < namesByType[types[j]]
+	})
+	return types
+}
+
+// instantiate wraps a template function, whose param'th parameter is iface,
+// in a function that accepts t there instead, and returns t wherever the
+// template returns iface.
+func instantiate(f reflect.Value, param int, iface, t reflect.Type) interface{} {
+	ft := f.Type()
+	in := make([]reflect.Type, ft.NumIn())
+	for i := range in {
+		in[i] = ft.In(i)
+	}
+	in[param] = t
+	out := make([]reflect.Type, ft.NumOut())
+	for i := range out {
+		if out[i] = ft.Out(i); out[i] == iface {
+			out[i] = t
+		}
+	}
+
+	return reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		component := args[param]
+		args[param] = component.Convert(iface)
+		results := f.Call(args)
+		for i, r := range results {
+			if ft.Out(i) != iface {
+				continue
+			}
+			if r.IsNil() || r.Elem().Type() != t {
+				results[i] = component
+			} else {
+				results[i] = r.Elem()
+			}
+		}
+		return results
+	}).Interface()
+}
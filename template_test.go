@@ -0,0 +1,84 @@
+package ecs_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+type Decayable interface {
+	Decay() Decayable
+}
+
+type (
+	Radiation int
+	Rust      int
+)
+
+func (r Radiation) Decay() Decayable { return r / 2 }
+func (r Rust) Decay() Decayable      { return r - 1 }
+
+func init() {
+	ecs.Register(Radiation(0))
+	ecs.Register(Rust(0))
+}
+
+func TestAddSystemTemplate(t *testing.T) {
+	world := ecs.NewWorld()
+	reactor := ecs.NewObject(Radiation(8), Rust(8))
+	pipe := ecs.NewObject(Rust(3))
+	world.AddObject(reactor)
+	world.AddObject(pipe)
+
+	err := world.AddSystemTemplate(ecs.System{
+		Name: "decay",
+		Func: func(d Decayable) Decayable { return d.Decay() },
+	}, (*Decayable)(nil))
+	if err != nil {
+		t.Fatalf("failed to add template: %s", err)
+	}
+	for _, name := range []string{"decay[ecs_test.Radiation]", "decay[ecs_test.Rust]"} {
+		info, ok := world.LookupSystem(name)
+		if !ok {
+			t.Errorf("missing system %s", name)
+			continue
+		}
+		if len(info.Writes) != 1 || info.Writes[0].Name() != name[len("decay[ecs_test."):len(name)-1] {
+			t.Errorf("%s should write its own component type, got %v", name, info.Writes)
+		}
+	}
+
+	world.Step()
+	if got := reactor.Component(Radiation(0)); got != Radiation(4) {
+		t.Errorf("bad radiation: got %v", got)
+	}
+	if got := reactor.Component(Rust(0)); got != Rust(7) {
+		t.Errorf("bad reactor rust: got %v", got)
+	}
+	if got := pipe.Component(Rust(0)); got != Rust(2) {
+		t.Errorf("bad pipe rust: got %v", got)
+	}
+}
+
+func TestAddSystemTemplateValidation(t *testing.T) {
+	world := ecs.NewWorld()
+	decay := func(d Decayable) Decayable { return d.Decay() }
+	for name, tc := range map[string]struct {
+		s          ecs.System
+		constraint interface{}
+	}{
+		"not an interface": {ecs.System{Func: decay}, Radiation(0)},
+		"not accepted":     {ecs.System{Func: func(Position) {}}, (*Decayable)(nil)},
+		"ticker":           {ecs.System{Func: decay, Ticker: make(chan time.Time)}, (*Decayable)(nil)},
+		"no implementers":  {ecs.System{Func: func(fmt.Stringer) {}}, (*fmt.Stringer)(nil)},
+	} {
+		if err := world.AddSystemTemplate(tc.s, tc.constraint); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if n := len(world.Systems()); n != 0 {
+		t.Errorf("no systems should have been added, got %d", n)
+	}
+}
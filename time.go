@@ -0,0 +1,164 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync"
+	"time"
+)
+
+func init() {
+	Register(&Time{})
+}
+
+// Time is a resource that tracks the passage of simulated time separately
+// from the wall clock, so that the simulation can be slowed down, sped up, or
+// frozen while tickers keep running. Systems that move things should scale by
+// Delta rather than assume a fixed tick rate.
+//
+// Time is advanced by the system returned by its System method, which should
+// be added to the world along with the resource itself:
+//
+//     clock := ecs.NewTime()
+//     world.AddResource(clock)
+//     world.AddSystem(clock.System(time.NewTicker(time.Second / 60).C))
+type Time struct {
+	mu      sync.RWMutex
+	wall    time.Time
+	elapsed time.Duration
+	delta   time.Duration
+	ticks   uint64
+	scale   float64
+	paused  bool
+}
+
+// NewTime creates a Time running at normal speed.
+func NewTime() *Time {
+	return &Time{scale: 1}
+}
+
+// Update advances the clock to the given wall time. The first update only
+// records the wall time, since there is no previous update to measure from.
+func (t *Time) Update(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.delta = 0
+	if !t.wall.IsZero() && !t.paused {
+		t.delta = time.Duration(float64(now.Sub(t.wall)) * t.scale)
+	}
+	t.elapsed += t.delta
+	t.wall = now
+	t.ticks++
+}
+
+// System returns a global system that updates the clock on every tick of the
+// given ticker.
+func (t *Time) System(ticker <-chan time.Time) System {
+	return System{
+		Func:   t.Update,
+		Name:   "Time",
+		Ticker: ticker,
+		Global: true,
+	}
+}
+
+// Wall returns the wall time of the most recent update.
+func (t *Time) Wall() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.wall
+}
+
+// Elapsed returns the total amount of simulated time that has passed.
+func (t *Time) Elapsed() time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.elapsed
+}
+
+// Delta returns the amount of simulated time that passed in the most recent
+// update. It is zero while paused.
+func (t *Time) Delta() time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.delta
+}
+
+// Ticks returns the number of times the clock has been updated.
+func (t *Time) Ticks() uint64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.ticks
+}
+
+// Scale returns the rate at which simulated time passes relative to wall
+// time.
+func (t *Time) Scale() float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.scale
+}
+
+// SetScale sets the rate at which simulated time passes relative to wall
+// time, such as 0.5 for slow motion.
+func (t *Time) SetScale(scale float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.scale = scale
+}
+
+// Pause freezes simulated time until Resume is called.
+func (t *Time) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused = true
+}
+
+// Resume unfreezes simulated time.
+func (t *Time) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused = false
+}
+
+// Paused reports whether simulated time is frozen.
+func (t *Time) Paused() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.paused
+}
+
+// timeState is the part of a Time that is kept by GobEncode.
+type timeState struct {
+	Elapsed, Delta time.Duration
+	Ticks          uint64
+	Scale          float64
+	Paused         bool
+}
+
+// GobEncode implements gob.GobEncoder, so that the clock survives
+// hibernation. The wall time of the last update isn't kept, so the first
+// update after decoding only records the wall time, rather than counting the
+// time spent hibernating.
+func (t *Time) GobEncode() ([]byte, error) {
+	t.mu.RLock()
+	state := timeState{t.elapsed, t.delta, t.ticks, t.scale, t.paused}
+	t.mu.RUnlock()
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(state)
+	return buf.Bytes(), err
+}
+
+// GobDecode implements gob.GobDecoder.
+func (t *Time) GobDecode(data []byte) error {
+	var state timeState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.wall = time.Time{}
+	t.elapsed, t.delta, t.ticks, t.scale, t.paused = state.Elapsed, state.Delta, state.Ticks, state.Scale, state.Paused
+	return nil
+}
@@ -0,0 +1,118 @@
+package ecs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestTimeResource(t *testing.T) {
+	clock := ecs.NewTime()
+	start := time.Unix(100, 0)
+
+	clock.Update(start)
+	clock.Update(start.Add(time.Second))
+	if got, want := clock.Delta(), time.Second; got != want {
+		t.Errorf("bad delta: got %s, want %s", got, want)
+	}
+
+	clock.SetScale(0.5)
+	clock.Update(start.Add(2 * time.Second))
+	if got, want := clock.Delta(), 500*time.Millisecond; got != want {
+		t.Errorf("bad scaled delta: got %s, want %s", got, want)
+	}
+
+	clock.Pause()
+	clock.Update(start.Add(3 * time.Second))
+	if got := clock.Delta(); got != 0 {
+		t.Errorf("delta should be zero while paused, got %s", got)
+	}
+
+	clock.Resume()
+	clock.Update(start.Add(4 * time.Second))
+
+	if got, want := clock.Elapsed(), 2*time.Second; got != want {
+		t.Errorf("bad elapsed time: got %s, want %s", got, want)
+	}
+	if got, want := clock.Ticks(), uint64(5); got != want {
+		t.Errorf("bad tick count: got %d, want %d", got, want)
+	}
+	if got, want := clock.Wall(), start.Add(4*time.Second); !got.Equal(want) {
+		t.Errorf("bad wall time: got %s, want %s", got, want)
+	}
+}
+
+func TestTimeSystem(t *testing.T) {
+	ticker := make(chan time.Time)
+	go func() {
+		ticker <- time.Unix(100, 0)
+		ticker <- time.Unix(101, 0)
+		close(ticker)
+	}()
+
+	clock := ecs.NewTime()
+	world := ecs.NewWorld()
+	world.AddResource(clock)
+	world.AddSystem(clock.System(ticker))
+
+	world.Run()
+
+	if got, want := clock.Elapsed(), time.Second; got != want {
+		t.Errorf("bad elapsed time: got %s, want %s", got, want)
+	}
+}
+
+func TestElapsedWall(t *testing.T) {
+	ticker := make(chan time.Time)
+	go func() {
+		ticker <- time.Unix(100, 0)
+		ticker <- time.Unix(100, 0).Add(250 * time.Millisecond)
+		close(ticker)
+	}()
+
+	var got []ecs.Elapsed
+	world := ecs.NewWorld()
+	world.AddSystem(ecs.System{Func: func(dt ecs.Elapsed) {
+		got = append(got, dt)
+	}, Ticker: ticker, Global: true})
+	world.Run()
+
+	if want := []ecs.Elapsed{0, ecs.Elapsed(250 * time.Millisecond)}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("bad elapsed times: got %v, want %v", got, want)
+	}
+}
+
+func TestElapsedSimulated(t *testing.T) {
+	clock := ecs.NewTime()
+	start := time.Unix(100, 0)
+	clock.Update(start)
+
+	world := ecs.NewWorld()
+	world.AddResource(clock)
+	world.AddObject(ecs.NewObject(Position(0)))
+	var got []ecs.Elapsed
+	world.AddSystem(ecs.System{Func: func(p Position, dt ecs.Elapsed) {
+		got = append(got, dt)
+	}})
+
+	world.Step()
+	clock.Update(start.Add(time.Second))
+	world.Step()
+	clock.SetScale(2)
+	clock.Update(start.Add(2 * time.Second))
+	world.Step()
+	clock.Pause()
+	clock.Update(start.Add(3 * time.Second))
+	world.Step()
+
+	want := []ecs.Elapsed{0, ecs.Elapsed(time.Second), ecs.Elapsed(2 * time.Second), 0}
+	if len(got) != len(want) {
+		t.Fatalf("bad elapsed times: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tick %d: got %v, want %v", i, time.Duration(got[i]), time.Duration(want[i]))
+		}
+	}
+}
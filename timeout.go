@@ -0,0 +1,73 @@
+package ecs
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// tickContext holds the context of a system's current tick, which systems
+// receive by accepting a context.Context. It is the world's run context, with
+// the system's Timeout applied if it has one.
+type tickContext struct {
+	ctx atomic.Pointer[context.Context]
+}
+
+func newTickContext() *tickContext {
+	c := new(tickContext)
+	ctx := context.Background()
+	c.ctx.Store(&ctx)
+	return c
+}
+
+func (c *tickContext) get() context.Context {
+	return *c.ctx.Load()
+}
+
+func (c *tickContext) set(ctx context.Context) {
+	c.ctx.Store(&ctx)
+}
+
+// expired reports whether the current tick's context is done.
+func (c *tickContext) expired() bool {
+	select {
+	case <-c.get().Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// startTimeout sets up the context for a tick that started at start, and for
+// systems with a Timeout, a watchdog that reports the tick if it runs too
+// long. The returned function must be called once the tick has finished.
+func (s *scheduledSystem) startTimeout(w *World, start time.Time) (stop func()) {
+	ctx := w.lifecycle.context()
+	if s.Timeout <= 0 {
+		s.ctx.set(ctx)
+		return func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	s.ctx.set(ctx)
+	name := s.name()
+	watchdog := time.AfterFunc(s.Timeout, func() {
+		w.reportSlowSystem(name, time.Since(start))
+	})
+	return func() {
+		watchdog.Stop()
+		cancel()
+	}
+}
+
+func (w *World) reportSlowSystem(name string, elapsed time.Duration) {
+	w.metrics().Counter("ecs_system_timeouts", 1, "system", name)
+	if w.OnSlowSystem != nil {
+		w.OnSlowSystem(name, elapsed)
+		return
+	}
+	w.logger().Warn("system tick timed out", "system", name, "elapsed", elapsed)
+}
@@ -0,0 +1,71 @@
+package ecs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestTimeout(t *testing.T) {
+	world := ecs.NewWorld()
+	for i := 0; i < 10; i++ {
+		world.AddObject(ecs.NewObject(Position(0)))
+	}
+
+	slow := make(chan string, 1)
+	world.OnSlowSystem = func(name string, elapsed time.Duration) {
+		if elapsed < 5*time.Millisecond {
+			t.Errorf("reported after %s, before the timeout", elapsed)
+		}
+		slow <- name
+	}
+	visited := 0
+	world.AddSystem(ecs.System{
+		Name: "wait",
+		Func: func(ctx context.Context, p Position) Position {
+			visited++
+			<-ctx.Done()
+			return p + 1
+		},
+		Timeout: 5 * time.Millisecond,
+	})
+	world.Step()
+
+	select {
+	case name := <-slow:
+		if name != "wait" {
+			t.Errorf("bad slow system: %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow system wasn't reported")
+	}
+	if visited != 1 {
+		t.Errorf("timed out tick visited %d objects, want 1", visited)
+	}
+}
+
+func TestTimeoutHung(t *testing.T) {
+	world := ecs.NewWorld()
+	world.AddObject(ecs.NewObject(Position(0)))
+
+	slow := make(chan struct{})
+	release := make(chan struct{})
+	world.OnSlowSystem = func(string, time.Duration) { close(slow) }
+	world.AddSystem(ecs.System{Func: func(Position) { <-release }, Timeout: time.Millisecond})
+	done := make(chan struct{})
+	go func() {
+		world.Step()
+		close(done)
+	}()
+
+	// the hung tick is reported while it is still running
+	select {
+	case <-slow:
+	case <-time.After(time.Second):
+		t.Error("hung system wasn't reported")
+	}
+	close(release)
+	<-done
+}
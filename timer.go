@@ -0,0 +1,163 @@
+package ecs
+
+import "time"
+
+func init() {
+	Register(Timer{})
+	Register(Cooldown{})
+}
+
+// Timer is a component that counts up to a duration, such as the fuse of a
+// bomb or the interval between enemy spawns. Timers are advanced by the
+// system returned by TimerSystem, and other systems check whether they have
+// finished:
+//
+//     world.AddObject(ecs.NewObject(ecs.NewTimer(3*time.Second, false), Bomb{}))
+//     world.AddSystem(ecs.TimerSystem(ticker))
+//
+//     func explode(w *ecs.World, e ecs.Entity, t ecs.Timer, b Bomb) {
+//         if t.JustFinished {
+//             ...
+//         }
+//     }
+type Timer struct {
+	Duration time.Duration
+	Elapsed  time.Duration
+
+	// Repeat makes the timer start over each time it finishes, rather than
+	// stopping.
+	Repeat bool
+
+	// Finished is set once a timer that doesn't repeat has reached its
+	// duration, and stays set until it is reset. JustFinished is set only
+	// for the advance in which the timer reached its duration, including
+	// each time a repeating timer does.
+	Finished, JustFinished bool
+}
+
+// NewTimer creates a timer that finishes after d.
+func NewTimer(d time.Duration, repeat bool) Timer {
+	return Timer{Duration: d, Repeat: repeat}
+}
+
+// Advance returns the timer after dt has passed.
+func (t Timer) Advance(dt time.Duration) Timer {
+	t.JustFinished = false
+	if t.Finished {
+		return t
+	}
+	t.Elapsed += dt
+	if t.Elapsed < t.Duration {
+		return t
+	}
+	t.JustFinished = true
+	if !t.Repeat {
+		t.Elapsed, t.Finished = t.Duration, true
+	} else if t.Duration > 0 {
+		t.Elapsed %= t.Duration
+	} else {
+		t.Elapsed = 0
+	}
+	return t
+}
+
+// Remaining returns how long is left until the timer finishes.
+func (t Timer) Remaining() time.Duration {
+	return t.Duration - t.Elapsed
+}
+
+// Fraction returns how far along the timer is, from 0 to 1.
+func (t Timer) Fraction() float64 {
+	if t.Duration <= 0 {
+		return 1
+	}
+	return float64(t.Elapsed) / float64(t.Duration)
+}
+
+// Reset returns the timer started over from zero.
+func (t Timer) Reset() Timer {
+	t.Elapsed, t.Finished, t.JustFinished = 0, false, false
+	return t
+}
+
+// Cooldown is a component that limits how often an action can be taken, such
+// as firing a weapon or casting a spell. Triggering it starts the cooldown,
+// and it can't be triggered again until Duration has passed:
+//
+//     func fire(c ecs.Cooldown, in Input) ecs.Cooldown {
+//         if in.Fire {
+//             if c, ok := c.Trigger(); ok {
+//                 ...
+//                 return c
+//             }
+//         }
+//         return c
+//     }
+//
+// Cooldowns are advanced by the system returned by CooldownSystem.
+type Cooldown struct {
+	Duration  time.Duration
+	Remaining time.Duration
+
+	// JustReady is set only for the advance in which the cooldown ended.
+	JustReady bool
+}
+
+// NewCooldown creates a cooldown that is ready to be triggered.
+func NewCooldown(d time.Duration) Cooldown {
+	return Cooldown{Duration: d}
+}
+
+// Ready reports whether the cooldown can be triggered.
+func (c Cooldown) Ready() bool {
+	return c.Remaining <= 0
+}
+
+// Trigger starts the cooldown if it is ready, and reports whether it was.
+func (c Cooldown) Trigger() (Cooldown, bool) {
+	if !c.Ready() {
+		return c, false
+	}
+	c.Remaining, c.JustReady = c.Duration, false
+	return c, true
+}
+
+// Advance returns the cooldown after dt has passed.
+func (c Cooldown) Advance(dt time.Duration) Cooldown {
+	c.JustReady = false
+	if c.Remaining <= 0 {
+		return c
+	}
+	c.Remaining -= dt
+	if c.Remaining <= 0 {
+		c.Remaining, c.JustReady = 0, true
+	}
+	return c
+}
+
+// TimerSystem returns a system that advances every Timer on each tick of the
+// given ticker by the Elapsed time since its last tick, so timers follow the
+// world's *Time resource, if it has one, through pauses and changes of scale.
+// Systems that check JustFinished should run after it on the same ticker.
+func TimerSystem(ticker <-chan time.Time) System {
+	return System{
+		Func: func(t Timer, dt Elapsed) Timer {
+			return t.Advance(time.Duration(dt))
+		},
+		Name:     "Timers",
+		Ticker:   ticker,
+		Parallel: true,
+	}
+}
+
+// CooldownSystem is like TimerSystem, but advances every Cooldown.
+func CooldownSystem(ticker <-chan time.Time) System {
+	return System{
+		Func: func(c Cooldown, dt Elapsed) Cooldown {
+			return c.Advance(time.Duration(dt))
+		},
+		Name:     "Cooldowns",
+		Ticker:   ticker,
+		Parallel: true,
+	}
+}
@@ -0,0 +1,80 @@
+package ecs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestTimer(t *testing.T) {
+	timer := ecs.NewTimer(time.Second, false)
+	timer = timer.Advance(600 * time.Millisecond)
+	if timer.Finished || timer.JustFinished {
+		t.Fatalf("timer finished early: %+v", timer)
+	}
+	timer = timer.Advance(600 * time.Millisecond)
+	if !timer.Finished || !timer.JustFinished || timer.Remaining() != 0 {
+		t.Fatalf("timer didn't finish: %+v", timer)
+	}
+	timer = timer.Advance(time.Second)
+	if !timer.Finished || timer.JustFinished {
+		t.Fatalf("timer finished again: %+v", timer)
+	}
+	if timer = timer.Reset(); timer.Finished || timer.Elapsed != 0 {
+		t.Errorf("timer not reset: %+v", timer)
+	}
+}
+
+func TestRepeatingTimer(t *testing.T) {
+	timer := ecs.NewTimer(time.Second, true).Advance(2500 * time.Millisecond)
+	if timer.Finished || !timer.JustFinished || timer.Elapsed != 500*time.Millisecond {
+		t.Errorf("bad repeating timer: %+v", timer)
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	c := ecs.NewCooldown(time.Second)
+	c, ok := c.Trigger()
+	if !ok || c.Ready() {
+		t.Fatalf("cooldown not triggered: %+v", c)
+	}
+	if _, ok := c.Trigger(); ok {
+		t.Fatal("triggered cooldown twice")
+	}
+	c = c.Advance(time.Second)
+	if !c.Ready() || !c.JustReady {
+		t.Fatalf("cooldown not ready: %+v", c)
+	}
+	if c = c.Advance(time.Second); c.JustReady {
+		t.Errorf("cooldown became ready again: %+v", c)
+	}
+}
+
+func TestTimerSystem(t *testing.T) {
+	clock := ecs.NewTime()
+	start := time.Unix(100, 0)
+	clock.Update(start)
+
+	world := ecs.NewWorld()
+	world.AddResource(clock)
+	e := world.AddObject(ecs.NewObject(ecs.NewTimer(time.Second, false), ecs.NewCooldown(time.Second)))
+	world.AddSystem(ecs.TimerSystem(nil))
+	world.AddSystem(ecs.CooldownSystem(nil))
+
+	ob := world.GetObject(e)
+	c, _ := ob.Component(ecs.Cooldown{}).(ecs.Cooldown).Trigger()
+	ob.SetComponent(c)
+
+	world.Step()
+	clock.Update(start.Add(time.Second))
+	world.Step()
+
+	timer := ob.Component(ecs.Timer{}).(ecs.Timer)
+	if !timer.JustFinished {
+		t.Errorf("timer didn't finish: %+v", timer)
+	}
+	if c := ob.Component(ecs.Cooldown{}).(ecs.Cooldown); !c.JustReady {
+		t.Errorf("cooldown not ready: %+v", c)
+	}
+}
@@ -0,0 +1,271 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Trace is a record of a world's state after each of a sequence of ticks,
+// along with the systems that changed each component along the way. Traces
+// of two runs that should have played out identically, such as a server and
+// a client of a deterministic game, can be compared with FindDivergence to
+// track down where and why they stopped agreeing. Objects are compared by
+// entity, so both runs should start from the same snapshot. A Trace can be
+// saved and loaded with encoding/json or encoding/gob.
+type Trace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// A Frame is the state of a world after one tick.
+type Frame struct {
+	// Objects are sorted by entity.
+	Objects []TracedObject `json:"objects"`
+}
+
+// A TracedObject is the state of one object after a tick.
+type TracedObject struct {
+	Entity Entity `json:"entity"`
+
+	// Components are sorted by type, and otherwise kept in the order the
+	// object holds them.
+	Components []TracedComponent `json:"components"`
+}
+
+// A TracedComponent is the state of one component after a tick.
+type TracedComponent struct {
+	// Type is the component's registered name.
+	Type string `json:"type"`
+
+	// Value is the component encoded as JSON.
+	Value string `json:"value"`
+
+	// Writers are the systems that changed components of this type on the
+	// object during the tick, in the order they did so.
+	Writers []string `json:"writers,omitempty"`
+}
+
+// A Recorder records a Trace of a world. The world should be advanced with
+// Step, so that the order systems run in is the same on every run:
+//
+//     rec := world.Record()
+//     defer rec.Stop()
+//     for i := 0; i < ticks; i++ {
+//         world.Step()
+//         if err := rec.Frame(); err != nil {
+//             ...
+//         }
+//     }
+//     trace := rec.Trace()
+type Recorder struct {
+	w      *World
+	cancel func()
+
+	mu      sync.Mutex
+	writers map[Entity]map[reflect.Type][]string
+	trace   Trace
+}
+
+// Record starts recording a trace of the world. Every component type must
+// have been registered with Register or RegisterName.
+func (w *World) Record() *Recorder {
+	r := &Recorder{w: w, writers: make(map[Entity]map[reflect.Type][]string)}
+	r.cancel = w.watchEverything(r.observe)
+	return r
+}
+
+func (r *Recorder) observe(change Change) {
+	if change.Kind == QueryMatched || change.System == "" {
+		return
+	}
+	t := reflect.TypeOf(change.Component)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byType := r.writers[change.Entity]
+	if byType == nil {
+		byType = make(map[reflect.Type][]string)
+		r.writers[change.Entity] = byType
+	}
+	byType[t] = append(byType[t], change.System)
+}
+
+// Frame records the current state of the world as the next frame of the
+// trace, and attributes every change since the previous frame to it.
+func (r *Recorder) Frame() error {
+	r.mu.Lock()
+	writers := r.writers
+	r.writers = make(map[Entity]map[reflect.Type][]string)
+	r.mu.Unlock()
+
+	objects := r.w.Objects()
+	frame := Frame{Objects: make([]TracedObject, len(objects))}
+	for i, ob := range objects {
+		traced := TracedObject{Entity: ob.entity}
+		for _, c := range ob.Components() {
+			t := reflect.TypeOf(c)
+			name, err := registeredName(t)
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(c)
+			if err != nil {
+				return fmt.Errorf("encoding %s of entity %d: %w", name, ob.entity, err)
+			}
+			traced.Components = append(traced.Components, TracedComponent{
+				Type:    name,
+				Value:   string(value),
+				Writers: writers[ob.entity][t],
+			})
+		}
+		sort.SliceStable(traced.Components, func(i, j int) bool {
+			return traced.Components[i].Type < traced.Components[j].Type
+		})
+		frame.Objects[i] = traced
+	}
+	sort.Slice(frame.Objects, func(i, j int) bool {
+		return frame.Objects[i].Entity < frame.Objects[j].Entity
+	})
+
+	r.mu.Lock()
+	r.trace.Frames = append(r.trace.Frames, frame)
+	r.mu.Unlock()
+	return nil
+}
+
+// Trace returns the frames recorded so far. It may be called while recording
+// continues, such as to compare a live run against a saved trace after every
+// tick.
+func (r *Recorder) Trace() *Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Trace{Frames: append([]Frame(nil), r.trace.Frames...)}
+}
+
+// Stop stops recording changes. Frames recorded so far are kept.
+func (r *Recorder) Stop() {
+	r.cancel()
+}
+
+// A Divergence is the first frame at which two traces disagree.
+type Divergence struct {
+	// Frame is the index of the first frame that differs.
+	Frame int
+
+	// Diffs are the components that differ, sorted by entity and type.
+	Diffs []ComponentDiff
+}
+
+// A ComponentDiff is a component that differs between two traces.
+type ComponentDiff struct {
+	Entity Entity
+	Type   string
+
+	// A and B are the component's JSON-encoded value in each trace, or
+	// empty if the object doesn't have it there, or doesn't exist at all.
+	A, B string
+
+	// WritersA and WritersB are the systems that changed the component
+	// during the divergent tick in each trace.
+	WritersA, WritersB []string
+}
+
+func (d *Divergence) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "traces diverge at frame %d:", d.Frame)
+	for _, diff := range d.Diffs {
+		fmt.Fprintf(&b, "\n\tentity %d %s: %s (written by %s) != %s (written by %s)",
+			diff.Entity, diff.Type,
+			describeValue(diff.A), describeWriters(diff.WritersA),
+			describeValue(diff.B), describeWriters(diff.WritersB))
+	}
+	return b.String()
+}
+
+func describeValue(v string) string {
+	if v == "" {
+		return "missing"
+	}
+	return v
+}
+
+func describeWriters(writers []string) string {
+	if len(writers) == 0 {
+		return "no system"
+	}
+	return strings.Join(writers, ", ")
+}
+
+// FindDivergence compares two traces frame by frame, and describes the first
+// frame at which they disagree, or returns nil if they agree for as many
+// frames as both have.
+func FindDivergence(a, b *Trace) *Divergence {
+	for i := 0; i < len(a.Frames) && i < len(b.Frames); i++ {
+		if diffs := diffFrames(a.Frames[i], b.Frames[i]); len(diffs) > 0 {
+			return &Divergence{Frame: i, Diffs: diffs}
+		}
+	}
+	return nil
+}
+
+func diffFrames(a, b Frame) []ComponentDiff {
+	var diffs []ComponentDiff
+	i, j := 0, 0
+	for i < len(a.Objects) || j < len(b.Objects) {
+		var oa, ob TracedObject
+		switch {
+		case j == len(b.Objects) || i < len(a.Objects) && a.Objects[i].Entity < b.Objects[j].Entity:
+			oa = a.Objects[i]
+			ob.Entity = oa.Entity
+			i++
+		case i == len(a.Objects) || b.Objects[j].Entity < a.Objects[i].Entity:
+			ob = b.Objects[j]
+			oa.Entity = ob.Entity
+			j++
+		default:
+			oa, ob = a.Objects[i], b.Objects[j]
+			i++
+			j++
+		}
+		diffs = append(diffs, diffObjects(oa, ob)...)
+	}
+	return diffs
+}
+
+// diffObjects compares two versions of an object, pairing up components of
+// the same type in order.
+func diffObjects(a, b TracedObject) []ComponentDiff {
+	var diffs []ComponentDiff
+	i, j := 0, 0
+	for i < len(a.Components) || j < len(b.Components) {
+		var ca, cb TracedComponent
+		switch {
+		case j == len(b.Components) || i < len(a.Components) && a.Components[i].Type < b.Components[j].Type:
+			ca = a.Components[i]
+			cb.Type = ca.Type
+			i++
+		case i == len(a.Components) || b.Components[j].Type < a.Components[i].Type:
+			cb = b.Components[j]
+			ca.Type = cb.Type
+			j++
+		default:
+			ca, cb = a.Components[i], b.Components[j]
+			i++
+			j++
+		}
+		if ca.Value != cb.Value {
+			diffs = append(diffs, ComponentDiff{
+				Entity:   a.Entity,
+				Type:     ca.Type,
+				A:        ca.Value,
+				B:        cb.Value,
+				WritersA: ca.Writers,
+				WritersB: cb.Writers,
+			})
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,110 @@
+package ecs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestFindDivergence(t *testing.T) {
+	ecs.Register(Position(0))
+	ecs.Register(Velocity(0))
+
+	// both runs start from the same snapshot, so their entities match
+	initial := ecs.NewWorld()
+	initial.AddObject(ecs.NewObject(Position(0), Velocity(1)))
+	initial.AddObject(ecs.NewObject(Position(100)))
+	var snapshot bytes.Buffer
+	if err := initial.SaveJSON(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(desync int) *ecs.Trace {
+		world := ecs.NewWorld()
+		if err := world.LoadJSON(bytes.NewReader(snapshot.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		tick := 0
+		world.AddSystem(ecs.System{Name: "movement", Func: func(p Position, v Velocity) Position {
+			return p + Position(v)
+		}})
+		world.AddSystem(ecs.System{Name: "wobble", Func: func(v Velocity) Velocity {
+			if tick == desync {
+				return v + 1
+			}
+			return v
+		}})
+
+		rec := world.Record()
+		defer rec.Stop()
+		for ; tick < 5; tick++ {
+			world.Step()
+			if err := rec.Frame(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Trace()
+	}
+
+	a, b := run(-1), run(2)
+	if d := ecs.FindDivergence(a, a); d != nil {
+		t.Fatalf("a trace diverges from itself: %s", d)
+	}
+
+	d := ecs.FindDivergence(a, b)
+	if d == nil {
+		t.Fatal("expected the traces to diverge")
+	}
+	if d.Frame != 2 {
+		t.Errorf("bad divergent frame: got %d, want 2", d.Frame)
+	}
+	want := []ecs.ComponentDiff{{
+		Entity:   d.Diffs[0].Entity,
+		Type:     "ecs_test.Velocity",
+		A:        "1",
+		B:        "2",
+		WritersA: []string{"wobble"},
+		WritersB: []string{"wobble"},
+	}}
+	if !reflect.DeepEqual(d.Diffs, want) {
+		t.Errorf("bad diffs:\ngot  %+v\nwant %+v", d.Diffs, want)
+	}
+	if !strings.Contains(d.String(), "ecs_test.Velocity: 1 (written by wobble) != 2 (written by wobble)") {
+		t.Errorf("bad report: %s", d)
+	}
+
+	// traces survive being saved, so a live run can be checked against one
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved ecs.Trace
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if d := ecs.FindDivergence(b, &saved); d != nil {
+		t.Errorf("saved trace diverges: %s", d)
+	}
+}
+
+func TestFindDivergenceMissingObject(t *testing.T) {
+	ecs.Register(Position(0))
+
+	a := &ecs.Trace{Frames: []ecs.Frame{{Objects: []ecs.TracedObject{
+		{Entity: 1, Components: []ecs.TracedComponent{{Type: "ecs_test.Position", Value: "3"}}},
+	}}}}
+	b := &ecs.Trace{Frames: []ecs.Frame{{}}}
+
+	d := ecs.FindDivergence(a, b)
+	if d == nil || len(d.Diffs) != 1 {
+		t.Fatalf("bad divergence: %v", d)
+	}
+	if diff := d.Diffs[0]; diff.Entity != 1 || diff.A != "3" || diff.B != "" {
+		t.Errorf("bad diff: %+v", diff)
+	}
+}
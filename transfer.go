@@ -0,0 +1,47 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Transferred is emitted by a world when an object is transferred into it
+// from another world with Transfer.
+type Transferred struct {
+	Entity Entity
+	From   *World
+}
+
+// Transfer moves the object with the given entity, along with every one of
+// its components, from w to dst, such as when a player walks from one map
+// instance into another. Since entities are unique across every world in the
+// process, the object keeps its entity.
+//
+// The move is atomic: the object is detached from w with all of its
+// components before being added to dst, so no system in either world ever
+// sees it partially moved or in both worlds at once. OnRemove hooks for its
+// components run in w and OnAdd hooks in dst, but finalizers don't run, since
+// the object lives on. Relations in w that target the object are removed,
+// while the object's own components, including any relations or Parent it
+// has, are moved as they are. Its children are not moved along with it.
+//
+// Transfer must not be called on an object that a system is currently
+// ticking; use Commands.Transfer from within a system instead.
+func (w *World) Transfer(entity Entity, dst *World) error {
+	if dst == w {
+		return errors.New("cannot transfer an object to its own world")
+	}
+	ob := w.detachObject(entity)
+	if ob == nil {
+		return fmt.Errorf("no object with entity %d", entity)
+	}
+	w.metrics().Counter("ecs_objects_removed", 1)
+	w.hookObject(ob, ComponentRemoved)
+	w.unrelateTarget(entity)
+
+	atomic.StoreInt32(&ob.despawning, 0)
+	dst.AddObject(ob)
+	dst.Emit(Transferred{Entity: entity, From: w})
+	return nil
+}
@@ -0,0 +1,73 @@
+package ecs_test
+
+import (
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestTransfer(t *testing.T) {
+	town, dungeon := ecs.NewWorld(), ecs.NewWorld()
+	var removed, added, finalized []ecs.Entity
+	town.OnRemove(Position(0), func(e ecs.Entity, _ interface{}) { removed = append(removed, e) })
+	town.AddFinalizer(Position(0), func(e ecs.Entity, _ interface{}) { finalized = append(finalized, e) })
+	dungeon.OnAdd(Position(0), func(e ecs.Entity, _ interface{}) { added = append(added, e) })
+	var arrivals []ecs.Transferred
+	ecs.OnMessage(dungeon, func(ev ecs.Transferred) { arrivals = append(arrivals, ev) })
+
+	player := town.AddObject(ecs.NewObject(Player{}, Position(3), Velocity(1)))
+	npc := town.AddObject(ecs.NewObject(Position(5)))
+	town.GetObject(npc).Relate("follows", player)
+
+	if err := town.Transfer(player, dungeon); err != nil {
+		t.Fatal(err)
+	}
+	if town.GetObject(player) != nil {
+		t.Error("object still in its original world")
+	}
+	ob := dungeon.GetObject(player)
+	if ob == nil {
+		t.Fatal("object not in its new world")
+	}
+	if got := len(ob.Components()); got != 3 {
+		t.Errorf("transferred object has %d components, want 3", got)
+	}
+	if got := dungeon.Query().With(Velocity(0)).Count(); got != 1 {
+		t.Errorf("query in new world matched %d objects, want 1", got)
+	}
+	if town.GetObject(npc).RelatedTo("follows", player) {
+		t.Error("relation to the transferred object survived in its original world")
+	}
+	if len(removed) != 1 || len(added) != 1 || len(finalized) != 0 {
+		t.Errorf("hooks: %d removed, %d added, %d finalized; want 1, 1, 0", len(removed), len(added), len(finalized))
+	}
+
+	dungeon.Step()
+	if len(arrivals) != 1 || arrivals[0].Entity != player || arrivals[0].From != town {
+		t.Errorf("arrivals = %v", arrivals)
+	}
+
+	if err := town.Transfer(player, dungeon); err == nil {
+		t.Error("expected an error transferring an object that isn't in the world")
+	}
+	if err := dungeon.Transfer(player, dungeon); err == nil {
+		t.Error("expected an error transferring an object to its own world")
+	}
+}
+
+func TestCommandsTransfer(t *testing.T) {
+	town, dungeon := ecs.NewWorld(), ecs.NewWorld()
+	town.AddObject(ecs.NewObject(Player{}, Position(0)))
+	town.AddSystem(ecs.System{
+		Func: func(c *ecs.Commands, e ecs.Entity, _ Player) {
+			c.Transfer(e, dungeon)
+		},
+	})
+	town.Step()
+	if got := len(town.Objects()); got != 0 {
+		t.Errorf("%d objects left in the original world, want 0", got)
+	}
+	if got := dungeon.Query().With(Player{}).Count(); got != 1 {
+		t.Errorf("%d players in the new world, want 1", got)
+	}
+}
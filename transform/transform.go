@@ -0,0 +1,169 @@
+// Package transform maintains the world-space position of objects arranged in
+// a hierarchy with ecs.Parent, so that moving a parent moves all of its
+// descendants.
+//
+// Each object in the hierarchy should have both a Local and a Global
+// component. Systems update an object's Local transform, which is relative to
+// its parent, and read its Global transform, which is kept up to date by the
+// Propagate system:
+//
+//     world.AddPlugin(transform.Plugin{})
+package transform
+
+import (
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+)
+
+// Transform positions an object in 2D space. A zero Scale is treated as 1, so
+// the zero Transform is the identity.
+type Transform struct {
+	Translation ecsmath.Vec2
+	Rotation    ecsmath.Angle
+	Scale       float64
+}
+
+func (t Transform) scale() float64 {
+	if t.Scale == 0 {
+		return 1
+	}
+	return t.Scale
+}
+
+// Mul returns the transform that applies child and then t, which is how a
+// child's local transform is combined with its parent's global transform.
+func (t Transform) Mul(child Transform) Transform {
+	return Transform{
+		Translation: t.Apply(child.Translation),
+		Rotation:    (t.Rotation + child.Rotation).Normalize(),
+		Scale:       t.scale() * child.scale(),
+	}
+}
+
+// Apply transforms a point.
+func (t Transform) Apply(p ecsmath.Vec2) ecsmath.Vec2 {
+	return p.Scale(t.scale()).Rotate(t.Rotation).Add(t.Translation)
+}
+
+// Local is an object's transform relative to its parent, or to the world if
+// it has no parent.
+type Local struct {
+	Transform
+}
+
+// Global is an object's transform relative to the world. It is computed by
+// Propagate and should not be modified directly.
+type Global struct {
+	Transform
+}
+
+// maxDepth bounds how far up the hierarchy Propagate will look, which protects
+// it from cycles.
+const maxDepth = 256
+
+// Propagate computes an object's global transform by combining its local
+// transform with those of all of its ancestors. Ancestors without a Local
+// component are treated as the identity.
+//
+// Propagate looks up each ancestor in the world, so it suits updating a
+// single object. The system returned by System updates every object in one
+// pass, sharing the work between objects with common ancestors.
+func Propagate(w *ecs.World, entity ecs.Entity, local Local, _ Global) Global {
+	ob := w.GetObject(entity)
+	if ob == nil {
+		return Global{local.Transform}
+	}
+	p := &propagator{lookup: w.GetObject, ancestors: make(map[ecs.Entity]Transform)}
+	return p.propagate(ob, local)
+}
+
+// PropagateAll updates the global transform of every object with both a
+// Local and a Global component.
+func PropagateAll(w *ecs.World) {
+	objects := w.Objects()
+	byEntity := make(map[ecs.Entity]*ecs.Object, len(objects))
+	for _, ob := range objects {
+		byEntity[ob.Entity()] = ob
+	}
+	p := &propagator{
+		lookup:    func(e ecs.Entity) *ecs.Object { return byEntity[e] },
+		ancestors: make(map[ecs.Entity]Transform),
+	}
+	for _, ob := range objects {
+		local, ok := ob.Component(Local{}).(Local)
+		if !ok || ob.Component(Global{}) == nil {
+			continue
+		}
+		ob.SetComponent(p.propagate(ob, local))
+	}
+}
+
+// propagator computes global transforms for a single pass, remembering those
+// of the ancestors it has seen, so that objects sharing a parent don't each
+// walk up the hierarchy again.
+type propagator struct {
+	lookup    func(ecs.Entity) *ecs.Object
+	ancestors map[ecs.Entity]Transform
+}
+
+func (p *propagator) propagate(ob *ecs.Object, local Local) Global {
+	parent, ok := ob.Component(ecs.Parent{}).(ecs.Parent)
+	if !ok {
+		return Global{local.Transform}
+	}
+	return Global{p.global(parent.Entity).Mul(local.Transform)}
+}
+
+// global returns the global transform of an ancestor, computing and
+// remembering it, and those of its own ancestors, if it hasn't been seen.
+func (p *propagator) global(entity ecs.Entity) Transform {
+	type link struct {
+		entity ecs.Entity
+		local  Transform
+	}
+	var (
+		chain []link
+		base  Transform
+	)
+	for len(chain) < maxDepth {
+		if t, ok := p.ancestors[entity]; ok {
+			base = t
+			break
+		}
+		ob := p.lookup(entity)
+		if ob == nil {
+			break
+		}
+		l, _ := ob.Component(Local{}).(Local)
+		chain = append(chain, link{entity, l.Transform})
+		parent, ok := ob.Component(ecs.Parent{}).(ecs.Parent)
+		if !ok {
+			break
+		}
+		entity = parent.Entity
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		base = base.Mul(chain[i].local)
+		p.ancestors[chain[i].entity] = base
+	}
+	return base
+}
+
+// System returns a global system that runs PropagateAll on every tick.
+func System() ecs.System {
+	return ecs.System{
+		Func:   PropagateAll,
+		Name:   "transform.Propagate",
+		Global: true,
+		Reads:  []interface{}{Local{}, ecs.Parent{}},
+		Writes: []interface{}{Global{}},
+	}
+}
+
+// Plugin adds the Propagate system to a world.
+type Plugin struct{}
+
+func (Plugin) Build(w *ecs.World) error {
+	return w.AddSystem(System())
+}
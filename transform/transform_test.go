@@ -0,0 +1,82 @@
+package transform_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+	"github.com/dradtke/ecs-go/ecsmath"
+	"github.com/dradtke/ecs-go/transform"
+)
+
+func TestPropagate(t *testing.T) {
+	world := ecs.NewWorld()
+	if err := world.AddPlugin(transform.Plugin{}); err != nil {
+		t.Fatalf("failed to add plugin: %s", err)
+	}
+
+	tank := world.AddObject(ecs.NewObject(
+		transform.Local{Transform: transform.Transform{
+			Translation: ecsmath.Vec2{X: 10},
+			Rotation:    ecsmath.Degrees(90),
+		}},
+		transform.Global{},
+	))
+	turret := ecs.NewObject(
+		ecs.Parent{Entity: tank},
+		transform.Local{Transform: transform.Transform{Translation: ecsmath.Vec2{X: 1}}},
+		transform.Global{},
+	)
+	world.AddObject(turret)
+
+	world.Run()
+
+	global := turret.Component(transform.Global{}).(transform.Global)
+	if p := global.Translation; math.Abs(p.X-10) > 1e-9 || math.Abs(p.Y-1) > 1e-9 {
+		t.Errorf("bad turret position: got %v, want {10 1}", p)
+	}
+	if got, want := global.Rotation.Degrees(), 90.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("bad turret rotation: got %v, want %v", got, want)
+	}
+
+	if got, want := world.Children(tank), []ecs.Entity{turret.Entity()}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("bad children: got %v, want %v", got, want)
+	}
+}
+
+func TestPropagateSharedAncestors(t *testing.T) {
+	world := ecs.NewWorld()
+	if err := world.AddPlugin(transform.Plugin{}); err != nil {
+		t.Fatalf("failed to add plugin: %s", err)
+	}
+
+	at := func(x float64) transform.Local {
+		return transform.Local{Transform: transform.Transform{Translation: ecsmath.Vec2{X: x}}}
+	}
+	root := ecs.NewObject(at(100), transform.Global{})
+	world.AddObject(root)
+	arm := world.AddObject(ecs.NewObject(ecs.Parent{Entity: root.Entity()}, at(10), transform.Global{}))
+	var hands []*ecs.Object
+	for i := 1; i <= 3; i++ {
+		hand := ecs.NewObject(ecs.Parent{Entity: arm}, at(float64(i)), transform.Global{})
+		world.AddObject(hand)
+		hands = append(hands, hand)
+	}
+
+	check := func(base float64) {
+		t.Helper()
+		for i, hand := range hands {
+			got := hand.Component(transform.Global{}).(transform.Global).Translation.X
+			if want := base + 10 + float64(i+1); math.Abs(got-want) > 1e-9 {
+				t.Errorf("bad position for hand %d: got %v, want %v", i, got, want)
+			}
+		}
+	}
+
+	world.Step()
+	check(100)
+
+	root.SetComponent(at(200))
+	world.Step()
+	check(200)
+}
@@ -0,0 +1,135 @@
+package ecs
+
+import "sync/atomic"
+
+// ChangeKind identifies what happened to a watched entity.
+type ChangeKind int
+
+const (
+	// ComponentChanged means a system overwrote one of the entity's
+	// components with its return value.
+	ComponentChanged ChangeKind = iota
+
+	// ComponentAdded means a component was added to the entity's object.
+	ComponentAdded
+
+	// ComponentRemoved means a component was removed from the entity's
+	// object.
+	ComponentRemoved
+
+	// QueryMatched means a system is about to be invoked on the entity.
+	QueryMatched
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ComponentChanged:
+		return "changed"
+	case ComponentAdded:
+		return "added"
+	case ComponentRemoved:
+		return "removed"
+	case QueryMatched:
+		return "matched"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes something that happened to a watched entity.
+type Change struct {
+	Entity Entity
+	Kind   ChangeKind
+
+	// Component is the new value for ComponentChanged and ComponentAdded,
+	// the removed value for ComponentRemoved, and nil for QueryMatched.
+	Component interface{}
+
+	// System is the name of the system responsible for the change, or empty
+	// if it was made outside of a system.
+	System string
+}
+
+type watcher struct {
+	fn func(Change)
+}
+
+// Watch registers a callback that is invoked on every change involving the
+// given entity, which is useful for tracking down why a particular entity is
+// misbehaving. The returned function removes the watch.
+//
+// Callbacks are invoked synchronously from whichever goroutine made the
+// change, so they should return quickly.
+func (w *World) Watch(entity Entity, fn func(change Change)) (cancel func()) {
+	wt := &watcher{fn: fn}
+
+	w.watchersMu.Lock()
+	w.watchers[entity] = append(w.watchers[entity], wt)
+	w.watchersMu.Unlock()
+	atomic.AddInt32(&w.watching, 1)
+
+	return func() {
+		w.watchersMu.Lock()
+		defer w.watchersMu.Unlock()
+		watchers := w.watchers[entity]
+		for i, other := range watchers {
+			if other == wt {
+				w.watchers[entity] = append(watchers[:i:i], watchers[i+1:]...)
+				if len(w.watchers[entity]) == 0 {
+					delete(w.watchers, entity)
+				}
+				atomic.AddInt32(&w.watching, -1)
+				return
+			}
+		}
+	}
+}
+
+// watchEverything registers a callback that is invoked on every change to
+// any entity. The returned function removes the watch.
+func (w *World) watchEverything(fn func(change Change)) (cancel func()) {
+	wt := &watcher{fn: fn}
+
+	w.watchersMu.Lock()
+	w.watchAll = append(w.watchAll, wt)
+	w.watchersMu.Unlock()
+	atomic.AddInt32(&w.watching, 1)
+
+	return func() {
+		w.watchersMu.Lock()
+		defer w.watchersMu.Unlock()
+		for i, other := range w.watchAll {
+			if other == wt {
+				w.watchAll = append(w.watchAll[:i:i], w.watchAll[i+1:]...)
+				atomic.AddInt32(&w.watching, -1)
+				return
+			}
+		}
+	}
+}
+
+// watched reports whether any entity is being watched, or any hooks are
+// registered, so that callers can avoid building a Change on every tick when
+// nothing is.
+func (w *World) watched() bool {
+	return atomic.LoadInt32(&w.watching) != 0
+}
+
+func (w *World) notifyWatchers(change Change) {
+	if !w.watched() {
+		return
+	}
+	w.runHooks(change)
+
+	w.watchersMu.RLock()
+	watchers := w.watchers[change.Entity]
+	all := w.watchAll
+	w.watchersMu.RUnlock()
+
+	for _, wt := range watchers {
+		wt.fn(change)
+	}
+	for _, wt := range all {
+		wt.fn(change)
+	}
+}
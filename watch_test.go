@@ -0,0 +1,68 @@
+package ecs_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dradtke/ecs-go"
+)
+
+func TestWatch(t *testing.T) {
+	movement := func(p Position, v Velocity) Position {
+		return Position(int(p) + int(v))
+	}
+
+	world := ecs.NewWorld()
+	world.AddSystem(ecs.System{Func: movement, Name: "movement"})
+
+	boss := ecs.NewObject(Position(1), Velocity(2))
+	world.AddObject(boss)
+	world.AddObject(ecs.NewObject(Position(1), Velocity(2)))
+
+	var changes []ecs.Change
+	cancel := world.Watch(boss.Entity(), func(change ecs.Change) {
+		changes = append(changes, change)
+	})
+
+	world.Run()
+	boss.AddComponent(Target{})
+	boss.RemoveComponent(Target{})
+
+	want := []ecs.Change{
+		{Entity: boss.Entity(), Kind: ecs.QueryMatched, System: "movement"},
+		{Entity: boss.Entity(), Kind: ecs.ComponentChanged, Component: Position(3), System: "movement"},
+		{Entity: boss.Entity(), Kind: ecs.ComponentAdded, Component: Target{}},
+		{Entity: boss.Entity(), Kind: ecs.ComponentRemoved, Component: Target{}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("bad changes:\ngot  %v\nwant %v", changes, want)
+	}
+
+	cancel()
+	changes = nil
+	world.Run()
+	if len(changes) != 0 {
+		t.Errorf("received changes after cancelling watch: %v", changes)
+	}
+}
+
+func TestWatchCancelDuringNotification(t *testing.T) {
+	world := ecs.NewWorld()
+	ob := ecs.NewObject(Position(1))
+	world.AddObject(ob)
+
+	calls := make(map[string]int)
+	var cancelFirst func()
+	cancelFirst = world.Watch(ob.Entity(), func(ecs.Change) {
+		calls["first"]++
+		cancelFirst()
+	})
+	world.Watch(ob.Entity(), func(ecs.Change) { calls["second"]++ })
+	world.Watch(ob.Entity(), func(ecs.Change) { calls["third"]++ })
+
+	ob.AddComponent(Target{})
+
+	if want := map[string]int{"first": 1, "second": 1, "third": 1}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("bad calls while a watch was cancelled: got %v, want %v", calls, want)
+	}
+}